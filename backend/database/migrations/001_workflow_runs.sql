@@ -0,0 +1,31 @@
+-- Migration 001: workflow execution history
+-- Run this in your Supabase SQL editor on databases created before workflow_runs existed
+
+-- Workflow runs (execution history)
+-- workflow_id is intentionally not a foreign key so history survives workflow deletion
+CREATE TABLE IF NOT EXISTS workflow_runs (
+    run_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workflow_id UUID NOT NULL,
+    project_id UUID NOT NULL,
+    caller_did VARCHAR(66) NOT NULL,
+    mode VARCHAR(20) NOT NULL DEFAULT 'sync' CHECK (mode IN ('sync', 'stream')),
+    status VARCHAR(20) NOT NULL CHECK (status IN ('succeeded', 'failed')),
+
+    -- Request and result
+    parameters JSONB DEFAULT '{}',
+    status_code INTEGER,
+    latency_ms INTEGER,
+    response_body TEXT,
+    response_truncated BOOLEAN DEFAULT FALSE,
+    error TEXT,
+
+    -- Timestamps
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_workflow_runs_workflow ON workflow_runs(workflow_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_workflow_runs_project ON workflow_runs(project_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_workflow_runs_caller ON workflow_runs(caller_did);
+
+COMMENT ON TABLE workflow_runs IS '工作流执行记录表';
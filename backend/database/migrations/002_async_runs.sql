@@ -0,0 +1,16 @@
+-- Migration 002: asynchronous workflow runs
+-- Adds the queued/running/cancelled states and the columns used by the run worker
+
+ALTER TABLE workflow_runs DROP CONSTRAINT IF EXISTS workflow_runs_mode_check;
+ALTER TABLE workflow_runs ADD CONSTRAINT workflow_runs_mode_check
+    CHECK (mode IN ('sync', 'stream', 'async'));
+
+ALTER TABLE workflow_runs DROP CONSTRAINT IF EXISTS workflow_runs_status_check;
+ALTER TABLE workflow_runs ADD CONSTRAINT workflow_runs_status_check
+    CHECK (status IN ('queued', 'running', 'succeeded', 'failed', 'cancelled'));
+
+ALTER TABLE workflow_runs ADD COLUMN IF NOT EXISTS request_headers JSONB;
+ALTER TABLE workflow_runs ADD COLUMN IF NOT EXISTS external_execute_id VARCHAR(255);
+ALTER TABLE workflow_runs ADD COLUMN IF NOT EXISTS started_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_workflow_runs_queue ON workflow_runs(created_at) WHERE status IN ('queued', 'running');
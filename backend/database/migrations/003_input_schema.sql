@@ -0,0 +1,20 @@
+-- Migration 003: workflow input schema
+-- Lets a workflow declare a JSON Schema that execution validates parameters against
+
+ALTER TABLE workflows ADD COLUMN IF NOT EXISTS input_schema JSONB DEFAULT '{}';
+
+CREATE OR REPLACE FUNCTION increment_workflow_content_version()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF (OLD.workflow_name != NEW.workflow_name OR 
+        OLD.description != NEW.description OR 
+        OLD.parameters != NEW.parameters OR
+        OLD.headers != NEW.headers OR
+        OLD.input_schema IS DISTINCT FROM NEW.input_schema) THEN
+        NEW.content_version = OLD.content_version + 1;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+COMMENT ON COLUMN workflows.input_schema IS '输入参数的JSON Schema（类型、必填、枚举、范围、默认值），空对象表示不校验';
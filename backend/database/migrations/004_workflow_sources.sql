@@ -0,0 +1,6 @@
+-- Migration 004: additional workflow sources
+-- Allows the Dify, Langflow, Flowise and OpenAI compatible source adapters
+
+ALTER TABLE workflows DROP CONSTRAINT IF EXISTS workflows_source_check;
+ALTER TABLE workflows ADD CONSTRAINT workflows_source_check
+    CHECK (source IN ('coze', 'n8n', 'dify', 'langflow', 'flowise', 'openai'));
@@ -0,0 +1,48 @@
+-- Migration 005: workflow version history
+-- Keeps the full definition of every workflow change for diff, rollback and pinned executions
+
+CREATE TABLE IF NOT EXISTS workflow_versions (
+    workflow_id UUID NOT NULL REFERENCES workflows(workflow_id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+
+    -- Definition snapshot
+    workflow_name VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL,
+    source VARCHAR(50) NOT NULL,
+    template_name VARCHAR(50) NOT NULL,
+    http_method VARCHAR(10) NOT NULL,
+    base_url VARCHAR(500) NOT NULL,
+    bearer_token TEXT NOT NULL,
+    external_workflow_id VARCHAR(255) NOT NULL,
+    parameters JSONB DEFAULT '{}',
+    headers JSONB DEFAULT '{}',
+    input_schema JSONB DEFAULT '{}',
+
+    -- Change
+    change_type VARCHAR(20) NOT NULL CHECK (change_type IN ('create', 'update', 'rollback')),
+    changed_fields TEXT[] NOT NULL DEFAULT '{}',
+    changed_by VARCHAR(66) NOT NULL,
+    rolled_back_from INTEGER,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (workflow_id, version)
+);
+
+-- The current definition of existing workflows becomes version 1
+INSERT INTO workflow_versions (
+    workflow_id, version, workflow_name, description, source, template_name,
+    http_method, base_url, bearer_token, external_workflow_id,
+    parameters, headers, input_schema, change_type, changed_by, created_at
+)
+SELECT
+    workflow_id, 1, workflow_name, description, source, template_name,
+    http_method, base_url, bearer_token, external_workflow_id,
+    parameters, headers, input_schema, 'create', creator_did, updated_at
+FROM workflows
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE workflow_runs ADD COLUMN IF NOT EXISTS workflow_version INTEGER;
+
+COMMENT ON TABLE workflow_versions IS '工作流版本历史表，每次变更后保存完整定义';
+COMMENT ON COLUMN workflow_versions.rolled_back_from IS '回滚时恢复的目标版本号';
+COMMENT ON COLUMN workflow_runs.workflow_version IS '本次执行使用的工作流版本';
@@ -0,0 +1,21 @@
+-- Migration 006: embedding refresh
+-- Storing a refreshed embedding no longer bumps workflows.updated_at
+
+CREATE OR REPLACE FUNCTION update_workflows_updated_at()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF (NEW.embedding IS DISTINCT FROM OLD.embedding OR
+        NEW.embedding_model IS DISTINCT FROM OLD.embedding_model OR
+        NEW.embedding_version IS DISTINCT FROM OLD.embedding_version) AND
+       (NEW.workflow_name, NEW.description, NEW.source, NEW.template_name, NEW.http_method,
+        NEW.base_url, NEW.bearer_token, NEW.external_workflow_id, NEW.parameters, NEW.headers,
+        NEW.input_schema, NEW.is_shared) IS NOT DISTINCT FROM
+       (OLD.workflow_name, OLD.description, OLD.source, OLD.template_name, OLD.http_method,
+        OLD.base_url, OLD.bearer_token, OLD.external_workflow_id, OLD.parameters, OLD.headers,
+        OLD.input_schema, OLD.is_shared) THEN
+        RETURN NEW;
+    END IF;
+    NEW.updated_at = CURRENT_TIMESTAMP;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,168 @@
+-- Migration 007: hybrid search
+-- Adds workflow tags, CJK bigram full-text search and filter parameters to the search functions
+
+ALTER TABLE workflows ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+CREATE INDEX IF NOT EXISTS idx_workflows_tags ON workflows USING gin (tags);
+
+-- Function: Split CJK text into overlapping bigrams for full-text search
+-- The text search parsers keep a run of Chinese characters as one word, so
+-- '翻译技术文档' is indexed as '翻译 译技 技术 术文 文档' instead
+CREATE OR REPLACE FUNCTION cjk_bigrams(p_text TEXT)
+RETURNS TEXT AS $$
+DECLARE
+    v_run TEXT;
+    v_result TEXT := '';
+    i INTEGER;
+BEGIN
+    FOR v_run IN
+        SELECT m[1] FROM regexp_matches(COALESCE(p_text, ''),
+            '([\u3040-\u30ff\u3400-\u4dbf\u4e00-\u9fff\uac00-\ud7af\uf900-\ufaff]+)', 'g') AS m
+    LOOP
+        IF char_length(v_run) = 1 THEN
+            v_result := v_result || ' ' || v_run;
+        ELSE
+            FOR i IN 1 .. char_length(v_run) - 1 LOOP
+                v_result := v_result || ' ' || substr(v_run, i, 2);
+            END LOOP;
+        END IF;
+    END LOOP;
+    RETURN btrim(v_result);
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+
+-- Function: Full-text document of a workflow (name and tags weigh more than the description)
+CREATE OR REPLACE FUNCTION workflow_search_document(p_name TEXT, p_description TEXT, p_tags TEXT[])
+RETURNS tsvector AS $$
+    SELECT setweight(to_tsvector('english', COALESCE(p_name, '')), 'A') ||
+           setweight(to_tsvector('simple', cjk_bigrams(p_name)), 'A') ||
+           setweight(to_tsvector('english', array_to_string(COALESCE(p_tags, '{}'), ' ')), 'A') ||
+           setweight(to_tsvector('english', COALESCE(p_description, '')), 'B') ||
+           setweight(to_tsvector('simple', cjk_bigrams(p_description)), 'B')
+$$ LANGUAGE sql IMMUTABLE;
+
+-- Function: Full-text query matching workflow_search_document
+-- English words must all match; CJK bigrams match if any of them does
+CREATE OR REPLACE FUNCTION workflow_search_query(p_query TEXT)
+RETURNS tsquery AS $$
+DECLARE
+    v_bigrams TEXT := cjk_bigrams(p_query);
+BEGIN
+    IF v_bigrams = '' THEN
+        RETURN plainto_tsquery('english', p_query);
+    END IF;
+    RETURN plainto_tsquery('english', p_query) ||
+           to_tsquery('simple', array_to_string(regexp_split_to_array(v_bigrams, '\s+'), ' | '));
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+
+CREATE INDEX IF NOT EXISTS idx_workflows_search ON workflows
+USING gin (workflow_search_document(workflow_name, description, tags));
+
+-- Search functions gained filter parameters; drop the old signatures so calls stay unambiguous
+DROP FUNCTION IF EXISTS search_workflows_vector(vector, UUID, INTEGER, FLOAT);
+DROP FUNCTION IF EXISTS search_workflows_fulltext(TEXT, UUID, INTEGER);
+
+-- Function: Search workflows using vector similarity (for RAG)
+-- Filters left NULL match every workflow; p_tags matches workflows having all of the tags
+CREATE OR REPLACE FUNCTION search_workflows_vector(
+    p_query_embedding vector(1536),
+    p_project_id UUID,
+    p_top_k INTEGER DEFAULT 5,
+    p_threshold FLOAT DEFAULT 0.7,
+    p_sources TEXT[] DEFAULT NULL,
+    p_template_names TEXT[] DEFAULT NULL,
+    p_tags TEXT[] DEFAULT NULL,
+    p_creator_did TEXT DEFAULT NULL
+)
+RETURNS TABLE (
+    workflow_id UUID,
+    workflow_name VARCHAR(255),
+    description TEXT,
+    source VARCHAR(50),
+    template_name VARCHAR(50),
+    tags TEXT[],
+    similarity FLOAT
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT 
+        w.workflow_id,
+        w.workflow_name,
+        w.description,
+        w.source,
+        w.template_name,
+        w.tags,
+        1 - (w.embedding <=> p_query_embedding) AS similarity
+    FROM workflows w
+    LEFT JOIN project_workflow_settings pws 
+        ON w.workflow_id = pws.workflow_id 
+        AND pws.project_id = p_project_id
+    WHERE (
+        w.project_id = p_project_id OR w.is_shared = true
+    )
+    AND (pws.is_hidden IS NULL OR pws.is_hidden = false)
+    AND (p_sources IS NULL OR w.source = ANY(p_sources))
+    AND (p_template_names IS NULL OR w.template_name = ANY(p_template_names))
+    AND (p_tags IS NULL OR w.tags @> p_tags)
+    AND (p_creator_did IS NULL OR w.creator_did = p_creator_did)
+    AND w.embedding IS NOT NULL
+    AND (1 - (w.embedding <=> p_query_embedding)) >= p_threshold
+    ORDER BY similarity DESC
+    LIMIT p_top_k;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Function: Search workflows using full-text search (fallback for RAG)
+-- Matches English words and Chinese, Japanese and Korean bigrams
+CREATE OR REPLACE FUNCTION search_workflows_fulltext(
+    p_query TEXT,
+    p_project_id UUID,
+    p_top_k INTEGER DEFAULT 5,
+    p_sources TEXT[] DEFAULT NULL,
+    p_template_names TEXT[] DEFAULT NULL,
+    p_tags TEXT[] DEFAULT NULL,
+    p_creator_did TEXT DEFAULT NULL
+)
+RETURNS TABLE (
+    workflow_id UUID,
+    workflow_name VARCHAR(255),
+    description TEXT,
+    source VARCHAR(50),
+    template_name VARCHAR(50),
+    tags TEXT[],
+    relevance FLOAT
+) AS $$
+DECLARE
+    v_query tsquery := workflow_search_query(p_query);
+BEGIN
+    RETURN QUERY
+    SELECT 
+        w.workflow_id,
+        w.workflow_name,
+        w.description,
+        w.source,
+        w.template_name,
+        w.tags,
+        ts_rank(
+            workflow_search_document(w.workflow_name, w.description, w.tags),
+            v_query
+        )::FLOAT AS relevance
+    FROM workflows w
+    LEFT JOIN project_workflow_settings pws 
+        ON w.workflow_id = pws.workflow_id 
+        AND pws.project_id = p_project_id
+    WHERE (
+        w.project_id = p_project_id OR w.is_shared = true
+    )
+    AND (pws.is_hidden IS NULL OR pws.is_hidden = false)
+    AND (p_sources IS NULL OR w.source = ANY(p_sources))
+    AND (p_template_names IS NULL OR w.template_name = ANY(p_template_names))
+    AND (p_tags IS NULL OR w.tags @> p_tags)
+    AND (p_creator_did IS NULL OR w.creator_did = p_creator_did)
+    AND workflow_search_document(w.workflow_name, w.description, w.tags) @@ v_query
+    ORDER BY relevance DESC
+    LIMIT p_top_k;
+END;
+$$ LANGUAGE plpgsql;
+
+COMMENT ON COLUMN workflows.tags IS '工作流标签（小写），用于搜索过滤';
@@ -0,0 +1,26 @@
+-- Migration 008: project API keys
+-- Adds hashed, scoped and revocable API keys for machine-to-machine workflow execution
+
+-- Project API keys for machine-to-machine calls
+-- Only the SHA-256 hash of a key is stored; prefix is the part shown in listings
+CREATE TABLE IF NOT EXISTS api_keys (
+    key_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    prefix VARCHAR(32) NOT NULL UNIQUE,
+    key_hash VARCHAR(64) NOT NULL,
+    scopes TEXT[] NOT NULL CHECK (scopes <@ ARRAY['execute', 'read']::TEXT[] AND cardinality(scopes) > 0),
+    workflow_ids UUID[] NOT NULL DEFAULT '{}',
+    created_by VARCHAR(66) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP,
+    last_used_at TIMESTAMP,
+    revoked_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_project ON api_keys(project_id, created_at DESC);
+
+COMMENT ON TABLE api_keys IS '项目API密钥表，用于CI和后端服务调用工作流';
+COMMENT ON COLUMN api_keys.key_hash IS '密钥的SHA-256哈希，明文只在创建时返回一次';
+COMMENT ON COLUMN api_keys.scopes IS '权限范围：execute（执行）、read（只读）';
+COMMENT ON COLUMN api_keys.workflow_ids IS '允许访问的工作流，空数组表示项目内全部工作流';
@@ -0,0 +1,19 @@
+-- Migration 009: workflow grants
+-- Adds per-workflow roles for the policy in pkg/policy
+
+-- Per-workflow roles on top of the project role in user_projects
+-- user_projects.role is one of viewer, runner, editor, admin, or the legacy member
+CREATE TABLE IF NOT EXISTS workflow_grants (
+    workflow_id UUID NOT NULL REFERENCES workflows(workflow_id) ON DELETE CASCADE,
+    user_did VARCHAR(66) NOT NULL,
+    role VARCHAR(20) NOT NULL CHECK (role IN ('viewer', 'runner', 'editor')),
+    granted_by VARCHAR(66) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (workflow_id, user_did)
+);
+
+CREATE INDEX IF NOT EXISTS idx_workflow_grants_user ON workflow_grants(user_did);
+
+COMMENT ON TABLE workflow_grants IS '工作流授权表，在项目角色之外给用户单个工作流的角色';
+COMMENT ON COLUMN workflow_grants.role IS '授予的角色：viewer（查看）、runner（执行）、editor（编辑）';
@@ -0,0 +1,218 @@
+-- Migration 010: targeted workflow sharing
+-- Shares workflows with chosen projects or organizations, read-only or executable,
+-- next to is_shared which still shares with every project
+
+-- Projects of organizations, kept in sync by the account service like user_projects
+CREATE TABLE IF NOT EXISTS organization_projects (
+    organization_id UUID NOT NULL,
+    project_id UUID NOT NULL,
+    PRIMARY KEY (organization_id, project_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_organization_projects_project ON organization_projects(project_id);
+
+-- Workflows shared with other projects, directly or through their organization
+CREATE TABLE IF NOT EXISTS workflow_shares (
+    workflow_id UUID NOT NULL REFERENCES workflows(workflow_id) ON DELETE CASCADE,
+    target_type VARCHAR(20) NOT NULL CHECK (target_type IN ('project', 'organization')),
+    target_id UUID NOT NULL,
+    access VARCHAR(20) NOT NULL DEFAULT 'execute' CHECK (access IN ('read', 'execute')),
+    shared_by VARCHAR(66) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (workflow_id, target_type, target_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_workflow_shares_target ON workflow_shares(target_type, target_id);
+
+-- Function: Access a project has to a workflow through workflow_shares
+-- Returns 'execute', 'read', or NULL when the workflow is not shared with it
+CREATE OR REPLACE FUNCTION workflow_share_access(p_workflow_id UUID, p_project_id UUID)
+RETURNS VARCHAR AS $$
+BEGIN
+    RETURN (
+        SELECT s.access
+        FROM workflow_shares s
+        WHERE s.workflow_id = p_workflow_id
+        AND (
+            (s.target_type = 'project' AND s.target_id = p_project_id)
+            OR
+            (s.target_type = 'organization' AND s.target_id IN (
+                SELECT op.organization_id FROM organization_projects op
+                WHERE op.project_id = p_project_id
+            ))
+        )
+        ORDER BY s.access = 'execute' DESC
+        LIMIT 1
+    );
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+-- Function: Get visible workflows for a project
+CREATE OR REPLACE FUNCTION get_visible_workflows(p_project_id UUID, p_user_did VARCHAR(66))
+RETURNS TABLE (
+    workflow_id UUID,
+    workflow_name VARCHAR(255),
+    description TEXT,
+    source VARCHAR(50),
+    template_name VARCHAR(50),
+    http_method VARCHAR(10),
+    base_url VARCHAR(500),
+    bearer_token TEXT,
+    external_workflow_id VARCHAR(255),
+    parameters JSONB,
+    headers JSONB,
+    project_id UUID,
+    creator_did VARCHAR(66),
+    is_shared BOOLEAN,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT 
+        w.workflow_id,
+        w.workflow_name,
+        w.description,
+        w.source,
+        w.template_name,
+        w.http_method,
+        w.base_url,
+        w.bearer_token,
+        w.external_workflow_id,
+        w.parameters,
+        w.headers,
+        w.project_id,
+        w.creator_did,
+        w.is_shared,
+        w.created_at,
+        w.updated_at
+    FROM workflows w
+    LEFT JOIN project_workflow_settings pws 
+        ON w.workflow_id = pws.workflow_id 
+        AND pws.project_id = p_project_id
+    WHERE (
+        -- Project's own workflows
+        w.project_id = p_project_id
+        OR
+        -- Workflows shared with every project, or with this project or its organization
+        w.is_shared = true
+        OR
+        workflow_share_access(w.workflow_id, p_project_id) IS NOT NULL
+    )
+    AND (
+        -- Not hidden
+        pws.is_hidden IS NULL OR pws.is_hidden = false
+    )
+    ORDER BY w.created_at DESC;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Function: Search workflows using vector similarity (for RAG)
+-- Filters left NULL match every workflow; p_tags matches workflows having all of the tags
+CREATE OR REPLACE FUNCTION search_workflows_vector(
+    p_query_embedding vector(1536),
+    p_project_id UUID,
+    p_top_k INTEGER DEFAULT 5,
+    p_threshold FLOAT DEFAULT 0.7,
+    p_sources TEXT[] DEFAULT NULL,
+    p_template_names TEXT[] DEFAULT NULL,
+    p_tags TEXT[] DEFAULT NULL,
+    p_creator_did TEXT DEFAULT NULL
+)
+RETURNS TABLE (
+    workflow_id UUID,
+    workflow_name VARCHAR(255),
+    description TEXT,
+    source VARCHAR(50),
+    template_name VARCHAR(50),
+    tags TEXT[],
+    similarity FLOAT
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT 
+        w.workflow_id,
+        w.workflow_name,
+        w.description,
+        w.source,
+        w.template_name,
+        w.tags,
+        1 - (w.embedding <=> p_query_embedding) AS similarity
+    FROM workflows w
+    LEFT JOIN project_workflow_settings pws 
+        ON w.workflow_id = pws.workflow_id 
+        AND pws.project_id = p_project_id
+    WHERE (
+        w.project_id = p_project_id OR w.is_shared = true
+        OR workflow_share_access(w.workflow_id, p_project_id) IS NOT NULL
+    )
+    AND (pws.is_hidden IS NULL OR pws.is_hidden = false)
+    AND (p_sources IS NULL OR w.source = ANY(p_sources))
+    AND (p_template_names IS NULL OR w.template_name = ANY(p_template_names))
+    AND (p_tags IS NULL OR w.tags @> p_tags)
+    AND (p_creator_did IS NULL OR w.creator_did = p_creator_did)
+    AND w.embedding IS NOT NULL
+    AND (1 - (w.embedding <=> p_query_embedding)) >= p_threshold
+    ORDER BY similarity DESC
+    LIMIT p_top_k;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Function: Search workflows using full-text search (fallback for RAG)
+-- Matches English words and Chinese, Japanese and Korean bigrams
+CREATE OR REPLACE FUNCTION search_workflows_fulltext(
+    p_query TEXT,
+    p_project_id UUID,
+    p_top_k INTEGER DEFAULT 5,
+    p_sources TEXT[] DEFAULT NULL,
+    p_template_names TEXT[] DEFAULT NULL,
+    p_tags TEXT[] DEFAULT NULL,
+    p_creator_did TEXT DEFAULT NULL
+)
+RETURNS TABLE (
+    workflow_id UUID,
+    workflow_name VARCHAR(255),
+    description TEXT,
+    source VARCHAR(50),
+    template_name VARCHAR(50),
+    tags TEXT[],
+    relevance FLOAT
+) AS $$
+DECLARE
+    v_query tsquery := workflow_search_query(p_query);
+BEGIN
+    RETURN QUERY
+    SELECT 
+        w.workflow_id,
+        w.workflow_name,
+        w.description,
+        w.source,
+        w.template_name,
+        w.tags,
+        ts_rank(
+            workflow_search_document(w.workflow_name, w.description, w.tags),
+            v_query
+        )::FLOAT AS relevance
+    FROM workflows w
+    LEFT JOIN project_workflow_settings pws 
+        ON w.workflow_id = pws.workflow_id 
+        AND pws.project_id = p_project_id
+    WHERE (
+        w.project_id = p_project_id OR w.is_shared = true
+        OR workflow_share_access(w.workflow_id, p_project_id) IS NOT NULL
+    )
+    AND (pws.is_hidden IS NULL OR pws.is_hidden = false)
+    AND (p_sources IS NULL OR w.source = ANY(p_sources))
+    AND (p_template_names IS NULL OR w.template_name = ANY(p_template_names))
+    AND (p_tags IS NULL OR w.tags @> p_tags)
+    AND (p_creator_did IS NULL OR w.creator_did = p_creator_did)
+    AND workflow_search_document(w.workflow_name, w.description, w.tags) @@ v_query
+    ORDER BY relevance DESC
+    LIMIT p_top_k;
+END;
+$$ LANGUAGE plpgsql;
+
+COMMENT ON TABLE organization_projects IS '组织与项目的对应关系，用于按组织共享工作流';
+COMMENT ON TABLE workflow_shares IS '工作流共享表，把工作流共享给指定项目或组织';
+COMMENT ON COLUMN workflow_shares.access IS '共享权限：read（只读）、execute（可执行）';
@@ -0,0 +1,48 @@
+-- Migration 011: audit log
+-- Adds the append-only audit_events table written by pkg/audit
+
+-- Audit log of workflow changes and executions
+-- Append-only: triggers reject UPDATE, DELETE and TRUNCATE. workflow_id is
+-- intentionally not a foreign key so events outlive deleted workflows
+CREATE TABLE IF NOT EXISTS audit_events (
+    event_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    action VARCHAR(50) NOT NULL,
+    actor_did VARCHAR(66) NOT NULL,
+    project_id UUID NOT NULL,
+    workflow_id UUID,
+
+    -- Field changes with secrets redacted, and action specific details
+    changes JSONB,
+    details JSONB,
+
+    -- Request
+    source_ip VARCHAR(64),
+    user_agent TEXT,
+    request_id VARCHAR(128),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_project ON audit_events(project_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_events_actor ON audit_events(actor_did, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_events_workflow ON audit_events(workflow_id, created_at DESC);
+
+-- Trigger: Keep audit events immutable
+CREATE OR REPLACE FUNCTION reject_audit_event_change()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trigger_audit_events_append_only
+BEFORE UPDATE OR DELETE ON audit_events
+FOR EACH ROW
+EXECUTE FUNCTION reject_audit_event_change();
+
+CREATE TRIGGER trigger_audit_events_no_truncate
+BEFORE TRUNCATE ON audit_events
+FOR EACH STATEMENT
+EXECUTE FUNCTION reject_audit_event_change();
+
+COMMENT ON TABLE audit_events IS '审计日志表，记录工作流的创建、修改、删除、共享、隐藏和执行，只允许追加';
+COMMENT ON COLUMN audit_events.changes IS '字段变更前后的值，token和敏感请求头已脱敏';
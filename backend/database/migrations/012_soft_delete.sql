@@ -0,0 +1,179 @@
+-- Migration 012: soft deletion of workflows
+-- Deleted workflows move to the trash and are hidden from listing, search and
+-- execution until restored, or purged by cmd/purge-workflows after TRASH_RETENTION_DAYS
+
+ALTER TABLE workflows ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+ALTER TABLE workflows ADD COLUMN IF NOT EXISTS deleted_by VARCHAR(66);
+
+CREATE INDEX IF NOT EXISTS idx_workflows_deleted ON workflows(project_id, deleted_at DESC) WHERE deleted_at IS NOT NULL;
+
+-- Function: Get visible workflows for a project
+CREATE OR REPLACE FUNCTION get_visible_workflows(p_project_id UUID, p_user_did VARCHAR(66))
+RETURNS TABLE (
+    workflow_id UUID,
+    workflow_name VARCHAR(255),
+    description TEXT,
+    source VARCHAR(50),
+    template_name VARCHAR(50),
+    http_method VARCHAR(10),
+    base_url VARCHAR(500),
+    bearer_token TEXT,
+    external_workflow_id VARCHAR(255),
+    parameters JSONB,
+    headers JSONB,
+    project_id UUID,
+    creator_did VARCHAR(66),
+    is_shared BOOLEAN,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT 
+        w.workflow_id,
+        w.workflow_name,
+        w.description,
+        w.source,
+        w.template_name,
+        w.http_method,
+        w.base_url,
+        w.bearer_token,
+        w.external_workflow_id,
+        w.parameters,
+        w.headers,
+        w.project_id,
+        w.creator_did,
+        w.is_shared,
+        w.created_at,
+        w.updated_at
+    FROM workflows w
+    LEFT JOIN project_workflow_settings pws 
+        ON w.workflow_id = pws.workflow_id 
+        AND pws.project_id = p_project_id
+    WHERE (
+        -- Project's own workflows
+        w.project_id = p_project_id
+        OR
+        -- Workflows shared with every project, or with this project or its organization
+        w.is_shared = true
+        OR
+        workflow_share_access(w.workflow_id, p_project_id) IS NOT NULL
+    )
+    AND (
+        -- Not hidden
+        pws.is_hidden IS NULL OR pws.is_hidden = false
+    )
+    AND w.deleted_at IS NULL
+    ORDER BY w.created_at DESC;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Function: Search workflows using vector similarity (for RAG)
+-- Filters left NULL match every workflow; p_tags matches workflows having all of the tags
+CREATE OR REPLACE FUNCTION search_workflows_vector(
+    p_query_embedding vector(1536),
+    p_project_id UUID,
+    p_top_k INTEGER DEFAULT 5,
+    p_threshold FLOAT DEFAULT 0.7,
+    p_sources TEXT[] DEFAULT NULL,
+    p_template_names TEXT[] DEFAULT NULL,
+    p_tags TEXT[] DEFAULT NULL,
+    p_creator_did TEXT DEFAULT NULL
+)
+RETURNS TABLE (
+    workflow_id UUID,
+    workflow_name VARCHAR(255),
+    description TEXT,
+    source VARCHAR(50),
+    template_name VARCHAR(50),
+    tags TEXT[],
+    similarity FLOAT
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT 
+        w.workflow_id,
+        w.workflow_name,
+        w.description,
+        w.source,
+        w.template_name,
+        w.tags,
+        1 - (w.embedding <=> p_query_embedding) AS similarity
+    FROM workflows w
+    LEFT JOIN project_workflow_settings pws 
+        ON w.workflow_id = pws.workflow_id 
+        AND pws.project_id = p_project_id
+    WHERE (
+        w.project_id = p_project_id OR w.is_shared = true
+        OR workflow_share_access(w.workflow_id, p_project_id) IS NOT NULL
+    )
+    AND (pws.is_hidden IS NULL OR pws.is_hidden = false)
+    AND w.deleted_at IS NULL
+    AND (p_sources IS NULL OR w.source = ANY(p_sources))
+    AND (p_template_names IS NULL OR w.template_name = ANY(p_template_names))
+    AND (p_tags IS NULL OR w.tags @> p_tags)
+    AND (p_creator_did IS NULL OR w.creator_did = p_creator_did)
+    AND w.embedding IS NOT NULL
+    AND (1 - (w.embedding <=> p_query_embedding)) >= p_threshold
+    ORDER BY similarity DESC
+    LIMIT p_top_k;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Function: Search workflows using full-text search (fallback for RAG)
+-- Matches English words and Chinese, Japanese and Korean bigrams
+CREATE OR REPLACE FUNCTION search_workflows_fulltext(
+    p_query TEXT,
+    p_project_id UUID,
+    p_top_k INTEGER DEFAULT 5,
+    p_sources TEXT[] DEFAULT NULL,
+    p_template_names TEXT[] DEFAULT NULL,
+    p_tags TEXT[] DEFAULT NULL,
+    p_creator_did TEXT DEFAULT NULL
+)
+RETURNS TABLE (
+    workflow_id UUID,
+    workflow_name VARCHAR(255),
+    description TEXT,
+    source VARCHAR(50),
+    template_name VARCHAR(50),
+    tags TEXT[],
+    relevance FLOAT
+) AS $$
+DECLARE
+    v_query tsquery := workflow_search_query(p_query);
+BEGIN
+    RETURN QUERY
+    SELECT 
+        w.workflow_id,
+        w.workflow_name,
+        w.description,
+        w.source,
+        w.template_name,
+        w.tags,
+        ts_rank(
+            workflow_search_document(w.workflow_name, w.description, w.tags),
+            v_query
+        )::FLOAT AS relevance
+    FROM workflows w
+    LEFT JOIN project_workflow_settings pws 
+        ON w.workflow_id = pws.workflow_id 
+        AND pws.project_id = p_project_id
+    WHERE (
+        w.project_id = p_project_id OR w.is_shared = true
+        OR workflow_share_access(w.workflow_id, p_project_id) IS NOT NULL
+    )
+    AND (pws.is_hidden IS NULL OR pws.is_hidden = false)
+    AND w.deleted_at IS NULL
+    AND (p_sources IS NULL OR w.source = ANY(p_sources))
+    AND (p_template_names IS NULL OR w.template_name = ANY(p_template_names))
+    AND (p_tags IS NULL OR w.tags @> p_tags)
+    AND (p_creator_did IS NULL OR w.creator_did = p_creator_did)
+    AND workflow_search_document(w.workflow_name, w.description, w.tags) @@ v_query
+    ORDER BY relevance DESC
+    LIMIT p_top_k;
+END;
+$$ LANGUAGE plpgsql;
+
+COMMENT ON COLUMN workflows.deleted_at IS '删除时间，非空表示在回收站中，超过保留期后被清理任务彻底删除';
+COMMENT ON COLUMN workflows.deleted_by IS '删除者 DID';
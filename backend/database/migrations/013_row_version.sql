@@ -0,0 +1,31 @@
+-- Migration 013: row versions for optimistic concurrency
+-- update-workflow, share-workflow and delete-workflow honor If-Match against
+-- the row_version returned as the ETag of get-workflow, so concurrent editors
+-- get 412 Precondition Failed instead of overwriting each other
+
+ALTER TABLE workflows ADD COLUMN IF NOT EXISTS row_version INTEGER NOT NULL DEFAULT 1;
+
+-- Trigger: Increment row_version when anything but the embedding changes
+-- Writes sent with If-Match only apply while row_version is unchanged
+CREATE OR REPLACE FUNCTION increment_workflow_row_version()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF (NEW.workflow_name, NEW.description, NEW.source, NEW.template_name, NEW.http_method,
+        NEW.base_url, NEW.bearer_token, NEW.external_workflow_id, NEW.parameters, NEW.headers,
+        NEW.input_schema, NEW.tags, NEW.is_shared, NEW.deleted_at) IS DISTINCT FROM
+       (OLD.workflow_name, OLD.description, OLD.source, OLD.template_name, OLD.http_method,
+        OLD.base_url, OLD.bearer_token, OLD.external_workflow_id, OLD.parameters, OLD.headers,
+        OLD.input_schema, OLD.tags, OLD.is_shared, OLD.deleted_at) THEN
+        NEW.row_version = OLD.row_version + 1;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trigger_increment_workflow_row_version ON workflows;
+CREATE TRIGGER trigger_increment_workflow_row_version
+BEFORE UPDATE ON workflows
+FOR EACH ROW
+EXECUTE FUNCTION increment_workflow_row_version();
+
+COMMENT ON COLUMN workflows.row_version IS '行版本号，任何变更（嵌入向量除外）时自动递增，作为 ETag 用于 If-Match 乐观并发控制';
@@ -0,0 +1,62 @@
+-- Migration 014: embedding model in vector search
+-- search_workflows_vector only compares the query with embeddings of the same
+-- model, so workflows not yet re-embedded by cmd/embed-workflows after a model
+-- change are left out of vector search instead of ranked by unrelated vectors
+
+-- The function gained the p_model parameter; drop the old signature so calls stay unambiguous
+DROP FUNCTION IF EXISTS search_workflows_vector(vector, UUID, INTEGER, FLOAT, TEXT[], TEXT[], TEXT[], TEXT);
+
+-- Function: Search workflows using vector similarity (for RAG)
+-- Filters left NULL match every workflow; p_tags matches workflows having all of the tags
+-- Only embeddings of p_model are compared, vectors of other models are not comparable
+CREATE OR REPLACE FUNCTION search_workflows_vector(
+    p_query_embedding vector(1536),
+    p_project_id UUID,
+    p_top_k INTEGER DEFAULT 5,
+    p_threshold FLOAT DEFAULT 0.7,
+    p_sources TEXT[] DEFAULT NULL,
+    p_template_names TEXT[] DEFAULT NULL,
+    p_tags TEXT[] DEFAULT NULL,
+    p_creator_did TEXT DEFAULT NULL,
+    p_model TEXT DEFAULT NULL
+)
+RETURNS TABLE (
+    workflow_id UUID,
+    workflow_name VARCHAR(255),
+    description TEXT,
+    source VARCHAR(50),
+    template_name VARCHAR(50),
+    tags TEXT[],
+    similarity FLOAT
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT 
+        w.workflow_id,
+        w.workflow_name,
+        w.description,
+        w.source,
+        w.template_name,
+        w.tags,
+        1 - (w.embedding <=> p_query_embedding) AS similarity
+    FROM workflows w
+    LEFT JOIN project_workflow_settings pws 
+        ON w.workflow_id = pws.workflow_id 
+        AND pws.project_id = p_project_id
+    WHERE (
+        w.project_id = p_project_id OR w.is_shared = true
+        OR workflow_share_access(w.workflow_id, p_project_id) IS NOT NULL
+    )
+    AND (pws.is_hidden IS NULL OR pws.is_hidden = false)
+    AND w.deleted_at IS NULL
+    AND (p_sources IS NULL OR w.source = ANY(p_sources))
+    AND (p_template_names IS NULL OR w.template_name = ANY(p_template_names))
+    AND (p_tags IS NULL OR w.tags @> p_tags)
+    AND (p_creator_did IS NULL OR w.creator_did = p_creator_did)
+    AND w.embedding IS NOT NULL
+    AND w.embedding_model = p_model
+    AND (1 - (w.embedding <=> p_query_embedding)) >= p_threshold
+    ORDER BY similarity DESC
+    LIMIT p_top_k;
+END;
+$$ LANGUAGE plpgsql;
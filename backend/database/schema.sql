@@ -9,7 +9,7 @@ CREATE TABLE IF NOT EXISTS workflows (
     workflow_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
     workflow_name VARCHAR(255) NOT NULL,
     description TEXT NOT NULL,
-    source VARCHAR(50) NOT NULL CHECK (source IN ('coze', 'n8n')),
+    source VARCHAR(50) NOT NULL CHECK (source IN ('coze', 'n8n', 'dify', 'langflow', 'flowise', 'openai')),
     template_name VARCHAR(50) NOT NULL CHECK (template_name IN ('workflow', 'streamflow')),
     
     -- HTTP request configuration
@@ -21,6 +21,7 @@ CREATE TABLE IF NOT EXISTS workflows (
     -- Parameters and headers (JSON)
     parameters JSONB DEFAULT '{}',
     headers JSONB DEFAULT '{}',
+    input_schema JSONB DEFAULT '{}',
     
     -- Project and creator
     project_id UUID NOT NULL,
@@ -29,6 +30,16 @@ CREATE TABLE IF NOT EXISTS workflows (
     -- Sharing status
     is_shared BOOLEAN DEFAULT FALSE,
     
+    -- Soft deletion: set while the workflow is in the trash, purged after the retention period
+    deleted_at TIMESTAMP,
+    deleted_by VARCHAR(66),
+    
+    -- Optimistic concurrency: bumped by every change, sent to clients as the ETag
+    row_version INTEGER NOT NULL DEFAULT 1,
+    
+    -- Labels for search filters (lower case)
+    tags TEXT[] NOT NULL DEFAULT '{}',
+    
     -- RAG support (for future AI assistant)
     embedding vector(1536),
     embedding_model VARCHAR(100),
@@ -45,6 +56,8 @@ CREATE INDEX IF NOT EXISTS idx_workflows_project ON workflows(project_id);
 CREATE INDEX IF NOT EXISTS idx_workflows_creator ON workflows(creator_did);
 CREATE INDEX IF NOT EXISTS idx_workflows_shared ON workflows(is_shared);
 CREATE INDEX IF NOT EXISTS idx_workflows_created ON workflows(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_workflows_tags ON workflows USING gin (tags);
+CREATE INDEX IF NOT EXISTS idx_workflows_deleted ON workflows(project_id, deleted_at DESC) WHERE deleted_at IS NOT NULL;
 
 -- Vector index for RAG
 -- Option 1: HNSW (better performance, requires pgvector 0.5.0+)
@@ -76,9 +89,21 @@ CREATE TABLE IF NOT EXISTS project_workflow_settings (
 CREATE INDEX IF NOT EXISTS idx_project_workflow_settings_project ON project_workflow_settings(project_id);
 
 -- Trigger: Update updated_at on workflows
+-- Storing a refreshed embedding is not an edit and keeps updated_at
 CREATE OR REPLACE FUNCTION update_workflows_updated_at()
 RETURNS TRIGGER AS $$
 BEGIN
+    IF (NEW.embedding IS DISTINCT FROM OLD.embedding OR
+        NEW.embedding_model IS DISTINCT FROM OLD.embedding_model OR
+        NEW.embedding_version IS DISTINCT FROM OLD.embedding_version) AND
+       (NEW.workflow_name, NEW.description, NEW.source, NEW.template_name, NEW.http_method,
+        NEW.base_url, NEW.bearer_token, NEW.external_workflow_id, NEW.parameters, NEW.headers,
+        NEW.input_schema, NEW.is_shared) IS NOT DISTINCT FROM
+       (OLD.workflow_name, OLD.description, OLD.source, OLD.template_name, OLD.http_method,
+        OLD.base_url, OLD.bearer_token, OLD.external_workflow_id, OLD.parameters, OLD.headers,
+        OLD.input_schema, OLD.is_shared) THEN
+        RETURN NEW;
+    END IF;
     NEW.updated_at = CURRENT_TIMESTAMP;
     RETURN NEW;
 END;
@@ -96,7 +121,8 @@ BEGIN
     IF (OLD.workflow_name != NEW.workflow_name OR 
         OLD.description != NEW.description OR 
         OLD.parameters != NEW.parameters OR
-        OLD.headers != NEW.headers) THEN
+        OLD.headers != NEW.headers OR
+        OLD.input_schema IS DISTINCT FROM NEW.input_schema) THEN
         NEW.content_version = OLD.content_version + 1;
     END IF;
     RETURN NEW;
@@ -108,6 +134,28 @@ BEFORE UPDATE ON workflows
 FOR EACH ROW
 EXECUTE FUNCTION increment_workflow_content_version();
 
+-- Trigger: Increment row_version when anything but the embedding changes
+-- Writes sent with If-Match only apply while row_version is unchanged
+CREATE OR REPLACE FUNCTION increment_workflow_row_version()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF (NEW.workflow_name, NEW.description, NEW.source, NEW.template_name, NEW.http_method,
+        NEW.base_url, NEW.bearer_token, NEW.external_workflow_id, NEW.parameters, NEW.headers,
+        NEW.input_schema, NEW.tags, NEW.is_shared, NEW.deleted_at) IS DISTINCT FROM
+       (OLD.workflow_name, OLD.description, OLD.source, OLD.template_name, OLD.http_method,
+        OLD.base_url, OLD.bearer_token, OLD.external_workflow_id, OLD.parameters, OLD.headers,
+        OLD.input_schema, OLD.tags, OLD.is_shared, OLD.deleted_at) THEN
+        NEW.row_version = OLD.row_version + 1;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trigger_increment_workflow_row_version
+BEFORE UPDATE ON workflows
+FOR EACH ROW
+EXECUTE FUNCTION increment_workflow_row_version();
+
 -- Trigger: Update updated_at on project_workflow_settings
 CREATE OR REPLACE FUNCTION update_project_workflow_settings_updated_at()
 RETURNS TRIGGER AS $$
@@ -169,23 +217,92 @@ BEGIN
         -- Project's own workflows
         w.project_id = p_project_id
         OR
-        -- Shared workflows from other projects
+        -- Workflows shared with every project, or with this project or its organization
         w.is_shared = true
+        OR
+        workflow_share_access(w.workflow_id, p_project_id) IS NOT NULL
     )
     AND (
         -- Not hidden
         pws.is_hidden IS NULL OR pws.is_hidden = false
     )
+    AND w.deleted_at IS NULL
     ORDER BY w.created_at DESC;
 END;
 $$ LANGUAGE plpgsql;
 
+-- Function: Split CJK text into overlapping bigrams for full-text search
+-- The text search parsers keep a run of Chinese characters as one word, so
+-- '翻译技术文档' is indexed as '翻译 译技 技术 术文 文档' instead
+CREATE OR REPLACE FUNCTION cjk_bigrams(p_text TEXT)
+RETURNS TEXT AS $$
+DECLARE
+    v_run TEXT;
+    v_result TEXT := '';
+    i INTEGER;
+BEGIN
+    FOR v_run IN
+        SELECT m[1] FROM regexp_matches(COALESCE(p_text, ''),
+            '([\u3040-\u30ff\u3400-\u4dbf\u4e00-\u9fff\uac00-\ud7af\uf900-\ufaff]+)', 'g') AS m
+    LOOP
+        IF char_length(v_run) = 1 THEN
+            v_result := v_result || ' ' || v_run;
+        ELSE
+            FOR i IN 1 .. char_length(v_run) - 1 LOOP
+                v_result := v_result || ' ' || substr(v_run, i, 2);
+            END LOOP;
+        END IF;
+    END LOOP;
+    RETURN btrim(v_result);
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+
+-- Function: Full-text document of a workflow (name and tags weigh more than the description)
+CREATE OR REPLACE FUNCTION workflow_search_document(p_name TEXT, p_description TEXT, p_tags TEXT[])
+RETURNS tsvector AS $$
+    SELECT setweight(to_tsvector('english', COALESCE(p_name, '')), 'A') ||
+           setweight(to_tsvector('simple', cjk_bigrams(p_name)), 'A') ||
+           setweight(to_tsvector('english', array_to_string(COALESCE(p_tags, '{}'), ' ')), 'A') ||
+           setweight(to_tsvector('english', COALESCE(p_description, '')), 'B') ||
+           setweight(to_tsvector('simple', cjk_bigrams(p_description)), 'B')
+$$ LANGUAGE sql IMMUTABLE;
+
+-- Function: Full-text query matching workflow_search_document
+-- English words must all match; CJK bigrams match if any of them does
+CREATE OR REPLACE FUNCTION workflow_search_query(p_query TEXT)
+RETURNS tsquery AS $$
+DECLARE
+    v_bigrams TEXT := cjk_bigrams(p_query);
+BEGIN
+    IF v_bigrams = '' THEN
+        RETURN plainto_tsquery('english', p_query);
+    END IF;
+    RETURN plainto_tsquery('english', p_query) ||
+           to_tsquery('simple', array_to_string(regexp_split_to_array(v_bigrams, '\s+'), ' | '));
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+
+CREATE INDEX IF NOT EXISTS idx_workflows_search ON workflows
+USING gin (workflow_search_document(workflow_name, description, tags));
+
+-- Search functions gained filter and model parameters; drop the old signatures so calls stay unambiguous
+DROP FUNCTION IF EXISTS search_workflows_vector(vector, UUID, INTEGER, FLOAT);
+DROP FUNCTION IF EXISTS search_workflows_fulltext(TEXT, UUID, INTEGER);
+DROP FUNCTION IF EXISTS search_workflows_vector(vector, UUID, INTEGER, FLOAT, TEXT[], TEXT[], TEXT[], TEXT);
+
 -- Function: Search workflows using vector similarity (for RAG)
+-- Filters left NULL match every workflow; p_tags matches workflows having all of the tags
+-- Only embeddings of p_model are compared, vectors of other models are not comparable
 CREATE OR REPLACE FUNCTION search_workflows_vector(
     p_query_embedding vector(1536),
     p_project_id UUID,
     p_top_k INTEGER DEFAULT 5,
-    p_threshold FLOAT DEFAULT 0.7
+    p_threshold FLOAT DEFAULT 0.7,
+    p_sources TEXT[] DEFAULT NULL,
+    p_template_names TEXT[] DEFAULT NULL,
+    p_tags TEXT[] DEFAULT NULL,
+    p_creator_did TEXT DEFAULT NULL,
+    p_model TEXT DEFAULT NULL
 )
 RETURNS TABLE (
     workflow_id UUID,
@@ -193,6 +310,7 @@ RETURNS TABLE (
     description TEXT,
     source VARCHAR(50),
     template_name VARCHAR(50),
+    tags TEXT[],
     similarity FLOAT
 ) AS $$
 BEGIN
@@ -203,6 +321,7 @@ BEGIN
         w.description,
         w.source,
         w.template_name,
+        w.tags,
         1 - (w.embedding <=> p_query_embedding) AS similarity
     FROM workflows w
     LEFT JOIN project_workflow_settings pws 
@@ -210,9 +329,16 @@ BEGIN
         AND pws.project_id = p_project_id
     WHERE (
         w.project_id = p_project_id OR w.is_shared = true
+        OR workflow_share_access(w.workflow_id, p_project_id) IS NOT NULL
     )
     AND (pws.is_hidden IS NULL OR pws.is_hidden = false)
+    AND w.deleted_at IS NULL
+    AND (p_sources IS NULL OR w.source = ANY(p_sources))
+    AND (p_template_names IS NULL OR w.template_name = ANY(p_template_names))
+    AND (p_tags IS NULL OR w.tags @> p_tags)
+    AND (p_creator_did IS NULL OR w.creator_did = p_creator_did)
     AND w.embedding IS NOT NULL
+    AND w.embedding_model = p_model
     AND (1 - (w.embedding <=> p_query_embedding)) >= p_threshold
     ORDER BY similarity DESC
     LIMIT p_top_k;
@@ -220,10 +346,15 @@ END;
 $$ LANGUAGE plpgsql;
 
 -- Function: Search workflows using full-text search (fallback for RAG)
+-- Matches English words and Chinese, Japanese and Korean bigrams
 CREATE OR REPLACE FUNCTION search_workflows_fulltext(
     p_query TEXT,
     p_project_id UUID,
-    p_top_k INTEGER DEFAULT 5
+    p_top_k INTEGER DEFAULT 5,
+    p_sources TEXT[] DEFAULT NULL,
+    p_template_names TEXT[] DEFAULT NULL,
+    p_tags TEXT[] DEFAULT NULL,
+    p_creator_did TEXT DEFAULT NULL
 )
 RETURNS TABLE (
     workflow_id UUID,
@@ -231,8 +362,11 @@ RETURNS TABLE (
     description TEXT,
     source VARCHAR(50),
     template_name VARCHAR(50),
+    tags TEXT[],
     relevance FLOAT
 ) AS $$
+DECLARE
+    v_query tsquery := workflow_search_query(p_query);
 BEGIN
     RETURN QUERY
     SELECT 
@@ -241,31 +375,237 @@ BEGIN
         w.description,
         w.source,
         w.template_name,
+        w.tags,
         ts_rank(
-            to_tsvector('english', w.workflow_name || ' ' || w.description),
-            plainto_tsquery('english', p_query)
-        ) AS relevance
+            workflow_search_document(w.workflow_name, w.description, w.tags),
+            v_query
+        )::FLOAT AS relevance
     FROM workflows w
     LEFT JOIN project_workflow_settings pws 
         ON w.workflow_id = pws.workflow_id 
         AND pws.project_id = p_project_id
     WHERE (
         w.project_id = p_project_id OR w.is_shared = true
+        OR workflow_share_access(w.workflow_id, p_project_id) IS NOT NULL
     )
     AND (pws.is_hidden IS NULL OR pws.is_hidden = false)
-    AND (
-        to_tsvector('english', w.workflow_name || ' ' || w.description) @@ 
-        plainto_tsquery('english', p_query)
-    )
+    AND w.deleted_at IS NULL
+    AND (p_sources IS NULL OR w.source = ANY(p_sources))
+    AND (p_template_names IS NULL OR w.template_name = ANY(p_template_names))
+    AND (p_tags IS NULL OR w.tags @> p_tags)
+    AND (p_creator_did IS NULL OR w.creator_did = p_creator_did)
+    AND workflow_search_document(w.workflow_name, w.description, w.tags) @@ v_query
     ORDER BY relevance DESC
     LIMIT p_top_k;
 END;
 $$ LANGUAGE plpgsql;
 
+-- Workflow runs (execution history)
+-- workflow_id is intentionally not a foreign key so history survives workflow deletion
+CREATE TABLE IF NOT EXISTS workflow_runs (
+    run_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workflow_id UUID NOT NULL,
+    project_id UUID NOT NULL,
+    caller_did VARCHAR(66) NOT NULL,
+    mode VARCHAR(20) NOT NULL DEFAULT 'sync' CHECK (mode IN ('sync', 'stream', 'async')),
+    status VARCHAR(20) NOT NULL CHECK (status IN ('queued', 'running', 'succeeded', 'failed', 'cancelled')),
+
+    -- Request and result
+    parameters JSONB DEFAULT '{}',
+    request_headers JSONB,
+    external_execute_id VARCHAR(255),
+    workflow_version INTEGER,
+    status_code INTEGER,
+    latency_ms INTEGER,
+    response_body TEXT,
+    response_truncated BOOLEAN DEFAULT FALSE,
+    error TEXT,
+
+    -- Timestamps
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    started_at TIMESTAMP,
+    finished_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_workflow_runs_workflow ON workflow_runs(workflow_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_workflow_runs_project ON workflow_runs(project_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_workflow_runs_caller ON workflow_runs(caller_did);
+CREATE INDEX IF NOT EXISTS idx_workflow_runs_queue ON workflow_runs(created_at) WHERE status IN ('queued', 'running');
+
+-- Workflow versions (full definition after every change)
+CREATE TABLE IF NOT EXISTS workflow_versions (
+    workflow_id UUID NOT NULL REFERENCES workflows(workflow_id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+
+    -- Definition snapshot
+    workflow_name VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL,
+    source VARCHAR(50) NOT NULL,
+    template_name VARCHAR(50) NOT NULL,
+    http_method VARCHAR(10) NOT NULL,
+    base_url VARCHAR(500) NOT NULL,
+    bearer_token TEXT NOT NULL,
+    external_workflow_id VARCHAR(255) NOT NULL,
+    parameters JSONB DEFAULT '{}',
+    headers JSONB DEFAULT '{}',
+    input_schema JSONB DEFAULT '{}',
+
+    -- Change
+    change_type VARCHAR(20) NOT NULL CHECK (change_type IN ('create', 'update', 'rollback')),
+    changed_fields TEXT[] NOT NULL DEFAULT '{}',
+    changed_by VARCHAR(66) NOT NULL,
+    rolled_back_from INTEGER,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (workflow_id, version)
+);
+
+-- Project API keys for machine-to-machine calls
+-- Only the SHA-256 hash of a key is stored; prefix is the part shown in listings
+CREATE TABLE IF NOT EXISTS api_keys (
+    key_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    prefix VARCHAR(32) NOT NULL UNIQUE,
+    key_hash VARCHAR(64) NOT NULL,
+    scopes TEXT[] NOT NULL CHECK (scopes <@ ARRAY['execute', 'read']::TEXT[] AND cardinality(scopes) > 0),
+    workflow_ids UUID[] NOT NULL DEFAULT '{}',
+    created_by VARCHAR(66) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP,
+    last_used_at TIMESTAMP,
+    revoked_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_project ON api_keys(project_id, created_at DESC);
+
+-- Per-workflow roles on top of the project role in user_projects
+-- user_projects.role is one of viewer, runner, editor, admin, or the legacy member
+CREATE TABLE IF NOT EXISTS workflow_grants (
+    workflow_id UUID NOT NULL REFERENCES workflows(workflow_id) ON DELETE CASCADE,
+    user_did VARCHAR(66) NOT NULL,
+    role VARCHAR(20) NOT NULL CHECK (role IN ('viewer', 'runner', 'editor')),
+    granted_by VARCHAR(66) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (workflow_id, user_did)
+);
+
+CREATE INDEX IF NOT EXISTS idx_workflow_grants_user ON workflow_grants(user_did);
+
+-- Projects of organizations, kept in sync by the account service like user_projects
+CREATE TABLE IF NOT EXISTS organization_projects (
+    organization_id UUID NOT NULL,
+    project_id UUID NOT NULL,
+    PRIMARY KEY (organization_id, project_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_organization_projects_project ON organization_projects(project_id);
+
+-- Workflows shared with other projects, directly or through their organization
+CREATE TABLE IF NOT EXISTS workflow_shares (
+    workflow_id UUID NOT NULL REFERENCES workflows(workflow_id) ON DELETE CASCADE,
+    target_type VARCHAR(20) NOT NULL CHECK (target_type IN ('project', 'organization')),
+    target_id UUID NOT NULL,
+    access VARCHAR(20) NOT NULL DEFAULT 'execute' CHECK (access IN ('read', 'execute')),
+    shared_by VARCHAR(66) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (workflow_id, target_type, target_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_workflow_shares_target ON workflow_shares(target_type, target_id);
+
+-- Function: Access a project has to a workflow through workflow_shares
+-- Returns 'execute', 'read', or NULL when the workflow is not shared with it
+CREATE OR REPLACE FUNCTION workflow_share_access(p_workflow_id UUID, p_project_id UUID)
+RETURNS VARCHAR AS $$
+BEGIN
+    RETURN (
+        SELECT s.access
+        FROM workflow_shares s
+        WHERE s.workflow_id = p_workflow_id
+        AND (
+            (s.target_type = 'project' AND s.target_id = p_project_id)
+            OR
+            (s.target_type = 'organization' AND s.target_id IN (
+                SELECT op.organization_id FROM organization_projects op
+                WHERE op.project_id = p_project_id
+            ))
+        )
+        ORDER BY s.access = 'execute' DESC
+        LIMIT 1
+    );
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+-- Audit log of workflow changes and executions
+-- Append-only: triggers reject UPDATE, DELETE and TRUNCATE. workflow_id is
+-- intentionally not a foreign key so events outlive deleted workflows
+CREATE TABLE IF NOT EXISTS audit_events (
+    event_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    action VARCHAR(50) NOT NULL,
+    actor_did VARCHAR(66) NOT NULL,
+    project_id UUID NOT NULL,
+    workflow_id UUID,
+
+    -- Field changes with secrets redacted, and action specific details
+    changes JSONB,
+    details JSONB,
+
+    -- Request
+    source_ip VARCHAR(64),
+    user_agent TEXT,
+    request_id VARCHAR(128),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_project ON audit_events(project_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_events_actor ON audit_events(actor_did, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_events_workflow ON audit_events(workflow_id, created_at DESC);
+
+-- Trigger: Keep audit events immutable
+CREATE OR REPLACE FUNCTION reject_audit_event_change()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trigger_audit_events_append_only
+BEFORE UPDATE OR DELETE ON audit_events
+FOR EACH ROW
+EXECUTE FUNCTION reject_audit_event_change();
+
+CREATE TRIGGER trigger_audit_events_no_truncate
+BEFORE TRUNCATE ON audit_events
+FOR EACH STATEMENT
+EXECUTE FUNCTION reject_audit_event_change();
+
 -- Comments
 COMMENT ON TABLE workflows IS 'AI工作流配置表';
 COMMENT ON TABLE project_workflow_settings IS '项目工作流设置表（用于隐藏工作流）';
 COMMENT ON COLUMN workflows.embedding IS '工作流向量表示（用于RAG搜索）';
 COMMENT ON COLUMN workflows.content_version IS '内容版本号，内容变更时自动递增';
 COMMENT ON COLUMN workflows.embedding_version IS '向量版本号，用于追踪向量是否需要更新';
+COMMENT ON COLUMN workflows.tags IS '工作流标签（小写），用于搜索过滤';
+COMMENT ON COLUMN workflows.input_schema IS '输入参数的JSON Schema（类型、必填、枚举、范围、默认值），空对象表示不校验';
+COMMENT ON COLUMN workflows.deleted_at IS '删除时间，非空表示在回收站中，超过保留期后被清理任务彻底删除';
+COMMENT ON COLUMN workflows.deleted_by IS '删除者 DID';
+COMMENT ON COLUMN workflows.row_version IS '行版本号，任何变更（嵌入向量除外）时自动递增，作为 ETag 用于 If-Match 乐观并发控制';
+COMMENT ON TABLE workflow_runs IS '工作流执行记录表';
+COMMENT ON TABLE workflow_versions IS '工作流版本历史表，每次变更后保存完整定义';
+COMMENT ON COLUMN workflow_versions.rolled_back_from IS '回滚时恢复的目标版本号';
+COMMENT ON COLUMN workflow_runs.workflow_version IS '本次执行使用的工作流版本';
+COMMENT ON TABLE api_keys IS '项目API密钥表，用于CI和后端服务调用工作流';
+COMMENT ON COLUMN api_keys.key_hash IS '密钥的SHA-256哈希，明文只在创建时返回一次';
+COMMENT ON COLUMN api_keys.scopes IS '权限范围：execute（执行）、read（只读）';
+COMMENT ON COLUMN api_keys.workflow_ids IS '允许访问的工作流，空数组表示项目内全部工作流';
+COMMENT ON TABLE workflow_grants IS '工作流授权表，在项目角色之外给用户单个工作流的角色';
+COMMENT ON COLUMN workflow_grants.role IS '授予的角色：viewer（查看）、runner（执行）、editor（编辑）';
+COMMENT ON TABLE organization_projects IS '组织与项目的对应关系，用于按组织共享工作流';
+COMMENT ON TABLE workflow_shares IS '工作流共享表，把工作流共享给指定项目或组织';
+COMMENT ON COLUMN workflow_shares.access IS '共享权限：read（只读）、execute（可执行）';
+COMMENT ON TABLE audit_events IS '审计日志表，记录工作流的创建、修改、删除、共享、隐藏和执行，只允许追加';
+COMMENT ON COLUMN audit_events.changes IS '字段变更前后的值，token和敏感请求头已脱敏';
 
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/xzero/ai-workflow/pkg/middleware"
+	"github.com/xzero/ai-workflow/pkg/models"
+	"github.com/xzero/ai-workflow/pkg/policy"
+	"github.com/xzero/ai-workflow/pkg/response"
+)
+
+// AddShares serves POST /api/workflows/{id}/shares. It shares the workflow
+// with projects and organizations, replacing the access of existing shares
+// with the same targets.
+func (a *API) AddShares(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	claims, ok := middleware.ClaimsFrom(ctx)
+	if !ok {
+		return response.Unauthorized("Missing credentials"), nil
+	}
+
+	// Get workflow_id from path parameters
+	workflowID := request.PathParameters["id"]
+	if workflowID == "" {
+		return response.BadRequest("Missing workflow_id"), nil
+	}
+
+	// Parse request body
+	var req models.AddWorkflowSharesRequest
+	if err := json.Unmarshal([]byte(request.Body), &req); err != nil {
+		return response.BadRequest("Invalid request body"), nil
+	}
+	if req.Access == "" {
+		req.Access = models.ShareAccessExecute
+	}
+	if !models.IsShareAccess(req.Access) {
+		return response.BadRequest("Invalid access, must be 'read' or 'execute'"), nil
+	}
+	if len(req.ProjectIDs) == 0 && len(req.OrganizationIDs) == 0 {
+		return response.BadRequest("Missing project_ids or organization_ids"), nil
+	}
+
+	wf, err := a.workflows.Get(workflowID)
+	if err != nil {
+		return lookupError(ctx, err, "workflow"), nil
+	}
+
+	if resp, ok := a.authorize(ctx, claims, policy.ActionWorkflowShare, policy.Workflow(wf), "Only project admin can share workflows"); !ok {
+		return resp, nil
+	}
+
+	// Collect the targets once each
+	targets := []models.WorkflowShare{}
+	for _, id := range req.ProjectIDs {
+		targets = append(targets, models.WorkflowShare{TargetType: models.ShareTargetProject, TargetID: id})
+	}
+	for _, id := range req.OrganizationIDs {
+		targets = append(targets, models.WorkflowShare{TargetType: models.ShareTargetOrganization, TargetID: id})
+	}
+	seen := map[models.WorkflowShare]bool{}
+	unique := targets[:0]
+	for _, target := range targets {
+		if target.TargetID == "" {
+			return response.BadRequest("Invalid " + target.TargetType + " ID"), nil
+		}
+		if target.TargetType == models.ShareTargetProject && target.TargetID == wf.ProjectID {
+			return response.BadRequest("Workflow already belongs to project " + target.TargetID), nil
+		}
+		if !seen[target] {
+			seen[target] = true
+			unique = append(unique, target)
+		}
+	}
+
+	shares := make([]models.WorkflowShare, 0, len(unique))
+	for _, target := range unique {
+		share := &models.WorkflowShare{
+			WorkflowID: workflowID,
+			TargetType: target.TargetType,
+			TargetID:   target.TargetID,
+			Access:     req.Access,
+			SharedBy:   claims.DID,
+		}
+		if err := a.workflows.SetShare(share); err != nil {
+			middleware.Log(ctx).Error("Error saving share", "error", err)
+			return response.InternalError("Failed to share workflow"), nil
+		}
+		shares = append(shares, *share)
+	}
+
+	a.audit.Emit(ctx, models.AuditEvent{
+		Action:     models.AuditWorkflowShare,
+		ProjectID:  wf.ProjectID,
+		WorkflowID: workflowID,
+		Details:    map[string]interface{}{"shares": shares},
+	})
+
+	return response.Success(map[string]interface{}{
+		"workflow_id": workflowID,
+		"shares":      shares,
+	}), nil
+}
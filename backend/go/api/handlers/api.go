@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/xzero/ai-workflow/pkg/audit"
+	"github.com/xzero/ai-workflow/pkg/auth"
+	"github.com/xzero/ai-workflow/pkg/db"
+	"github.com/xzero/ai-workflow/pkg/embedding"
+	"github.com/xzero/ai-workflow/pkg/llm"
+	"github.com/xzero/ai-workflow/pkg/middleware"
+	"github.com/xzero/ai-workflow/pkg/models"
+	"github.com/xzero/ai-workflow/pkg/policy"
+	"github.com/xzero/ai-workflow/pkg/response"
+	"github.com/xzero/ai-workflow/pkg/search"
+	"github.com/xzero/ai-workflow/pkg/secrets"
+	"github.com/xzero/ai-workflow/pkg/store"
+)
+
+// embedTimeout bounds the embedding refresh after a write, the embed-workflows
+// worker catches up on anything that did not finish in time
+const embedTimeout = 5 * time.Second
+
+// API implements the HTTP endpoints. Handlers take API Gateway proxy
+// requests, so the same code serves the Lambda functions in cmd/ and the
+// standalone server in cmd/server.
+type API struct {
+	db        *sql.DB
+	workflows store.WorkflowStore
+	runs      store.RunStore
+	search    store.SearchStore
+	policy    *policy.Policy
+	apiKeys   store.APIKeyStore
+	audit     *audit.Emitter
+	auditLog  store.AuditStore
+	keys      secrets.KeyProvider
+	verifier  auth.Verifier
+	logger    *slog.Logger
+	embedder  embedding.Provider
+	llm       llm.Provider
+}
+
+// New returns the API backed by database. keys may be nil, in which case
+// bearer tokens are stored unencrypted. Tokens are verified with JWT_SECRET
+// until SetVerifier is called; API keys are accepted as well.
+func New(database *sql.DB, keys secrets.KeyProvider) *API {
+	postgres := store.NewPostgres(database)
+	return &API{
+		db:        database,
+		workflows: postgres,
+		runs:      postgres,
+		search:    postgres,
+		policy:    policy.New(postgres),
+		apiKeys:   postgres,
+		audit:     audit.NewEmitter(postgres),
+		auditLog:  postgres,
+		keys:      keys,
+		verifier:  auth.NewHMACVerifier(os.Getenv("JWT_SECRET")),
+		logger:    middleware.NewJSONLogger(),
+	}
+}
+
+// NewFromEnv connects to the database configured in the environment (see
+// db.ConfigFromEnv) and loads the token verifier, secrets key, embedding
+// provider and LLM provider. It also routes the log package through the
+// JSON logger.
+func NewFromEnv() (*API, error) {
+	verifier, err := auth.VerifierFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	database, err := db.ConnectFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := secrets.ProviderFromEnv()
+	if err != nil {
+		database.Close()
+		return nil, err
+	}
+	if keys == nil {
+		log.Printf("Warning: SECRETS_KEY is not configured, bearer tokens are stored unencrypted")
+	}
+
+	embedder, err := embedding.ProviderFromEnv()
+	if err != nil {
+		database.Close()
+		return nil, err
+	}
+
+	model, err := llm.ProviderFromEnv()
+	if err != nil {
+		database.Close()
+		return nil, err
+	}
+
+	a := New(database, keys)
+	a.SetVerifier(verifier)
+	a.SetEmbedder(embedder)
+	a.SetLLM(model)
+	slog.SetDefault(a.logger)
+	return a, nil
+}
+
+// DB returns the database used by the handlers
+func (a *API) DB() *sql.DB {
+	return a.db
+}
+
+// SetWorkflowStore replaces the store of workflows, versions, project roles
+// and grants, such as with a store.Memory in tests
+func (a *API) SetWorkflowStore(workflows store.WorkflowStore) {
+	a.workflows = workflows
+	a.policy = policy.New(workflows)
+}
+
+// SetRunStore replaces the store of workflow runs
+func (a *API) SetRunStore(runs store.RunStore) {
+	a.runs = runs
+}
+
+// SetSearchStore replaces the store searched for workflows and holding their
+// embeddings. Pass the same store.Memory as SetWorkflowStore so searches see
+// its workflows.
+func (a *API) SetSearchStore(index store.SearchStore) {
+	a.search = index
+}
+
+// WorkflowStore returns the store of workflows
+func (a *API) WorkflowStore() store.WorkflowStore {
+	return a.workflows
+}
+
+// SetAPIKeyStore replaces the store API keys are created in and verified
+// against
+func (a *API) SetAPIKeyStore(apiKeys store.APIKeyStore) {
+	a.apiKeys = apiKeys
+}
+
+// SetAuditStore replaces the store the audit log is written to and read from
+func (a *API) SetAuditStore(auditLog store.AuditStore) {
+	a.audit = audit.NewEmitter(auditLog)
+	a.auditLog = auditLog
+}
+
+// SetVerifier sets the verifier of bearer tokens other than API keys
+func (a *API) SetVerifier(verifier auth.Verifier) {
+	a.verifier = verifier
+}
+
+// SetLogger sets the logger of the middleware chain
+func (a *API) SetLogger(logger *slog.Logger) {
+	a.logger = logger
+}
+
+// Wrap returns h behind the middleware chain: request ID, client info, JSON
+// access log, panic recovery and authentication. h reads the caller with
+// middleware.ClaimsFrom.
+func (a *API) Wrap(h ProxyHandler) ProxyHandler {
+	return middleware.Chain(h,
+		middleware.RequestID(),
+		middleware.Client(),
+		middleware.Logger(a.logger),
+		middleware.Recover(),
+		middleware.Authenticate(a.tokenVerifier()),
+	)
+}
+
+// tokenVerifier returns the verifier of bearer tokens, API keys included
+func (a *API) tokenVerifier() auth.Verifier {
+	return auth.WithAPIKeys(a.verifier, a.apiKeys)
+}
+
+// WrapPublic is Wrap without authentication
+func (a *API) WrapPublic(h ProxyHandler) ProxyHandler {
+	return middleware.Chain(h,
+		middleware.RequestID(),
+		middleware.Client(),
+		middleware.Logger(a.logger),
+		middleware.Recover(),
+	)
+}
+
+// Keys returns the key provider used for bearer tokens
+func (a *API) Keys() secrets.KeyProvider {
+	return a.keys
+}
+
+// SetEmbedder sets the provider used to embed search queries and written
+// workflows. Without one, search falls back to full-text search.
+func (a *API) SetEmbedder(provider embedding.Provider) {
+	a.embedder = provider
+}
+
+// Embedder returns the embedding provider, nil if none is configured
+func (a *API) Embedder() embedding.Provider {
+	return a.embedder
+}
+
+// SetLLM sets the language model used by the assistant. Without one, the
+// assistant endpoint is unavailable.
+func (a *API) SetLLM(provider llm.Provider) {
+	a.llm = provider
+}
+
+// LLM returns the language model, nil if none is configured
+func (a *API) LLM() llm.Provider {
+	return a.llm
+}
+
+// applyVersion replaces the definition of wf with a pinned version. A zero
+// version keeps the latest definition. It returns store.ErrNotFound when the
+// version does not exist.
+func (a *API) applyVersion(wf *models.Workflow, version int) error {
+	if version == 0 || version == wf.Version {
+		return nil
+	}
+	v, err := a.workflows.GetVersion(wf.WorkflowID, version)
+	if err != nil {
+		return err
+	}
+	v.Apply(wf)
+	return nil
+}
+
+// refreshEmbedding embeds a created or updated workflow. Failures are only
+// logged since the write itself succeeded.
+func (a *API) refreshEmbedding(ctx context.Context, workflowID string) {
+	if a.embedder == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, embedTimeout)
+	defer cancel()
+	if err := search.EmbedWorkflow(ctx, a.search, a.embedder, workflowID); err != nil {
+		middleware.Log(ctx).Error("Error embedding workflow", "workflow_id", workflowID, "error", err)
+	}
+}
+
+// auditRun records the execution of wf as run, which may be recorded
+// against another project
+func (a *API) auditRun(ctx context.Context, wf *models.Workflow, run *models.WorkflowRun, runID string) {
+	details := map[string]interface{}{
+		"run_id":  runID,
+		"mode":    run.Mode,
+		"status":  run.Status,
+		"version": run.WorkflowVersion,
+	}
+	if run.ProjectID != wf.ProjectID {
+		details["run_project_id"] = run.ProjectID
+	}
+	a.audit.Emit(ctx, models.AuditEvent{
+		Action:     models.AuditWorkflowExecute,
+		ActorDID:   run.CallerDID,
+		ProjectID:  wf.ProjectID,
+		WorkflowID: wf.WorkflowID,
+		Details:    details,
+	})
+}
+
+// authorize asks the policy whether the caller may perform action on
+// resource. When not, ok is false and resp is a 403 with message, or a 500
+// when the check failed.
+func (a *API) authorize(ctx context.Context, claims *auth.Claims, action policy.Action, resource policy.Resource, message string) (resp events.APIGatewayProxyResponse, ok bool) {
+	allowed, err := a.policy.Authorize(claims, action, resource)
+	if err != nil {
+		middleware.Log(ctx).Error("Error checking permissions", "error", err)
+		return response.InternalError("Failed to check permissions"), false
+	}
+	if !allowed {
+		return response.Forbidden(message), false
+	}
+	return resp, true
+}
+
+// lookupError maps store.ErrNotFound to a 404 and logs any other error of
+// getting what, such as "workflow" or "workflow version", as a 500
+func lookupError(ctx context.Context, err error, what string) events.APIGatewayProxyResponse {
+	if err == store.ErrNotFound {
+		return response.NotFound(strings.ToUpper(what[:1]) + what[1:] + " not found")
+	}
+	middleware.Log(ctx).Error("Error getting "+what, "error", err)
+	return response.InternalError("Failed to get " + what)
+}
@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/xzero/ai-workflow/pkg/auth"
+	"github.com/xzero/ai-workflow/pkg/models"
+	"github.com/xzero/ai-workflow/pkg/secrets"
+	"github.com/xzero/ai-workflow/pkg/store"
+)
+
+const (
+	testSecret  = "test-secret"
+	testProject = "project-1"
+
+	// Callers; the creator is a plain member creating the test workflows
+	adminDID    = "did:example:admin"
+	editorDID   = "did:example:editor"
+	creatorDID  = "did:example:creator"
+	memberDID   = "did:example:member"
+	runnerDID   = "did:example:runner"
+	viewerDID   = "did:example:viewer"
+	outsiderDID = "did:example:outsider"
+
+	// Credentials of the test workflows, which responses must never carry
+	upstreamToken  = "upstream-token"
+	upstreamAPIKey = "upstream-api-key"
+)
+
+// testServer serves the routes of an API backed by a store.Memory
+type testServer struct {
+	t      *testing.T
+	api    *API
+	mem    *store.Memory
+	router *Router
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	keys, err := secrets.NewLocalKeyProvider(bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mem := store.NewMemory()
+	a := New(nil, keys)
+	a.SetWorkflowStore(mem)
+	a.SetRunStore(mem)
+	a.SetSearchStore(mem)
+	a.SetAPIKeyStore(mem)
+	a.SetAuditStore(mem)
+	a.SetVerifier(auth.NewHMACVerifier(testSecret))
+	a.SetLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	mem.AddMember(testProject, adminDID, models.RoleAdmin)
+	mem.AddMember(testProject, editorDID, models.RoleEditor)
+	mem.AddMember(testProject, creatorDID, models.RoleMember)
+	mem.AddMember(testProject, memberDID, models.RoleMember)
+	mem.AddMember(testProject, runnerDID, models.RoleRunner)
+	mem.AddMember(testProject, viewerDID, models.RoleViewer)
+
+	return &testServer{t: t, api: a, mem: mem, router: NewRouter(a.Routes())}
+}
+
+// do sends a request as did, anonymously when did is empty
+func (s *testServer) do(did, method, path, body string, headers map[string]string) *httptest.ResponseRecorder {
+	s.t.Helper()
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	if did != "" {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, &auth.Claims{DID: did}).SignedString([]byte(testSecret))
+		if err != nil {
+			s.t.Fatal(err)
+		}
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	for k, v := range headers {
+		r.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, r)
+	return w
+}
+
+// createWorkflow creates the Summarize workflow of testProject as
+// creatorDID and returns its ID
+func (s *testServer) createWorkflow() string {
+	s.t.Helper()
+	return s.postWorkflow(models.CreateWorkflowRequest{
+		WorkflowName:       "Summarize",
+		Description:        "Summarizes a document",
+		Source:             "n8n",
+		TemplateName:       "workflow",
+		HTTPMethod:         "POST",
+		BaseURL:            "https://n8n.example/webhook",
+		BearerToken:        upstreamToken,
+		ExternalWorkflowID: "wf-42",
+		Headers:            json.RawMessage(`{"X-API-Key": "` + upstreamAPIKey + `", "X-Trace": "on"}`),
+		ProjectID:          testProject,
+	})
+}
+
+// postWorkflow creates the workflow of req as creatorDID and returns its ID
+func (s *testServer) postWorkflow(req models.CreateWorkflowRequest) string {
+	s.t.Helper()
+	body, _ := json.Marshal(req)
+	w := s.do(creatorDID, http.MethodPost, "/api/workflows", string(body), nil)
+	if w.Code != http.StatusOK {
+		s.t.Fatalf("creating workflow: status %d: %s", w.Code, w.Body)
+	}
+	var created struct {
+		WorkflowID string `json:"workflow_id"`
+	}
+	decodeData(s.t, w, &created)
+	return created.WorkflowID
+}
+
+// decodeData decodes the data of a success response into v
+func decodeData(t *testing.T, w *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	var body struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decoding response %q: %v", w.Body, err)
+	}
+	if err := json.Unmarshal(body.Data, v); err != nil {
+		t.Fatalf("decoding data %s: %v", body.Data, err)
+	}
+}
+
+func TestAuthorization(t *testing.T) {
+	const update = `{"description": "Summarizes anything"}`
+
+	tests := []struct {
+		name   string
+		did    string
+		method string
+		path   string // {id} is replaced by the test workflow
+		body   string
+		setup  func(t *testing.T, s *testServer, workflowID string)
+		want   int
+	}{
+		{"anonymous", "", http.MethodGet, "/api/workflows/{id}", "", nil, http.StatusUnauthorized},
+		{"API key principal in a JWT", "apikey:key-1", http.MethodGet, "/api/workflows/{id}", "", nil, http.StatusUnauthorized},
+		{"viewer reads", viewerDID, http.MethodGet, "/api/workflows/{id}", "", nil, http.StatusOK},
+		{"outsider reads", outsiderDID, http.MethodGet, "/api/workflows/{id}", "", nil, http.StatusForbidden},
+		{"outsider reads a shared workflow", outsiderDID, http.MethodGet, "/api/workflows/{id}", "", func(t *testing.T, s *testServer, id string) {
+			if err := s.mem.SetShared(id, true, 0); err != nil {
+				t.Fatal(err)
+			}
+		}, http.StatusOK},
+		{"unknown workflow", adminDID, http.MethodGet, "/api/workflows/missing", "", nil, http.StatusNotFound},
+
+		{"viewer lists", viewerDID, http.MethodGet, "/api/projects/" + testProject + "/workflows", "", nil, http.StatusOK},
+		{"outsider lists", outsiderDID, http.MethodGet, "/api/projects/" + testProject + "/workflows", "", nil, http.StatusForbidden},
+
+		{"viewer updates", viewerDID, http.MethodPut, "/api/workflows/{id}", update, nil, http.StatusForbidden},
+		{"runner updates", runnerDID, http.MethodPut, "/api/workflows/{id}", update, nil, http.StatusForbidden},
+		{"other member updates", memberDID, http.MethodPut, "/api/workflows/{id}", update, nil, http.StatusForbidden},
+		{"creator updates", creatorDID, http.MethodPut, "/api/workflows/{id}", update, nil, http.StatusOK},
+		{"editor updates", editorDID, http.MethodPut, "/api/workflows/{id}", update, nil, http.StatusOK},
+		{"viewer with an editor grant updates", viewerDID, http.MethodPut, "/api/workflows/{id}", update, func(t *testing.T, s *testServer, id string) {
+			if err := s.mem.SetGrant(&models.WorkflowGrant{WorkflowID: id, UserDID: viewerDID, Role: models.RoleEditor}); err != nil {
+				t.Fatal(err)
+			}
+		}, http.StatusOK},
+
+		{"editor deletes", editorDID, http.MethodDelete, "/api/workflows/{id}", "", nil, http.StatusForbidden},
+		{"creator deletes", creatorDID, http.MethodDelete, "/api/workflows/{id}", "", nil, http.StatusOK},
+		{"admin deletes", adminDID, http.MethodDelete, "/api/workflows/{id}", "", nil, http.StatusOK},
+
+		{"editor reveals the token", editorDID, http.MethodGet, "/api/workflows/{id}/token", "", nil, http.StatusForbidden},
+		{"creator reveals the token", creatorDID, http.MethodGet, "/api/workflows/{id}/token", "", nil, http.StatusOK},
+
+		{"editor shares", editorDID, http.MethodPut, "/api/workflows/{id}/share", `{"is_shared": true}`, nil, http.StatusForbidden},
+		{"viewer reads the audit log", viewerDID, http.MethodGet, "/api/projects/" + testProject + "/audit-events", "", nil, http.StatusForbidden},
+		{"admin reads the audit log", adminDID, http.MethodGet, "/api/projects/" + testProject + "/audit-events", "", nil, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t)
+			id := s.createWorkflow()
+			if tt.setup != nil {
+				tt.setup(t, s, id)
+			}
+			w := s.do(tt.did, tt.method, strings.ReplaceAll(tt.path, "{id}", id), tt.body, nil)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
+
+func TestCreateWorkflowAuthorization(t *testing.T) {
+	tests := []struct {
+		did  string
+		want int
+	}{
+		{viewerDID, http.StatusForbidden},
+		{runnerDID, http.StatusForbidden},
+		{memberDID, http.StatusOK},
+		{outsiderDID, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.did, func(t *testing.T) {
+			s := newTestServer(t)
+			body := `{"workflow_name": "a", "description": "b", "source": "n8n", "template_name": "workflow",
+				"http_method": "POST", "base_url": "https://n8n.example", "bearer_token": "t",
+				"external_workflow_id": "c", "project_id": "` + testProject + `"}`
+			w := s.do(tt.did, http.MethodPost, "/api/workflows", body, nil)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
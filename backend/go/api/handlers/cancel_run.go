@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/xzero/ai-workflow/pkg/middleware"
+	"github.com/xzero/ai-workflow/pkg/models"
+	"github.com/xzero/ai-workflow/pkg/policy"
+	"github.com/xzero/ai-workflow/pkg/response"
+)
+
+// CancelRun serves DELETE /api/runs/{runId}
+func (a *API) CancelRun(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	claims, ok := middleware.ClaimsFrom(ctx)
+	if !ok {
+		return response.Unauthorized("Missing credentials"), nil
+	}
+
+	// Get run_id from path parameters
+	runID := request.PathParameters["runId"]
+	if runID == "" {
+		return response.BadRequest("Missing run_id"), nil
+	}
+
+	// Get run to check permissions
+	run, err := a.runs.GetRun(runID)
+	if err != nil {
+		return lookupError(ctx, err, "run"), nil
+	}
+
+	// Check permissions
+	// Caller can cancel their own run
+	// Admin can cancel any run of the project
+	if resp, ok := a.authorize(ctx, claims, policy.ActionRunCancel, policy.Run(run), "Only admin or caller can cancel this run"); !ok {
+		return resp, nil
+	}
+
+	// Cancel run; the worker notices the status change and aborts the upstream call
+	cancelled, err := a.runs.CancelRun(runID)
+	if err != nil {
+		middleware.Log(ctx).Error("Error cancelling run", "error", err)
+		return response.InternalError("Failed to cancel run"), nil
+	}
+	if !cancelled {
+		return response.Error(409, "Run already finished with status "+run.Status), nil
+	}
+
+	return response.Success(map[string]interface{}{
+		"run_id": runID,
+		"status": models.RunStatusCancelled,
+	}), nil
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/xzero/ai-workflow/pkg/auth"
+	"github.com/xzero/ai-workflow/pkg/middleware"
+	"github.com/xzero/ai-workflow/pkg/models"
+	"github.com/xzero/ai-workflow/pkg/policy"
+	"github.com/xzero/ai-workflow/pkg/response"
+	"github.com/xzero/ai-workflow/pkg/store"
+)
+
+const maxAPIKeyNameLength = 255
+
+// CreateAPIKey serves POST /api/projects/{projectId}/api-keys. The key is
+// only returned in this response; afterwards it is identified by its prefix.
+func (a *API) CreateAPIKey(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	claims, ok := middleware.ClaimsFrom(ctx)
+	if !ok {
+		return response.Unauthorized("Missing credentials"), nil
+	}
+
+	// Get project_id from path parameters
+	projectID := request.PathParameters["projectId"]
+	if projectID == "" {
+		return response.BadRequest("Missing project_id"), nil
+	}
+
+	// Parse request body
+	var req models.CreateAPIKeyRequest
+	if err := json.Unmarshal([]byte(request.Body), &req); err != nil {
+		return response.BadRequest("Invalid request body"), nil
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > maxAPIKeyNameLength {
+		return response.BadRequest("Invalid name, must be 1 to 255 characters"), nil
+	}
+	scopes, ok := apiKeyScopes(req.Scopes)
+	if !ok {
+		return response.BadRequest("Invalid scopes, must be one or more of: execute, read"), nil
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return response.BadRequest("Invalid expires_at, must be in the future"), nil
+	}
+
+	// Check permissions - only project admin can manage API keys
+	if resp, ok := a.authorize(ctx, claims, policy.ActionManageAPIKeys, policy.Project(projectID), "Only project admin can manage API keys"); !ok {
+		return resp, nil
+	}
+
+	// Keys may be limited to workflows the project can see
+	workflowIDs := []string{}
+	for _, id := range req.WorkflowIDs {
+		wf, err := a.workflows.Get(id)
+		if err == store.ErrNotFound {
+			return response.BadRequest("Invalid workflow_ids, workflow not found: " + id), nil
+		}
+		if err != nil {
+			return lookupError(ctx, err, "workflow"), nil
+		}
+		visible, err := a.policy.Authorize(claims, policy.ActionWorkflowRead, policy.WorkflowIn(projectID, wf))
+		if err != nil {
+			middleware.Log(ctx).Error("Error checking permissions", "error", err)
+			return response.InternalError("Failed to check permissions"), nil
+		}
+		if !visible {
+			return response.BadRequest("Invalid workflow_ids, workflow not found: " + id), nil
+		}
+		workflowIDs = append(workflowIDs, wf.WorkflowID)
+	}
+
+	secret, prefix, hash, err := auth.GenerateAPIKey()
+	if err != nil {
+		middleware.Log(ctx).Error("Error generating API key", "error", err)
+		return response.InternalError("Failed to create API key"), nil
+	}
+	key := &models.APIKey{
+		ProjectID:   projectID,
+		Name:        req.Name,
+		Prefix:      prefix,
+		KeyHash:     hash,
+		Scopes:      scopes,
+		WorkflowIDs: workflowIDs,
+		CreatedBy:   claims.DID,
+		ExpiresAt:   req.ExpiresAt,
+	}
+	if _, err := a.apiKeys.CreateAPIKey(key); err != nil {
+		middleware.Log(ctx).Error("Error creating API key", "error", err)
+		return response.InternalError("Failed to create API key"), nil
+	}
+
+	a.audit.Emit(ctx, models.AuditEvent{
+		Action:    models.AuditAPIKeyCreate,
+		ProjectID: projectID,
+		Details: map[string]interface{}{
+			"key_id":       key.KeyID,
+			"name":         key.Name,
+			"prefix":       key.Prefix,
+			"scopes":       key.Scopes,
+			"workflow_ids": key.WorkflowIDs,
+		},
+	})
+
+	return response.Success(&models.CreateAPIKeyResponse{APIKey: key, Key: secret}), nil
+}
+
+// apiKeyScopes deduplicates scopes, false when one is unknown or none is given
+func apiKeyScopes(scopes []string) ([]string, bool) {
+	seen := map[string]bool{}
+	result := []string{}
+	for _, scope := range scopes {
+		if scope != models.APIKeyScopeExecute && scope != models.APIKeyScopeRead {
+			return nil, false
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			result = append(result, scope)
+		}
+	}
+	return result, len(result) > 0
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/xzero/ai-workflow/pkg/audit"
+	"github.com/xzero/ai-workflow/pkg/middleware"
+	"github.com/xzero/ai-workflow/pkg/models"
+	"github.com/xzero/ai-workflow/pkg/policy"
+	"github.com/xzero/ai-workflow/pkg/response"
+	"github.com/xzero/ai-workflow/pkg/schema"
+	"github.com/xzero/ai-workflow/pkg/search"
+	"github.com/xzero/ai-workflow/pkg/secrets"
+	"github.com/xzero/ai-workflow/pkg/workflow"
+)
+
+// CreateWorkflow serves POST /api/workflows
+func (a *API) CreateWorkflow(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	claims, ok := middleware.ClaimsFrom(ctx)
+	if !ok {
+		return response.Unauthorized("Missing credentials"), nil
+	}
+
+	// Parse request body
+	var req models.CreateWorkflowRequest
+	if err := json.Unmarshal([]byte(request.Body), &req); err != nil {
+		return response.BadRequest("Invalid request body"), nil
+	}
+
+	// Validate fields
+	if err := validateCreateRequest(&req); err != nil {
+		return response.BadRequest(err.Error()), nil
+	}
+
+	// Members, editors and admins of the project can create workflows
+	if resp, ok := a.authorize(ctx, claims, policy.ActionWorkflowCreate, policy.Project(req.ProjectID), "Access denied to this project"); !ok {
+		return resp, nil
+	}
+
+	// Set default parameters and headers if not provided
+	if req.Parameters == nil {
+		req.Parameters = json.RawMessage("{}")
+	}
+	if req.Headers == nil {
+		req.Headers = json.RawMessage("{}")
+	}
+	if schema.IsEmpty(req.InputSchema) {
+		req.InputSchema = json.RawMessage("{}")
+	}
+
+	// Encrypt bearer token at rest
+	var err error
+	req.BearerToken, err = secrets.Encrypt(ctx, a.keys, req.BearerToken)
+	if err != nil {
+		middleware.Log(ctx).Error("Error encrypting bearer token", "error", err)
+		return response.InternalError("Failed to encrypt bearer token"), nil
+	}
+
+	// Create workflow
+	workflowID, err := a.workflows.Create(&req, claims.DID)
+	if err != nil {
+		middleware.Log(ctx).Error("Error creating workflow", "error", err)
+		return response.InternalError("Failed to create workflow"), nil
+	}
+
+	a.refreshEmbedding(ctx, workflowID)
+
+	event := models.AuditEvent{Action: models.AuditWorkflowCreate, ProjectID: req.ProjectID, WorkflowID: workflowID}
+	if created, err := a.workflows.GetLatestVersion(workflowID); err == nil {
+		event.Changes = audit.Changes(nil, created)
+	}
+	a.audit.Emit(ctx, event)
+
+	return response.Success(map[string]interface{}{
+		"workflow_id":   workflowID,
+		"workflow_name": req.WorkflowName,
+	}), nil
+}
+
+// validateCreateRequest checks the fields of a workflow to create, from POST
+// /api/workflows or from an imported bundle, and normalizes its tags
+func validateCreateRequest(req *models.CreateWorkflowRequest) error {
+	// Validate required fields
+	if req.WorkflowName == "" || req.Description == "" || req.Source == "" ||
+		req.TemplateName == "" || req.HTTPMethod == "" || req.BaseURL == "" ||
+		req.BearerToken == "" || req.ExternalWorkflowID == "" || req.ProjectID == "" {
+		return errors.New("Missing required fields")
+	}
+
+	// Validate source
+	if !workflow.IsSupportedSource(req.Source) {
+		return errors.New("Invalid source, must be one of: " + strings.Join(workflow.Sources(), ", "))
+	}
+
+	// Validate template_name
+	if req.TemplateName != "workflow" && req.TemplateName != "streamflow" {
+		return errors.New("Invalid template_name, must be 'workflow' or 'streamflow'")
+	}
+
+	// Validate http_method
+	if req.HTTPMethod != "GET" && req.HTTPMethod != "POST" && req.HTTPMethod != "PUT" {
+		return errors.New("Invalid http_method, must be 'GET', 'POST', or 'PUT'")
+	}
+
+	// Validate input_schema
+	if _, err := schema.Parse(req.InputSchema); err != nil {
+		return errors.New("Invalid input_schema: " + err.Error())
+	}
+
+	// Validate tags
+	tags, err := search.NormalizeTags(req.Tags)
+	if err != nil {
+		return errors.New("Invalid tags: " + err.Error())
+	}
+	req.Tags = tags
+	return nil
+}
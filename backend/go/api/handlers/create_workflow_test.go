@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/xzero/ai-workflow/pkg/models"
+)
+
+func TestValidateCreateRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     models.CreateWorkflowRequest
+		wantErr string
+	}{
+		{"valid", models.CreateWorkflowRequest{
+			WorkflowName: "Summarize", Description: "Summarizes a document", Source: "n8n", TemplateName: "workflow",
+			HTTPMethod: "POST", BaseURL: "https://n8n.example/webhook", BearerToken: "t", ExternalWorkflowID: "wf-42",
+			ProjectID: testProject, Tags: []string{" Docs "},
+		}, ""},
+		{"missing project", models.CreateWorkflowRequest{
+			WorkflowName: "Summarize", Description: "Summarizes a document", Source: "n8n", TemplateName: "workflow",
+			HTTPMethod: "POST", BaseURL: "https://n8n.example/webhook", BearerToken: "t", ExternalWorkflowID: "wf-42",
+		}, "Missing required fields"},
+		{"unknown source", models.CreateWorkflowRequest{
+			WorkflowName: "Summarize", Description: "Summarizes a document", Source: "zapier", TemplateName: "workflow",
+			HTTPMethod: "POST", BaseURL: "https://n8n.example/webhook", BearerToken: "t", ExternalWorkflowID: "wf-42",
+			ProjectID: testProject,
+		}, "Invalid source, must be one of: "},
+		{"unknown template", models.CreateWorkflowRequest{
+			WorkflowName: "Summarize", Description: "Summarizes a document", Source: "n8n", TemplateName: "chatflow",
+			HTTPMethod: "POST", BaseURL: "https://n8n.example/webhook", BearerToken: "t", ExternalWorkflowID: "wf-42",
+			ProjectID: testProject,
+		}, "Invalid template_name, must be 'workflow' or 'streamflow'"},
+		{"unknown method", models.CreateWorkflowRequest{
+			WorkflowName: "Summarize", Description: "Summarizes a document", Source: "n8n", TemplateName: "workflow",
+			HTTPMethod: "DELETE", BaseURL: "https://n8n.example/webhook", BearerToken: "t", ExternalWorkflowID: "wf-42",
+			ProjectID: testProject,
+		}, "Invalid http_method, must be 'GET', 'POST', or 'PUT'"},
+		{"invalid schema", models.CreateWorkflowRequest{
+			WorkflowName: "Summarize", Description: "Summarizes a document", Source: "n8n", TemplateName: "workflow",
+			HTTPMethod: "POST", BaseURL: "https://n8n.example/webhook", BearerToken: "t", ExternalWorkflowID: "wf-42",
+			ProjectID: testProject, InputSchema: json.RawMessage(`{"type": "tuple"}`),
+		}, "Invalid input_schema: "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateCreateRequest(&tt.req)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("validateCreateRequest = %v, want nil", err)
+				}
+				if len(tt.req.Tags) != 1 || tt.req.Tags[0] != "docs" {
+					t.Errorf("tags = %q, want [docs]", tt.req.Tags)
+				}
+				return
+			}
+			if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+				t.Errorf("validateCreateRequest = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/xzero/ai-workflow/pkg/audit"
+	"github.com/xzero/ai-workflow/pkg/middleware"
+	"github.com/xzero/ai-workflow/pkg/models"
+	"github.com/xzero/ai-workflow/pkg/policy"
+	"github.com/xzero/ai-workflow/pkg/response"
+	"github.com/xzero/ai-workflow/pkg/store"
+)
+
+// inUseWindow is how far back runs from other projects count as use
+const inUseWindow = 30 * 24 * time.Hour
+
+// DeleteWorkflow serves DELETE /api/workflows/{id}. The workflow moves to the
+// trash, from where it can be restored until it is purged. Workflows other
+// projects still use are only deleted with force=true.
+func (a *API) DeleteWorkflow(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	claims, ok := middleware.ClaimsFrom(ctx)
+	if !ok {
+		return response.Unauthorized("Missing credentials"), nil
+	}
+
+	// Get workflow_id from path parameters
+	workflowID := request.PathParameters["id"]
+	if workflowID == "" {
+		return response.BadRequest("Missing workflow_id"), nil
+	}
+
+	// Get workflow to check permissions
+	workflow, err := a.workflows.Get(workflowID)
+	if err != nil {
+		return lookupError(ctx, err, "workflow"), nil
+	}
+
+	// Check permissions
+	// Admin can delete any workflow in the project
+	// Creator can delete their own workflow
+	if resp, ok := a.authorize(ctx, claims, policy.ActionWorkflowDelete, policy.Workflow(workflow), "Only admin or creator can delete this workflow"); !ok {
+		return resp, nil
+	}
+
+	rowVersion, resp, ok := checkPrecondition(request, workflow)
+	if !ok {
+		return resp, nil
+	}
+
+	force := false
+	if v := request.QueryStringParameters["force"]; v != "" {
+		if force, err = strconv.ParseBool(v); err != nil {
+			return response.BadRequest(errBadParam("force").Error()), nil
+		}
+	}
+
+	// Deleting a workflow breaks the projects it is shared with or run from
+	usage, err := a.workflows.GetUsage(workflowID, time.Now().Add(-inUseWindow))
+	if err != nil {
+		middleware.Log(ctx).Error("Error getting workflow usage", "error", err)
+		return response.InternalError("Failed to delete workflow"), nil
+	}
+	if usage.InUse() && !force {
+		return response.Conflict("Workflow is used by other projects, delete with force=true to proceed", usage), nil
+	}
+
+	// Move workflow to the trash
+	if err := a.workflows.Delete(workflowID, claims.DID, rowVersion); err != nil {
+		switch err {
+		case store.ErrPreconditionFailed:
+			return a.lostRace(ctx, workflowID), nil
+		case store.ErrNotFound:
+			return lookupError(ctx, err, "workflow"), nil
+		}
+		middleware.Log(ctx).Error("Error deleting workflow", "error", err)
+		return response.InternalError("Failed to delete workflow"), nil
+	}
+
+	// The event keeps the deleted definition
+	event := models.AuditEvent{
+		Action:     models.AuditWorkflowDelete,
+		ProjectID:  workflow.ProjectID,
+		WorkflowID: workflowID,
+		Changes:    audit.Changes(models.DefinitionOf(workflow), nil),
+	}
+	if usage.InUse() {
+		event.Details = map[string]interface{}{"force": true, "usage": usage}
+	}
+	a.audit.Emit(ctx, event)
+
+	return response.Success(map[string]interface{}{
+		"message":     "Workflow moved to trash",
+		"workflow_id": workflowID,
+	}), nil
+}
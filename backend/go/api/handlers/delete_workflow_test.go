@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/xzero/ai-workflow/pkg/models"
+)
+
+func TestDeleteWorkflowInUse(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(t *testing.T, s *testServer, workflowID string)
+		path  string
+		want  int
+	}{
+		{"unused", nil, "", http.StatusOK},
+		{"shared with every project", func(t *testing.T, s *testServer, id string) {
+			if err := s.mem.SetShared(id, true, 0); err != nil {
+				t.Fatal(err)
+			}
+		}, "", http.StatusConflict},
+		{"hidden by another project", func(t *testing.T, s *testServer, id string) {
+			if err := s.mem.SetHidden("project-2", id, true); err != nil {
+				t.Fatal(err)
+			}
+		}, "", http.StatusConflict},
+		{"hidden by its own project", func(t *testing.T, s *testServer, id string) {
+			if err := s.mem.SetHidden(testProject, id, true); err != nil {
+				t.Fatal(err)
+			}
+		}, "", http.StatusOK},
+		{"forced", func(t *testing.T, s *testServer, id string) {
+			if err := s.mem.SetShared(id, true, 0); err != nil {
+				t.Fatal(err)
+			}
+		}, "?force=true", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t)
+			id := s.createWorkflow()
+			if tt.setup != nil {
+				tt.setup(t, s, id)
+			}
+			w := s.do(adminDID, http.MethodDelete, "/api/workflows/"+id+tt.path, "", nil)
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+			if _, err := s.mem.Get(id); (err == nil) != (tt.want == http.StatusConflict) {
+				t.Errorf("Get after status %d: %v", w.Code, err)
+			}
+		})
+	}
+}
+
+func TestWorkflowUsage(t *testing.T) {
+	s := newTestServer(t)
+	id := s.createWorkflow()
+	if err := s.mem.SetShared(id, true, 0); err != nil {
+		t.Fatal(err)
+	}
+	for _, projectID := range []string{"project-3", testProject, "project-2"} {
+		if err := s.mem.SetHidden(projectID, id, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w := s.do(adminDID, http.MethodDelete, "/api/workflows/"+id, "", nil)
+	if w.Code != http.StatusConflict {
+		t.Fatalf("status = %d, want 409: %s", w.Code, w.Body)
+	}
+	var body struct {
+		Details models.WorkflowUsage `json:"details"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	got := body.Details
+	if !got.IsShared {
+		t.Error("is_shared = false, want true")
+	}
+	if len(got.SettingsProjectIDs) != 2 || got.SettingsProjectIDs[0] != "project-2" || got.SettingsProjectIDs[1] != "project-3" {
+		t.Errorf("settings_project_ids = %v, want [project-2 project-3]", got.SettingsProjectIDs)
+	}
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/xzero/ai-workflow/pkg/middleware"
+	"github.com/xzero/ai-workflow/pkg/models"
+	"github.com/xzero/ai-workflow/pkg/policy"
+	"github.com/xzero/ai-workflow/pkg/response"
+	"github.com/xzero/ai-workflow/pkg/secrets"
+)
+
+// DiffVersions serves GET /api/workflows/{id}/versions/diff?from=N&to=M.
+// to defaults to the latest version and from to the version before to.
+func (a *API) DiffVersions(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	claims, ok := middleware.ClaimsFrom(ctx)
+	if !ok {
+		return response.Unauthorized("Missing credentials"), nil
+	}
+
+	// Get workflow_id from path parameters
+	workflowID := request.PathParameters["id"]
+	if workflowID == "" {
+		return response.BadRequest("Missing workflow_id"), nil
+	}
+
+	var from, to int
+	var err error
+	if v := request.QueryStringParameters["from"]; v != "" {
+		if from, err = parseVersion(v); err != nil {
+			return response.BadRequest(errBadParam("from").Error()), nil
+		}
+	}
+	if v := request.QueryStringParameters["to"]; v != "" {
+		if to, err = parseVersion(v); err != nil {
+			return response.BadRequest(errBadParam("to").Error()), nil
+		}
+	}
+
+	// Version history is visible to the owning project and grantees, not through sharing
+	wf, err := a.workflows.Get(workflowID)
+	if err != nil {
+		return lookupError(ctx, err, "workflow"), nil
+	}
+
+	if resp, ok := a.authorize(ctx, claims, policy.ActionWorkflowReadVersions, policy.Workflow(wf), "Access denied to this workflow"); !ok {
+		return resp, nil
+	}
+
+	var toVersion *models.WorkflowVersion
+	if to == 0 {
+		toVersion, err = a.workflows.GetLatestVersion(workflowID)
+	} else {
+		toVersion, err = a.workflows.GetVersion(workflowID, to)
+	}
+	if err != nil {
+		return lookupError(ctx, err, "workflow version"), nil
+	}
+
+	if from == 0 {
+		from = toVersion.Version - 1
+		if from == 0 {
+			return response.BadRequest("Version 1 has no previous version, set from"), nil
+		}
+	}
+	fromVersion, err := a.workflows.GetVersion(workflowID, from)
+	if err != nil {
+		return lookupError(ctx, err, "workflow version"), nil
+	}
+
+	showConfig, err := a.policy.Authorize(claims, policy.ActionWorkflowReadConfig, policy.Workflow(wf))
+	if err != nil {
+		middleware.Log(ctx).Error("Error checking permissions", "error", err)
+		return response.InternalError("Failed to check permissions"), nil
+	}
+
+	changes := []models.VersionChange{}
+	for _, change := range fromVersion.Diff(toVersion) {
+		// Like the versions themselves, only callers who may read the
+		// configuration see its changes
+		if isConfigField(change.Field) && !showConfig {
+			continue
+		}
+		// Report that credentials changed without revealing them
+		switch change.Field {
+		case "bearer_token":
+			change.From, change.To = secrets.Redacted, secrets.Redacted
+		case "headers":
+			change.From = secrets.MaskHeadersJSON(fromVersion.Headers)
+			change.To = secrets.MaskHeadersJSON(toVersion.Headers)
+		}
+		changes = append(changes, change)
+	}
+
+	return response.Success(models.DiffVersionsResponse{
+		WorkflowID: workflowID,
+		From:       fromVersion.Version,
+		To:         toVersion.Version,
+		Changes:    changes,
+	}), nil
+}
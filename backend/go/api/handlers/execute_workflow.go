@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/xzero/ai-workflow/pkg/middleware"
+	"github.com/xzero/ai-workflow/pkg/models"
+	"github.com/xzero/ai-workflow/pkg/policy"
+	"github.com/xzero/ai-workflow/pkg/response"
+	"github.com/xzero/ai-workflow/pkg/secrets"
+	"github.com/xzero/ai-workflow/pkg/workflow"
+)
+
+// ExecuteWorkflow serves POST /api/workflows/{id}/execute
+func (a *API) ExecuteWorkflow(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	claims, ok := middleware.ClaimsFrom(ctx)
+	if !ok {
+		return response.Unauthorized("Missing credentials"), nil
+	}
+
+	// Get workflow_id from path parameters
+	workflowID := request.PathParameters["id"]
+	if workflowID == "" {
+		return response.BadRequest("Missing workflow_id"), nil
+	}
+
+	// Get execution mode: sync (default) or async
+	mode := request.QueryStringParameters["mode"]
+	if mode != "" && mode != models.RunModeSync && mode != models.RunModeAsync {
+		return response.BadRequest("Invalid mode, must be 'sync' or 'async'"), nil
+	}
+
+	// Parse request body
+	var req models.ExecuteWorkflowRequest
+	if err := json.Unmarshal([]byte(request.Body), &req); err != nil {
+		return response.BadRequest("Invalid request body"), nil
+	}
+
+	// Get workflow
+	wf, err := a.workflows.Get(workflowID)
+	if err != nil {
+		return lookupError(ctx, err, "workflow"), nil
+	}
+
+	// Runners of the workflow's project, grantees, or anyone if the workflow is shared
+	if resp, ok := a.authorize(ctx, claims, policy.ActionWorkflowExecute, policy.Workflow(wf), "Access denied to this workflow"); !ok {
+		return resp, nil
+	}
+
+	// Run a pinned version instead of the latest definition
+	if err := a.applyVersion(wf, req.Version); err != nil {
+		return lookupError(ctx, err, "workflow version"), nil
+	}
+
+	// The run is recorded against the caller's project, which defaults to the workflow's project
+	projectID := wf.ProjectID
+	if req.ProjectID != "" && req.ProjectID != wf.ProjectID {
+		if resp, ok := a.authorize(ctx, claims, policy.ActionRunCreate, policy.WorkflowIn(req.ProjectID, wf), "Access denied to this project"); !ok {
+			return resp, nil
+		}
+		projectID = req.ProjectID
+	}
+
+	// Validate parameters against the workflow's input schema
+	fieldErrors, err := workflow.ValidateParameters(wf, &req)
+	if err != nil {
+		middleware.Log(ctx).Error("Error validating parameters", "error", err)
+		return response.BadRequest("Invalid parameters or input schema"), nil
+	}
+	if len(fieldErrors) > 0 {
+		return response.ValidationError("Invalid parameters", fieldErrors), nil
+	}
+
+	// Decrypt bearer token for the upstream call
+	wf.BearerToken, err = secrets.Decrypt(ctx, a.keys, wf.BearerToken)
+	if err != nil {
+		middleware.Log(ctx).Error("Error decrypting bearer token", "error", err)
+		return response.InternalError("Failed to decrypt bearer token"), nil
+	}
+
+	// Async mode queues the run for the run worker and returns immediately
+	if mode == models.RunModeAsync {
+		run := workflow.NewRun(wf, &req, claims.DID, projectID, models.RunModeAsync, time.Now())
+		run.Status = models.RunStatusQueued
+		// Unpinned runs execute the definition that is current when the worker picks them up
+		run.WorkflowVersion = req.Version
+		run.RequestHeaders, err = secrets.EncryptJSON(ctx, a.keys, req.Headers)
+		if err != nil {
+			middleware.Log(ctx).Error("Error encrypting request headers", "error", err)
+			return response.InternalError("Failed to queue workflow run"), nil
+		}
+		runID, err := a.runs.CreateRun(run)
+		if err != nil {
+			middleware.Log(ctx).Error("Error queuing workflow run", "error", err)
+			return response.InternalError("Failed to queue workflow run"), nil
+		}
+		a.auditRun(ctx, wf, run, runID)
+
+		return response.Accepted(map[string]interface{}{
+			"run_id": runID,
+			"status": models.RunStatusQueued,
+		}), nil
+	}
+
+	// Execute workflow
+	result, err := a.executeSync(ctx, wf, &req, claims.DID, projectID)
+	if err != nil {
+		middleware.Log(ctx).Error("Error executing workflow", "error", err)
+		return response.InternalError("Failed to execute workflow: " + err.Error()), nil
+	}
+
+	return response.Success(result), nil
+}
+
+// executeSync runs wf, whose bearer token must be decrypted, and records the
+// run against projectID. Secret headers are masked in the result.
+func (a *API) executeSync(ctx context.Context, wf *models.Workflow, req *models.ExecuteWorkflowRequest, callerDID, projectID string) (*models.ExecuteWorkflowResponse, error) {
+	run := workflow.NewRun(wf, req, callerDID, projectID, models.RunModeSync, time.Now())
+	result, err := workflow.Execute(ctx, wf, req)
+	workflow.FinishExecution(run, result, err)
+	runID, recordErr := a.runs.CreateRun(run)
+	if recordErr != nil {
+		middleware.Log(ctx).Error("Error recording workflow run", "error", recordErr)
+	}
+	a.auditRun(ctx, wf, run, runID)
+
+	if err != nil {
+		return nil, err
+	}
+	result.RunID = runID
+	result.Request.Headers = secrets.MaskHeaders(result.Request.Headers)
+	result.Response.Headers = secrets.MaskHeaders(result.Response.Headers)
+	return result, nil
+}
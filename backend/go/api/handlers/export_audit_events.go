@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/xzero/ai-workflow/pkg/middleware"
+	"github.com/xzero/ai-workflow/pkg/policy"
+	"github.com/xzero/ai-workflow/pkg/response"
+)
+
+const (
+	// exportPageSize is the number of events read per query
+	exportPageSize = 500
+	// maxExportBytes keeps the export under the 6MB Lambda response limit
+	maxExportBytes = 5 << 20
+)
+
+// ExportAuditEvents serves GET /api/projects/{projectId}/audit-events/export.
+// It takes the filters of ListAuditEvents except limit and offset and returns
+// the matching events oldest first as JSON Lines. An export cut short by the
+// size limit has the X-Export-Truncated header; the next one continues with
+// since set to the created_at of its last event, which is included again.
+func (a *API) ExportAuditEvents(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	claims, ok := middleware.ClaimsFrom(ctx)
+	if !ok {
+		return response.Unauthorized("Missing credentials"), nil
+	}
+
+	projectID := request.PathParameters["projectId"]
+	if projectID == "" {
+		return response.BadRequest("Missing project_id"), nil
+	}
+
+	filter, err := parseAuditFilter(request.QueryStringParameters)
+	if err != nil {
+		return response.BadRequest(err.Error()), nil
+	}
+	filter.ProjectID = projectID
+	filter.Ascending = true
+	filter.Limit = exportPageSize
+	filter.Offset = 0
+
+	if resp, ok := a.authorize(ctx, claims, policy.ActionReadAudit, policy.Project(projectID), "Only project admin can read the audit log"); !ok {
+		return resp, nil
+	}
+
+	var body bytes.Buffer
+	count, truncated := 0, false
+	for !truncated {
+		page, err := a.auditLog.ListAuditEvents(filter)
+		if err != nil {
+			middleware.Log(ctx).Error("Error exporting audit events", "error", err)
+			return response.InternalError("Failed to export audit events"), nil
+		}
+
+		for _, event := range page {
+			line, err := json.Marshal(event)
+			if err != nil {
+				middleware.Log(ctx).Error("Error encoding audit event", "error", err)
+				return response.InternalError("Failed to export audit events"), nil
+			}
+			if body.Len()+len(line)+1 > maxExportBytes {
+				truncated = true
+				break
+			}
+			body.Write(line)
+			body.WriteByte('\n')
+			count++
+		}
+
+		if len(page) < filter.Limit {
+			break
+		}
+		filter.Offset += len(page)
+	}
+
+	headers := map[string]string{
+		"Content-Type":                "application/x-ndjson",
+		"Access-Control-Allow-Origin": "*",
+		"X-Export-Count":              strconv.Itoa(count),
+	}
+	if truncated {
+		headers["X-Export-Truncated"] = "true"
+	}
+	return events.APIGatewayProxyResponse{
+		StatusCode: 200,
+		Headers:    headers,
+		Body:       body.String(),
+	}, nil
+}
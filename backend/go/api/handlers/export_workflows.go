@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/xzero/ai-workflow/pkg/bundle"
+	"github.com/xzero/ai-workflow/pkg/db"
+	"github.com/xzero/ai-workflow/pkg/middleware"
+	"github.com/xzero/ai-workflow/pkg/models"
+	"github.com/xzero/ai-workflow/pkg/policy"
+	"github.com/xzero/ai-workflow/pkg/response"
+)
+
+// maxBundleWorkflows caps the workflows of one export or import
+const maxBundleWorkflows = 500
+
+// ExportWorkflows serves GET /api/projects/{projectId}/workflows/export. It
+// returns the project's own workflows, or those listed in workflow_ids, as a
+// bundle in format json (default) or yaml. Secrets become placeholders. A
+// whole-project export leaves out the workflows whose configuration the
+// caller may not read.
+func (a *API) ExportWorkflows(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	claims, ok := middleware.ClaimsFrom(ctx)
+	if !ok {
+		return response.Unauthorized("Missing credentials"), nil
+	}
+
+	projectID := request.PathParameters["projectId"]
+	if projectID == "" {
+		return response.BadRequest("Missing project_id"), nil
+	}
+
+	format := request.QueryStringParameters["format"]
+	if format == "" {
+		format = bundle.FormatJSON
+	}
+	if !bundle.IsFormat(format) {
+		return response.BadRequest(errBadParam("format").Error()), nil
+	}
+
+	var workflowIDs []string
+	for _, id := range strings.Split(request.QueryStringParameters["workflow_ids"], ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			workflowIDs = append(workflowIDs, id)
+		}
+	}
+	if len(workflowIDs) > maxBundleWorkflows {
+		return response.BadRequest(errBadParam("workflow_ids").Error()), nil
+	}
+
+	workflows := []models.Workflow{}
+	if len(workflowIDs) > 0 {
+		// Every selected workflow must be exportable
+		for _, id := range workflowIDs {
+			wf, err := a.workflows.Get(id)
+			if err != nil {
+				return lookupError(ctx, err, "workflow"), nil
+			}
+			if wf.ProjectID != projectID {
+				return response.NotFound("Workflow not found in this project: " + id), nil
+			}
+			if resp, ok := a.authorize(ctx, claims, policy.ActionWorkflowReadConfig, policy.Workflow(wf), "Only editors or the creator can export this workflow"); !ok {
+				return resp, nil
+			}
+			workflows = append(workflows, *wf)
+		}
+	} else {
+		if resp, ok := a.authorize(ctx, claims, policy.ActionProjectRead, policy.Project(projectID), "Access denied to this project"); !ok {
+			return resp, nil
+		}
+		all, err := a.listOwnWorkflows(projectID)
+		if err != nil {
+			middleware.Log(ctx).Error("Error listing workflows", "error", err)
+			return response.InternalError("Failed to export workflows"), nil
+		}
+		if len(all) > maxBundleWorkflows {
+			return response.BadRequest("Too many workflows, export a selection with workflow_ids"), nil
+		}
+		for i := range all {
+			allowed, err := a.policy.Authorize(claims, policy.ActionWorkflowReadConfig, policy.Workflow(&all[i]))
+			if err != nil {
+				middleware.Log(ctx).Error("Error checking permissions", "error", err)
+				return response.InternalError("Failed to check permissions"), nil
+			}
+			if allowed {
+				workflows = append(workflows, all[i])
+				workflowIDs = append(workflowIDs, all[i].WorkflowID)
+			}
+		}
+	}
+
+	b, err := bundle.Export(workflows, projectID)
+	if err != nil {
+		middleware.Log(ctx).Error("Error exporting workflows", "error", err)
+		return response.InternalError("Failed to export workflows"), nil
+	}
+	body, err := bundle.Encode(b, format)
+	if err != nil {
+		middleware.Log(ctx).Error("Error encoding bundle", "error", err)
+		return response.InternalError("Failed to export workflows"), nil
+	}
+
+	a.audit.Emit(ctx, models.AuditEvent{
+		Action:    models.AuditWorkflowExport,
+		ProjectID: projectID,
+		Details:   map[string]interface{}{"format": format, "workflow_ids": workflowIDs},
+	})
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: 200,
+		Headers: map[string]string{
+			"Content-Type":                bundle.ContentType(format),
+			"Content-Disposition":         `attachment; filename="workflows.` + format + `"`,
+			"Access-Control-Allow-Origin": "*",
+		},
+		Body: string(body),
+	}, nil
+}
+
+// listOwnWorkflows returns the workflows of projectID, hidden ones included,
+// sorted by name. It stops after maxBundleWorkflows+1 so callers can tell
+// that there are too many.
+func (a *API) listOwnWorkflows(projectID string) ([]models.Workflow, error) {
+	filter := models.ListWorkflowsFilter{
+		ProjectID:     projectID,
+		Scope:         models.WorkflowScopeOwn,
+		IncludeHidden: true,
+		Sort:          models.WorkflowSortName,
+		Limit:         maxLimit,
+	}
+
+	workflows := []models.Workflow{}
+	for len(workflows) <= maxBundleWorkflows {
+		page, err := a.workflows.List(filter)
+		if err != nil {
+			return nil, err
+		}
+		workflows = append(workflows, page...)
+		if len(page) < filter.Limit {
+			break
+		}
+		cursor := db.WorkflowCursorOf(&page[len(page)-1], filter.Sort, filter.Descending)
+		filter.After = &cursor
+	}
+	return workflows, nil
+}
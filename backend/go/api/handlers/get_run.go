@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/xzero/ai-workflow/pkg/middleware"
+	"github.com/xzero/ai-workflow/pkg/policy"
+	"github.com/xzero/ai-workflow/pkg/response"
+)
+
+// GetRun serves GET /api/runs/{runId}
+func (a *API) GetRun(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	claims, ok := middleware.ClaimsFrom(ctx)
+	if !ok {
+		return response.Unauthorized("Missing credentials"), nil
+	}
+
+	// Get run_id from path parameters
+	runID := request.PathParameters["runId"]
+	if runID == "" {
+		return response.BadRequest("Missing run_id"), nil
+	}
+
+	// Get run
+	run, err := a.runs.GetRun(runID)
+	if err != nil {
+		return lookupError(ctx, err, "run"), nil
+	}
+
+	// The caller can always see their own runs, project members see every run of the project
+	if resp, ok := a.authorize(ctx, claims, policy.ActionRunRead, policy.Run(run), "Access denied to this run"); !ok {
+		return resp, nil
+	}
+
+	return response.Success(run), nil
+}
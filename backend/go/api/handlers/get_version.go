@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/xzero/ai-workflow/pkg/middleware"
+	"github.com/xzero/ai-workflow/pkg/policy"
+	"github.com/xzero/ai-workflow/pkg/response"
+)
+
+// GetVersion serves GET /api/workflows/{id}/versions/{version}
+func (a *API) GetVersion(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	claims, ok := middleware.ClaimsFrom(ctx)
+	if !ok {
+		return response.Unauthorized("Missing credentials"), nil
+	}
+
+	// Get workflow_id and version from path parameters
+	workflowID := request.PathParameters["id"]
+	if workflowID == "" {
+		return response.BadRequest("Missing workflow_id"), nil
+	}
+	version, err := parseVersion(request.PathParameters["version"])
+	if err != nil {
+		return response.BadRequest("Invalid version"), nil
+	}
+
+	// Version history is visible to the owning project and grantees, not through sharing
+	wf, err := a.workflows.Get(workflowID)
+	if err != nil {
+		return lookupError(ctx, err, "workflow"), nil
+	}
+
+	if resp, ok := a.authorize(ctx, claims, policy.ActionWorkflowReadVersions, policy.Workflow(wf), "Access denied to this workflow"); !ok {
+		return resp, nil
+	}
+
+	v, err := a.workflows.GetVersion(workflowID, version)
+	if err != nil {
+		return lookupError(ctx, err, "workflow version"), nil
+	}
+
+	showConfig, err := a.policy.Authorize(claims, policy.ActionWorkflowReadConfig, policy.Workflow(wf))
+	if err != nil {
+		middleware.Log(ctx).Error("Error checking permissions", "error", err)
+		return response.InternalError("Failed to check permissions"), nil
+	}
+	projectVersion(v, showConfig)
+	return response.Success(v), nil
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/xzero/ai-workflow/pkg/auth"
+	"github.com/xzero/ai-workflow/pkg/middleware"
+	"github.com/xzero/ai-workflow/pkg/models"
+	"github.com/xzero/ai-workflow/pkg/policy"
+	"github.com/xzero/ai-workflow/pkg/response"
+	"github.com/xzero/ai-workflow/pkg/secrets"
+)
+
+// GetWorkflow serves GET /api/workflows/{id}
+func (a *API) GetWorkflow(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	claims, ok := middleware.ClaimsFrom(ctx)
+	if !ok {
+		return response.Unauthorized("Missing credentials"), nil
+	}
+
+	// Get workflow_id from path parameters
+	workflowID := request.PathParameters["id"]
+	if workflowID == "" {
+		return response.BadRequest("Missing workflow_id"), nil
+	}
+
+	wf, err := a.workflows.Get(workflowID)
+	if err != nil {
+		return lookupError(ctx, err, "workflow"), nil
+	}
+
+	// Project members, grantees and, for shared workflows, everyone
+	if resp, ok := a.authorize(ctx, claims, policy.ActionWorkflowRead, policy.Workflow(wf), "Access denied to this workflow"); !ok {
+		return resp, nil
+	}
+	showConfig, err := a.policy.Authorize(claims, policy.ActionWorkflowReadConfig, policy.Workflow(wf))
+	if err != nil {
+		middleware.Log(ctx).Error("Error checking permissions", "error", err)
+		return response.InternalError("Failed to check permissions"), nil
+	}
+
+	access, err := a.policy.Role(claims, policy.Workflow(wf))
+	if err != nil {
+		middleware.Log(ctx).Error("Error checking permissions", "error", err)
+		return response.InternalError("Failed to check permissions"), nil
+	}
+	if access != models.RoleAdmin && wf.CreatorDID == claims.DID {
+		access = models.WorkflowAccessCreator
+	} else if access == "" {
+		access = models.WorkflowAccessShared
+	}
+
+	// The ETag is sent back in If-Match by update, share and delete
+	return withETag(response.Success(projectWorkflow(wf, access, showConfig)), wf), nil
+}
+
+// projectListed applies the projection of projectWorkflow to listed
+// workflows: callers not allowed policy.ActionWorkflowReadConfig on a row
+// do not see its endpoint and headers, the others get them masked.
+func (a *API) projectListed(claims *auth.Claims, workflows []models.Workflow) error {
+	for i := range workflows {
+		w := &workflows[i]
+		showConfig, err := a.policy.Authorize(claims, policy.ActionWorkflowReadConfig, policy.Workflow(w))
+		if err != nil {
+			return err
+		}
+		if showConfig {
+			w.BearerToken = secrets.Redacted
+			w.Headers = secrets.MaskHeadersJSON(w.Headers)
+			continue
+		}
+		w.HTTPMethod = ""
+		w.BaseURL = ""
+		w.BearerToken = ""
+		w.ExternalWorkflowID = ""
+		w.Headers = nil
+	}
+	return nil
+}
+
+// projectWorkflow returns the fields of wf that a caller with access may see.
+// Only callers allowed policy.ActionWorkflowReadConfig, such as admins,
+// editors and creators, see where and how the workflow is called, with
+// credential headers masked; plaintext is only served by RevealToken.
+func projectWorkflow(wf *models.Workflow, access string, showConfig bool) *models.WorkflowDetail {
+	detail := &models.WorkflowDetail{
+		WorkflowID:   wf.WorkflowID,
+		WorkflowName: wf.WorkflowName,
+		Description:  wf.Description,
+		Source:       wf.Source,
+		TemplateName: wf.TemplateName,
+		Parameters:   wf.Parameters,
+		InputSchema:  wf.InputSchema,
+		ProjectID:    wf.ProjectID,
+		CreatorDID:   wf.CreatorDID,
+		IsShared:     wf.IsShared,
+		Tags:         wf.Tags,
+		Version:      wf.Version,
+		RowVersion:   wf.RowVersion,
+		Access:       access,
+		CreatedAt:    wf.CreatedAt,
+		UpdatedAt:    wf.UpdatedAt,
+	}
+
+	if showConfig {
+		detail.HTTPMethod = wf.HTTPMethod
+		detail.BaseURL = wf.BaseURL
+		detail.BearerToken = secrets.Redacted
+		detail.ExternalWorkflowID = wf.ExternalWorkflowID
+		detail.Headers = secrets.MaskHeadersJSON(wf.Headers)
+	}
+
+	return detail
+}
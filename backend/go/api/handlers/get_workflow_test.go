@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/xzero/ai-workflow/pkg/models"
+	"github.com/xzero/ai-workflow/pkg/secrets"
+)
+
+// checkProjection checks the configuration fields of a returned workflow
+func checkProjection(t *testing.T, w map[string]interface{}, showConfig bool) {
+	t.Helper()
+	config := []string{"http_method", "base_url", "bearer_token", "external_workflow_id", "headers"}
+	if !showConfig {
+		for _, field := range config {
+			if _, ok := w[field]; ok {
+				t.Errorf("%s = %v, want it left out", field, w[field])
+			}
+		}
+		return
+	}
+
+	if w["base_url"] != "https://n8n.example/webhook" || w["external_workflow_id"] != "wf-42" {
+		t.Errorf("base_url, external_workflow_id = %v, %v, want the stored values", w["base_url"], w["external_workflow_id"])
+	}
+	if w["bearer_token"] != secrets.Redacted {
+		t.Errorf("bearer_token = %v, want %q", w["bearer_token"], secrets.Redacted)
+	}
+	headers, _ := w["headers"].(map[string]interface{})
+	if headers["X-API-Key"] != secrets.Redacted || headers["X-Trace"] != "on" {
+		t.Errorf("headers = %v, want X-API-Key masked and X-Trace kept", headers)
+	}
+}
+
+func TestGetWorkflowProjection(t *testing.T) {
+	tests := []struct {
+		did        string
+		access     string
+		showConfig bool
+	}{
+		{adminDID, models.RoleAdmin, true},
+		{editorDID, models.RoleEditor, true},
+		{creatorDID, models.WorkflowAccessCreator, true},
+		{memberDID, models.RoleMember, false},
+		{runnerDID, models.RoleRunner, false},
+		{viewerDID, models.RoleViewer, false},
+		{outsiderDID, models.WorkflowAccessShared, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.did, func(t *testing.T) {
+			s := newTestServer(t)
+			id := s.createWorkflow()
+			if err := s.mem.SetShared(id, true, 0); err != nil {
+				t.Fatal(err)
+			}
+
+			w := s.do(tt.did, http.MethodGet, "/api/workflows/"+id, "", nil)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+			if body := w.Body.String(); strings.Contains(body, upstreamToken) || strings.Contains(body, upstreamAPIKey) {
+				t.Fatalf("response carries a credential: %s", body)
+			}
+
+			var got map[string]interface{}
+			decodeData(t, w, &got)
+			if got["access"] != tt.access {
+				t.Errorf("access = %v, want %s", got["access"], tt.access)
+			}
+			if got["workflow_name"] != "Summarize" {
+				t.Errorf("workflow_name = %v, want Summarize", got["workflow_name"])
+			}
+			checkProjection(t, got, tt.showConfig)
+		})
+	}
+}
+
+func TestListWorkflowsProjection(t *testing.T) {
+	tests := []struct {
+		did        string
+		showConfig bool
+	}{
+		{adminDID, true},
+		{editorDID, true},
+		{creatorDID, true},
+		{memberDID, false},
+		{viewerDID, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.did, func(t *testing.T) {
+			s := newTestServer(t)
+			s.createWorkflow()
+
+			w := s.do(tt.did, http.MethodGet, "/api/projects/"+testProject+"/workflows", "", nil)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+			if body := w.Body.String(); strings.Contains(body, upstreamToken) || strings.Contains(body, upstreamAPIKey) {
+				t.Fatalf("response carries a credential: %s", body)
+			}
+
+			var got []map[string]interface{}
+			decodeData(t, w, &got)
+			if len(got) != 1 {
+				t.Fatalf("got %d workflows, want 1", len(got))
+			}
+			checkProjection(t, got[0], tt.showConfig)
+		})
+	}
+}
+
+func TestListTrashProjection(t *testing.T) {
+	s := newTestServer(t)
+	id := s.createWorkflow()
+	if w := s.do(adminDID, http.MethodDelete, "/api/workflows/"+id, "", nil); w.Code != http.StatusOK {
+		t.Fatalf("delete status = %d: %s", w.Code, w.Body)
+	}
+
+	tests := []struct {
+		did        string
+		showConfig bool
+	}{
+		{adminDID, true},
+		{viewerDID, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.did, func(t *testing.T) {
+			w := s.do(tt.did, http.MethodGet, "/api/projects/"+testProject+"/trash", "", nil)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+			var got []map[string]interface{}
+			decodeData(t, w, &got)
+			if len(got) != 1 {
+				t.Fatalf("got %d workflows, want 1", len(got))
+			}
+			checkProjection(t, got[0], tt.showConfig)
+		})
+	}
+}
+
+func TestUpdateKeepsMaskedCredentials(t *testing.T) {
+	s := newTestServer(t)
+	id := s.createWorkflow()
+
+	// Send back the masked values a GET returned
+	body, _ := json.Marshal(map[string]interface{}{
+		"bearer_token": secrets.Redacted,
+		"headers":      map[string]string{"X-API-Key": secrets.Redacted, "X-Trace": "off"},
+	})
+	if w := s.do(editorDID, http.MethodPut, "/api/workflows/"+id, string(body), nil); w.Code != http.StatusOK {
+		t.Fatalf("update status = %d: %s", w.Code, w.Body)
+	}
+
+	w := s.do(creatorDID, http.MethodGet, "/api/workflows/"+id+"/token", "", nil)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), upstreamToken) {
+		t.Errorf("revealed token: status %d: %s, want %s", w.Code, w.Body, upstreamToken)
+	}
+	stored, err := s.mem.Get(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var headers map[string]string
+	if err := json.Unmarshal(stored.Headers, &headers); err != nil {
+		t.Fatal(err)
+	}
+	if headers["X-API-Key"] != upstreamAPIKey || headers["X-Trace"] != "off" {
+		t.Errorf("stored headers = %v, want X-API-Key kept and X-Trace updated", headers)
+	}
+}
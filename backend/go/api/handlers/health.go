@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/xzero/ai-workflow/pkg/middleware"
+	"github.com/xzero/ai-workflow/pkg/response"
+)
+
+// Health serves GET /health for container health checks of cmd/server
+func (a *API) Health(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	if err := a.db.PingContext(ctx); err != nil {
+		middleware.Log(ctx).Error("Error pinging database", "error", err)
+		return response.Error(503, "Database unavailable"), nil
+	}
+
+	return response.Success(map[string]interface{}{
+		"status": "ok",
+	}), nil
+}
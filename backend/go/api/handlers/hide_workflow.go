@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/xzero/ai-workflow/pkg/middleware"
+	"github.com/xzero/ai-workflow/pkg/models"
+	"github.com/xzero/ai-workflow/pkg/policy"
+	"github.com/xzero/ai-workflow/pkg/response"
+)
+
+// HideWorkflow serves PUT /api/projects/{projectId}/workflows/{workflowId}/hide
+func (a *API) HideWorkflow(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	claims, ok := middleware.ClaimsFrom(ctx)
+	if !ok {
+		return response.Unauthorized("Missing credentials"), nil
+	}
+
+	// Get project_id and workflow_id from path parameters
+	projectID := request.PathParameters["projectId"]
+	workflowID := request.PathParameters["workflowId"]
+
+	if projectID == "" || workflowID == "" {
+		return response.BadRequest("Missing project_id or workflow_id"), nil
+	}
+
+	// Parse request body
+	var req models.HideWorkflowRequest
+	if err := json.Unmarshal([]byte(request.Body), &req); err != nil {
+		return response.BadRequest("Invalid request body"), nil
+	}
+
+	// Check permissions - only project admin can hide workflows
+	if resp, ok := a.authorize(ctx, claims, policy.ActionWorkflowHide, policy.Project(projectID), "Only project admin can hide workflows"); !ok {
+		return resp, nil
+	}
+
+	// Check if workflow exists
+	wf, err := a.workflows.Get(workflowID)
+	if err != nil {
+		return lookupError(ctx, err, "workflow"), nil
+	}
+
+	// Update hide status
+	if err := a.workflows.SetHidden(projectID, workflowID, req.IsHidden); err != nil {
+		middleware.Log(ctx).Error("Error updating hide status", "error", err)
+		return response.InternalError("Failed to update hide status"), nil
+	}
+
+	// Recorded in the project hiding the workflow, which may not own it
+	a.audit.Emit(ctx, models.AuditEvent{
+		Action:     models.AuditWorkflowHide,
+		ProjectID:  projectID,
+		WorkflowID: workflowID,
+		Details:    map[string]interface{}{"is_hidden": req.IsHidden, "workflow_project_id": wf.ProjectID},
+	})
+
+	return response.Success(map[string]interface{}{
+		"project_id":  projectID,
+		"workflow_id": workflowID,
+		"is_hidden":   req.IsHidden,
+	}), nil
+}
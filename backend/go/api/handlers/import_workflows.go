@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/xzero/ai-workflow/pkg/audit"
+	"github.com/xzero/ai-workflow/pkg/auth"
+	"github.com/xzero/ai-workflow/pkg/bundle"
+	"github.com/xzero/ai-workflow/pkg/middleware"
+	"github.com/xzero/ai-workflow/pkg/models"
+	"github.com/xzero/ai-workflow/pkg/policy"
+	"github.com/xzero/ai-workflow/pkg/response"
+	"github.com/xzero/ai-workflow/pkg/secrets"
+	"github.com/xzero/ai-workflow/pkg/store"
+)
+
+// ImportWorkflows serves POST /api/projects/{projectId}/workflows/import.
+// The body, JSON or YAML, carries a bundle, the strategy for workflows
+// named like an existing one, the values of the bundle's placeholders and
+// whether to only report what would happen. Each workflow is imported on
+// its own and reported in the per-item result; one failing does not stop
+// the others. Imported workflows are not embedded inline, which would call
+// the provider once per workflow; EmbedWorkflowsFunction picks them up as
+// stale.
+func (a *API) ImportWorkflows(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	claims, ok := middleware.ClaimsFrom(ctx)
+	if !ok {
+		return response.Unauthorized("Missing credentials"), nil
+	}
+
+	projectID := request.PathParameters["projectId"]
+	if projectID == "" {
+		return response.BadRequest("Missing project_id"), nil
+	}
+
+	req, err := bundle.DecodeImport([]byte(request.Body))
+	if err != nil {
+		return response.BadRequest("Invalid request body: " + err.Error()), nil
+	}
+	if req.Strategy == "" {
+		req.Strategy = models.ImportSkip
+	}
+	switch req.Strategy {
+	case models.ImportSkip, models.ImportOverwrite, models.ImportRename:
+	default:
+		return response.BadRequest("Invalid strategy, must be 'skip', 'overwrite' or 'rename'"), nil
+	}
+	if len(req.Bundle.Workflows) == 0 {
+		return response.BadRequest("Bundle has no workflows"), nil
+	}
+	if len(req.Bundle.Workflows) > maxBundleWorkflows {
+		return response.BadRequest(fmt.Sprintf("Bundle has more than %d workflows", maxBundleWorkflows)), nil
+	}
+
+	// Members, editors and admins of the project can create workflows
+	if resp, ok := a.authorize(ctx, claims, policy.ActionWorkflowCreate, policy.Project(projectID), "Access denied to this project"); !ok {
+		return resp, nil
+	}
+
+	existing, err := a.listOwnWorkflows(projectID)
+	if err != nil {
+		middleware.Log(ctx).Error("Error listing workflows", "error", err)
+		return response.InternalError("Failed to import workflows"), nil
+	}
+	if len(existing) > maxBundleWorkflows {
+		return response.BadRequest("Too many workflows in the project to check for conflicts"), nil
+	}
+	byName := map[string][]models.Workflow{}
+	for _, w := range existing {
+		byName[w.WorkflowName] = append(byName[w.WorkflowName], w)
+	}
+
+	result := models.ImportWorkflowsResponse{
+		ProjectID: projectID,
+		Strategy:  req.Strategy,
+		DryRun:    req.DryRun,
+		Summary:   map[string]int{},
+		Items:     []models.ImportItemResult{},
+	}
+	for i := range req.Bundle.Workflows {
+		item := a.importWorkflow(ctx, claims, projectID, req, &req.Bundle.Workflows[i], byName)
+		item.Index = i
+		result.Summary[item.Status]++
+		result.Items = append(result.Items, item)
+	}
+
+	return response.Success(result), nil
+}
+
+// importWorkflow imports one workflow of the bundle. Names it creates are
+// added to byName, so later workflows of the bundle conflict with them.
+func (a *API) importWorkflow(ctx context.Context, claims *auth.Claims, projectID string, req *models.ImportWorkflowsRequest, bw *models.BundleWorkflow, byName map[string][]models.Workflow) models.ImportItemResult {
+	item := models.ImportItemResult{WorkflowName: bw.WorkflowName}
+	fail := func(message string) models.ImportItemResult {
+		item.Status = models.ImportStatusFailed
+		item.Error = message
+		return item
+	}
+
+	resolved, missing := bundle.Resolve(bw, req.Secrets)
+	if len(missing) > 0 {
+		item.MissingSecrets = missing
+	}
+	createReq, err := bundle.CreateRequest(resolved, projectID)
+	if err != nil {
+		return fail(err.Error())
+	}
+	if err := validateCreateRequest(createReq); err != nil {
+		return fail(err.Error())
+	}
+
+	var target *models.Workflow
+	item.Status = models.ImportStatusCreated
+	if matches := byName[createReq.WorkflowName]; len(matches) > 0 {
+		switch req.Strategy {
+		case models.ImportSkip:
+			item.Status = models.ImportStatusSkipped
+			item.WorkflowID = matches[0].WorkflowID
+			item.MissingSecrets = nil
+			return item
+		case models.ImportOverwrite:
+			if len(matches) > 1 {
+				return fail(fmt.Sprintf("%d workflows are named %q, rename them first", len(matches), createReq.WorkflowName))
+			}
+			target = &matches[0]
+			item.Status = models.ImportStatusOverwritten
+			item.WorkflowID = target.WorkflowID
+		case models.ImportRename:
+			createReq.WorkflowName = freeName(createReq.WorkflowName, byName)
+			item.Status = models.ImportStatusRenamed
+			item.ImportedAs = createReq.WorkflowName
+		}
+	}
+
+	if target != nil {
+		allowed, err := a.policy.Authorize(claims, policy.ActionWorkflowUpdate, policy.Workflow(target))
+		if err != nil {
+			middleware.Log(ctx).Error("Error checking permissions", "error", err)
+			return fail("Failed to check permissions")
+		}
+		if !allowed {
+			return fail("Only editors or the creator can overwrite this workflow")
+		}
+		return a.overwriteWorkflow(ctx, claims, req.DryRun, createReq, target.WorkflowID, item)
+	}
+
+	// New workflows need every secret
+	if len(missing) > 0 {
+		return fail("Missing secrets")
+	}
+	if req.DryRun {
+		byName[createReq.WorkflowName] = append(byName[createReq.WorkflowName], models.Workflow{WorkflowName: createReq.WorkflowName})
+		return item
+	}
+
+	createReq.BearerToken, err = secrets.Encrypt(ctx, a.keys, createReq.BearerToken)
+	if err != nil {
+		middleware.Log(ctx).Error("Error encrypting bearer token", "error", err)
+		return fail("Failed to encrypt bearer token")
+	}
+	workflowID, err := a.workflows.Create(createReq, claims.DID)
+	if err != nil {
+		middleware.Log(ctx).Error("Error creating workflow", "error", err)
+		return fail("Failed to create workflow")
+	}
+	item.WorkflowID = workflowID
+	byName[createReq.WorkflowName] = append(byName[createReq.WorkflowName], models.Workflow{WorkflowID: workflowID, WorkflowName: createReq.WorkflowName})
+
+	event := models.AuditEvent{
+		Action:     models.AuditWorkflowCreate,
+		ProjectID:  projectID,
+		WorkflowID: workflowID,
+		Details:    map[string]interface{}{"import": true},
+	}
+	if created, err := a.workflows.GetLatestVersion(workflowID); err == nil {
+		event.Changes = audit.Changes(nil, created)
+	}
+	a.audit.Emit(ctx, event)
+	return item
+}
+
+// overwriteWorkflow updates a workflow with the definition of createReq,
+// keeping the stored secrets the bundle has no value for. The write only
+// applies if nobody changes the workflow in between.
+func (a *API) overwriteWorkflow(ctx context.Context, claims *auth.Claims, dryRun bool, createReq *models.CreateWorkflowRequest, workflowID string, item models.ImportItemResult) models.ImportItemResult {
+	fail := func(message string) models.ImportItemResult {
+		item.Status = models.ImportStatusFailed
+		item.Error = message
+		return item
+	}
+
+	target, err := a.workflows.Get(workflowID)
+	if err != nil {
+		middleware.Log(ctx).Error("Error getting workflow", "error", err)
+		return fail("Failed to get workflow")
+	}
+	update, err := bundle.UpdateRequest(createReq, target)
+	if err != nil {
+		return fail("Invalid headers of the existing workflow")
+	}
+	if dryRun {
+		return item
+	}
+
+	if update.BearerToken != nil {
+		encrypted, err := secrets.Encrypt(ctx, a.keys, *update.BearerToken)
+		if err != nil {
+			middleware.Log(ctx).Error("Error encrypting bearer token", "error", err)
+			return fail("Failed to encrypt bearer token")
+		}
+		update.BearerToken = &encrypted
+	}
+
+	version, err := a.workflows.Update(target.WorkflowID, update, claims.DID, target.RowVersion)
+	if err == store.ErrPreconditionFailed {
+		return fail("Workflow was modified during the import")
+	}
+	if err != nil {
+		middleware.Log(ctx).Error("Error updating workflow", "error", err)
+		return fail("Failed to update workflow")
+	}
+
+	if changes := updateChanges(target, update, version); len(changes) > 0 {
+		details := map[string]interface{}{"import": true}
+		if version != nil {
+			details["version"] = version.Version
+		}
+		a.audit.Emit(ctx, models.AuditEvent{
+			Action:     models.AuditWorkflowUpdate,
+			ProjectID:  target.ProjectID,
+			WorkflowID: target.WorkflowID,
+			Changes:    changes,
+			Details:    details,
+		})
+	}
+	return item
+}
+
+// freeName returns name followed by the first " (n)" no workflow has
+func freeName(name string, byName map[string][]models.Workflow) string {
+	for n := 2; ; n++ {
+		candidate := name + " (" + strconv.Itoa(n) + ")"
+		if len(byName[candidate]) == 0 {
+			return candidate
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/xzero/ai-workflow/pkg/middleware"
+	"github.com/xzero/ai-workflow/pkg/policy"
+	"github.com/xzero/ai-workflow/pkg/response"
+)
+
+// ListAPIKeys serves GET /api/projects/{projectId}/api-keys. Revoked and
+// expired keys are listed too; the keys themselves never are.
+func (a *API) ListAPIKeys(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	claims, ok := middleware.ClaimsFrom(ctx)
+	if !ok {
+		return response.Unauthorized("Missing credentials"), nil
+	}
+
+	// Get project_id from path parameters
+	projectID := request.PathParameters["projectId"]
+	if projectID == "" {
+		return response.BadRequest("Missing project_id"), nil
+	}
+
+	// Check permissions - only project admin can manage API keys
+	if resp, ok := a.authorize(ctx, claims, policy.ActionManageAPIKeys, policy.Project(projectID), "Only project admin can manage API keys"); !ok {
+		return resp, nil
+	}
+
+	keys, err := a.apiKeys.ListAPIKeys(projectID)
+	if err != nil {
+		middleware.Log(ctx).Error("Error listing API keys", "error", err)
+		return response.InternalError("Failed to list API keys"), nil
+	}
+
+	return response.Success(keys), nil
+}
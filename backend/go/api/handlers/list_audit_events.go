@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/xzero/ai-workflow/pkg/middleware"
+	"github.com/xzero/ai-workflow/pkg/models"
+	"github.com/xzero/ai-workflow/pkg/policy"
+	"github.com/xzero/ai-workflow/pkg/response"
+)
+
+// ListAuditEvents serves GET /api/projects/{projectId}/audit-events, newest first
+func (a *API) ListAuditEvents(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	claims, ok := middleware.ClaimsFrom(ctx)
+	if !ok {
+		return response.Unauthorized("Missing credentials"), nil
+	}
+
+	projectID := request.PathParameters["projectId"]
+	if projectID == "" {
+		return response.BadRequest("Missing project_id"), nil
+	}
+
+	filter, err := parseAuditFilter(request.QueryStringParameters)
+	if err != nil {
+		return response.BadRequest(err.Error()), nil
+	}
+	filter.ProjectID = projectID
+
+	if resp, ok := a.authorize(ctx, claims, policy.ActionReadAudit, policy.Project(projectID), "Only project admin can read the audit log"); !ok {
+		return resp, nil
+	}
+
+	// Fetch one extra row to know whether another page exists
+	limit := filter.Limit
+	filter.Limit = limit + 1
+	auditEvents, err := a.auditLog.ListAuditEvents(filter)
+	if err != nil {
+		middleware.Log(ctx).Error("Error listing audit events", "error", err)
+		return response.InternalError("Failed to list audit events"), nil
+	}
+
+	hasMore := len(auditEvents) > limit
+	if hasMore {
+		auditEvents = auditEvents[:limit]
+	}
+
+	page := response.Page{Limit: limit, Offset: filter.Offset, HasMore: hasMore}
+	return response.Paginated(auditEvents, page), nil
+}
+
+// parseAuditFilter reads actor_did, action, workflow_id, since, until, limit
+// and offset query parameters
+func parseAuditFilter(params map[string]string) (models.ListAuditEventsFilter, error) {
+	filter := models.ListAuditEventsFilter{
+		ActorDID:   params["actor_did"],
+		Action:     params["action"],
+		WorkflowID: params["workflow_id"],
+		Limit:      defaultLimit,
+	}
+
+	if filter.Action != "" && !models.IsAuditAction(filter.Action) {
+		return filter, errBadParam("action")
+	}
+
+	if v := params["since"]; v != "" {
+		since, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, errBadParam("since")
+		}
+		filter.Since = &since
+	}
+	if v := params["until"]; v != "" {
+		until, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, errBadParam("until")
+		}
+		filter.Until = &until
+	}
+
+	if v := params["limit"]; v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxLimit {
+			return filter, errBadParam("limit")
+		}
+		filter.Limit = limit
+	}
+	if v := params["offset"]; v != "" {
+		offset, err := strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return filter, errBadParam("offset")
+		}
+		filter.Offset = offset
+	}
+
+	return filter, nil
+}
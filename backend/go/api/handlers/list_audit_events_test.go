@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/xzero/ai-workflow/pkg/models"
+	"github.com/xzero/ai-workflow/pkg/response"
+)
+
+func TestListAuditEventsPage(t *testing.T) {
+	s := newTestServer(t)
+	id := s.createWorkflow()
+	for _, description := range []string{"first", "second"} {
+		if w := s.do(editorDID, http.MethodPut, "/api/workflows/"+id, `{"description": "`+description+`"}`, nil); w.Code != http.StatusOK {
+			t.Fatalf("update status = %d: %s", w.Code, w.Body)
+		}
+	}
+
+	// create, update, update: the second page of one holds the first update
+	w := s.do(adminDID, http.MethodGet, "/api/projects/"+testProject+"/audit-events?limit=1&offset=1", "", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	var body struct {
+		Data       []models.AuditEvent `json:"data"`
+		Pagination response.Page       `json:"pagination"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Data) != 1 || body.Data[0].Action != models.AuditWorkflowUpdate {
+		t.Errorf("data = %+v, want one workflow.update", body.Data)
+	}
+	if want := (response.Page{Limit: 1, Offset: 1, HasMore: true}); body.Pagination != want {
+		t.Errorf("pagination = %+v, want %+v", body.Pagination, want)
+	}
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/xzero/ai-workflow/pkg/middleware"
+	"github.com/xzero/ai-workflow/pkg/policy"
+	"github.com/xzero/ai-workflow/pkg/response"
+)
+
+// ListGrants serves GET /api/workflows/{id}/grants
+func (a *API) ListGrants(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	claims, ok := middleware.ClaimsFrom(ctx)
+	if !ok {
+		return response.Unauthorized("Missing credentials"), nil
+	}
+
+	// Get workflow_id from path parameters
+	workflowID := request.PathParameters["id"]
+	if workflowID == "" {
+		return response.BadRequest("Missing workflow_id"), nil
+	}
+
+	wf, err := a.workflows.Get(workflowID)
+	if err != nil {
+		return lookupError(ctx, err, "workflow"), nil
+	}
+
+	if resp, ok := a.authorize(ctx, claims, policy.ActionWorkflowGrant, policy.Workflow(wf), "Only admin or creator can manage grants"); !ok {
+		return resp, nil
+	}
+
+	grants, err := a.workflows.ListGrants(workflowID)
+	if err != nil {
+		middleware.Log(ctx).Error("Error listing grants", "error", err)
+		return response.InternalError("Failed to list grants"), nil
+	}
+
+	return response.Success(grants), nil
+}
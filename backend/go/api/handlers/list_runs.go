@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/xzero/ai-workflow/pkg/middleware"
+	"github.com/xzero/ai-workflow/pkg/models"
+	"github.com/xzero/ai-workflow/pkg/policy"
+	"github.com/xzero/ai-workflow/pkg/response"
+)
+
+const (
+	defaultLimit = 20
+	maxLimit     = 100
+)
+
+// ListRuns serves GET /api/workflows/{id}/runs and GET /api/projects/{projectId}/runs
+func (a *API) ListRuns(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	claims, ok := middleware.ClaimsFrom(ctx)
+	if !ok {
+		return response.Unauthorized("Missing credentials"), nil
+	}
+
+	// Parse filters and pagination
+	filter, err := parseFilter(request.QueryStringParameters)
+	if err != nil {
+		return response.BadRequest(err.Error()), nil
+	}
+
+	if workflowID := request.PathParameters["id"]; workflowID != "" {
+		// Runs of one workflow
+		wf, err := a.workflows.Get(workflowID)
+		if err != nil {
+			return lookupError(ctx, err, "workflow"), nil
+		}
+
+		// Members of the owning project see every run,
+		// users of a shared workflow only see their own runs
+		allRuns, err := a.policy.Authorize(claims, policy.ActionRunRead, policy.Workflow(wf))
+		if err != nil {
+			middleware.Log(ctx).Error("Error checking permissions", "error", err)
+			return response.InternalError("Failed to check permissions"), nil
+		}
+		if !allRuns {
+			if resp, ok := a.authorize(ctx, claims, policy.ActionWorkflowRead, policy.Workflow(wf), "Access denied to this workflow"); !ok {
+				return resp, nil
+			}
+			filter.CallerDID = claims.DID
+		}
+		filter.WorkflowID = workflowID
+	} else if projectID := request.PathParameters["projectId"]; projectID != "" {
+		// Runs started from one project
+		if resp, ok := a.authorize(ctx, claims, policy.ActionProjectRead, policy.Project(projectID), "Access denied to this project"); !ok {
+			return resp, nil
+		}
+		filter.ProjectID = projectID
+		filter.WorkflowID = request.QueryStringParameters["workflow_id"]
+	} else {
+		return response.BadRequest("Missing workflow_id or project_id"), nil
+	}
+
+	// Fetch one extra row to know whether another page exists
+	limit := filter.Limit
+	filter.Limit = limit + 1
+	runs, err := a.runs.ListRuns(filter)
+	if err != nil {
+		middleware.Log(ctx).Error("Error listing runs", "error", err)
+		return response.InternalError("Failed to list runs"), nil
+	}
+
+	hasMore := len(runs) > limit
+	if hasMore {
+		runs = runs[:limit]
+	}
+
+	return response.Success(models.ListRunsResponse{
+		Runs:    runs,
+		Limit:   limit,
+		Offset:  filter.Offset,
+		HasMore: hasMore,
+	}), nil
+}
+
+// parseFilter reads status, caller_did, since, until, limit and offset query parameters
+func parseFilter(params map[string]string) (models.ListRunsFilter, error) {
+	filter := models.ListRunsFilter{
+		CallerDID: params["caller_did"],
+		Status:    params["status"],
+		Limit:     defaultLimit,
+	}
+
+	switch filter.Status {
+	case "", models.RunStatusQueued, models.RunStatusRunning, models.RunStatusSucceeded,
+		models.RunStatusFailed, models.RunStatusCancelled:
+	default:
+		return filter, errBadParam("status")
+	}
+
+	if v := params["since"]; v != "" {
+		since, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, errBadParam("since")
+		}
+		filter.Since = &since
+	}
+	if v := params["until"]; v != "" {
+		until, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, errBadParam("until")
+		}
+		filter.Until = &until
+	}
+
+	if v := params["limit"]; v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxLimit {
+			return filter, errBadParam("limit")
+		}
+		filter.Limit = limit
+	}
+	if v := params["offset"]; v != "" {
+		offset, err := strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return filter, errBadParam("offset")
+		}
+		filter.Offset = offset
+	}
+
+	return filter, nil
+}
+
+// errBadParam reports an invalid query parameter
+func errBadParam(name string) error {
+	return errors.New("Invalid query parameter: " + name)
+}
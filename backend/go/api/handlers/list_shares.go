@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/xzero/ai-workflow/pkg/middleware"
+	"github.com/xzero/ai-workflow/pkg/policy"
+	"github.com/xzero/ai-workflow/pkg/response"
+)
+
+// ListShares serves GET /api/workflows/{id}/shares
+func (a *API) ListShares(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	claims, ok := middleware.ClaimsFrom(ctx)
+	if !ok {
+		return response.Unauthorized("Missing credentials"), nil
+	}
+
+	// Get workflow_id from path parameters
+	workflowID := request.PathParameters["id"]
+	if workflowID == "" {
+		return response.BadRequest("Missing workflow_id"), nil
+	}
+
+	wf, err := a.workflows.Get(workflowID)
+	if err != nil {
+		return lookupError(ctx, err, "workflow"), nil
+	}
+
+	if resp, ok := a.authorize(ctx, claims, policy.ActionWorkflowShare, policy.Workflow(wf), "Only project admin can share workflows"); !ok {
+		return resp, nil
+	}
+
+	shares, err := a.workflows.ListShares(workflowID)
+	if err != nil {
+		middleware.Log(ctx).Error("Error listing shares", "error", err)
+		return response.InternalError("Failed to list shares"), nil
+	}
+
+	return response.Success(map[string]interface{}{
+		"workflow_id": workflowID,
+		"is_shared":   wf.IsShared,
+		"shares":      shares,
+	}), nil
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/xzero/ai-workflow/pkg/middleware"
+	"github.com/xzero/ai-workflow/pkg/policy"
+	"github.com/xzero/ai-workflow/pkg/response"
+)
+
+// ListTrash serves GET /api/projects/{projectId}/trash, the deleted workflows
+// of the project that were not purged yet
+func (a *API) ListTrash(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	claims, ok := middleware.ClaimsFrom(ctx)
+	if !ok {
+		return response.Unauthorized("Missing credentials"), nil
+	}
+
+	// Get project_id from path parameters
+	projectID := request.PathParameters["projectId"]
+	if projectID == "" {
+		return response.BadRequest("Missing project_id"), nil
+	}
+
+	// Parse pagination
+	filter, err := parseFilter(map[string]string{
+		"limit":  request.QueryStringParameters["limit"],
+		"offset": request.QueryStringParameters["offset"],
+	})
+	if err != nil {
+		return response.BadRequest(err.Error()), nil
+	}
+
+	if resp, ok := a.authorize(ctx, claims, policy.ActionProjectRead, policy.Project(projectID), "Access denied to this project"); !ok {
+		return resp, nil
+	}
+
+	// Fetch one extra row to know whether another page exists
+	workflows, err := a.workflows.ListDeleted(projectID, filter.Limit+1, filter.Offset)
+	if err != nil {
+		middleware.Log(ctx).Error("Error listing trash", "error", err)
+		return response.InternalError("Failed to list trash"), nil
+	}
+
+	hasMore := len(workflows) > filter.Limit
+	if hasMore {
+		workflows = workflows[:filter.Limit]
+	}
+
+	// Never expose credentials, nor endpoints to callers who may not read them
+	if err := a.projectListed(claims, workflows); err != nil {
+		middleware.Log(ctx).Error("Error checking permissions", "error", err)
+		return response.InternalError("Failed to check permissions"), nil
+	}
+
+	page := response.Page{Limit: filter.Limit, Offset: filter.Offset, HasMore: hasMore}
+	return response.Paginated(workflows, page), nil
+}
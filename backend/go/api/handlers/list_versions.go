@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/xzero/ai-workflow/pkg/middleware"
+	"github.com/xzero/ai-workflow/pkg/models"
+	"github.com/xzero/ai-workflow/pkg/policy"
+	"github.com/xzero/ai-workflow/pkg/response"
+	"github.com/xzero/ai-workflow/pkg/secrets"
+)
+
+// ListVersions serves GET /api/workflows/{id}/versions
+func (a *API) ListVersions(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	claims, ok := middleware.ClaimsFrom(ctx)
+	if !ok {
+		return response.Unauthorized("Missing credentials"), nil
+	}
+
+	// Get workflow_id from path parameters
+	workflowID := request.PathParameters["id"]
+	if workflowID == "" {
+		return response.BadRequest("Missing workflow_id"), nil
+	}
+
+	// Parse pagination
+	filter, err := parseFilter(map[string]string{
+		"limit":  request.QueryStringParameters["limit"],
+		"offset": request.QueryStringParameters["offset"],
+	})
+	if err != nil {
+		return response.BadRequest(err.Error()), nil
+	}
+
+	// Version history is visible to the owning project and grantees, not through sharing
+	wf, err := a.workflows.Get(workflowID)
+	if err != nil {
+		return lookupError(ctx, err, "workflow"), nil
+	}
+
+	if resp, ok := a.authorize(ctx, claims, policy.ActionWorkflowReadVersions, policy.Workflow(wf), "Access denied to this workflow"); !ok {
+		return resp, nil
+	}
+
+	// Fetch one extra row to know whether another page exists
+	versions, err := a.workflows.ListVersions(workflowID, filter.Limit+1, filter.Offset)
+	if err != nil {
+		middleware.Log(ctx).Error("Error listing versions", "error", err)
+		return response.InternalError("Failed to list versions"), nil
+	}
+
+	hasMore := len(versions) > filter.Limit
+	if hasMore {
+		versions = versions[:filter.Limit]
+	}
+	showConfig, err := a.policy.Authorize(claims, policy.ActionWorkflowReadConfig, policy.Workflow(wf))
+	if err != nil {
+		middleware.Log(ctx).Error("Error checking permissions", "error", err)
+		return response.InternalError("Failed to check permissions"), nil
+	}
+	for i := range versions {
+		projectVersion(&versions[i], showConfig)
+	}
+
+	return response.Success(models.ListVersionsResponse{
+		Versions: versions,
+		Limit:    filter.Limit,
+		Offset:   filter.Offset,
+		HasMore:  hasMore,
+	}), nil
+}
+
+// parseVersion parses a positive version number
+func parseVersion(value string) (int, error) {
+	version, err := strconv.Atoi(value)
+	if err != nil || version < 1 {
+		return 0, errBadParam("version")
+	}
+	return version, nil
+}
+
+// projectVersion applies the projection of projectWorkflow to a version:
+// callers allowed policy.ActionWorkflowReadConfig get its credentials
+// masked, the others do not see its endpoint and headers at all
+func projectVersion(v *models.WorkflowVersion, showConfig bool) {
+	if showConfig {
+		v.BearerToken = secrets.Redacted
+		v.Headers = secrets.MaskHeadersJSON(v.Headers)
+		return
+	}
+	v.HTTPMethod = ""
+	v.BaseURL = ""
+	v.BearerToken = ""
+	v.ExternalWorkflowID = ""
+	v.Headers = nil
+}
+
+// isConfigField reports whether a version field is hidden by projectVersion
+func isConfigField(field string) bool {
+	switch field {
+	case "http_method", "base_url", "bearer_token", "external_workflow_id", "headers":
+		return true
+	}
+	return false
+}
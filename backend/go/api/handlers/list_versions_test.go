@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/xzero/ai-workflow/pkg/secrets"
+)
+
+func TestVersionsProjection(t *testing.T) {
+	tests := []struct {
+		did        string
+		showConfig bool
+	}{
+		{adminDID, true},
+		{editorDID, true},
+		{creatorDID, true},
+		{memberDID, false},
+		{runnerDID, false},
+		{viewerDID, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.did, func(t *testing.T) {
+			s := newTestServer(t)
+			id := s.createWorkflow()
+			update := `{"description": "Summarizes anything", "base_url": "https://n8n.example/v2", "bearer_token": "rotated-token"}`
+			if w := s.do(creatorDID, http.MethodPut, "/api/workflows/"+id, update, nil); w.Code != http.StatusOK {
+				t.Fatalf("update status = %d: %s", w.Code, w.Body)
+			}
+
+			for _, path := range []string{"/versions", "/versions/1", "/versions/diff?from=1&to=2"} {
+				w := s.do(tt.did, http.MethodGet, "/api/workflows/"+id+path, "", nil)
+				if w.Code != http.StatusOK {
+					t.Fatalf("%s status = %d: %s", path, w.Code, w.Body)
+				}
+				body := w.Body.String()
+				if strings.Contains(body, upstreamToken) || strings.Contains(body, "rotated-token") || strings.Contains(body, upstreamAPIKey) {
+					t.Errorf("%s carries a credential: %s", path, body)
+				}
+			}
+
+			var version map[string]interface{}
+			decodeData(t, s.do(tt.did, http.MethodGet, "/api/workflows/"+id+"/versions/1", "", nil), &version)
+			checkProjection(t, version, tt.showConfig)
+
+			var list struct {
+				Versions []map[string]interface{} `json:"versions"`
+			}
+			decodeData(t, s.do(tt.did, http.MethodGet, "/api/workflows/"+id+"/versions", "", nil), &list)
+			if len(list.Versions) != 2 {
+				t.Fatalf("listed %d versions, want 2", len(list.Versions))
+			}
+			checkProjection(t, list.Versions[1], tt.showConfig)
+
+			var diff struct {
+				Changes []struct {
+					Field string          `json:"field"`
+					From  json.RawMessage `json:"from"`
+					To    json.RawMessage `json:"to"`
+				} `json:"changes"`
+			}
+			decodeData(t, s.do(tt.did, http.MethodGet, "/api/workflows/"+id+"/versions/diff?from=1&to=2", "", nil), &diff)
+			fields := map[string]string{}
+			for _, c := range diff.Changes {
+				fields[c.Field] = string(c.To)
+			}
+			if _, ok := fields["description"]; !ok {
+				t.Errorf("changes = %v, want description", fields)
+			}
+			_, hasBaseURL := fields["base_url"]
+			_, hasToken := fields["bearer_token"]
+			if hasBaseURL != tt.showConfig || hasToken != tt.showConfig {
+				t.Errorf("changes = %v, want base_url and bearer_token reported: %v", fields, tt.showConfig)
+			}
+			if tt.showConfig && fields["bearer_token"] != `"`+secrets.Redacted+`"` {
+				t.Errorf("bearer_token change to %s, want it masked", fields["bearer_token"])
+			}
+		})
+	}
+}
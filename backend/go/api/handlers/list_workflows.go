@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/xzero/ai-workflow/pkg/db"
+	"github.com/xzero/ai-workflow/pkg/middleware"
+	"github.com/xzero/ai-workflow/pkg/models"
+	"github.com/xzero/ai-workflow/pkg/policy"
+	"github.com/xzero/ai-workflow/pkg/response"
+	"github.com/xzero/ai-workflow/pkg/workflow"
+)
+
+const (
+	defaultWorkflowLimit   = 50
+	maxWorkflowLimit       = 200
+	maxWorkflowQueryLength = 200
+)
+
+// ListWorkflows serves GET /api/projects/{projectId}/workflows. Results are
+// paginated with an opaque cursor; see parseWorkflowFilter for the filters.
+func (a *API) ListWorkflows(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	claims, ok := middleware.ClaimsFrom(ctx)
+	if !ok {
+		return response.Unauthorized("Missing credentials"), nil
+	}
+
+	// Get project_id from path parameters
+	projectID := request.PathParameters["projectId"]
+	if projectID == "" {
+		return response.BadRequest("Missing project_id"), nil
+	}
+
+	// Check if user has access to the project
+	if resp, ok := a.authorize(ctx, claims, policy.ActionProjectRead, policy.Project(projectID), "Access denied to this project"); !ok {
+		return resp, nil
+	}
+
+	// Parse filters, sorting and pagination
+	filter, err := parseWorkflowFilter(request.QueryStringParameters)
+	if err != nil {
+		return response.BadRequest(err.Error()), nil
+	}
+	filter.ProjectID = projectID
+
+	// Fetch one extra row to know whether another page exists
+	limit := filter.Limit
+	filter.Limit = limit + 1
+	workflows, err := a.workflows.List(filter)
+	if err != nil {
+		middleware.Log(ctx).Error("Error getting workflows", "error", err)
+		return response.InternalError("Failed to get workflows"), nil
+	}
+
+	page := response.Page{Limit: limit, HasMore: len(workflows) > limit}
+	if page.HasMore {
+		workflows = workflows[:limit]
+		page.NextCursor = encodeWorkflowCursor(db.WorkflowCursorOf(&workflows[limit-1], filter.Sort, filter.Descending))
+	}
+
+	// Never expose credentials; admins and creators use the reveal endpoint
+	if err := a.projectListed(claims, workflows); err != nil {
+		middleware.Log(ctx).Error("Error checking permissions", "error", err)
+		return response.InternalError("Failed to check permissions"), nil
+	}
+
+	return response.Paginated(workflows, page), nil
+}
+
+// parseWorkflowFilter reads the scope, source, template_name, creator_did,
+// tag, q, include_hidden, sort, order, cursor and limit query parameters
+func parseWorkflowFilter(params map[string]string) (models.ListWorkflowsFilter, error) {
+	filter := models.ListWorkflowsFilter{
+		Scope:        params["scope"],
+		Source:       params["source"],
+		TemplateName: params["template_name"],
+		CreatorDID:   params["creator_did"],
+		Tag:          strings.ToLower(strings.TrimSpace(params["tag"])),
+		Query:        strings.TrimSpace(params["q"]),
+		Sort:         params["sort"],
+		Limit:        defaultWorkflowLimit,
+	}
+
+	switch filter.Scope {
+	case "":
+		filter.Scope = models.WorkflowScopeAll
+	case models.WorkflowScopeAll, models.WorkflowScopeOwn, models.WorkflowScopeShared:
+	default:
+		return filter, errBadParam("scope")
+	}
+	if filter.Source != "" && !workflow.IsSupportedSource(filter.Source) {
+		return filter, errBadParam("source")
+	}
+	if filter.TemplateName != "" && filter.TemplateName != "workflow" && filter.TemplateName != "streamflow" {
+		return filter, errBadParam("template_name")
+	}
+	if len([]rune(filter.Query)) > maxWorkflowQueryLength {
+		return filter, errBadParam("q")
+	}
+
+	if v := params["include_hidden"]; v != "" {
+		includeHidden, err := strconv.ParseBool(v)
+		if err != nil {
+			return filter, errBadParam("include_hidden")
+		}
+		filter.IncludeHidden = includeHidden
+	}
+
+	// Dates sort newest first, names alphabetically
+	if filter.Sort == "" {
+		filter.Sort = models.WorkflowSortCreatedAt
+	}
+	if !db.IsWorkflowSort(filter.Sort) {
+		return filter, errBadParam("sort")
+	}
+	switch params["order"] {
+	case "":
+		filter.Descending = filter.Sort != models.WorkflowSortName
+	case "asc":
+	case "desc":
+		filter.Descending = true
+	default:
+		return filter, errBadParam("order")
+	}
+
+	if v := params["limit"]; v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxWorkflowLimit {
+			return filter, errBadParam("limit")
+		}
+		filter.Limit = limit
+	}
+
+	// A cursor only continues the sort it was issued for
+	if v := params["cursor"]; v != "" {
+		cursor, err := decodeWorkflowCursor(v)
+		if err != nil || cursor.Sort != filter.Sort || cursor.Descending != filter.Descending {
+			return filter, errBadParam("cursor")
+		}
+		filter.After = cursor
+	}
+
+	return filter, nil
+}
+
+// encodeWorkflowCursor returns cursor as an opaque URL-safe string
+func encodeWorkflowCursor(cursor models.WorkflowCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeWorkflowCursor(s string) (*models.WorkflowCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	var cursor models.WorkflowCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, err
+	}
+	if cursor.Value == "" || cursor.WorkflowID == "" {
+		return nil, errors.New("incomplete cursor")
+	}
+	return &cursor, nil
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/xzero/ai-workflow/pkg/middleware"
+	"github.com/xzero/ai-workflow/pkg/models"
+	"github.com/xzero/ai-workflow/pkg/response"
+)
+
+// headerRequirePrecondition opts a client into mandatory If-Match on writes
+const headerRequirePrecondition = "X-Require-Precondition"
+
+// etag returns the strong entity tag of the workflow, its quoted RowVersion
+func etag(wf *models.Workflow) string {
+	return `"` + strconv.Itoa(wf.RowVersion) + `"`
+}
+
+// withETag sets the ETag of wf on resp and lets browsers read it
+func withETag(resp events.APIGatewayProxyResponse, wf *models.Workflow) events.APIGatewayProxyResponse {
+	if resp.Headers == nil {
+		resp.Headers = map[string]string{}
+	}
+	resp.Headers["ETag"] = etag(wf)
+	resp.Headers["Access-Control-Expose-Headers"] = "ETag"
+	return resp
+}
+
+// withCurrentETag sets on resp the ETag of the workflow as re-read after a
+// successful write. resp is returned unchanged when the read fails; the
+// write itself succeeded.
+func (a *API) withCurrentETag(ctx context.Context, resp events.APIGatewayProxyResponse, workflowID string) events.APIGatewayProxyResponse {
+	wf, err := a.workflows.Get(workflowID)
+	if err != nil {
+		middleware.Log(ctx).Error("Error getting workflow", "error", err)
+		return resp
+	}
+	return withETag(resp, wf)
+}
+
+// checkPrecondition evaluates the If-Match header of a write to wf and returns
+// the RowVersion the store must still find, 0 when the write is unconditional.
+// When ok is false resp is a 412 for a stale tag, or a 428 when the client
+// sent X-Require-Precondition without If-Match. Weak tags never match.
+func checkPrecondition(request events.APIGatewayProxyRequest, wf *models.Workflow) (rowVersion int, resp events.APIGatewayProxyResponse, ok bool) {
+	ifMatch := strings.TrimSpace(middleware.Header(request.Headers, "If-Match"))
+	if ifMatch == "" {
+		if required, _ := strconv.ParseBool(middleware.Header(request.Headers, headerRequirePrecondition)); required {
+			return 0, response.PreconditionRequired("If-Match header is required"), false
+		}
+		return 0, resp, true
+	}
+	if ifMatch == "*" {
+		return 0, resp, true
+	}
+
+	current := etag(wf)
+	for _, tag := range strings.Split(ifMatch, ",") {
+		if strings.TrimSpace(tag) == current {
+			return wf.RowVersion, resp, true
+		}
+	}
+	return 0, preconditionFailed(wf), false
+}
+
+// preconditionFailed returns the 412 for a write based on an older version of wf
+func preconditionFailed(wf *models.Workflow) events.APIGatewayProxyResponse {
+	resp := response.PreconditionFailed("Workflow was modified by someone else, reload it and try again",
+		map[string]interface{}{"row_version": wf.RowVersion})
+	return withETag(resp, wf)
+}
+
+// lostRace returns the 412 for a write that the store rejected with
+// store.ErrPreconditionFailed, reporting the version that won
+func (a *API) lostRace(ctx context.Context, workflowID string) events.APIGatewayProxyResponse {
+	wf, err := a.workflows.Get(workflowID)
+	if err != nil {
+		return lookupError(ctx, err, "workflow")
+	}
+	return preconditionFailed(wf)
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestUpdatePreconditions(t *testing.T) {
+	const update = `{"description": "Summarizes anything"}`
+
+	tests := []struct {
+		name     string
+		headers  map[string]string
+		want     int
+		wantETag string // ETag of the response, unchecked when empty
+	}{
+		{"no If-Match", nil, http.StatusOK, ""},
+		{"current tag", map[string]string{"If-Match": `"1"`}, http.StatusOK, ""},
+		{"one of several tags", map[string]string{"If-Match": `"7", "1"`}, http.StatusOK, ""},
+		{"any", map[string]string{"If-Match": "*"}, http.StatusOK, ""},
+		{"stale tag", map[string]string{"If-Match": `"0"`}, http.StatusPreconditionFailed, `"1"`},
+		{"weak tag", map[string]string{"If-Match": `W/"1"`}, http.StatusPreconditionFailed, `"1"`},
+		{"required and missing", map[string]string{headerRequirePrecondition: "true"}, http.StatusPreconditionRequired, ""},
+		{"required and present", map[string]string{headerRequirePrecondition: "true", "If-Match": `"1"`}, http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t)
+			id := s.createWorkflow()
+
+			w := s.do(editorDID, http.MethodPut, "/api/workflows/"+id, update, tt.headers)
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+			if tt.wantETag != "" && w.Header().Get("ETag") != tt.wantETag {
+				t.Errorf("ETag = %q, want %q", w.Header().Get("ETag"), tt.wantETag)
+			}
+
+			stored, err := s.mem.Get(id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if updated := stored.Description == "Summarizes anything"; updated != (tt.want == http.StatusOK) {
+				t.Errorf("description = %q after status %d", stored.Description, w.Code)
+			}
+		})
+	}
+}
+
+func TestConcurrentWriters(t *testing.T) {
+	s := newTestServer(t)
+	id := s.createWorkflow()
+
+	// Both writers read version 1
+	w := s.do(editorDID, http.MethodGet, "/api/workflows/"+id, "", nil)
+	tag := w.Header().Get("ETag")
+	if tag != `"1"` {
+		t.Fatalf("ETag = %q, want \"1\"", tag)
+	}
+
+	first := s.do(editorDID, http.MethodPut, "/api/workflows/"+id, `{"description": "first"}`, map[string]string{"If-Match": tag})
+	if first.Code != http.StatusOK {
+		t.Fatalf("first update: status %d: %s", first.Code, first.Body)
+	}
+	second := s.do(creatorDID, http.MethodPut, "/api/workflows/"+id, `{"description": "second"}`, map[string]string{"If-Match": tag})
+	if second.Code != http.StatusPreconditionFailed {
+		t.Fatalf("second update: status %d, want 412: %s", second.Code, second.Body)
+	}
+	if got := second.Header().Get("ETag"); got != `"2"` {
+		t.Errorf("ETag of the 412 = %q, want \"2\"", got)
+	}
+
+	// A stale delete is rejected too, the current tag goes through
+	if w := s.do(adminDID, http.MethodDelete, "/api/workflows/"+id, "", map[string]string{"If-Match": tag}); w.Code != http.StatusPreconditionFailed {
+		t.Errorf("stale delete: status %d, want 412: %s", w.Code, w.Body)
+	}
+	if w := s.do(adminDID, http.MethodDelete, "/api/workflows/"+id, "", map[string]string{"If-Match": `"2"`}); w.Code != http.StatusOK {
+		t.Errorf("delete: status %d: %s", w.Code, w.Body)
+	}
+}
+
+func TestWritesReturnETag(t *testing.T) {
+	s := newTestServer(t)
+	id := s.createWorkflow()
+
+	// Each write returns the tag the next one sends
+	writes := []struct {
+		name   string
+		did    string
+		method string
+		path   string
+		body   string
+		want   string
+	}{
+		{"update", editorDID, http.MethodPut, "/api/workflows/" + id, `{"description": "Summarizes anything"}`, `"2"`},
+		{"share", adminDID, http.MethodPut, "/api/workflows/" + id + "/share", `{"is_shared": true}`, `"3"`},
+		{"rollback", creatorDID, http.MethodPost, "/api/workflows/" + id + "/versions/1/rollback", "", `"4"`},
+		{"rollback to the current definition", creatorDID, http.MethodPost, "/api/workflows/" + id + "/versions/1/rollback", "", `"4"`},
+	}
+	tag := `"1"`
+	for _, tt := range writes {
+		w := s.do(tt.did, tt.method, tt.path, tt.body, map[string]string{"If-Match": tag})
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status %d: %s", tt.name, w.Code, w.Body)
+		}
+		if tag = w.Header().Get("ETag"); tag != tt.want {
+			t.Fatalf("%s: ETag = %q, want %q", tt.name, tag, tt.want)
+		}
+	}
+}
+
+func TestRollbackPreconditions(t *testing.T) {
+	s := newTestServer(t)
+	id := s.createWorkflow()
+	if w := s.do(editorDID, http.MethodPut, "/api/workflows/"+id, `{"description": "Summarizes anything"}`, nil); w.Code != http.StatusOK {
+		t.Fatalf("update status = %d: %s", w.Code, w.Body)
+	}
+
+	w := s.do(editorDID, http.MethodPost, "/api/workflows/"+id+"/versions/1/rollback", "", map[string]string{"If-Match": `"1"`})
+	if w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("stale rollback: status %d, want 412: %s", w.Code, w.Body)
+	}
+	if got := w.Header().Get("ETag"); got != `"2"` {
+		t.Errorf("ETag of the 412 = %q, want \"2\"", got)
+	}
+	stored, err := s.mem.Get(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Description != "Summarizes anything" {
+		t.Errorf("description = %q, want the update kept", stored.Description)
+	}
+
+	w = s.do(editorDID, http.MethodPost, "/api/workflows/"+id+"/versions/1/rollback", "", map[string]string{headerRequirePrecondition: "true"})
+	if w.Code != http.StatusPreconditionRequired {
+		t.Errorf("rollback without If-Match: status %d, want 428: %s", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/xzero/ai-workflow/pkg/middleware"
+	"github.com/xzero/ai-workflow/pkg/models"
+	"github.com/xzero/ai-workflow/pkg/policy"
+	"github.com/xzero/ai-workflow/pkg/response"
+	"github.com/xzero/ai-workflow/pkg/store"
+)
+
+// RestoreWorkflow serves POST /api/workflows/{id}/restore, taking a deleted
+// workflow out of the trash. Whoever may delete a workflow may restore it.
+func (a *API) RestoreWorkflow(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	claims, ok := middleware.ClaimsFrom(ctx)
+	if !ok {
+		return response.Unauthorized("Missing credentials"), nil
+	}
+
+	// Get workflow_id from path parameters
+	workflowID := request.PathParameters["id"]
+	if workflowID == "" {
+		return response.BadRequest("Missing workflow_id"), nil
+	}
+
+	wf, err := a.workflows.GetDeleted(workflowID)
+	if err != nil {
+		return lookupError(ctx, err, "deleted workflow"), nil
+	}
+
+	if resp, ok := a.authorize(ctx, claims, policy.ActionWorkflowDelete, policy.Workflow(wf), "Only admin or creator can restore this workflow"); !ok {
+		return resp, nil
+	}
+
+	if err := a.workflows.Restore(workflowID); err != nil {
+		if err == store.ErrNotFound {
+			return lookupError(ctx, err, "deleted workflow"), nil
+		}
+		middleware.Log(ctx).Error("Error restoring workflow", "error", err)
+		return response.InternalError("Failed to restore workflow"), nil
+	}
+
+	a.audit.Emit(ctx, models.AuditEvent{
+		Action:     models.AuditWorkflowRestore,
+		ProjectID:  wf.ProjectID,
+		WorkflowID: workflowID,
+		Details: map[string]interface{}{
+			"deleted_at": wf.DeletedAt,
+			"deleted_by": wf.DeletedBy,
+		},
+	})
+
+	return response.Success(map[string]interface{}{
+		"message":     "Workflow restored",
+		"workflow_id": workflowID,
+	}), nil
+}
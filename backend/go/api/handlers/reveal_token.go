@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/xzero/ai-workflow/pkg/middleware"
+	"github.com/xzero/ai-workflow/pkg/policy"
+	"github.com/xzero/ai-workflow/pkg/response"
+	"github.com/xzero/ai-workflow/pkg/secrets"
+)
+
+// RevealToken serves GET /api/workflows/{id}/token, the only endpoint returning a stored bearer token
+func (a *API) RevealToken(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	claims, ok := middleware.ClaimsFrom(ctx)
+	if !ok {
+		return response.Unauthorized("Missing credentials"), nil
+	}
+
+	// Get workflow_id from path parameters
+	workflowID := request.PathParameters["id"]
+	if workflowID == "" {
+		return response.BadRequest("Missing workflow_id"), nil
+	}
+
+	// Get workflow to check permissions
+	workflow, err := a.workflows.Get(workflowID)
+	if err != nil {
+		return lookupError(ctx, err, "workflow"), nil
+	}
+
+	// Check permissions
+	// Admin can reveal the token of any workflow in the project
+	// Creator can reveal the token of their own workflow
+	if resp, ok := a.authorize(ctx, claims, policy.ActionWorkflowRevealToken, policy.Workflow(workflow), "Only admin or creator can reveal the bearer token"); !ok {
+		return resp, nil
+	}
+
+	bearerToken, err := secrets.Decrypt(ctx, a.keys, workflow.BearerToken)
+	if err != nil {
+		middleware.Log(ctx).Error("Error decrypting bearer token", "error", err)
+		return response.InternalError("Failed to decrypt bearer token"), nil
+	}
+
+	middleware.Log(ctx).Info("Bearer token revealed", "workflow_id", workflowID)
+
+	resp := response.Success(map[string]interface{}{
+		"workflow_id":  workflowID,
+		"bearer_token": bearerToken,
+	})
+	resp.Headers["Cache-Control"] = "no-store"
+	return resp, nil
+}
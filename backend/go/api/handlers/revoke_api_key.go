@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/xzero/ai-workflow/pkg/middleware"
+	"github.com/xzero/ai-workflow/pkg/models"
+	"github.com/xzero/ai-workflow/pkg/policy"
+	"github.com/xzero/ai-workflow/pkg/response"
+)
+
+// RevokeAPIKey serves DELETE /api/projects/{projectId}/api-keys/{keyId}. The
+// key is kept so its runs still name it, but is rejected from now on.
+func (a *API) RevokeAPIKey(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	claims, ok := middleware.ClaimsFrom(ctx)
+	if !ok {
+		return response.Unauthorized("Missing credentials"), nil
+	}
+
+	// Get project_id and key_id from path parameters
+	projectID := request.PathParameters["projectId"]
+	keyID := request.PathParameters["keyId"]
+	if projectID == "" || keyID == "" {
+		return response.BadRequest("Missing project_id or key_id"), nil
+	}
+
+	// Check permissions - only project admin can manage API keys
+	if resp, ok := a.authorize(ctx, claims, policy.ActionManageAPIKeys, policy.Project(projectID), "Only project admin can manage API keys"); !ok {
+		return resp, nil
+	}
+
+	key, err := a.apiKeys.RevokeAPIKey(projectID, keyID)
+	if err != nil {
+		return lookupError(ctx, err, "API key"), nil
+	}
+
+	a.audit.Emit(ctx, models.AuditEvent{
+		Action:    models.AuditAPIKeyRevoke,
+		ProjectID: projectID,
+		Details:   map[string]interface{}{"key_id": key.KeyID, "name": key.Name, "prefix": key.Prefix},
+	})
+
+	return response.Success(key), nil
+}
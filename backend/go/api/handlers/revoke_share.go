@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/xzero/ai-workflow/pkg/middleware"
+	"github.com/xzero/ai-workflow/pkg/models"
+	"github.com/xzero/ai-workflow/pkg/policy"
+	"github.com/xzero/ai-workflow/pkg/response"
+)
+
+// RevokeShare serves DELETE /api/workflows/{id}/shares/{targetType}/{targetId}
+func (a *API) RevokeShare(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	claims, ok := middleware.ClaimsFrom(ctx)
+	if !ok {
+		return response.Unauthorized("Missing credentials"), nil
+	}
+
+	// Get workflow_id and the target from path parameters
+	workflowID := request.PathParameters["id"]
+	targetType := request.PathParameters["targetType"]
+	targetID := request.PathParameters["targetId"]
+	if workflowID == "" || targetID == "" {
+		return response.BadRequest("Missing workflow_id or target ID"), nil
+	}
+	if targetType != models.ShareTargetProject && targetType != models.ShareTargetOrganization {
+		return response.BadRequest("Invalid target type, must be 'project' or 'organization'"), nil
+	}
+
+	wf, err := a.workflows.Get(workflowID)
+	if err != nil {
+		return lookupError(ctx, err, "workflow"), nil
+	}
+
+	if resp, ok := a.authorize(ctx, claims, policy.ActionWorkflowShare, policy.Workflow(wf), "Only project admin can share workflows"); !ok {
+		return resp, nil
+	}
+
+	removed, err := a.workflows.DeleteShare(workflowID, targetType, targetID)
+	if err != nil {
+		middleware.Log(ctx).Error("Error revoking share", "error", err)
+		return response.InternalError("Failed to revoke share"), nil
+	}
+	if !removed {
+		return response.NotFound("Share not found"), nil
+	}
+
+	a.audit.Emit(ctx, models.AuditEvent{
+		Action:     models.AuditWorkflowUnshare,
+		ProjectID:  wf.ProjectID,
+		WorkflowID: workflowID,
+		Details:    map[string]interface{}{"target_type": targetType, "target_id": targetID},
+	})
+
+	return response.Success(map[string]interface{}{
+		"workflow_id": workflowID,
+		"target_type": targetType,
+		"target_id":   targetID,
+		"revoked":     true,
+	}), nil
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/xzero/ai-workflow/pkg/audit"
+	"github.com/xzero/ai-workflow/pkg/middleware"
+	"github.com/xzero/ai-workflow/pkg/models"
+	"github.com/xzero/ai-workflow/pkg/policy"
+	"github.com/xzero/ai-workflow/pkg/response"
+	"github.com/xzero/ai-workflow/pkg/secrets"
+	"github.com/xzero/ai-workflow/pkg/store"
+)
+
+// RollbackWorkflow serves POST /api/workflows/{id}/versions/{version}/rollback.
+// The definition of the version becomes the latest version again; the
+// history in between is kept.
+func (a *API) RollbackWorkflow(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	claims, ok := middleware.ClaimsFrom(ctx)
+	if !ok {
+		return response.Unauthorized("Missing credentials"), nil
+	}
+
+	// Get workflow_id and version from path parameters
+	workflowID := request.PathParameters["id"]
+	if workflowID == "" {
+		return response.BadRequest("Missing workflow_id"), nil
+	}
+	version, err := parseVersion(request.PathParameters["version"])
+	if err != nil {
+		return response.BadRequest("Invalid version"), nil
+	}
+
+	// Get workflow to check permissions
+	wf, err := a.workflows.Get(workflowID)
+	if err != nil {
+		return lookupError(ctx, err, "workflow"), nil
+	}
+
+	// Rolling back is an update: editors of the project and the creator
+	if resp, ok := a.authorize(ctx, claims, policy.ActionWorkflowUpdate, policy.Workflow(wf), "Only editors or the creator can roll back this workflow"); !ok {
+		return resp, nil
+	}
+
+	rowVersion, resp, ok := checkPrecondition(request, wf)
+	if !ok {
+		return resp, nil
+	}
+
+	target, err := a.workflows.GetVersion(workflowID, version)
+	if err != nil {
+		return lookupError(ctx, err, "workflow version"), nil
+	}
+
+	// Versions recorded before encryption at rest was enabled may hold a
+	// plaintext token, which must not be restored as such
+	target.BearerToken, err = secrets.Encrypt(ctx, a.keys, target.BearerToken)
+	if err != nil {
+		middleware.Log(ctx).Error("Error encrypting bearer token", "error", err)
+		return response.InternalError("Failed to encrypt bearer token"), nil
+	}
+
+	saved, err := a.workflows.Rollback(target, claims.DID, rowVersion)
+	if err == store.ErrPreconditionFailed {
+		return a.lostRace(ctx, workflowID), nil
+	}
+	if err != nil {
+		middleware.Log(ctx).Error("Error rolling back workflow", "error", err)
+		return response.InternalError("Failed to roll back workflow"), nil
+	}
+
+	if saved == nil {
+		resp = response.Success(map[string]interface{}{
+			"workflow_id": workflowID,
+			"version":     wf.Version,
+			"message":     "Workflow already matches version " + strconv.Itoa(version),
+		})
+		return withETag(resp, wf), nil
+	}
+
+	a.refreshEmbedding(ctx, workflowID)
+	a.audit.Emit(ctx, models.AuditEvent{
+		Action:     models.AuditWorkflowRollback,
+		ProjectID:  wf.ProjectID,
+		WorkflowID: workflowID,
+		Changes:    audit.Changes(models.DefinitionOf(wf), saved),
+		Details:    map[string]interface{}{"version": saved.Version, "rolled_back_from": version},
+	})
+
+	resp = response.Success(map[string]interface{}{
+		"workflow_id":      workflowID,
+		"version":          saved.Version,
+		"rolled_back_from": version,
+		"changed_fields":   saved.ChangedFields,
+		"message":          "Workflow rolled back successfully",
+	})
+	return a.withCurrentETag(ctx, resp, workflowID), nil
+}
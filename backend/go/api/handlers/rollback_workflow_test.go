@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/xzero/ai-workflow/pkg/secrets"
+)
+
+func TestRollbackEncryptsPlaintextToken(t *testing.T) {
+	s := newTestServer(t)
+
+	// Version 1 is recorded before encryption at rest was enabled
+	keys := s.api.keys
+	s.api.keys = nil
+	id := s.createWorkflow()
+	s.api.keys = keys
+
+	if w := s.do(creatorDID, http.MethodPut, "/api/workflows/"+id, `{"bearer_token": "rotated-token"}`, nil); w.Code != http.StatusOK {
+		t.Fatalf("update status = %d: %s", w.Code, w.Body)
+	}
+	if w := s.do(creatorDID, http.MethodPost, "/api/workflows/"+id+"/versions/1/rollback", "", nil); w.Code != http.StatusOK {
+		t.Fatalf("rollback status = %d: %s", w.Code, w.Body)
+	}
+
+	stored, err := s.mem.Get(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !secrets.IsEncrypted(stored.BearerToken) {
+		t.Fatalf("bearer_token = %q, want it encrypted", stored.BearerToken)
+	}
+	if token, err := secrets.Decrypt(context.Background(), keys, stored.BearerToken); err != nil || token != upstreamToken {
+		t.Errorf("decrypted token = %q, %v, want %q", token, err, upstreamToken)
+	}
+}
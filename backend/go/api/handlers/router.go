@@ -0,0 +1,365 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"log"
+	"log/slog"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/xzero/ai-workflow/pkg/middleware"
+	"github.com/xzero/ai-workflow/pkg/response"
+)
+
+// maxBodySize matches the Lambda request payload limit
+const maxBodySize = 6 * 1024 * 1024
+
+// ProxyHandler handles an API Gateway proxy request
+type ProxyHandler = middleware.Handler
+
+// StreamHandler handles a Lambda Function URL request with a streamed response
+type StreamHandler func(ctx context.Context, request events.LambdaFunctionURLRequest) (*events.LambdaFunctionURLStreamingResponse, error)
+
+// Route binds a method and an API Gateway path template such as
+// /api/workflows/{id} to a handler. Exactly one of Handler and Stream is set.
+type Route struct {
+	Method  string
+	Path    string
+	Handler ProxyHandler
+	Stream  StreamHandler
+}
+
+// Routes returns every endpoint with the paths used in template.yaml, plus
+// the health check of the standalone server. Proxy handlers are wrapped like
+// the Lambda functions in cmd/, so they log their own requests.
+func (a *API) Routes() []Route {
+	return []Route{
+		{Method: http.MethodGet, Path: "/health", Handler: a.WrapPublic(a.Health)},
+		{Method: http.MethodGet, Path: "/api/projects/{projectId}/workflows", Handler: a.Wrap(a.ListWorkflows)},
+		{Method: http.MethodGet, Path: "/api/projects/{projectId}/workflows/export", Handler: a.Wrap(a.ExportWorkflows)},
+		{Method: http.MethodPost, Path: "/api/projects/{projectId}/workflows/import", Handler: a.Wrap(a.ImportWorkflows)},
+		{Method: http.MethodPost, Path: "/api/workflows", Handler: a.Wrap(a.CreateWorkflow)},
+		{Method: http.MethodPost, Path: "/api/workflows/search", Handler: a.Wrap(a.SearchWorkflows)},
+		{Method: http.MethodPost, Path: "/api/assistant/run", Handler: a.Wrap(a.RunAssistant)},
+		{Method: http.MethodGet, Path: "/api/workflows/{id}", Handler: a.Wrap(a.GetWorkflow)},
+		{Method: http.MethodPut, Path: "/api/workflows/{id}", Handler: a.Wrap(a.UpdateWorkflow)},
+		{Method: http.MethodDelete, Path: "/api/workflows/{id}", Handler: a.Wrap(a.DeleteWorkflow)},
+		{Method: http.MethodPost, Path: "/api/workflows/{id}/restore", Handler: a.Wrap(a.RestoreWorkflow)},
+		{Method: http.MethodGet, Path: "/api/projects/{projectId}/trash", Handler: a.Wrap(a.ListTrash)},
+		{Method: http.MethodPost, Path: "/api/workflows/{id}/execute", Handler: a.Wrap(a.ExecuteWorkflow)},
+		{Method: http.MethodPost, Path: "/api/workflows/{id}/stream", Stream: a.StreamWorkflow},
+		{Method: http.MethodPut, Path: "/api/workflows/{id}/share", Handler: a.Wrap(a.ShareWorkflow)},
+		{Method: http.MethodGet, Path: "/api/workflows/{id}/shares", Handler: a.Wrap(a.ListShares)},
+		{Method: http.MethodPost, Path: "/api/workflows/{id}/shares", Handler: a.Wrap(a.AddShares)},
+		{Method: http.MethodDelete, Path: "/api/workflows/{id}/shares/{targetType}/{targetId}", Handler: a.Wrap(a.RevokeShare)},
+		{Method: http.MethodPut, Path: "/api/projects/{projectId}/workflows/{workflowId}/hide", Handler: a.Wrap(a.HideWorkflow)},
+		{Method: http.MethodGet, Path: "/api/workflows/{id}/token", Handler: a.Wrap(a.RevealToken)},
+		{Method: http.MethodGet, Path: "/api/workflows/{id}/grants", Handler: a.Wrap(a.ListGrants)},
+		{Method: http.MethodPut, Path: "/api/workflows/{id}/grants", Handler: a.Wrap(a.SetGrant)},
+		{Method: http.MethodGet, Path: "/api/workflows/{id}/versions", Handler: a.Wrap(a.ListVersions)},
+		{Method: http.MethodGet, Path: "/api/workflows/{id}/versions/diff", Handler: a.Wrap(a.DiffVersions)},
+		{Method: http.MethodGet, Path: "/api/workflows/{id}/versions/{version}", Handler: a.Wrap(a.GetVersion)},
+		{Method: http.MethodPost, Path: "/api/workflows/{id}/versions/{version}/rollback", Handler: a.Wrap(a.RollbackWorkflow)},
+		{Method: http.MethodGet, Path: "/api/workflows/{id}/runs", Handler: a.Wrap(a.ListRuns)},
+		{Method: http.MethodGet, Path: "/api/projects/{projectId}/runs", Handler: a.Wrap(a.ListRuns)},
+		{Method: http.MethodGet, Path: "/api/runs/{runId}", Handler: a.Wrap(a.GetRun)},
+		{Method: http.MethodDelete, Path: "/api/runs/{runId}", Handler: a.Wrap(a.CancelRun)},
+		{Method: http.MethodPost, Path: "/api/projects/{projectId}/api-keys", Handler: a.Wrap(a.CreateAPIKey)},
+		{Method: http.MethodGet, Path: "/api/projects/{projectId}/api-keys", Handler: a.Wrap(a.ListAPIKeys)},
+		{Method: http.MethodDelete, Path: "/api/projects/{projectId}/api-keys/{keyId}", Handler: a.Wrap(a.RevokeAPIKey)},
+		{Method: http.MethodGet, Path: "/api/projects/{projectId}/audit-events", Handler: a.Wrap(a.ListAuditEvents)},
+		{Method: http.MethodGet, Path: "/api/projects/{projectId}/audit-events/export", Handler: a.Wrap(a.ExportAuditEvents)},
+	}
+}
+
+// Router serves routes over net/http. It translates every request into the
+// Lambda event the handler expects, so handlers behave exactly as they do
+// behind API Gateway, and answers CORS preflights like the API Gateway
+// configuration in template.yaml.
+type Router struct {
+	routes []Route
+}
+
+// NewRouter returns a router for routes
+func NewRouter(routes []Route) *Router {
+	return &Router{routes: routes}
+}
+
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rt.serve(w, r)
+}
+
+// serve dispatches r and returns the response status
+func (rt *Router) serve(w http.ResponseWriter, r *http.Request) int {
+	if r.Method == http.MethodOptions {
+		setCORSHeaders(w.Header())
+		w.WriteHeader(http.StatusNoContent)
+		return http.StatusNoContent
+	}
+
+	route, params, allowed := rt.match(r.Method, r.URL.Path)
+	if route == nil {
+		if len(allowed) > 0 {
+			w.Header().Set("Allow", strings.Join(allowed, ", "))
+			return writeProxyResponse(w, response.Error(http.StatusMethodNotAllowed, "Method not allowed"))
+		}
+		return writeProxyResponse(w, response.NotFound("Route not found"))
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return writeProxyResponse(w, response.Error(http.StatusRequestEntityTooLarge, "Request body too large"))
+		}
+		return writeProxyResponse(w, response.BadRequest("Failed to read request body"))
+	}
+
+	if route.Stream != nil {
+		// Streamed responses bypass the middleware chain, so they are logged here
+		start := time.Now()
+		status := serveStream(w, r, route, body)
+		slog.Info("request", "method", r.Method, "path", r.URL.Path, "route", route.Path,
+			"status", status, "latency_ms", time.Since(start).Milliseconds())
+		return status
+	}
+
+	resp, err := route.Handler(r.Context(), proxyRequest(r, route, params, body))
+	if err != nil {
+		log.Printf("Error handling %s %s: %v", r.Method, r.URL.Path, err)
+		return writeProxyResponse(w, response.InternalError("Internal server error"))
+	}
+	return writeProxyResponse(w, resp)
+}
+
+// match finds the route for method and path. Routes with more literal
+// segments win over routes with path parameters. When only the method
+// differs, the allowed methods are returned instead.
+func (rt *Router) match(method, path string) (*Route, map[string]string, []string) {
+	segments := splitPath(path)
+
+	var best *Route
+	var bestParams map[string]string
+	bestScore := -1
+	var allowed []string
+	for i := range rt.routes {
+		route := &rt.routes[i]
+		params, score, ok := matchPath(splitPath(route.Path), segments)
+		if !ok {
+			continue
+		}
+		if route.Method != method {
+			allowed = append(allowed, route.Method)
+			continue
+		}
+		if score > bestScore {
+			best, bestParams, bestScore = route, params, score
+		}
+	}
+	if best != nil {
+		return best, bestParams, nil
+	}
+
+	sort.Strings(allowed)
+	return nil, nil, allowed
+}
+
+// matchPath matches path segments against a template and returns the path
+// parameters and the number of literal segments
+func matchPath(template, segments []string) (map[string]string, int, bool) {
+	if len(template) != len(segments) {
+		return nil, 0, false
+	}
+	params := map[string]string{}
+	score := 0
+	for i, part := range template {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			if segments[i] == "" {
+				return nil, 0, false
+			}
+			params[part[1:len(part)-1]] = segments[i]
+			continue
+		}
+		if part != segments[i] {
+			return nil, 0, false
+		}
+		score++
+	}
+	return params, score, true
+}
+
+func splitPath(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
+
+// proxyRequest builds the API Gateway event of r
+func proxyRequest(r *http.Request, route *Route, params map[string]string, body []byte) events.APIGatewayProxyRequest {
+	headers := map[string]string{}
+	for k, v := range r.Header {
+		headers[k] = v[0]
+	}
+	if r.Host != "" {
+		headers["Host"] = r.Host
+	}
+
+	query := map[string]string{}
+	for k, v := range r.URL.Query() {
+		query[k] = v[0]
+	}
+
+	request := events.APIGatewayProxyRequest{
+		Resource:                        route.Path,
+		Path:                            r.URL.Path,
+		HTTPMethod:                      r.Method,
+		Headers:                         headers,
+		MultiValueHeaders:               r.Header,
+		QueryStringParameters:           query,
+		MultiValueQueryStringParameters: r.URL.Query(),
+		PathParameters:                  params,
+		RequestContext: events.APIGatewayProxyRequestContext{
+			ResourcePath: route.Path,
+			Path:         r.URL.Path,
+			HTTPMethod:   r.Method,
+			Stage:        "local",
+			Identity:     events.APIGatewayRequestIdentity{SourceIP: sourceIP(r), UserAgent: r.UserAgent()},
+		},
+	}
+	request.Body, request.IsBase64Encoded = encodeBody(body)
+	return request
+}
+
+// sourceIP returns the client address of r without its port, as API
+// Gateway reports it
+func sourceIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// writeProxyResponse writes an API Gateway response and returns its status
+func writeProxyResponse(w http.ResponseWriter, resp events.APIGatewayProxyResponse) int {
+	for k, v := range resp.Headers {
+		w.Header().Set(k, v)
+	}
+	for k, values := range resp.MultiValueHeaders {
+		for _, v := range values {
+			w.Header().Add(k, v)
+		}
+	}
+
+	status := resp.StatusCode
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.WriteHeader(status)
+
+	if resp.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(resp.Body)
+		if err != nil {
+			log.Printf("Error decoding response body: %v", err)
+			return status
+		}
+		w.Write(decoded)
+	} else {
+		io.WriteString(w, resp.Body)
+	}
+	return status
+}
+
+// serveStream calls a streaming handler with the Function URL event of r and
+// flushes the response body to the client as it is produced
+func serveStream(w http.ResponseWriter, r *http.Request, route *Route, body []byte) int {
+	// Function URLs deliver lower-case header names
+	headers := map[string]string{}
+	for k, v := range r.Header {
+		headers[strings.ToLower(k)] = strings.Join(v, ",")
+	}
+	query := map[string]string{}
+	for k, v := range r.URL.Query() {
+		query[k] = strings.Join(v, ",")
+	}
+
+	request := events.LambdaFunctionURLRequest{
+		RawPath:               r.URL.Path,
+		RawQueryString:        r.URL.RawQuery,
+		Headers:               headers,
+		QueryStringParameters: query,
+		RequestContext: events.LambdaFunctionURLRequestContext{
+			HTTP: events.LambdaFunctionURLRequestContextHTTPDescription{
+				Method:    r.Method,
+				Path:      r.URL.Path,
+				SourceIP:  sourceIP(r),
+				UserAgent: r.UserAgent(),
+			},
+		},
+	}
+	request.Body, request.IsBase64Encoded = encodeBody(body)
+
+	resp, err := route.Stream(r.Context(), request)
+	if err != nil {
+		log.Printf("Error handling %s %s: %v", r.Method, r.URL.Path, err)
+		return writeProxyResponse(w, response.InternalError("Internal server error"))
+	}
+	if closer, ok := resp.Body.(io.Closer); ok {
+		// Unblocks the producer when the client goes away
+		defer closer.Close()
+	}
+
+	for k, v := range resp.Headers {
+		w.Header().Set(k, v)
+	}
+	status := resp.StatusCode
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.WriteHeader(status)
+
+	if resp.Body == nil {
+		return status
+	}
+	flusher, _ := w.(http.Flusher)
+	buf := make([]byte, 4096)
+	for {
+		n, err := resp.Body.Read(buf)
+		if n > 0 {
+			if _, writeErr := w.Write(buf[:n]); writeErr != nil {
+				return status
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		if err != nil {
+			if err != io.EOF {
+				log.Printf("Error streaming %s: %v", r.URL.Path, err)
+			}
+			return status
+		}
+	}
+}
+
+// setCORSHeaders mirrors the Cors settings of the API Gateway in template.yaml
+func setCORSHeaders(h http.Header) {
+	h.Set("Access-Control-Allow-Origin", "*")
+	h.Set("Access-Control-Allow-Headers", "Content-Type,Authorization,X-Amz-Date,X-Api-Key,X-Amz-Security-Token,If-Match,X-Require-Precondition")
+	h.Set("Access-Control-Allow-Methods", "GET,POST,PUT,DELETE,OPTIONS")
+	h.Set("Access-Control-Max-Age", "600")
+}
+
+// encodeBody returns body as a string, base64 encoding binary payloads
+func encodeBody(body []byte) (string, bool) {
+	if utf8.Valid(body) {
+		return string(body), false
+	}
+	return base64.StdEncoding.EncodeToString(body), true
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/xzero/ai-workflow/pkg/assistant"
+	"github.com/xzero/ai-workflow/pkg/middleware"
+	"github.com/xzero/ai-workflow/pkg/models"
+	"github.com/xzero/ai-workflow/pkg/policy"
+	"github.com/xzero/ai-workflow/pkg/response"
+	"github.com/xzero/ai-workflow/pkg/search"
+	"github.com/xzero/ai-workflow/pkg/secrets"
+	"github.com/xzero/ai-workflow/pkg/workflow"
+)
+
+// RunAssistant serves POST /api/assistant/run. It searches the workflows
+// matching a free-text instruction, lets the LLM fill the parameters of the
+// best match and returns the proposed execution, or runs it when execute is
+// set and the parameters are valid.
+func (a *API) RunAssistant(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	claims, ok := middleware.ClaimsFrom(ctx)
+	if !ok {
+		return response.Unauthorized("Missing credentials"), nil
+	}
+
+	if a.llm == nil {
+		return response.Error(http.StatusServiceUnavailable, "Assistant is not configured"), nil
+	}
+
+	// Parse request body
+	var req models.AssistantRequest
+	if err := json.Unmarshal([]byte(request.Body), &req); err != nil {
+		return response.BadRequest("Invalid request body"), nil
+	}
+	if err := assistant.Normalize(&req); err != nil {
+		return response.BadRequest("Invalid assistant request: " + err.Error()), nil
+	}
+	searchReq := models.SearchWorkflowRequest{
+		Query:     req.Instruction,
+		ProjectID: req.ProjectID,
+		TopK:      req.TopK,
+		Mode:      search.MethodHybrid,
+		Filters:   req.Filters,
+	}
+	if err := search.Normalize(&searchReq); err != nil {
+		return response.BadRequest("Invalid assistant request: " + err.Error()), nil
+	}
+
+	// Check if user has access to the project
+	if resp, ok := a.authorize(ctx, claims, policy.ActionProjectRead, policy.Project(req.ProjectID), "Access denied to this project"); !ok {
+		return resp, nil
+	}
+
+	// Pick the workflow: the one asked for, or the best search match
+	result := &models.AssistantResponse{Candidates: []models.SearchWorkflowResult{}}
+	workflowID := req.WorkflowID
+	if workflowID == "" {
+		found, err := search.Search(ctx, a.search, a.embedder, searchReq)
+		if err != nil {
+			middleware.Log(ctx).Error("Error searching workflows", "error", err)
+			return response.InternalError("Failed to search workflows"), nil
+		}
+		result.Candidates = found.Results
+		result.SearchMethod = found.SearchMethod
+		if len(found.Results) == 0 {
+			result.Message = "No matching workflow found"
+			return response.Success(result), nil
+		}
+		workflowID = found.Results[0].WorkflowID
+	}
+
+	wf, err := a.workflows.Get(workflowID)
+	if err != nil {
+		return lookupError(ctx, err, "workflow"), nil
+	}
+	// Same rule as searching from the project: its own or shared workflows
+	if resp, ok := a.authorize(ctx, claims, policy.ActionWorkflowRead, policy.WorkflowIn(req.ProjectID, wf), "Access denied to this workflow"); !ok {
+		return resp, nil
+	}
+
+	// Map the instruction onto the workflow's parameters
+	fill, err := assistant.FillParameters(ctx, a.llm, wf, req.Instruction)
+	if err != nil {
+		middleware.Log(ctx).Error("Error filling parameters", "error", err)
+		return response.InternalError("Failed to fill workflow parameters"), nil
+	}
+
+	parameters, err := json.Marshal(fill.Parameters)
+	if err != nil {
+		middleware.Log(ctx).Error("Error encoding parameters", "error", err)
+		return response.InternalError("Failed to fill workflow parameters"), nil
+	}
+	execReq := models.ExecuteWorkflowRequest{
+		Parameters: parameters,
+		ProjectID:  req.ProjectID,
+		Version:    wf.Version,
+	}
+	fieldErrors, err := workflow.ValidateParameters(wf, &execReq)
+	if err != nil {
+		middleware.Log(ctx).Error("Error validating parameters", "error", err)
+		return response.BadRequest("Invalid parameters or input schema"), nil
+	}
+
+	result.Proposal = &models.AssistantProposal{
+		WorkflowID:   wf.WorkflowID,
+		WorkflowName: wf.WorkflowName,
+		Version:      wf.Version,
+		Parameters:   fill.Parameters,
+		Explanation:  fill.Explanation,
+		Errors:       fieldErrors,
+		Ready:        len(fieldErrors) == 0,
+	}
+
+	if !req.Execute {
+		return response.Success(result), nil
+	}
+	if !result.Proposal.Ready {
+		result.Message = "Proposed parameters are invalid, workflow not executed"
+		return response.Success(result), nil
+	}
+
+	// Executing needs the same permissions as POST /api/workflows/{id}/execute
+	if resp, ok := a.authorize(ctx, claims, policy.ActionWorkflowExecute, policy.Workflow(wf), "Access denied to this workflow"); !ok {
+		return resp, nil
+	}
+	if wf.ProjectID != req.ProjectID {
+		if resp, ok := a.authorize(ctx, claims, policy.ActionRunCreate, policy.WorkflowIn(req.ProjectID, wf), "Access denied to this project"); !ok {
+			return resp, nil
+		}
+	}
+
+	// Decrypt bearer token for the upstream call
+	wf.BearerToken, err = secrets.Decrypt(ctx, a.keys, wf.BearerToken)
+	if err != nil {
+		middleware.Log(ctx).Error("Error decrypting bearer token", "error", err)
+		return response.InternalError("Failed to decrypt bearer token"), nil
+	}
+
+	result.Result, err = a.executeSync(ctx, wf, &execReq, claims.DID, req.ProjectID)
+	if err != nil {
+		middleware.Log(ctx).Error("Error executing workflow", "error", err)
+		return response.InternalError("Failed to execute workflow: " + err.Error()), nil
+	}
+	result.Executed = true
+
+	return response.Success(result), nil
+}
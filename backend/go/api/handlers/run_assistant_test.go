@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/xzero/ai-workflow/pkg/llm"
+	"github.com/xzero/ai-workflow/pkg/models"
+)
+
+const translateSchema = `{
+	"type": "object",
+	"properties": {
+		"text": {"type": "string", "minLength": 1},
+		"language": {"type": "string", "enum": ["fr", "de"]}
+	},
+	"required": ["text", "language"]
+}`
+
+func TestRunAssistantProposes(t *testing.T) {
+	model := llm.NewFake(`Sure: {"parameters": {"language": "fr", "tone": "formal"}, "explanation": "French was asked for."}`)
+	s := newTestServer(t)
+	s.api.SetLLM(model)
+	id := s.postWorkflow(models.CreateWorkflowRequest{
+		WorkflowName:       "Translate",
+		Description:        "Translates text",
+		Source:             "n8n",
+		TemplateName:       "workflow",
+		HTTPMethod:         "POST",
+		BaseURL:            "https://n8n.example/webhook",
+		BearerToken:        upstreamToken,
+		ExternalWorkflowID: "wf-42",
+		InputSchema:        json.RawMessage(translateSchema),
+		ProjectID:          testProject,
+	})
+
+	w := s.do(runnerDID, http.MethodPost, "/api/assistant/run", `{"instruction": "translate to french", "project_id": "`+testProject+`"}`, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	var got models.AssistantResponse
+	decodeData(t, w, &got)
+
+	if len(got.Candidates) == 0 || got.Candidates[0].WorkflowID != id {
+		t.Fatalf("candidates = %v, want the Translate workflow first", got.Candidates)
+	}
+	p := got.Proposal
+	if p == nil || p.WorkflowID != id {
+		t.Fatalf("proposal = %+v, want the Translate workflow", p)
+	}
+	// Unknown names are dropped and the missing text is reported
+	if len(p.Parameters) != 1 || p.Parameters["language"] != "fr" {
+		t.Errorf("parameters = %v, want only language=fr", p.Parameters)
+	}
+	if p.Ready || len(p.Errors) != 1 || p.Errors[0].Field != "text" {
+		t.Errorf("ready, errors = %v, %v, want text reported missing", p.Ready, p.Errors)
+	}
+	if p.Explanation != "French was asked for." {
+		t.Errorf("explanation = %q", p.Explanation)
+	}
+	if got.Executed {
+		t.Error("proposal was executed")
+	}
+
+	// The model sees the parameters but never the workflow's credentials
+	requests := model.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("model got %d requests, want 1", len(requests))
+	}
+	prompt := requests[0].Messages[len(requests[0].Messages)-1].Content
+	if !strings.Contains(prompt, `"language"`) || strings.Contains(prompt, upstreamToken) || strings.Contains(prompt, "n8n.example") {
+		t.Errorf("prompt = %s", prompt)
+	}
+}
+
+func TestRunAssistantExecutes(t *testing.T) {
+	var upstreamAuth string
+	var upstreamBody map[string]interface{}
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamAuth = r.Header.Get("Authorization")
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &upstreamBody)
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"output": "Bonjour"}`)
+	}))
+	defer upstream.Close()
+
+	model := llm.NewFake(`{"parameters": {"language": "fr", "text": "Hello"}}`)
+	s := newTestServer(t)
+	s.api.SetLLM(model)
+	id := s.postWorkflow(models.CreateWorkflowRequest{
+		WorkflowName:       "Translate",
+		Description:        "Translates text",
+		Source:             "n8n",
+		TemplateName:       "workflow",
+		HTTPMethod:         "POST",
+		BaseURL:            upstream.URL,
+		BearerToken:        upstreamToken,
+		ExternalWorkflowID: "wf-42",
+		InputSchema:        json.RawMessage(translateSchema),
+		ProjectID:          testProject,
+	})
+
+	body := `{"instruction": "say hello in french", "project_id": "` + testProject + `", "workflow_id": "` + id + `", "execute": true}`
+	w := s.do(runnerDID, http.MethodPost, "/api/assistant/run", body, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	var got models.AssistantResponse
+	decodeData(t, w, &got)
+	if !got.Executed || got.Result == nil || got.Result.RunID == "" {
+		t.Fatalf("executed, result = %v, %+v, want a recorded run", got.Executed, got.Result)
+	}
+	if upstreamAuth != "Bearer "+upstreamToken {
+		t.Errorf("upstream Authorization = %q, want the decrypted token", upstreamAuth)
+	}
+	if upstreamBody["text"] != "Hello" || upstreamBody["language"] != "fr" {
+		t.Errorf("upstream body = %v, want the proposed parameters", upstreamBody)
+	}
+	if strings.Contains(w.Body.String(), upstreamToken) {
+		t.Errorf("response carries the token: %s", w.Body)
+	}
+
+	runs, err := s.mem.ListRuns(models.ListRunsFilter{WorkflowID: id, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 1 || runs[0].CallerDID != runnerDID {
+		t.Errorf("runs = %+v, want one run by the runner", runs)
+	}
+}
+
+func TestRunAssistantRejects(t *testing.T) {
+	const body = `{"instruction": "translate", "project_id": "` + testProject + `", "execute": true}`
+
+	tests := []struct {
+		name  string
+		model llm.Provider
+		did   string
+		want  int
+	}{
+		{"not configured", nil, runnerDID, http.StatusServiceUnavailable},
+		{"outsider", llm.NewFake(), outsiderDID, http.StatusForbidden},
+		{"viewer executes", llm.NewFake(`{"parameters": {"language": "fr", "text": "Hello"}}`), viewerDID, http.StatusForbidden},
+		{"invalid proposal is not executed", llm.NewFake(`{"parameters": {"language": "es"}}`), runnerDID, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t)
+			s.api.SetLLM(tt.model)
+			s.postWorkflow(models.CreateWorkflowRequest{
+				WorkflowName:       "Translate",
+				Description:        "Translates text",
+				Source:             "n8n",
+				TemplateName:       "workflow",
+				HTTPMethod:         "POST",
+				BaseURL:            "https://n8n.example/webhook",
+				BearerToken:        upstreamToken,
+				ExternalWorkflowID: "wf-42",
+				InputSchema:        json.RawMessage(translateSchema),
+				ProjectID:          testProject,
+			})
+
+			w := s.do(tt.did, http.MethodPost, "/api/assistant/run", body, nil)
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+			var got models.AssistantResponse
+			decodeData(t, w, &got)
+			if got.Executed || got.Proposal == nil || got.Proposal.Ready {
+				t.Errorf("executed, proposal = %v, %+v, want an invalid proposal", got.Executed, got.Proposal)
+			}
+		})
+	}
+}
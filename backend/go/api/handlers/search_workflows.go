@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/xzero/ai-workflow/pkg/middleware"
+	"github.com/xzero/ai-workflow/pkg/models"
+	"github.com/xzero/ai-workflow/pkg/policy"
+	"github.com/xzero/ai-workflow/pkg/response"
+	"github.com/xzero/ai-workflow/pkg/search"
+)
+
+// SearchWorkflows serves POST /api/workflows/search
+func (a *API) SearchWorkflows(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	claims, ok := middleware.ClaimsFrom(ctx)
+	if !ok {
+		return response.Unauthorized("Missing credentials"), nil
+	}
+
+	// Parse request body
+	var req models.SearchWorkflowRequest
+	if err := json.Unmarshal([]byte(request.Body), &req); err != nil {
+		return response.BadRequest("Invalid request body"), nil
+	}
+	if err := search.Normalize(&req); err != nil {
+		return response.BadRequest("Invalid search request: " + err.Error()), nil
+	}
+
+	// Check if user has access to the project
+	if resp, ok := a.authorize(ctx, claims, policy.ActionProjectRead, policy.Project(req.ProjectID), "Access denied to this project"); !ok {
+		return resp, nil
+	}
+
+	// Search the project's own and shared workflows, hidden ones excluded
+	result, err := search.Search(ctx, a.search, a.embedder, req)
+	if err == search.ErrVectorUnavailable {
+		return response.BadRequest("Vector search is not configured, use mode 'auto', 'fulltext' or 'hybrid'"), nil
+	}
+	if err != nil {
+		middleware.Log(ctx).Error("Error searching workflows", "error", err)
+		return response.InternalError("Failed to search workflows"), nil
+	}
+
+	return response.Success(result), nil
+}
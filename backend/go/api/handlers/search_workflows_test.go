@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/xzero/ai-workflow/pkg/embedding"
+	"github.com/xzero/ai-workflow/pkg/models"
+)
+
+func TestSearchWorkflows(t *testing.T) {
+	tests := []struct {
+		name       string
+		provider   embedding.Provider
+		body       string
+		wantMethod string
+		wantTop    string // "summarize", "translate" or "" for no results
+	}{
+		{"full-text without provider", nil, `{"query": "translate french", "project_id": "` + testProject + `"}`, "fulltext", "translate"},
+		{"auto falls back to full text", nil, `{"query": "document", "project_id": "` + testProject + `"}`, "fulltext", "summarize"},
+		{"auto uses vectors", embedding.NewLocalProvider(embedding.Dimensions), `{"query": "translates text into french", "threshold": 0.5, "project_id": "` + testProject + `"}`, "vector", "translate"},
+		{"hybrid", embedding.NewLocalProvider(embedding.Dimensions), `{"query": "summarizes a document", "mode": "hybrid", "threshold": 0.5, "project_id": "` + testProject + `"}`, "hybrid", "summarize"},
+		{"hybrid without provider", nil, `{"query": "summarizes", "mode": "hybrid", "project_id": "` + testProject + `"}`, "fulltext", "summarize"},
+		{"source filter", nil, `{"query": "summarizes translates", "filters": {"sources": ["dify"]}, "project_id": "` + testProject + `"}`, "fulltext", "translate"},
+		{"tag filter", nil, `{"query": "summarizes translates", "filters": {"tags": ["Language"]}, "project_id": "` + testProject + `"}`, "fulltext", "translate"},
+		{"no match", nil, `{"query": "invoice", "project_id": "` + testProject + `"}`, "fulltext", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t)
+			if tt.provider != nil {
+				s.api.SetEmbedder(tt.provider)
+			}
+			summarize := s.createWorkflow()
+			translate := s.postWorkflow(models.CreateWorkflowRequest{
+				WorkflowName:       "Translate",
+				Description:        "Translates text into French",
+				Source:             "dify",
+				TemplateName:       "workflow",
+				HTTPMethod:         "POST",
+				BaseURL:            "https://dify.example/v1/workflows/run",
+				BearerToken:        upstreamToken,
+				ExternalWorkflowID: "app-7",
+				Tags:               []string{"language"},
+				ProjectID:          testProject,
+			})
+			names := map[string]string{summarize: "summarize", translate: "translate"}
+
+			w := s.do(viewerDID, http.MethodPost, "/api/workflows/search", tt.body, nil)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+			var got models.SearchWorkflowResponse
+			decodeData(t, w, &got)
+			if got.SearchMethod != tt.wantMethod {
+				t.Errorf("search_method = %q, want %q", got.SearchMethod, tt.wantMethod)
+			}
+			top := ""
+			if len(got.Results) > 0 {
+				top = names[got.Results[0].WorkflowID]
+			}
+			if top != tt.wantTop {
+				t.Errorf("top result = %q, want %q (%d results)", top, tt.wantTop, len(got.Results))
+			}
+		})
+	}
+}
+
+func TestSearchWorkflowsRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		did  string
+		body string
+		want int
+	}{
+		{"outsider", outsiderDID, `{"query": "summarize", "project_id": "` + testProject + `"}`, http.StatusForbidden},
+		{"vector without provider", viewerDID, `{"query": "summarize", "mode": "vector", "project_id": "` + testProject + `"}`, http.StatusBadRequest},
+		{"missing query", viewerDID, `{"project_id": "` + testProject + `"}`, http.StatusBadRequest},
+		{"unknown source filter", viewerDID, `{"query": "a", "filters": {"sources": ["zapier"]}, "project_id": "` + testProject + `"}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t)
+			s.createWorkflow()
+			if w := s.do(tt.did, http.MethodPost, "/api/workflows/search", tt.body, nil); w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
+
+func TestSearchSkipsHiddenWorkflows(t *testing.T) {
+	s := newTestServer(t)
+	summarize := s.createWorkflow()
+	if w := s.do(adminDID, http.MethodPut, "/api/projects/"+testProject+"/workflows/"+summarize+"/hide", `{"is_hidden": true}`, nil); w.Code != http.StatusOK {
+		t.Fatalf("hide status = %d: %s", w.Code, w.Body)
+	}
+
+	w := s.do(viewerDID, http.MethodPost, "/api/workflows/search", `{"query": "summarizes", "project_id": "`+testProject+`"}`, nil)
+	var got models.SearchWorkflowResponse
+	decodeData(t, w, &got)
+	if len(got.Results) != 0 {
+		t.Errorf("results = %v, want none", got.Results)
+	}
+}
+
+func TestSearchComparesEmbeddingsOfTheSameModel(t *testing.T) {
+	provider := embedding.NewLocalProvider(embedding.Dimensions)
+	s := newTestServer(t)
+	s.api.SetEmbedder(provider)
+	s.createWorkflow()
+	translate := s.postWorkflow(models.CreateWorkflowRequest{
+		WorkflowName:       "Translate",
+		Description:        "Translates text into French",
+		Source:             "dify",
+		TemplateName:       "workflow",
+		HTTPMethod:         "POST",
+		BaseURL:            "https://dify.example/v1/workflows/run",
+		BearerToken:        upstreamToken,
+		ExternalWorkflowID: "app-7",
+		ProjectID:          testProject,
+	})
+
+	// The Translate embedding was computed by another model; even a vector
+	// equal to the query's must not be compared with it
+	const query = "translates text into french"
+	vector, err := embedding.EmbedOne(context.Background(), provider, query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := s.mem.SetEmbedding(translate, vector, "other-model", 1); !ok || err != nil {
+		t.Fatalf("SetEmbedding = %v, %v", ok, err)
+	}
+
+	w := s.do(viewerDID, http.MethodPost, "/api/workflows/search", `{"query": "`+query+`", "mode": "vector", "threshold": 0.01, "project_id": "`+testProject+`"}`, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	var got models.SearchWorkflowResponse
+	decodeData(t, w, &got)
+	for _, r := range got.Results {
+		if r.WorkflowID == translate {
+			t.Errorf("results = %v, want Translate left out", got.Results)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/xzero/ai-workflow/pkg/audit"
+	"github.com/xzero/ai-workflow/pkg/middleware"
+	"github.com/xzero/ai-workflow/pkg/models"
+	"github.com/xzero/ai-workflow/pkg/policy"
+	"github.com/xzero/ai-workflow/pkg/response"
+)
+
+// SetGrant serves PUT /api/workflows/{id}/grants. It gives a user a role on
+// the workflow on top of their project role, or removes the grant when the
+// role is empty.
+func (a *API) SetGrant(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	claims, ok := middleware.ClaimsFrom(ctx)
+	if !ok {
+		return response.Unauthorized("Missing credentials"), nil
+	}
+
+	// Get workflow_id from path parameters
+	workflowID := request.PathParameters["id"]
+	if workflowID == "" {
+		return response.BadRequest("Missing workflow_id"), nil
+	}
+
+	// Parse request body
+	var req models.SetWorkflowGrantRequest
+	if err := json.Unmarshal([]byte(request.Body), &req); err != nil {
+		return response.BadRequest("Invalid request body"), nil
+	}
+	if req.UserDID == "" {
+		return response.BadRequest("Missing user_did"), nil
+	}
+	if _, isKey := models.APIKeyIDOf(req.UserDID); isKey {
+		return response.BadRequest("API keys are limited to workflows when they are created"), nil
+	}
+	if req.Role != "" && !models.IsGrantRole(req.Role) {
+		return response.BadRequest("Invalid role, must be 'viewer', 'runner' or 'editor'"), nil
+	}
+
+	wf, err := a.workflows.Get(workflowID)
+	if err != nil {
+		return lookupError(ctx, err, "workflow"), nil
+	}
+
+	if resp, ok := a.authorize(ctx, claims, policy.ActionWorkflowGrant, policy.Workflow(wf), "Only admin or creator can manage grants"); !ok {
+		return resp, nil
+	}
+
+	prev, err := a.workflows.GetWorkflowRole(req.UserDID, workflowID)
+	if err != nil {
+		middleware.Log(ctx).Error("Error getting grant", "error", err)
+		return response.InternalError("Failed to get grant"), nil
+	}
+	event := models.AuditEvent{
+		Action:     models.AuditWorkflowGrant,
+		ProjectID:  wf.ProjectID,
+		WorkflowID: workflowID,
+		Changes:    audit.Change("role", prev, req.Role),
+		Details:    map[string]interface{}{"user_did": req.UserDID},
+	}
+
+	if req.Role == "" {
+		removed, err := a.workflows.DeleteGrant(workflowID, req.UserDID)
+		if err != nil {
+			middleware.Log(ctx).Error("Error removing grant", "error", err)
+			return response.InternalError("Failed to remove grant"), nil
+		}
+		if removed {
+			a.audit.Emit(ctx, event)
+		}
+		return response.Success(map[string]interface{}{
+			"workflow_id": workflowID,
+			"user_did":    req.UserDID,
+			"removed":     removed,
+		}), nil
+	}
+
+	grant := &models.WorkflowGrant{
+		WorkflowID: workflowID,
+		UserDID:    req.UserDID,
+		Role:       req.Role,
+		GrantedBy:  claims.DID,
+	}
+	if err := a.workflows.SetGrant(grant); err != nil {
+		middleware.Log(ctx).Error("Error saving grant", "error", err)
+		return response.InternalError("Failed to save grant"), nil
+	}
+	a.audit.Emit(ctx, event)
+
+	return response.Success(grant), nil
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/xzero/ai-workflow/pkg/audit"
+	"github.com/xzero/ai-workflow/pkg/middleware"
+	"github.com/xzero/ai-workflow/pkg/models"
+	"github.com/xzero/ai-workflow/pkg/policy"
+	"github.com/xzero/ai-workflow/pkg/response"
+	"github.com/xzero/ai-workflow/pkg/store"
+)
+
+// ShareWorkflow serves PUT /api/workflows/{id}/share
+func (a *API) ShareWorkflow(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	claims, ok := middleware.ClaimsFrom(ctx)
+	if !ok {
+		return response.Unauthorized("Missing credentials"), nil
+	}
+
+	// Get workflow_id from path parameters
+	workflowID := request.PathParameters["id"]
+	if workflowID == "" {
+		return response.BadRequest("Missing workflow_id"), nil
+	}
+
+	// Parse request body
+	var req models.ShareWorkflowRequest
+	if err := json.Unmarshal([]byte(request.Body), &req); err != nil {
+		return response.BadRequest("Invalid request body"), nil
+	}
+
+	// Get workflow to check permissions
+	workflow, err := a.workflows.Get(workflowID)
+	if err != nil {
+		return lookupError(ctx, err, "workflow"), nil
+	}
+
+	// Check permissions - only project admin can share workflows
+	if resp, ok := a.authorize(ctx, claims, policy.ActionWorkflowShare, policy.Workflow(workflow), "Only project admin can share workflows"); !ok {
+		return resp, nil
+	}
+
+	rowVersion, resp, ok := checkPrecondition(request, workflow)
+	if !ok {
+		return resp, nil
+	}
+
+	// Update is_shared status
+	if err := a.workflows.SetShared(workflowID, req.IsShared, rowVersion); err != nil {
+		switch err {
+		case store.ErrPreconditionFailed:
+			return a.lostRace(ctx, workflowID), nil
+		case store.ErrNotFound:
+			return lookupError(ctx, err, "workflow"), nil
+		}
+		middleware.Log(ctx).Error("Error updating share status", "error", err)
+		return response.InternalError("Failed to update share status"), nil
+	}
+
+	a.audit.Emit(ctx, models.AuditEvent{
+		Action:     models.AuditWorkflowShare,
+		ProjectID:  workflow.ProjectID,
+		WorkflowID: workflowID,
+		Changes:    audit.Change("is_shared", workflow.IsShared, req.IsShared),
+	})
+
+	resp = response.Success(map[string]interface{}{
+		"workflow_id": workflowID,
+		"is_shared":   req.IsShared,
+	})
+	return a.withCurrentETag(ctx, resp, workflowID), nil
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/xzero/ai-workflow/pkg/middleware"
+	"github.com/xzero/ai-workflow/pkg/models"
+	"github.com/xzero/ai-workflow/pkg/policy"
+	"github.com/xzero/ai-workflow/pkg/response"
+	"github.com/xzero/ai-workflow/pkg/secrets"
+	"github.com/xzero/ai-workflow/pkg/workflow"
+)
+
+// StreamWorkflow serves POST /api/workflows/{id}/stream through a Lambda Function URL
+// in RESPONSE_STREAM mode, relaying upstream events as Server-Sent Events
+func (a *API) StreamWorkflow(ctx context.Context, request events.LambdaFunctionURLRequest) (*events.LambdaFunctionURLStreamingResponse, error) {
+	// The Function URL bypasses the middleware chain, which would set these
+	ctx = middleware.WithRequestID(ctx, request.RequestContext.RequestID)
+	ctx = middleware.WithClient(ctx, middleware.ClientInfo{
+		SourceIP:  request.RequestContext.HTTP.SourceIP,
+		UserAgent: request.RequestContext.HTTP.UserAgent,
+	})
+
+	// Function URLs deliver lower-case header names
+	claims, resp, ok := middleware.Verify(ctx, a.tokenVerifier(), request.Headers["authorization"])
+	if !ok {
+		return errorResponse(resp), nil
+	}
+
+	// Get workflow_id from the path (/api/workflows/{id}/stream) or query string
+	workflowID := workflowIDFromPath(request.RawPath)
+	if workflowID == "" {
+		workflowID = request.QueryStringParameters["workflow_id"]
+	}
+	if workflowID == "" {
+		return errorResponse(response.BadRequest("Missing workflow_id")), nil
+	}
+
+	// The Function URL bypasses the middleware chain, so API keys are checked here
+	if claims.APIKey != nil {
+		if resp, ok := middleware.AuthorizeKey(claims.APIKey, events.APIGatewayProxyRequest{
+			HTTPMethod:     "POST",
+			Resource:       "/api/workflows/{id}/stream",
+			PathParameters: map[string]string{"id": workflowID},
+		}); !ok {
+			return errorResponse(resp), nil
+		}
+	}
+
+	// Parse request body
+	body := request.Body
+	if request.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(body)
+		if err != nil {
+			return errorResponse(response.BadRequest("Invalid request body")), nil
+		}
+		body = string(decoded)
+	}
+	var req models.ExecuteWorkflowRequest
+	if body != "" {
+		if err := json.Unmarshal([]byte(body), &req); err != nil {
+			return errorResponse(response.BadRequest("Invalid request body")), nil
+		}
+	}
+
+	// Get workflow
+	wf, err := a.workflows.Get(workflowID)
+	if err != nil {
+		return errorResponse(lookupError(ctx, err, "workflow")), nil
+	}
+
+	// Runners of the workflow's project, grantees, or anyone if the workflow is shared
+	if resp, ok := a.authorize(ctx, claims, policy.ActionWorkflowExecute, policy.Workflow(wf), "Access denied to this workflow"); !ok {
+		return errorResponse(resp), nil
+	}
+
+	// Run a pinned version instead of the latest definition
+	if err := a.applyVersion(wf, req.Version); err != nil {
+		return errorResponse(lookupError(ctx, err, "workflow version")), nil
+	}
+
+	if wf.TemplateName != workflow.TemplateStreamflow {
+		return errorResponse(response.BadRequest("Workflow is not a streamflow workflow")), nil
+	}
+
+	// Decrypt bearer token for the upstream call
+	wf.BearerToken, err = secrets.Decrypt(ctx, a.keys, wf.BearerToken)
+	if err != nil {
+		middleware.Log(ctx).Error("Error decrypting bearer token", "error", err)
+		return errorResponse(response.InternalError("Failed to decrypt bearer token")), nil
+	}
+
+	// The run is recorded against the caller's project, which defaults to the workflow's project
+	projectID := wf.ProjectID
+	if req.ProjectID != "" && req.ProjectID != wf.ProjectID {
+		if resp, ok := a.authorize(ctx, claims, policy.ActionRunCreate, policy.WorkflowIn(req.ProjectID, wf), "Access denied to this project"); !ok {
+			return errorResponse(resp), nil
+		}
+		projectID = req.ProjectID
+	}
+
+	// Validate parameters against the workflow's input schema
+	fieldErrors, err := workflow.ValidateParameters(wf, &req)
+	if err != nil {
+		middleware.Log(ctx).Error("Error validating parameters", "error", err)
+		return errorResponse(response.BadRequest("Invalid parameters or input schema")), nil
+	}
+	if len(fieldErrors) > 0 {
+		return errorResponse(response.ValidationError("Invalid parameters", fieldErrors)), nil
+	}
+
+	// Relay events through a pipe so each one is flushed as soon as it is parsed
+	pr, pw := io.Pipe()
+	go func() {
+		// The run is recorded before the pipe closes because Lambda may
+		// freeze the environment as soon as the response has ended
+		defer pw.Close()
+
+		run := workflow.NewRun(wf, &req, claims.DID, projectID, models.RunModeStream, time.Now())
+		var output strings.Builder
+		var upstreamErr string
+		statusCode, err := workflow.Stream(ctx, wf, &req, func(event models.StreamEvent) error {
+			switch event.Type {
+			case workflow.EventMessageDelta:
+				if output.Len() <= workflow.MaxRunBodySize {
+					output.WriteString(event.Content)
+				}
+			case workflow.EventError:
+				upstreamErr = event.Error
+			}
+			return workflow.WriteSSE(pw, event)
+		})
+		if err != nil {
+			middleware.Log(ctx).Error("Error streaming workflow", "error", err)
+			workflow.WriteSSE(pw, models.StreamEvent{Type: workflow.EventError, Error: err.Error()})
+			workflow.WriteSSE(pw, models.StreamEvent{Type: workflow.EventDone})
+		} else if upstreamErr != "" {
+			err = errors.New(upstreamErr)
+		}
+
+		workflow.Finish(run, statusCode, output.String(), err)
+		runID, err := a.runs.CreateRun(run)
+		if err != nil {
+			middleware.Log(ctx).Error("Error recording workflow run", "error", err)
+		}
+		a.auditRun(ctx, wf, run, runID)
+	}()
+
+	return &events.LambdaFunctionURLStreamingResponse{
+		StatusCode: 200,
+		Headers: map[string]string{
+			"Content-Type":                "text/event-stream",
+			"Cache-Control":               "no-cache",
+			"Access-Control-Allow-Origin": "*",
+		},
+		Body: pr,
+	}, nil
+}
+
+// workflowIDFromPath extracts {id} from /api/workflows/{id}/stream
+func workflowIDFromPath(path string) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) == 4 && parts[0] == "api" && parts[1] == "workflows" && parts[3] == "stream" {
+		return parts[2]
+	}
+	return ""
+}
+
+// errorResponse converts a JSON error response into a streaming response
+func errorResponse(resp events.APIGatewayProxyResponse) *events.LambdaFunctionURLStreamingResponse {
+	return &events.LambdaFunctionURLStreamingResponse{
+		StatusCode: resp.StatusCode,
+		Headers:    resp.Headers,
+		Body:       strings.NewReader(resp.Body),
+	}
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/xzero/ai-workflow/pkg/audit"
+	"github.com/xzero/ai-workflow/pkg/middleware"
+	"github.com/xzero/ai-workflow/pkg/models"
+	"github.com/xzero/ai-workflow/pkg/policy"
+	"github.com/xzero/ai-workflow/pkg/response"
+	"github.com/xzero/ai-workflow/pkg/schema"
+	"github.com/xzero/ai-workflow/pkg/search"
+	"github.com/xzero/ai-workflow/pkg/secrets"
+	"github.com/xzero/ai-workflow/pkg/store"
+	"github.com/xzero/ai-workflow/pkg/workflow"
+)
+
+// UpdateWorkflow serves PUT /api/workflows/{id}
+func (a *API) UpdateWorkflow(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	claims, ok := middleware.ClaimsFrom(ctx)
+	if !ok {
+		return response.Unauthorized("Missing credentials"), nil
+	}
+
+	// Get workflow_id from path parameters
+	workflowID := request.PathParameters["id"]
+	if workflowID == "" {
+		return response.BadRequest("Missing workflow_id"), nil
+	}
+
+	// Parse request body
+	var req models.UpdateWorkflowRequest
+	if err := json.Unmarshal([]byte(request.Body), &req); err != nil {
+		return response.BadRequest("Invalid request body"), nil
+	}
+
+	// Get workflow to check permissions
+	wf, err := a.workflows.Get(workflowID)
+	if err != nil {
+		return lookupError(ctx, err, "workflow"), nil
+	}
+
+	// Editors can modify any workflow of the project, creators their own
+	if resp, ok := a.authorize(ctx, claims, policy.ActionWorkflowUpdate, policy.Workflow(wf), "Only editors or the creator can update this workflow"); !ok {
+		return resp, nil
+	}
+
+	// Writes based on an older version are rejected rather than overwriting it
+	rowVersion, resp, ok := checkPrecondition(request, wf)
+	if !ok {
+		return resp, nil
+	}
+
+	// Validate fields if provided
+	if req.Source != nil && !workflow.IsSupportedSource(*req.Source) {
+		return response.BadRequest("Invalid source, must be one of: " + strings.Join(workflow.Sources(), ", ")), nil
+	}
+	if req.TemplateName != nil && *req.TemplateName != "workflow" && *req.TemplateName != "streamflow" {
+		return response.BadRequest("Invalid template_name, must be 'workflow' or 'streamflow'"), nil
+	}
+	if req.HTTPMethod != nil && *req.HTTPMethod != "GET" && *req.HTTPMethod != "POST" && *req.HTTPMethod != "PUT" {
+		return response.BadRequest("Invalid http_method, must be 'GET', 'POST', or 'PUT'"), nil
+	}
+	if req.InputSchema != nil {
+		// An empty object removes the schema
+		if _, err := schema.Parse(*req.InputSchema); err != nil {
+			return response.BadRequest("Invalid input_schema: " + err.Error()), nil
+		}
+	}
+
+	if req.Tags != nil {
+		tags, err := search.NormalizeTags(*req.Tags)
+		if err != nil {
+			return response.BadRequest("Invalid tags: " + err.Error()), nil
+		}
+		req.Tags = &tags
+	}
+
+	// A masked token sent back by the client leaves the stored token unchanged
+	if req.BearerToken != nil {
+		if secrets.IsRedacted(*req.BearerToken) {
+			req.BearerToken = nil
+		} else {
+			encrypted, err := secrets.Encrypt(ctx, a.keys, *req.BearerToken)
+			if err != nil {
+				middleware.Log(ctx).Error("Error encrypting bearer token", "error", err)
+				return response.InternalError("Failed to encrypt bearer token"), nil
+			}
+			req.BearerToken = &encrypted
+		}
+	}
+
+	// Masked header values sent back by the client keep their stored values
+	if req.Headers != nil {
+		headers, err := secrets.RestoreMaskedHeaders(*req.Headers, wf.Headers)
+		if err != nil {
+			return response.BadRequest("Invalid headers, must be an object of strings"), nil
+		}
+		req.Headers = &headers
+	}
+
+	// Update workflow
+	version, err := a.workflows.Update(workflowID, &req, claims.DID, rowVersion)
+	if err == store.ErrPreconditionFailed {
+		return a.lostRace(ctx, workflowID), nil
+	}
+	if err != nil {
+		middleware.Log(ctx).Error("Error updating workflow", "error", err)
+		return response.InternalError("Failed to update workflow"), nil
+	}
+
+	result := map[string]interface{}{
+		"workflow_id": workflowID,
+		"message":     "Workflow updated successfully",
+	}
+	changes := updateChanges(wf, &req, version)
+	if len(changes) > 0 {
+		details := map[string]interface{}{}
+		changedFields := []string{}
+		for _, c := range changes {
+			changedFields = append(changedFields, c.Field)
+		}
+		result["changed_fields"] = changedFields
+		if version != nil {
+			a.refreshEmbedding(ctx, workflowID)
+			details["version"] = version.Version
+			result["version"] = version.Version
+		}
+		a.audit.Emit(ctx, models.AuditEvent{
+			Action:     models.AuditWorkflowUpdate,
+			ProjectID:  wf.ProjectID,
+			WorkflowID: workflowID,
+			Changes:    changes,
+			Details:    details,
+		})
+	}
+	return a.withCurrentETag(ctx, response.Success(result), workflowID), nil
+}
+
+// updateChanges returns the field changes req made to wf: those of the new
+// version, nil when the definition did not change, and of the tags, which
+// are not versioned
+func updateChanges(wf *models.Workflow, req *models.UpdateWorkflowRequest, version *models.WorkflowVersion) []models.VersionChange {
+	changes := []models.VersionChange{}
+	if version != nil {
+		changes = audit.Changes(models.DefinitionOf(wf), version)
+	}
+	if req.Tags != nil {
+		changes = append(changes, audit.TagsChange(wf.Tags, *req.Tags)...)
+	}
+	return changes
+}
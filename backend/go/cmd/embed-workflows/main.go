@@ -0,0 +1,85 @@
+// Command embed-workflows computes missing and stale workflow embeddings.
+// On Lambda it runs on a schedule; locally it runs once:
+//
+//	go run ./cmd/embed-workflows [-all] [-batch 50]
+//
+// -all recomputes every embedding, e.g. after changing EMBEDDING_MODEL.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/xzero/ai-workflow/pkg/db"
+	"github.com/xzero/ai-workflow/pkg/embedding"
+	"github.com/xzero/ai-workflow/pkg/search"
+	"github.com/xzero/ai-workflow/pkg/store"
+)
+
+var database *sql.DB
+var provider embedding.Provider
+
+func init() {
+	var err error
+	database, err = db.ConnectFromEnv()
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+
+	provider, err = embedding.ProviderFromEnv()
+	if err != nil {
+		log.Fatal("Failed to load embedding provider:", err)
+	}
+}
+
+// batchSize reads EMBEDDING_BATCH_SIZE
+func batchSize() int {
+	if v, err := strconv.Atoi(os.Getenv("EMBEDDING_BATCH_SIZE")); err == nil && v > 0 {
+		return v
+	}
+	return search.DefaultBatchSize
+}
+
+// handler is invoked on a schedule and embeds every stale workflow
+func handler(ctx context.Context) error {
+	if provider == nil {
+		log.Printf("No embedding provider configured, skipping")
+		return nil
+	}
+	n, err := search.RefreshEmbeddings(ctx, store.NewPostgres(database), provider, batchSize())
+	log.Printf("Stored %d embeddings with %s", n, provider.Model())
+	return err
+}
+
+func main() {
+	if os.Getenv("AWS_LAMBDA_RUNTIME_API") != "" {
+		lambda.Start(handler)
+		return
+	}
+
+	all := flag.Bool("all", false, "recompute every embedding")
+	batch := flag.Int("batch", batchSize(), "workflows per embedding request")
+	flag.Parse()
+
+	if provider == nil {
+		log.Fatal("EMBEDDING_PROVIDER or OPENAI_API_KEY must be set")
+	}
+	if *all {
+		n, err := db.ResetEmbeddings(database)
+		if err != nil {
+			log.Fatal("Failed to reset embeddings:", err)
+		}
+		log.Printf("Marked %d embeddings stale", n)
+	}
+
+	n, err := search.RefreshEmbeddings(context.Background(), store.NewPostgres(database), provider, *batch)
+	log.Printf("Stored %d embeddings with %s", n, provider.Model())
+	if err != nil {
+		log.Fatal(err)
+	}
+}
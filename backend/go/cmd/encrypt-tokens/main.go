@@ -0,0 +1,70 @@
+// Command encrypt-tokens encrypts bearer tokens stored before encryption at
+// rest was enabled, in workflows and in their recorded versions. It is safe
+// to run repeatedly.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	"github.com/xzero/ai-workflow/pkg/db"
+	"github.com/xzero/ai-workflow/pkg/secrets"
+)
+
+func main() {
+	database, err := db.ConnectFromEnv()
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	defer database.Close()
+
+	keys, err := secrets.ProviderFromEnv()
+	if err != nil {
+		log.Fatal("Failed to load secrets key:", err)
+	}
+	if keys == nil {
+		log.Fatal("SECRETS_KEY or SECRETS_KEY_FILE must be set")
+	}
+
+	ctx := context.Background()
+	workflows := encryptTokens(ctx, database, keys, "workflows", "workflow_id")
+	versions := encryptTokens(ctx, database, keys, "workflow_versions", "workflow_id || ':' || version")
+
+	log.Printf("Encrypted %d bearer tokens of workflows and %d of workflow versions", workflows, versions)
+}
+
+// encryptTokens encrypts the plaintext bearer tokens of table, whose rows
+// are identified by the key expression, and returns how many it encrypted
+func encryptTokens(ctx context.Context, database *sql.DB, keys secrets.KeyProvider, table, key string) int {
+	rows, err := database.Query(`SELECT ` + key + `, bearer_token FROM ` + table + ` WHERE bearer_token NOT LIKE 'enc:v1:%'`)
+	if err != nil {
+		log.Fatalf("Failed to list %s: %v", table, err)
+	}
+
+	tokens := map[string]string{}
+	for rows.Next() {
+		var id, bearerToken string
+		if err := rows.Scan(&id, &bearerToken); err != nil {
+			log.Fatalf("Failed to read %s: %v", table, err)
+		}
+		tokens[id] = bearerToken
+	}
+	rows.Close()
+
+	for id, bearerToken := range tokens {
+		encrypted, err := secrets.Encrypt(ctx, keys, bearerToken)
+		if err != nil {
+			log.Fatalf("Failed to encrypt token of %s %s: %v", table, id, err)
+		}
+		// Only replace the token if nobody changed it in the meantime
+		_, err = database.Exec(
+			`UPDATE `+table+` SET bearer_token = $1 WHERE `+key+` = $2 AND bearer_token = $3`,
+			encrypted, id, bearerToken,
+		)
+		if err != nil {
+			log.Fatalf("Failed to update %s %s: %v", table, id, err)
+		}
+	}
+	return len(tokens)
+}
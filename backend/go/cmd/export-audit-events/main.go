@@ -0,0 +1,22 @@
+package main
+
+import (
+	"log"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/xzero/ai-workflow/api/handlers"
+)
+
+var api *handlers.API
+
+func init() {
+	var err error
+	api, err = handlers.NewFromEnv()
+	if err != nil {
+		log.Fatal("Failed to initialize API:", err)
+	}
+}
+
+func main() {
+	lambda.Start(api.Wrap(api.ExportAuditEvents))
+}
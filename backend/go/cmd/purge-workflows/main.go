@@ -0,0 +1,101 @@
+// Command purge-workflows deletes for good the workflows that stayed in the
+// trash longer than TRASH_RETENTION_DAYS (default 30). On Lambda it runs on
+// a schedule; locally it runs once:
+//
+//	go run ./cmd/purge-workflows [-days 30]
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/xzero/ai-workflow/pkg/audit"
+	"github.com/xzero/ai-workflow/pkg/db"
+	"github.com/xzero/ai-workflow/pkg/models"
+	"github.com/xzero/ai-workflow/pkg/store"
+)
+
+const (
+	defaultRetentionDays = 30
+	purgeBatchSize       = 100
+)
+
+var database *sql.DB
+
+func init() {
+	var err error
+	database, err = db.ConnectFromEnv()
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+}
+
+// retentionDays reads TRASH_RETENTION_DAYS
+func retentionDays() int {
+	if v, err := strconv.Atoi(os.Getenv("TRASH_RETENTION_DAYS")); err == nil && v > 0 {
+		return v
+	}
+	return defaultRetentionDays
+}
+
+// purge deletes the workflows moved to the trash more than days ago, in
+// batches, and records each in the audit log
+func purge(ctx context.Context, days int) (int, error) {
+	cutoff := time.Now().Add(-time.Duration(days) * 24 * time.Hour)
+	emitter := audit.NewEmitter(store.NewPostgres(database))
+
+	total := 0
+	for {
+		purged, err := db.PurgeDeletedWorkflows(database, cutoff, purgeBatchSize)
+		if err != nil {
+			return total, err
+		}
+		for _, w := range purged {
+			emitter.Emit(ctx, models.AuditEvent{
+				Action:     models.AuditWorkflowPurge,
+				ActorDID:   models.AuditSystemActor,
+				ProjectID:  w.ProjectID,
+				WorkflowID: w.WorkflowID,
+				Details: map[string]interface{}{
+					"workflow_name":  w.WorkflowName,
+					"deleted_at":     w.DeletedAt,
+					"deleted_by":     w.DeletedBy,
+					"retention_days": days,
+				},
+			})
+		}
+		total += len(purged)
+		if len(purged) < purgeBatchSize {
+			return total, nil
+		}
+	}
+}
+
+// handler is invoked on a schedule and purges the expired trash
+func handler(ctx context.Context) error {
+	n, err := purge(ctx, retentionDays())
+	log.Printf("Purged %d workflows", n)
+	return err
+}
+
+func main() {
+	if os.Getenv("AWS_LAMBDA_RUNTIME_API") != "" {
+		lambda.Start(handler)
+		return
+	}
+
+	days := flag.Int("days", retentionDays(), "days deleted workflows stay in the trash")
+	flag.Parse()
+
+	n, err := purge(context.Background(), *days)
+	log.Printf("Purged %d workflows", n)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
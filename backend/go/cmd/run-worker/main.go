@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"os"
+	"time"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/xzero/ai-workflow/pkg/db"
+	"github.com/xzero/ai-workflow/pkg/secrets"
+	"github.com/xzero/ai-workflow/pkg/worker"
+)
+
+var database *sql.DB
+var keys secrets.KeyProvider
+
+func init() {
+	var err error
+	database, err = db.ConnectFromEnv()
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+
+	keys, err = secrets.ProviderFromEnv()
+	if err != nil {
+		log.Fatal("Failed to load secrets key:", err)
+	}
+}
+
+// handler is invoked on a schedule and drains the run queue until it stays
+// empty for WORKER_IDLE_EXIT or the invocation deadline gets close
+func handler(ctx context.Context) error {
+	opts := worker.OptionsFromEnv(keys)
+	if opts.IdleExit == 0 {
+		opts.IdleExit = 50 * time.Second
+	}
+	return worker.Run(ctx, database, opts)
+}
+
+func main() {
+	// Outside Lambda the worker runs as a long-lived process
+	if os.Getenv("AWS_LAMBDA_RUNTIME_API") == "" {
+		log.Printf("Run worker started")
+		if err := worker.Run(context.Background(), database, worker.OptionsFromEnv(keys)); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	lambda.Start(handler)
+}
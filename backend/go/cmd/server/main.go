@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/xzero/ai-workflow/api/handlers"
+	"github.com/xzero/ai-workflow/pkg/worker"
+)
+
+// shutdownTimeout bounds how long in-flight requests may take on shutdown
+const shutdownTimeout = 30 * time.Second
+
+// main serves every API route from a single process, for local development
+// and self-hosting. HOST and PORT select the listen address (default :8080);
+// RUN_WORKER=true also runs the async run worker in the same process.
+func main() {
+	api, err := handlers.NewFromEnv()
+	if err != nil {
+		log.Fatal("Failed to initialize API:", err)
+	}
+	defer api.DB().Close()
+
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+	server := &http.Server{
+		Addr:              net.JoinHostPort(os.Getenv("HOST"), port),
+		Handler:           handlers.NewRouter(api.Routes()),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var wg sync.WaitGroup
+	if runWorker, _ := strconv.ParseBool(os.Getenv("RUN_WORKER")); runWorker {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			log.Printf("Run worker started")
+			if err := worker.Run(ctx, api.DB(), worker.OptionsFromEnv(api.Keys())); err != nil {
+				log.Printf("Run worker stopped: %v", err)
+			}
+		}()
+	}
+
+	go func() {
+		log.Printf("Listening on %s", server.Addr)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal("Server failed:", err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Printf("Shutting down")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Error shutting down server: %v", err)
+	}
+	wg.Wait()
+}
@@ -6,4 +6,5 @@ require (
 	github.com/aws/aws-lambda-go v1.41.0
 	github.com/golang-jwt/jwt/v5 v5.2.0
 	github.com/lib/pq v1.10.9
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -0,0 +1,191 @@
+// Package assistant maps a free-text instruction onto the parameters of a
+// workflow with a language model.
+package assistant
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/xzero/ai-workflow/pkg/llm"
+	"github.com/xzero/ai-workflow/pkg/models"
+	"github.com/xzero/ai-workflow/pkg/schema"
+)
+
+// Request limits
+const (
+	DefaultCandidates = 3
+	MaxCandidates     = 10
+)
+
+const systemPrompt = `You fill in the input parameters of a workflow from a user's instruction.
+You receive a JSON document with the instruction, the workflow and its parameters.
+Reply with one JSON object: {"parameters": {...}, "explanation": "..."}.
+- Only use the listed parameter names.
+- Leave out parameters the instruction does not determine; never invent values.
+- Match each parameter's type and allowed values.
+- Keep the explanation to one sentence, in the language of the instruction.`
+
+// Field is a workflow parameter the model may fill
+type Field struct {
+	Name        string        `json:"name"`
+	Type        string        `json:"type,omitempty"`
+	Title       string        `json:"title,omitempty"`
+	Description string        `json:"description,omitempty"`
+	Enum        []interface{} `json:"enum,omitempty"`
+	Required    bool          `json:"required,omitempty"`
+}
+
+// Fill is the model's answer for one workflow
+type Fill struct {
+	Parameters  map[string]interface{}
+	Explanation string
+}
+
+// Normalize validates req and fills in the default top_k. The instruction
+// and filters are checked by search.Normalize.
+func Normalize(req *models.AssistantRequest) error {
+	req.Instruction = strings.TrimSpace(req.Instruction)
+	if req.Instruction == "" {
+		return errors.New("instruction is required")
+	}
+	if req.ProjectID == "" {
+		return errors.New("project_id is required")
+	}
+	if req.TopK == 0 {
+		req.TopK = DefaultCandidates
+	}
+	if req.TopK < 1 || req.TopK > MaxCandidates {
+		return fmt.Errorf("top_k must be between 1 and %d", MaxCandidates)
+	}
+	return nil
+}
+
+// Fields lists the parameters of wf: the properties of its input schema and
+// the keys of its default parameters. Default values are left out since
+// they may hold secrets.
+func Fields(wf *models.Workflow) []Field {
+	fields := map[string]*Field{}
+
+	if inputSchema, err := schema.Parse(wf.InputSchema); err == nil && inputSchema != nil {
+		for name, property := range inputSchema.Properties {
+			fields[name] = &Field{
+				Name:        name,
+				Type:        property.Type,
+				Title:       property.Title,
+				Description: property.Description,
+				Enum:        property.Enum,
+			}
+		}
+		for _, name := range inputSchema.Required {
+			if field, ok := fields[name]; ok {
+				field.Required = true
+			} else {
+				fields[name] = &Field{Name: name, Required: true}
+			}
+		}
+	}
+
+	var defaults map[string]interface{}
+	if json.Unmarshal(wf.Parameters, &defaults) == nil {
+		for name, value := range defaults {
+			if _, ok := fields[name]; !ok {
+				fields[name] = &Field{Name: name, Type: typeOf(value)}
+			}
+		}
+	}
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make([]Field, len(names))
+	for i, name := range names {
+		result[i] = *fields[name]
+	}
+	return result
+}
+
+// FillParameters asks provider for the parameters of wf that instruction
+// determines. Names that are not parameters of wf are dropped.
+func FillParameters(ctx context.Context, provider llm.Provider, wf *models.Workflow, instruction string) (*Fill, error) {
+	fields := Fields(wf)
+	document, err := json.Marshal(map[string]interface{}{
+		"instruction": instruction,
+		"workflow": map[string]string{
+			"name":        wf.WorkflowName,
+			"description": wf.Description,
+		},
+		"parameters": fields,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := provider.Complete(ctx, llm.Request{
+		Messages: []llm.Message{
+			{Role: llm.RoleSystem, Content: systemPrompt},
+			{Role: llm.RoleUser, Content: string(document)},
+		},
+		JSON: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var answer struct {
+		Parameters  map[string]interface{} `json:"parameters"`
+		Explanation string                 `json:"explanation"`
+	}
+	if err := json.Unmarshal([]byte(extractJSON(reply)), &answer); err != nil {
+		return nil, fmt.Errorf("model reply is not a JSON object: %w", err)
+	}
+
+	known := map[string]bool{}
+	for _, field := range fields {
+		known[field.Name] = true
+	}
+	fill := &Fill{Parameters: map[string]interface{}{}, Explanation: strings.TrimSpace(answer.Explanation)}
+	for name, value := range answer.Parameters {
+		if known[name] && value != nil {
+			fill.Parameters[name] = value
+		}
+	}
+	return fill, nil
+}
+
+// extractJSON returns the outermost JSON object of reply, dropping Markdown
+// code fences and text around it
+func extractJSON(reply string) string {
+	start := strings.Index(reply, "{")
+	end := strings.LastIndex(reply, "}")
+	if start < 0 || end < start {
+		return reply
+	}
+	return reply[start : end+1]
+}
+
+// typeOf returns the JSON Schema type of a default parameter value
+func typeOf(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return ""
+}
@@ -0,0 +1,115 @@
+// Package audit records who changed or executed workflows. Handlers emit
+// events through one Emitter, which fills in the actor and request details
+// from the context and redacts secrets from field changes.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/xzero/ai-workflow/pkg/middleware"
+	"github.com/xzero/ai-workflow/pkg/models"
+	"github.com/xzero/ai-workflow/pkg/secrets"
+)
+
+// Sink appends audit events; store.AuditStore implements it
+type Sink interface {
+	AppendAuditEvent(event *models.AuditEvent) error
+}
+
+// Emitter stores the audit events of requests
+type Emitter struct {
+	sink Sink
+}
+
+// NewEmitter returns an emitter appending to sink
+func NewEmitter(sink Sink) *Emitter {
+	return &Emitter{sink: sink}
+}
+
+// Emit records event with the caller, request ID, source IP and user agent
+// of ctx. Failing to store it is logged rather than returned: the change it
+// describes has already been made.
+func (e *Emitter) Emit(ctx context.Context, event models.AuditEvent) {
+	if claims, ok := middleware.ClaimsFrom(ctx); ok && event.ActorDID == "" {
+		event.ActorDID = claims.DID
+	}
+	client := middleware.ClientFrom(ctx)
+	event.SourceIP = client.SourceIP
+	event.UserAgent = client.UserAgent
+	event.RequestID = middleware.RequestIDFrom(ctx)
+	event.Changes = Redact(event.Changes)
+
+	if err := e.sink.AppendAuditEvent(&event); err != nil {
+		middleware.Log(ctx).Error("Error recording audit event", "error", err, "action", event.Action)
+	}
+}
+
+// Changes returns the field changes from before to after. A nil before
+// records every field of after, as for a created workflow.
+func Changes(before, after *models.WorkflowVersion) []models.VersionChange {
+	if before == nil {
+		before = &models.WorkflowVersion{}
+	}
+	if after == nil {
+		after = &models.WorkflowVersion{}
+	}
+	return before.Diff(after)
+}
+
+// Change returns a change of one field with comparable values, or none when
+// the value is the same
+func Change(field string, from, to interface{}) []models.VersionChange {
+	if from == to {
+		return nil
+	}
+	return []models.VersionChange{{Field: field, From: from, To: to}}
+}
+
+// TagsChange returns the change of a workflow's tags, or none when the tags
+// are the same. Tags are not versioned, so Changes does not report them.
+func TagsChange(from, to []string) []models.VersionChange {
+	if len(from) == len(to) {
+		same := true
+		for i := range from {
+			if from[i] != to[i] {
+				same = false
+				break
+			}
+		}
+		if same {
+			return nil
+		}
+	}
+	return []models.VersionChange{{Field: "tags", From: from, To: to}}
+}
+
+// Redact masks the bearer token and credential headers in changes. Both
+// sides of a changed token become secrets.Redacted, or "" when unset.
+func Redact(changes []models.VersionChange) []models.VersionChange {
+	for i, c := range changes {
+		switch c.Field {
+		case "bearer_token":
+			changes[i].From = redactValue(c.From)
+			changes[i].To = redactValue(c.To)
+		case "headers":
+			changes[i].From = redactHeaders(c.From)
+			changes[i].To = redactHeaders(c.To)
+		}
+	}
+	return changes
+}
+
+func redactValue(v interface{}) interface{} {
+	if s, ok := v.(string); ok && s == "" {
+		return ""
+	}
+	return secrets.Redacted
+}
+
+func redactHeaders(v interface{}) interface{} {
+	if raw, ok := v.(json.RawMessage); ok {
+		return secrets.MaskHeadersJSON(raw)
+	}
+	return v
+}
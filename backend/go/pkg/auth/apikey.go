@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/xzero/ai-workflow/pkg/models"
+)
+
+// APIKeyPrefix starts every API key, so keys are told apart from JWTs and
+// found by secret scanners
+const APIKeyPrefix = "wfk_"
+
+// apiKeyIDLength is the number of random bytes in the prefix of a key
+const apiKeyIDLength = 6
+
+var (
+	errRevokedKey = errors.New("API key was revoked")
+	errExpiredKey = errors.New("API key expired")
+	// errReservedDID rejects tokens claiming the principal of an API key
+	errReservedDID = errors.New("token subject is reserved for API keys")
+)
+
+// APIKeyLookup finds API keys by the prefix of their token
+type APIKeyLookup interface {
+	// GetAPIKeyByPrefix returns the key with prefix, including revoked and expired keys
+	GetAPIKeyByPrefix(prefix string) (*models.APIKey, error)
+	// TouchAPIKey records that a key was used
+	TouchAPIKey(keyID string) error
+}
+
+// GenerateAPIKey returns a new key with its prefix and hash. The key is
+// wfk_<12 hex characters>_<43 base64url characters>, the first two parts
+// being the prefix.
+func GenerateAPIKey() (key, prefix, hash string, err error) {
+	id := make([]byte, apiKeyIDLength)
+	secret := make([]byte, 32)
+	if _, err := rand.Read(id); err != nil {
+		return "", "", "", err
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", "", err
+	}
+	prefix = APIKeyPrefix + hex.EncodeToString(id)
+	key = prefix + "_" + base64.RawURLEncoding.EncodeToString(secret)
+	return key, prefix, HashAPIKey(key), nil
+}
+
+// HashAPIKey returns the hex SHA-256 hash a key is stored by. Keys carry 256
+// random bits, so a fast hash is enough.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsAPIKey reports whether a bearer token is an API key rather than a JWT
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, APIKeyPrefix)
+}
+
+// apiKeyPrefix returns the prefix part of key. The secret part may contain
+// underscores, so the prefix is cut at its fixed length.
+func apiKeyPrefix(key string) (string, bool) {
+	n := len(APIKeyPrefix) + apiKeyIDLength*2
+	if len(key) <= n || key[n] != '_' {
+		return "", false
+	}
+	return key[:n], true
+}
+
+// APIKeyVerifier accepts API keys and passes JWTs to another verifier
+type APIKeyVerifier struct {
+	next Verifier
+	keys APIKeyLookup
+}
+
+// WithAPIKeys returns a verifier accepting the API keys of keys as well as
+// the tokens accepted by next. The claims of a key carry it in APIKey and
+// its principal, see models.APIKey.Principal, in DID.
+func WithAPIKeys(next Verifier, keys APIKeyLookup) *APIKeyVerifier {
+	return &APIKeyVerifier{next: next, keys: keys}
+}
+
+// Verify checks an API key or, for other tokens, calls the next verifier.
+// Only keys may act as key principals, so other tokens whose DID looks like
+// one are rejected.
+func (v *APIKeyVerifier) Verify(token string) (*Claims, error) {
+	if !IsAPIKey(token) {
+		claims, err := v.next.Verify(token)
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(claims.DID, models.APIKeyPrincipalPrefix) {
+			return nil, errReservedDID
+		}
+		return claims, nil
+	}
+
+	prefix, ok := apiKeyPrefix(token)
+	if !ok {
+		return nil, errUnknownKey
+	}
+	key, err := v.keys.GetAPIKeyByPrefix(prefix)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errUnknownKey, err)
+	}
+	if subtle.ConstantTimeCompare([]byte(HashAPIKey(token)), []byte(key.KeyHash)) != 1 {
+		return nil, errUnknownKey
+	}
+	if key.RevokedAt != nil {
+		return nil, errRevokedKey
+	}
+	if key.IsExpired(time.Now()) {
+		return nil, errExpiredKey
+	}
+
+	if err := v.keys.TouchAPIKey(key.KeyID); err != nil {
+		log.Printf("Error recording API key use: %v", err)
+	}
+	return &Claims{DID: key.Principal(), Username: key.Name, APIKey: key}, nil
+}
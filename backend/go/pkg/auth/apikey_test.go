@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/xzero/ai-workflow/pkg/models"
+)
+
+// keyTable is an APIKeyLookup over a map of keys by prefix
+type keyTable struct {
+	keys    map[string]*models.APIKey
+	touched []string
+}
+
+func (k *keyTable) GetAPIKeyByPrefix(prefix string) (*models.APIKey, error) {
+	key, ok := k.keys[prefix]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return key, nil
+}
+
+func (k *keyTable) TouchAPIKey(keyID string) error {
+	k.touched = append(k.touched, keyID)
+	return nil
+}
+
+func TestGenerateAPIKey(t *testing.T) {
+	key, prefix, hash, err := GenerateAPIKey()
+	if err != nil {
+		t.Fatalf("GenerateAPIKey: %v", err)
+	}
+	if !IsAPIKey(key) || !strings.HasPrefix(key, prefix+"_") {
+		t.Errorf("key %q does not start with %q", key, prefix+"_")
+	}
+	if got, ok := apiKeyPrefix(key); !ok || got != prefix {
+		t.Errorf("apiKeyPrefix = %q, %v, want %q", got, ok, prefix)
+	}
+	if hash != HashAPIKey(key) {
+		t.Error("hash does not match HashAPIKey")
+	}
+}
+
+func TestAPIKeyVerifier(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	keys := []*models.APIKey{
+		{KeyID: "key-1", ProjectID: "project-1", Name: "ci", Scopes: []string{models.APIKeyScopeExecute}},
+		{KeyID: "key-2", ProjectID: "project-1", Name: "ci", Scopes: []string{models.APIKeyScopeExecute}, RevokedAt: &past},
+		{KeyID: "key-3", ProjectID: "project-1", Name: "ci", Scopes: []string{models.APIKeyScopeExecute}, ExpiresAt: &past},
+	}
+	table := &keyTable{keys: map[string]*models.APIKey{}}
+	secrets := map[string]string{}
+	for _, key := range keys {
+		secret, prefix, hash, err := GenerateAPIKey()
+		if err != nil {
+			t.Fatalf("GenerateAPIKey: %v", err)
+		}
+		key.Prefix, key.KeyHash = prefix, hash
+		table.keys[prefix] = key
+		secrets[key.KeyID] = secret
+	}
+	v := WithAPIKeys(NewHMACVerifier(testSecret), table)
+	valid := &Claims{
+		DID:              "did:example:alice",
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+	}
+	keyPrincipal := &Claims{
+		DID:              models.APIKeyPrincipalPrefix + "key-1",
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		want  string // reason code, empty when the token is accepted
+	}{
+		{"valid key", secrets["key-1"], ""},
+		{"wrong secret", keys[0].Prefix + "_" + strings.Repeat("A", 43), ReasonUnknownKey},
+		{"unknown prefix", APIKeyPrefix + "000000000000_secret", ReasonUnknownKey},
+		{"no secret", keys[0].Prefix, ReasonUnknownKey},
+		{"revoked", secrets["key-2"], ReasonRevoked},
+		{"expired", secrets["key-3"], ReasonExpired},
+		{"JWT", sign(t, jwt.SigningMethodHS256, "", []byte(testSecret), valid), ""},
+		{"JWT with an API key principal", sign(t, jwt.SigningMethodHS256, "", []byte(testSecret), keyPrincipal), ReasonInvalidClaims},
+		{"bad JWT", sign(t, jwt.SigningMethodHS256, "", []byte("other-secret"), valid), ReasonBadSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := v.Verify(tt.token)
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("Verify: %v", err)
+				}
+				if claims.APIKey != nil && claims.DID != claims.APIKey.Principal() {
+					t.Errorf("DID = %q, want %q", claims.DID, claims.APIKey.Principal())
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("Verify accepted the token")
+			}
+			if got := Reason(err); got != tt.want {
+				t.Errorf("Reason = %q, want %q (%v)", got, tt.want, err)
+			}
+		})
+	}
+
+	if len(table.touched) != 1 || table.touched[0] != "key-1" {
+		t.Errorf("touched keys = %v, want [key-1]", table.touched)
+	}
+}
@@ -0,0 +1,224 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultJWKSCacheTTL is how long fetched keys are used before refetching
+	DefaultJWKSCacheTTL = time.Hour
+	// minJWKSRefresh limits refetches triggered by unknown key IDs, so forged
+	// kids cannot make every request hit the identity provider
+	minJWKSRefresh = time.Minute
+	jwksTimeout    = 5 * time.Second
+	maxJWKSSize    = 1 << 20
+)
+
+// errUnknownKey is returned when no key of the set matches the token's kid
+var errUnknownKey = errors.New("no matching signing key")
+
+// KeySource returns the public key a token was signed with
+type KeySource interface {
+	Key(kid string) (interface{}, error)
+}
+
+// JWKS is a KeySource reading a JSON Web Key Set from a URL or a local file.
+// Keys are cached for the TTL and refetched early when a token names an
+// unknown key ID, which picks up rotated keys. When a refetch fails the
+// cached keys keep being used.
+type JWKS struct {
+	load func() ([]byte, error)
+	ttl  time.Duration
+
+	mu        sync.Mutex
+	keys      map[string]interface{}
+	fetchedAt time.Time
+}
+
+// NewJWKS returns the key set published at url
+func NewJWKS(url string, ttl time.Duration) *JWKS {
+	client := &http.Client{Timeout: jwksTimeout}
+	return newJWKS(ttl, func() ([]byte, error) {
+		resp, err := client.Get(url)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("fetching %s: status %d", url, resp.StatusCode)
+		}
+		return io.ReadAll(io.LimitReader(resp.Body, maxJWKSSize))
+	})
+}
+
+// NewJWKSFile returns the key set stored at path, a stand-in for the
+// identity provider in tests and local development. The file is reread like
+// a remote key set, so keys can be rotated by replacing it.
+func NewJWKSFile(path string, ttl time.Duration) *JWKS {
+	return newJWKS(ttl, func() ([]byte, error) {
+		return os.ReadFile(path)
+	})
+}
+
+func newJWKS(ttl time.Duration, load func() ([]byte, error)) *JWKS {
+	if ttl <= 0 {
+		ttl = DefaultJWKSCacheTTL
+	}
+	return &JWKS{load: load, ttl: ttl}
+}
+
+// Key returns the key with ID kid. A token without kid matches the only key
+// of a set holding one key.
+func (s *JWKS) Key(kid string) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	age := time.Since(s.fetchedAt)
+	if s.keys == nil || age > s.ttl || (s.lookup(kid) == nil && age > minJWKSRefresh) {
+		if err := s.refresh(); err != nil {
+			if s.keys == nil {
+				return nil, err
+			}
+			log.Printf("Error refreshing JWKS, using cached keys: %v", err)
+		}
+	}
+
+	if key := s.lookup(kid); key != nil {
+		return key, nil
+	}
+	return nil, errUnknownKey
+}
+
+// lookup finds kid in the cached keys; s.mu must be held
+func (s *JWKS) lookup(kid string) interface{} {
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key
+		}
+	}
+	return s.keys[kid]
+}
+
+// refresh reloads the key set; s.mu must be held
+func (s *JWKS) refresh() error {
+	// Failed loads are not retried before minJWKSRefresh either
+	s.fetchedAt = time.Now()
+
+	data, err := s.load()
+	if err != nil {
+		return err
+	}
+	keys, err := ParseJWKS(data)
+	if err != nil {
+		return err
+	}
+	s.keys = keys
+	return nil
+}
+
+// jwk is one key of a JSON Web Key Set (RFC 7517)
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// ParseJWKS returns the RSA and EC signing keys of a key set by key ID.
+// Encryption keys, other key types and keys that cannot be parsed are
+// skipped; it fails only when no signing key is left.
+func ParseJWKS(data []byte) (map[string]interface{}, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid JWKS: %w", err)
+	}
+
+	keys := map[string]interface{}{}
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		var key interface{}
+		var err error
+		switch k.Kty {
+		case "RSA":
+			key, err = rsaKey(k)
+		case "EC":
+			key, err = ecKey(k)
+		default:
+			continue
+		}
+		if err != nil {
+			// One bad key must not take down the keys still in use
+			log.Printf("Skipping invalid JWKS key %q: %v", k.Kid, err)
+			continue
+		}
+		keys[k.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("JWKS has no signing keys")
+	}
+	return keys, nil
+}
+
+func rsaKey(k jwk) (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, err
+	}
+	exponent := new(big.Int).SetBytes(e)
+	if len(n) == 0 || !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+		return nil, errors.New("invalid RSA parameters")
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+}
+
+func ecKey(k jwk) (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch k.Crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+	}
+	x, err := base64.RawURLEncoding.DecodeString(k.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := base64.RawURLEncoding.DecodeString(k.Y)
+	if err != nil {
+		return nil, err
+	}
+	key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+	if !curve.IsOnCurve(key.X, key.Y) {
+		return nil, errors.New("point is not on the curve")
+	}
+	return key, nil
+}
@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseJWKS(t *testing.T) {
+	rsaKey, ecKey := newRSAKey(t), newECKey(t)
+
+	badPoint := ecJWK("bad-point", &ecKey.PublicKey)
+	badPoint.Y = badPoint.X
+	badCurve := ecJWK("bad-curve", &ecKey.PublicKey)
+	badCurve.Crv = "secp256k1"
+	encryption := rsaJWK("enc", &rsaKey.PublicKey)
+	encryption.Use = "enc"
+	signing := rsaJWK("sig", &rsaKey.PublicKey)
+	signing.Use = "sig"
+
+	data, err := json.Marshal(map[string][]jwk{"keys": {
+		rsaJWK("rsa", &rsaKey.PublicKey),
+		ecJWK("ec", &ecKey.PublicKey),
+		signing,
+		encryption,
+		{Kty: "oct", Kid: "oct"},
+		{Kty: "RSA", Kid: "bad-rsa", N: "!", E: "AQAB"},
+		badPoint,
+		badCurve,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := ParseJWKS(data)
+	if err != nil {
+		t.Fatalf("ParseJWKS: %v", err)
+	}
+	for _, kid := range []string{"rsa", "ec", "sig"} {
+		if keys[kid] == nil {
+			t.Errorf("key %q is missing", kid)
+		}
+	}
+	if len(keys) != 3 {
+		t.Errorf("got %d keys, want 3", len(keys))
+	}
+}
+
+func TestParseJWKSWithoutSigningKeys(t *testing.T) {
+	for _, data := range []string{
+		`not json`,
+		`{"keys": []}`,
+		`{"keys": [{"kty": "oct", "kid": "a"}]}`,
+		`{"keys": [{"kty": "RSA", "kid": "a", "n": "", "e": "AQAB"}]}`,
+	} {
+		if _, err := ParseJWKS([]byte(data)); err == nil {
+			t.Errorf("ParseJWKS(%s) succeeded", data)
+		}
+	}
+}
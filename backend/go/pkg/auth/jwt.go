@@ -5,30 +5,22 @@ import (
 	"strings"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/xzero/ai-workflow/pkg/models"
 )
 
-// Claims represents JWT claims
+// Claims represents JWT claims. Requests made with an API key get claims
+// with the key in APIKey and its principal in DID.
 type Claims struct {
-	DID      string `json:"did"`
-	Username string `json:"username"`
+	DID      string         `json:"did"`
+	Username string         `json:"username"`
+	APIKey   *models.APIKey `json:"-"`
 	jwt.RegisteredClaims
 }
 
-// ValidateToken validates a JWT token and returns the claims
+// ValidateToken validates an HS256 token signed with secret and returns the
+// claims. Use a Verifier to check the issuer and audience as well.
 func ValidateToken(tokenString, secret string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(secret), nil
-	})
-
-	if err != nil {
-		return nil, err
-	}
-
-	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
-		return claims, nil
-	}
-
-	return nil, errors.New("invalid token")
+	return NewHMACVerifier(secret).Verify(tokenString)
 }
 
 // ExtractToken extracts the token from the Authorization header
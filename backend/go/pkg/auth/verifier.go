@@ -0,0 +1,242 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// DefaultLeeway is the clock skew tolerated for exp, nbf and iat
+const DefaultLeeway = 30 * time.Second
+
+// Reasons a token is rejected, see Reason
+const (
+	ReasonMalformed            = "malformed"
+	ReasonExpired              = "expired"
+	ReasonNotYetValid          = "not_yet_valid"
+	ReasonBadSignature         = "bad_signature"
+	ReasonUnsupportedAlgorithm = "unsupported_algorithm"
+	ReasonUnknownKey           = "unknown_key"
+	ReasonRevoked              = "revoked"
+	ReasonWrongIssuer          = "wrong_issuer"
+	ReasonWrongAudience        = "wrong_audience"
+	ReasonInvalidClaims        = "invalid_claims"
+)
+
+var (
+	errUnsupportedAlgorithm = errors.New("signing algorithm is not accepted")
+	errWrongIssuer          = errors.New("token has wrong issuer")
+	errWrongAudience        = errors.New("token has wrong audience")
+)
+
+// Verifier checks a bearer token and returns its claims
+type Verifier interface {
+	Verify(token string) (*Claims, error)
+}
+
+// VerifierConfig configures a JWTVerifier
+type VerifierConfig struct {
+	Algorithms []string      // accepted alg values, such as HS256, RS256 or ES256
+	Secret     string        // key of the HS* algorithms
+	Keys       KeySource     // public keys of the RS* and ES* algorithms
+	Issuer     string        // required iss, unchecked when empty
+	Audiences  []string      // aud must contain one of them, unchecked when empty
+	Leeway     time.Duration // clock skew tolerance
+}
+
+// JWTVerifier verifies signed JWTs against a VerifierConfig
+type JWTVerifier struct {
+	cfg        VerifierConfig
+	algorithms map[string]bool
+	parser     *jwt.Parser
+}
+
+// NewVerifier checks cfg and returns its verifier
+func NewVerifier(cfg VerifierConfig) (*JWTVerifier, error) {
+	if len(cfg.Algorithms) == 0 {
+		return nil, errors.New("no JWT algorithms configured")
+	}
+	algorithms := map[string]bool{}
+	for _, alg := range cfg.Algorithms {
+		switch {
+		case strings.HasPrefix(alg, "HS"):
+			if cfg.Secret == "" {
+				return nil, fmt.Errorf("%s requires a secret", alg)
+			}
+		case strings.HasPrefix(alg, "RS"), strings.HasPrefix(alg, "ES"):
+			if cfg.Keys == nil {
+				return nil, fmt.Errorf("%s requires a JWKS", alg)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported JWT algorithm %q", alg)
+		}
+		if jwt.GetSigningMethod(alg) == nil {
+			return nil, fmt.Errorf("unsupported JWT algorithm %q", alg)
+		}
+		algorithms[alg] = true
+	}
+
+	return &JWTVerifier{
+		cfg:        cfg,
+		algorithms: algorithms,
+		parser:     jwt.NewParser(jwt.WithLeeway(cfg.Leeway)),
+	}, nil
+}
+
+// NewHMACVerifier returns a verifier for HS256 tokens signed with secret
+func NewHMACVerifier(secret string) *JWTVerifier {
+	return &JWTVerifier{
+		cfg:        VerifierConfig{Algorithms: []string{"HS256"}, Secret: secret},
+		algorithms: map[string]bool{"HS256": true},
+		parser:     jwt.NewParser(),
+	}
+}
+
+// Verify checks the signature, algorithm, time claims, issuer and audience
+// of token. Errors carry a reason code, see Reason.
+func (v *JWTVerifier) Verify(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := v.parser.ParseWithClaims(tokenString, claims, v.key)
+	if err != nil {
+		return nil, err
+	}
+
+	if v.cfg.Issuer != "" && claims.Issuer != v.cfg.Issuer {
+		return nil, errWrongIssuer
+	}
+	if len(v.cfg.Audiences) > 0 && !containsAny(claims.Audience, v.cfg.Audiences) {
+		return nil, errWrongAudience
+	}
+	return claims, nil
+}
+
+// key returns the verification key of token after checking its algorithm
+func (v *JWTVerifier) key(token *jwt.Token) (interface{}, error) {
+	alg := token.Method.Alg()
+	if !v.algorithms[alg] {
+		return nil, errUnsupportedAlgorithm
+	}
+	if strings.HasPrefix(alg, "HS") {
+		if v.cfg.Secret == "" {
+			return nil, errUnknownKey
+		}
+		return []byte(v.cfg.Secret), nil
+	}
+	kid, _ := token.Header["kid"].(string)
+	return v.cfg.Keys.Key(kid)
+}
+
+// Reason returns the reason code of a Verify error
+func Reason(err error) string {
+	switch {
+	case errors.Is(err, errUnsupportedAlgorithm):
+		return ReasonUnsupportedAlgorithm
+	case errors.Is(err, errUnknownKey):
+		return ReasonUnknownKey
+	case errors.Is(err, errRevokedKey):
+		return ReasonRevoked
+	case errors.Is(err, errExpiredKey):
+		return ReasonExpired
+	case errors.Is(err, errWrongIssuer):
+		return ReasonWrongIssuer
+	case errors.Is(err, errWrongAudience):
+		return ReasonWrongAudience
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return ReasonExpired
+	case errors.Is(err, jwt.ErrTokenNotValidYet), errors.Is(err, jwt.ErrTokenUsedBeforeIssued):
+		return ReasonNotYetValid
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid), errors.Is(err, jwt.ErrInvalidKeyType):
+		return ReasonBadSignature
+	case errors.Is(err, jwt.ErrTokenMalformed):
+		return ReasonMalformed
+	case errors.Is(err, jwt.ErrTokenUnverifiable):
+		// Failure to load the keys
+		return ReasonUnknownKey
+	default:
+		return ReasonInvalidClaims
+	}
+}
+
+// VerifierFromEnv returns the verifier configured by:
+//
+//   - JWT_ALGORITHMS: accepted algorithms, default RS256,ES256 with a JWKS and HS256 otherwise
+//   - JWT_SECRET: key of the HS* algorithms
+//   - JWT_JWKS_URL or JWT_JWKS_FILE: key set of the RS* and ES* algorithms
+//   - JWT_JWKS_CACHE_TTL: how long keys are cached, default 1h
+//   - JWT_ISSUER, JWT_AUDIENCE: required iss and aud (comma separated); both
+//     must be set when a JWKS is used
+//   - JWT_LEEWAY: clock skew tolerance, default 30s
+//
+// Everything is read once, at startup, rather than on every request.
+func VerifierFromEnv() (Verifier, error) {
+	cfg := VerifierConfig{
+		Secret:    os.Getenv("JWT_SECRET"),
+		Issuer:    os.Getenv("JWT_ISSUER"),
+		Audiences: splitList(os.Getenv("JWT_AUDIENCE")),
+		Leeway:    DefaultLeeway,
+	}
+
+	var err error
+	if v := os.Getenv("JWT_LEEWAY"); v != "" {
+		if cfg.Leeway, err = time.ParseDuration(v); err != nil || cfg.Leeway < 0 {
+			return nil, fmt.Errorf("invalid JWT_LEEWAY: %q", v)
+		}
+	}
+	ttl := DefaultJWKSCacheTTL
+	if v := os.Getenv("JWT_JWKS_CACHE_TTL"); v != "" {
+		if ttl, err = time.ParseDuration(v); err != nil || ttl <= 0 {
+			return nil, fmt.Errorf("invalid JWT_JWKS_CACHE_TTL: %q", v)
+		}
+	}
+
+	if url := os.Getenv("JWT_JWKS_URL"); url != "" {
+		cfg.Keys = NewJWKS(url, ttl)
+	} else if path := os.Getenv("JWT_JWKS_FILE"); path != "" {
+		cfg.Keys = NewJWKSFile(path, ttl)
+	}
+	if cfg.Keys != nil && (cfg.Issuer == "" || len(cfg.Audiences) == 0) {
+		return nil, errors.New("JWT_ISSUER and JWT_AUDIENCE are required with a JWKS")
+	}
+
+	cfg.Algorithms = splitList(os.Getenv("JWT_ALGORITHMS"))
+	if len(cfg.Algorithms) == 0 {
+		if cfg.Keys != nil {
+			cfg.Algorithms = []string{"RS256", "ES256"}
+		} else if cfg.Secret != "" {
+			cfg.Algorithms = []string{"HS256"}
+		} else {
+			return nil, errors.New("JWT_SECRET or JWT_JWKS_URL is not configured")
+		}
+	}
+
+	verifier, err := NewVerifier(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return verifier, nil
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func containsAny(values, wanted []string) bool {
+	for _, v := range values {
+		for _, w := range wanted {
+			if v == w {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,283 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testSecret   = "test-secret"
+	testIssuer   = "https://issuer.example"
+	testAudience = "workflows"
+)
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key interface{}, claims *Claims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("signing token: %v", err)
+	}
+	return signed
+}
+
+func newRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating RSA key: %v", err)
+	}
+	return key
+}
+
+func newECKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating EC key: %v", err)
+	}
+	return key
+}
+
+func rsaJWK(kid string, key *rsa.PublicKey) jwk {
+	return jwk{
+		Kty: "RSA",
+		Kid: kid,
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func ecJWK(kid string, key *ecdsa.PublicKey) jwk {
+	return jwk{
+		Kty: "EC",
+		Kid: kid,
+		Crv: "P-256",
+		X:   base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+		Y:   base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+	}
+}
+
+// writeJWKS replaces the key set at path with keys
+func writeJWKS(t *testing.T, path string, keys ...jwk) {
+	t.Helper()
+	data, err := json.Marshal(map[string][]jwk{"keys": keys})
+	if err != nil {
+		t.Fatalf("encoding JWKS: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("writing JWKS: %v", err)
+	}
+}
+
+func TestVerifyHMAC(t *testing.T) {
+	v, err := NewVerifier(VerifierConfig{
+		Algorithms: []string{"HS256"},
+		Secret:     testSecret,
+		Issuer:     testIssuer,
+		Audiences:  []string{"other", testAudience},
+		Leeway:     DefaultLeeway,
+	})
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+	now := time.Now()
+	iat, exp := jwt.NewNumericDate(now), jwt.NewNumericDate(now.Add(time.Hour))
+	aud := jwt.ClaimStrings{testAudience}
+
+	tests := []struct {
+		name   string
+		method jwt.SigningMethod
+		key    interface{}
+		claims jwt.RegisteredClaims
+		want   string // reason code, empty when the token is accepted
+	}{
+		{"valid", jwt.SigningMethodHS256, []byte(testSecret), jwt.RegisteredClaims{Issuer: testIssuer, Audience: aud, IssuedAt: iat, ExpiresAt: exp}, ""},
+		{"expired within leeway", jwt.SigningMethodHS256, []byte(testSecret), jwt.RegisteredClaims{Issuer: testIssuer, Audience: aud, IssuedAt: iat, ExpiresAt: jwt.NewNumericDate(now.Add(-10 * time.Second))}, ""},
+		{"expired", jwt.SigningMethodHS256, []byte(testSecret), jwt.RegisteredClaims{Issuer: testIssuer, Audience: aud, IssuedAt: iat, ExpiresAt: jwt.NewNumericDate(now.Add(-time.Hour))}, ReasonExpired},
+		{"not yet valid", jwt.SigningMethodHS256, []byte(testSecret), jwt.RegisteredClaims{Issuer: testIssuer, Audience: aud, IssuedAt: iat, ExpiresAt: exp, NotBefore: jwt.NewNumericDate(now.Add(time.Hour))}, ReasonNotYetValid},
+		{"bad signature", jwt.SigningMethodHS256, []byte("other-secret"), jwt.RegisteredClaims{Issuer: testIssuer, Audience: aud, IssuedAt: iat, ExpiresAt: exp}, ReasonBadSignature},
+		{"wrong issuer", jwt.SigningMethodHS256, []byte(testSecret), jwt.RegisteredClaims{Issuer: "https://evil.example", Audience: aud, IssuedAt: iat, ExpiresAt: exp}, ReasonWrongIssuer},
+		{"missing issuer", jwt.SigningMethodHS256, []byte(testSecret), jwt.RegisteredClaims{Audience: aud, IssuedAt: iat, ExpiresAt: exp}, ReasonWrongIssuer},
+		{"wrong audience", jwt.SigningMethodHS256, []byte(testSecret), jwt.RegisteredClaims{Issuer: testIssuer, Audience: jwt.ClaimStrings{"billing"}, IssuedAt: iat, ExpiresAt: exp}, ReasonWrongAudience},
+		{"algorithm not configured", jwt.SigningMethodHS384, []byte(testSecret), jwt.RegisteredClaims{Issuer: testIssuer, Audience: aud, IssuedAt: iat, ExpiresAt: exp}, ReasonUnsupportedAlgorithm},
+		{"alg none", jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, jwt.RegisteredClaims{Issuer: testIssuer, Audience: aud, IssuedAt: iat, ExpiresAt: exp}, ReasonUnsupportedAlgorithm},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := sign(t, tt.method, "", tt.key, &Claims{DID: "did:example:alice", RegisteredClaims: tt.claims})
+			claims, err := v.Verify(token)
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("Verify: %v", err)
+				}
+				if claims.DID != "did:example:alice" {
+					t.Errorf("DID = %q, want did:example:alice", claims.DID)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("Verify accepted the token")
+			}
+			if got := Reason(err); got != tt.want {
+				t.Errorf("Reason = %q, want %q (%v)", got, tt.want, err)
+			}
+		})
+	}
+}
+
+func TestVerifyMalformed(t *testing.T) {
+	v := NewHMACVerifier(testSecret)
+	if _, err := v.Verify("not-a-token"); Reason(err) != ReasonMalformed {
+		t.Errorf("Reason = %q, want %q (%v)", Reason(err), ReasonMalformed, err)
+	}
+}
+
+func TestVerifyJWKS(t *testing.T) {
+	rsaKey, ecKey, otherRSAKey := newRSAKey(t), newECKey(t), newRSAKey(t)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, path, rsaJWK("rsa", &rsaKey.PublicKey), ecJWK("ec", &ecKey.PublicKey))
+
+	v, err := NewVerifier(VerifierConfig{
+		Algorithms: []string{"RS256", "ES256"},
+		Keys:       NewJWKSFile(path, time.Hour),
+		Issuer:     testIssuer,
+		Audiences:  []string{testAudience},
+	})
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+
+	now := time.Now()
+	iat, exp := jwt.NewNumericDate(now), jwt.NewNumericDate(now.Add(time.Hour))
+	aud := jwt.ClaimStrings{testAudience}
+
+	tests := []struct {
+		name   string
+		method jwt.SigningMethod
+		kid    string
+		key    interface{}
+		claims jwt.RegisteredClaims
+		want   string
+	}{
+		{"RS256", jwt.SigningMethodRS256, "rsa", rsaKey, jwt.RegisteredClaims{Issuer: testIssuer, Audience: aud, IssuedAt: iat, ExpiresAt: exp}, ""},
+		{"ES256", jwt.SigningMethodES256, "ec", ecKey, jwt.RegisteredClaims{Issuer: testIssuer, Audience: aud, IssuedAt: iat, ExpiresAt: exp}, ""},
+		{"expired", jwt.SigningMethodRS256, "rsa", rsaKey, jwt.RegisteredClaims{Issuer: testIssuer, Audience: aud, IssuedAt: iat, ExpiresAt: jwt.NewNumericDate(now.Add(-time.Minute))}, ReasonExpired},
+		{"signed by another key", jwt.SigningMethodRS256, "rsa", otherRSAKey, jwt.RegisteredClaims{Issuer: testIssuer, Audience: aud, IssuedAt: iat, ExpiresAt: exp}, ReasonBadSignature},
+		{"kid of another key type", jwt.SigningMethodRS256, "ec", rsaKey, jwt.RegisteredClaims{Issuer: testIssuer, Audience: aud, IssuedAt: iat, ExpiresAt: exp}, ReasonBadSignature},
+		{"unknown kid", jwt.SigningMethodRS256, "missing", rsaKey, jwt.RegisteredClaims{Issuer: testIssuer, Audience: aud, IssuedAt: iat, ExpiresAt: exp}, ReasonUnknownKey},
+		{"no kid with several keys", jwt.SigningMethodRS256, "", rsaKey, jwt.RegisteredClaims{Issuer: testIssuer, Audience: aud, IssuedAt: iat, ExpiresAt: exp}, ReasonUnknownKey},
+		{"RS384", jwt.SigningMethodRS384, "rsa", rsaKey, jwt.RegisteredClaims{Issuer: testIssuer, Audience: aud, IssuedAt: iat, ExpiresAt: exp}, ReasonUnsupportedAlgorithm},
+		{"HS256 with the public key as secret", jwt.SigningMethodHS256, "rsa", rsaKey.PublicKey.N.Bytes(), jwt.RegisteredClaims{Issuer: testIssuer, Audience: aud, IssuedAt: iat, ExpiresAt: exp}, ReasonUnsupportedAlgorithm},
+		{"wrong issuer", jwt.SigningMethodES256, "ec", ecKey, jwt.RegisteredClaims{Issuer: "https://evil.example", Audience: aud, IssuedAt: iat, ExpiresAt: exp}, ReasonWrongIssuer},
+		{"wrong audience", jwt.SigningMethodES256, "ec", ecKey, jwt.RegisteredClaims{Issuer: testIssuer, IssuedAt: iat, ExpiresAt: exp}, ReasonWrongAudience},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := sign(t, tt.method, tt.kid, tt.key, &Claims{DID: "did:example:alice", RegisteredClaims: tt.claims})
+			_, err := v.Verify(token)
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("Verify: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("Verify accepted the token")
+			}
+			if got := Reason(err); got != tt.want {
+				t.Errorf("Reason = %q, want %q (%v)", got, tt.want, err)
+			}
+		})
+	}
+}
+
+func TestVerifyKeyRotation(t *testing.T) {
+	oldKey, newKey := newRSAKey(t), newRSAKey(t)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, path, rsaJWK("old", &oldKey.PublicKey))
+
+	keys := NewJWKSFile(path, time.Hour)
+	v, err := NewVerifier(VerifierConfig{
+		Algorithms: []string{"RS256"},
+		Keys:       keys,
+		Issuer:     testIssuer,
+		Audiences:  []string{testAudience},
+	})
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+	claims := &Claims{
+		DID: "did:example:alice",
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    testIssuer,
+			Audience:  jwt.ClaimStrings{testAudience},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}
+	oldToken := sign(t, jwt.SigningMethodRS256, "old", oldKey, claims)
+	newToken := sign(t, jwt.SigningMethodRS256, "new", newKey, claims)
+
+	verify := func(token, want string) {
+		t.Helper()
+		_, err := v.Verify(token)
+		switch {
+		case err == nil && want != "":
+			t.Errorf("Verify accepted the token, want %q", want)
+		case err != nil && Reason(err) != want:
+			t.Errorf("Reason = %q, want %q (%v)", Reason(err), want, err)
+		}
+	}
+
+	verify(oldToken, "")
+
+	// The identity provider rotates to the new key
+	writeJWKS(t, path, rsaJWK("new", &newKey.PublicKey))
+
+	// Unknown kids refetch the set at most once per minJWKSRefresh
+	verify(newToken, ReasonUnknownKey)
+	keys.fetchedAt = time.Now().Add(-2 * minJWKSRefresh)
+	verify(newToken, "")
+	verify(oldToken, ReasonUnknownKey)
+
+	// A failed refresh keeps the cached keys
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	keys.fetchedAt = time.Now().Add(-2 * time.Hour)
+	verify(newToken, "")
+}
+
+func TestNewVerifierRejectsIncompleteConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  VerifierConfig
+	}{
+		{"no algorithms", VerifierConfig{Secret: testSecret}},
+		{"HS256 without secret", VerifierConfig{Algorithms: []string{"HS256"}}},
+		{"RS256 without keys", VerifierConfig{Algorithms: []string{"RS256"}, Secret: testSecret}},
+		{"unknown algorithm", VerifierConfig{Algorithms: []string{"none"}, Secret: testSecret}},
+		{"unknown HS size", VerifierConfig{Algorithms: []string{"HS1"}, Secret: testSecret}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewVerifier(tt.cfg); err == nil {
+				t.Error("NewVerifier succeeded")
+			}
+		})
+	}
+}
@@ -0,0 +1,308 @@
+// Package bundle converts workflows to and from portable bundles, so
+// definitions can move between projects and environments. Exported bundles
+// never contain secrets: the bearer token and credential headers become
+// ${NAME} placeholders that the importer supplies again.
+package bundle
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/xzero/ai-workflow/pkg/models"
+	"github.com/xzero/ai-workflow/pkg/schema"
+	"github.com/xzero/ai-workflow/pkg/secrets"
+	"gopkg.in/yaml.v3"
+)
+
+// Encodings of a bundle
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+)
+
+// placeholderPattern matches ${NAME} in secret values
+var placeholderPattern = regexp.MustCompile(`\$\{([A-Z0-9_]+)\}`)
+
+// IsFormat reports whether format is a supported encoding
+func IsFormat(format string) bool {
+	return format == FormatJSON || format == FormatYAML
+}
+
+// ContentType returns the media type of an encoding
+func ContentType(format string) string {
+	if format == FormatYAML {
+		return "application/yaml"
+	}
+	return "application/json"
+}
+
+// Export returns the bundle of workflows exported from projectID. Bearer
+// tokens and credential headers are replaced by placeholders named after
+// the workflow, such as ${TRANSLATE_BEARER_TOKEN}.
+func Export(workflows []models.Workflow, projectID string) (*models.WorkflowBundle, error) {
+	b := &models.WorkflowBundle{
+		Format:     models.BundleFormat,
+		Version:    models.BundleVersion,
+		ExportedAt: time.Now().UTC(),
+		ProjectID:  projectID,
+		Secrets:    []string{},
+		Workflows:  []models.BundleWorkflow{},
+	}
+
+	prefixes := map[string]bool{}
+	for i := range workflows {
+		w := &workflows[i]
+		prefix := placeholderPrefix(w.WorkflowName, i, prefixes)
+
+		item := models.BundleWorkflow{
+			WorkflowName:       w.WorkflowName,
+			Description:        w.Description,
+			Source:             w.Source,
+			TemplateName:       w.TemplateName,
+			HTTPMethod:         w.HTTPMethod,
+			BaseURL:            w.BaseURL,
+			BearerToken:        placeholder(prefix + "_BEARER_TOKEN"),
+			ExternalWorkflowID: w.ExternalWorkflowID,
+			Tags:               w.Tags,
+		}
+		b.Secrets = append(b.Secrets, prefix+"_BEARER_TOKEN")
+
+		if err := decodeJSON(w.Parameters, &item.Parameters); err != nil {
+			return nil, fmt.Errorf("workflow %s: invalid parameters: %w", w.WorkflowID, err)
+		}
+		if !schema.IsEmpty(w.InputSchema) {
+			if err := decodeJSON(w.InputSchema, &item.InputSchema); err != nil {
+				return nil, fmt.Errorf("workflow %s: invalid input_schema: %w", w.WorkflowID, err)
+			}
+		}
+
+		var headers map[string]string
+		if err := decodeJSON(w.Headers, &headers); err != nil {
+			return nil, fmt.Errorf("workflow %s: invalid headers: %w", w.WorkflowID, err)
+		}
+		if len(headers) > 0 {
+			item.Headers = map[string]string{}
+			names := make([]string, 0, len(headers))
+			for k := range headers {
+				names = append(names, k)
+			}
+			sort.Strings(names)
+			for _, k := range names {
+				v := headers[k]
+				if !secrets.IsSensitiveHeader(k) || v == "" {
+					item.Headers[k] = v
+					continue
+				}
+				name := prefix + "_HEADER_" + identifier(k)
+				b.Secrets = append(b.Secrets, name)
+				// Keep the auth scheme, e.g. "Bearer ${NAME}"
+				if scheme, _, ok := strings.Cut(v, " "); ok {
+					item.Headers[k] = scheme + " " + placeholder(name)
+				} else {
+					item.Headers[k] = placeholder(name)
+				}
+			}
+		}
+
+		b.Workflows = append(b.Workflows, item)
+	}
+	return b, nil
+}
+
+// Encode writes b as JSON or YAML
+func Encode(b *models.WorkflowBundle, format string) ([]byte, error) {
+	if format == FormatYAML {
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(b); err != nil {
+			return nil, err
+		}
+		if err := enc.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+	return json.MarshalIndent(b, "", "  ")
+}
+
+// DecodeImport reads an import request sent as JSON or YAML and checks the
+// bundle it carries
+func DecodeImport(body []byte) (*models.ImportWorkflowsRequest, error) {
+	var req models.ImportWorkflowsRequest
+	trimmed := bytes.TrimSpace(body)
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		if err := json.Unmarshal(trimmed, &req); err != nil {
+			return nil, err
+		}
+	} else if err := yaml.Unmarshal(trimmed, &req); err != nil {
+		return nil, err
+	}
+
+	if req.Bundle == nil {
+		return nil, errors.New("missing bundle")
+	}
+	if req.Bundle.Format != models.BundleFormat {
+		return nil, fmt.Errorf("unsupported bundle format %q, want %q", req.Bundle.Format, models.BundleFormat)
+	}
+	if req.Bundle.Version < 1 || req.Bundle.Version > models.BundleVersion {
+		return nil, fmt.Errorf("unsupported bundle version %d, this server reads up to %d", req.Bundle.Version, models.BundleVersion)
+	}
+	return &req, nil
+}
+
+// Resolve returns a copy of w with placeholders replaced by their values in
+// values, and the names that have none. Unresolved secrets are left masked
+// with secrets.Redacted, which updates treat as "keep the stored value".
+func Resolve(w *models.BundleWorkflow, values map[string]string) (*models.BundleWorkflow, []string) {
+	missing := []string{}
+	seen := map[string]bool{}
+	resolve := func(s string) string {
+		unresolved := false
+		s = placeholderPattern.ReplaceAllStringFunc(s, func(m string) string {
+			name := m[2 : len(m)-1]
+			if v, ok := values[name]; ok && v != "" {
+				return v
+			}
+			if !seen[name] {
+				seen[name] = true
+				missing = append(missing, name)
+			}
+			unresolved = true
+			return m
+		})
+		if unresolved {
+			return secrets.Redacted
+		}
+		return s
+	}
+
+	c := *w
+	c.BearerToken = resolve(w.BearerToken)
+	if w.Headers != nil {
+		c.Headers = make(map[string]string, len(w.Headers))
+		for k, v := range w.Headers {
+			c.Headers[k] = resolve(v)
+		}
+	}
+	sort.Strings(missing)
+	return &c, missing
+}
+
+// CreateRequest converts w into the request creating it in projectID. The
+// request is not validated yet; imports check it like POST /api/workflows.
+func CreateRequest(w *models.BundleWorkflow, projectID string) (*models.CreateWorkflowRequest, error) {
+	req := &models.CreateWorkflowRequest{
+		WorkflowName:       w.WorkflowName,
+		Description:        w.Description,
+		Source:             w.Source,
+		TemplateName:       w.TemplateName,
+		HTTPMethod:         w.HTTPMethod,
+		BaseURL:            w.BaseURL,
+		BearerToken:        w.BearerToken,
+		ExternalWorkflowID: w.ExternalWorkflowID,
+		Tags:               w.Tags,
+		ProjectID:          projectID,
+	}
+
+	var err error
+	if req.Parameters, err = encodeJSON(w.Parameters); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+	if req.Headers, err = encodeJSON(w.Headers); err != nil {
+		return nil, fmt.Errorf("invalid headers: %w", err)
+	}
+	if req.InputSchema, err = encodeJSON(w.InputSchema); err != nil {
+		return nil, fmt.Errorf("invalid input_schema: %w", err)
+	}
+	return req, nil
+}
+
+// UpdateRequest converts a validated create request into the update
+// overwriting every field of an existing workflow. Masked secrets keep the
+// values stored in current.
+func UpdateRequest(req *models.CreateWorkflowRequest, current *models.Workflow) (*models.UpdateWorkflowRequest, error) {
+	headers, err := secrets.RestoreMaskedHeaders(req.Headers, current.Headers)
+	if err != nil {
+		return nil, err
+	}
+	update := &models.UpdateWorkflowRequest{
+		WorkflowName:       &req.WorkflowName,
+		Description:        &req.Description,
+		Source:             &req.Source,
+		TemplateName:       &req.TemplateName,
+		HTTPMethod:         &req.HTTPMethod,
+		BaseURL:            &req.BaseURL,
+		ExternalWorkflowID: &req.ExternalWorkflowID,
+		Parameters:         &req.Parameters,
+		Headers:            &headers,
+		InputSchema:        &req.InputSchema,
+		Tags:               &req.Tags,
+	}
+	if !secrets.IsRedacted(req.BearerToken) {
+		update.BearerToken = &req.BearerToken
+	}
+	return update, nil
+}
+
+// placeholderPrefix derives the placeholder prefix of the i-th workflow from
+// its name, falling back to WORKFLOW_<n> for names without ASCII letters or
+// digits and adding the position when two workflows would share a prefix
+func placeholderPrefix(name string, i int, used map[string]bool) string {
+	prefix := identifier(name)
+	if prefix == "" {
+		prefix = fmt.Sprintf("WORKFLOW_%d", i+1)
+	}
+	if used[prefix] {
+		prefix = fmt.Sprintf("%s_%d", prefix, i+1)
+	}
+	used[prefix] = true
+	return prefix
+}
+
+// identifier turns s into an upper case name of letters, digits and underscores
+func identifier(s string) string {
+	var b strings.Builder
+	underscore := false
+	for _, r := range strings.ToUpper(s) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			if underscore && b.Len() > 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(r)
+			underscore = false
+		} else {
+			underscore = true
+		}
+	}
+	return b.String()
+}
+
+func placeholder(name string) string {
+	return "${" + name + "}"
+}
+
+// decodeJSON unmarshals a JSON column, leaving v unset when it is empty
+func decodeJSON(raw json.RawMessage, v interface{}) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	return json.Unmarshal(raw, v)
+}
+
+// encodeJSON marshals a field read from a bundle, an absent one as {}
+func encodeJSON(v interface{}) (json.RawMessage, error) {
+	if v == nil {
+		return json.RawMessage("{}"), nil
+	}
+	if m, ok := v.(map[string]string); ok && m == nil {
+		return json.RawMessage("{}"), nil
+	}
+	return json.Marshal(v)
+}
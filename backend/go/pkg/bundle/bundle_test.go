@@ -0,0 +1,188 @@
+package bundle
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/xzero/ai-workflow/pkg/models"
+	"github.com/xzero/ai-workflow/pkg/secrets"
+)
+
+const (
+	token  = "upstream-token-1234"
+	apiKey = "upstream-key-5678"
+)
+
+func TestExportNeverLeaksSecrets(t *testing.T) {
+	workflows := []models.Workflow{
+		{
+			WorkflowID:         "wf1",
+			WorkflowName:       "Translate",
+			Description:        "Translates text",
+			Source:             "dify",
+			TemplateName:       "workflow",
+			HTTPMethod:         "POST",
+			BaseURL:            "https://dify.example/v1/workflows/run",
+			BearerToken:        token,
+			ExternalWorkflowID: "app-7",
+			Parameters:         json.RawMessage(`{"lang": "fr"}`),
+			Headers:            json.RawMessage(`{"Authorization": "Bearer ` + token + `", "X-API-Key": "` + apiKey + `", "X-Trace": "on"}`),
+			InputSchema:        json.RawMessage(`{}`),
+		},
+		{
+			WorkflowID:   "wf2",
+			WorkflowName: "translate",
+			BearerToken:  token,
+			Headers:      json.RawMessage(`{"X-Session-Token": "` + apiKey + `"}`),
+		},
+		{
+			WorkflowID:   "wf3",
+			WorkflowName: "Résumé!",
+			BearerToken:  token,
+		},
+	}
+
+	b, err := Export(workflows, "p1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, format := range []string{FormatJSON, FormatYAML} {
+		encoded, err := Encode(b, format)
+		if err != nil {
+			t.Fatalf("Encode(%s): %v", format, err)
+		}
+		if s := string(encoded); strings.Contains(s, token) || strings.Contains(s, apiKey) {
+			t.Errorf("%s bundle carries a secret:\n%s", format, s)
+		}
+	}
+
+	wantSecrets := []string{
+		"TRANSLATE_BEARER_TOKEN", "TRANSLATE_HEADER_AUTHORIZATION", "TRANSLATE_HEADER_X_API_KEY",
+		"TRANSLATE_2_BEARER_TOKEN", "TRANSLATE_2_HEADER_X_SESSION_TOKEN",
+		"R_SUM_BEARER_TOKEN",
+	}
+	if !reflect.DeepEqual(b.Secrets, wantSecrets) {
+		t.Errorf("secrets = %v, want %v", b.Secrets, wantSecrets)
+	}
+
+	first := b.Workflows[0]
+	wantHeaders := map[string]string{
+		"Authorization": "Bearer ${TRANSLATE_HEADER_AUTHORIZATION}",
+		"X-API-Key":     "${TRANSLATE_HEADER_X_API_KEY}",
+		"X-Trace":       "on",
+	}
+	if first.BearerToken != "${TRANSLATE_BEARER_TOKEN}" || !reflect.DeepEqual(first.Headers, wantHeaders) {
+		t.Errorf("bearer_token, headers = %q, %v, want placeholders", first.BearerToken, first.Headers)
+	}
+	if b.Workflows[2].Headers != nil {
+		t.Errorf("headers of a workflow without headers = %v, want none", b.Workflows[2].Headers)
+	}
+}
+
+func TestPlaceholderPrefix(t *testing.T) {
+	used := map[string]bool{}
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Summarize PDF", "SUMMARIZE_PDF"},
+		{"summarize-pdf", "SUMMARIZE_PDF_2"},
+		{"  --  ", "WORKFLOW_3"},
+		{"日本語", "WORKFLOW_4"},
+		{"v2 API", "V2_API"},
+	}
+	for i, tt := range tests {
+		if got := placeholderPrefix(tt.name, i, used); got != tt.want {
+			t.Errorf("placeholderPrefix(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	w := &models.BundleWorkflow{
+		WorkflowName: "Translate",
+		BearerToken:  "${TRANSLATE_BEARER_TOKEN}",
+		Headers: map[string]string{
+			"Authorization": "Bearer ${TRANSLATE_HEADER_AUTHORIZATION}",
+			"X-Trace":       "on",
+		},
+	}
+	tests := []struct {
+		name        string
+		values      map[string]string
+		wantToken   string
+		wantAuth    string
+		wantMissing []string
+	}{
+		{"all values", map[string]string{"TRANSLATE_BEARER_TOKEN": token, "TRANSLATE_HEADER_AUTHORIZATION": apiKey}, token, "Bearer " + apiKey, []string{}},
+		{"no values", nil, secrets.Redacted, secrets.Redacted, []string{"TRANSLATE_BEARER_TOKEN", "TRANSLATE_HEADER_AUTHORIZATION"}},
+		{"empty value", map[string]string{"TRANSLATE_BEARER_TOKEN": "", "TRANSLATE_HEADER_AUTHORIZATION": apiKey}, secrets.Redacted, "Bearer " + apiKey, []string{"TRANSLATE_BEARER_TOKEN"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, missing := Resolve(w, tt.values)
+			if got.BearerToken != tt.wantToken || got.Headers["Authorization"] != tt.wantAuth {
+				t.Errorf("bearer_token, Authorization = %q, %q, want %q, %q", got.BearerToken, got.Headers["Authorization"], tt.wantToken, tt.wantAuth)
+			}
+			if got.Headers["X-Trace"] != "on" {
+				t.Errorf("X-Trace = %q, want it kept", got.Headers["X-Trace"])
+			}
+			if !reflect.DeepEqual(missing, tt.wantMissing) {
+				t.Errorf("missing = %v, want %v", missing, tt.wantMissing)
+			}
+		})
+	}
+
+	// The bundle itself keeps its placeholders
+	if w.BearerToken != "${TRANSLATE_BEARER_TOKEN}" || w.Headers["Authorization"] != "Bearer ${TRANSLATE_HEADER_AUTHORIZATION}" {
+		t.Errorf("Resolve changed the bundle: %v", w)
+	}
+}
+
+func TestDecodeImport(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		wantErr bool
+	}{
+		{"json", `{"bundle": {"format": "` + models.BundleFormat + `", "version": 1, "workflows": []}}`, false},
+		{"yaml", "bundle:\n  format: " + models.BundleFormat + "\n  version: 1\n  workflows: []\n", false},
+		{"missing bundle", `{"values": {}}`, true},
+		{"other format", `{"bundle": {"format": "zapier", "version": 1}}`, true},
+		{"newer version", `{"bundle": {"format": "` + models.BundleFormat + `", "version": 99}}`, true},
+		{"version 0", `{"bundle": {"format": "` + models.BundleFormat + `"}}`, true},
+		{"invalid json", `{"bundle": `, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeImport([]byte(tt.body))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DecodeImport error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUpdateRequestKeepsMaskedSecrets(t *testing.T) {
+	current := &models.Workflow{Headers: json.RawMessage(`{"X-API-Key": "` + apiKey + `"}`)}
+	req := &models.CreateWorkflowRequest{
+		BearerToken: secrets.Redacted,
+		Headers:     json.RawMessage(`{"X-API-Key": "` + secrets.Redacted + `", "X-Trace": "on"}`),
+	}
+	update, err := UpdateRequest(req, current)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if update.BearerToken != nil {
+		t.Errorf("bearer_token = %q, want it left unchanged", *update.BearerToken)
+	}
+	var headers map[string]string
+	if err := json.Unmarshal(*update.Headers, &headers); err != nil {
+		t.Fatal(err)
+	}
+	if headers["X-API-Key"] != apiKey || headers["X-Trace"] != "on" {
+		t.Errorf("headers = %v, want X-API-Key kept and X-Trace added", headers)
+	}
+}
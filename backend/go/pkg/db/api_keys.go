@@ -0,0 +1,113 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/xzero/ai-workflow/pkg/models"
+)
+
+// apiKeyColumns are the columns scanned by apiKeyDest
+const apiKeyColumns = `
+	key_id, project_id, name, prefix, key_hash, scopes, workflow_ids,
+	created_by, created_at, expires_at, last_used_at, revoked_at
+`
+
+// touchInterval limits how often last_used_at is written for a busy key
+const touchInterval = time.Minute
+
+func apiKeyDest(k *models.APIKey) []interface{} {
+	return []interface{}{
+		&k.KeyID,
+		&k.ProjectID,
+		&k.Name,
+		&k.Prefix,
+		&k.KeyHash,
+		pq.Array(&k.Scopes),
+		pq.Array(&k.WorkflowIDs),
+		&k.CreatedBy,
+		&k.CreatedAt,
+		&k.ExpiresAt,
+		&k.LastUsedAt,
+		&k.RevokedAt,
+	}
+}
+
+// CreateAPIKey inserts key and returns its ID. key.KeyHash must be set.
+func CreateAPIKey(db *sql.DB, key *models.APIKey) (string, error) {
+	query := `
+		INSERT INTO api_keys (project_id, name, prefix, key_hash, scopes, workflow_ids, created_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING key_id, created_at
+	`
+	err := db.QueryRow(
+		query,
+		key.ProjectID,
+		key.Name,
+		key.Prefix,
+		key.KeyHash,
+		pq.Array(key.Scopes),
+		pq.Array(key.WorkflowIDs),
+		key.CreatedBy,
+		key.ExpiresAt,
+	).Scan(&key.KeyID, &key.CreatedAt)
+	return key.KeyID, err
+}
+
+// GetAPIKeyByPrefix returns the key with prefix, revoked and expired keys
+// included. It returns sql.ErrNoRows when there is none.
+func GetAPIKeyByPrefix(db *sql.DB, prefix string) (*models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE prefix = $1`
+	var k models.APIKey
+	if err := db.QueryRow(query, prefix).Scan(apiKeyDest(&k)...); err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+// ListAPIKeys returns the keys of a project, newest first
+func ListAPIKeys(db *sql.DB, projectID string) ([]models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE project_id = $1 ORDER BY created_at DESC`
+	rows, err := db.Query(query, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		var k models.APIKey
+		if err := rows.Scan(apiKeyDest(&k)...); err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+// RevokeAPIKey revokes a key of a project and returns it. Revoking a revoked
+// key keeps its revocation time. It returns sql.ErrNoRows when the project
+// has no such key.
+func RevokeAPIKey(db *sql.DB, projectID, keyID string) (*models.APIKey, error) {
+	query := `
+		UPDATE api_keys SET revoked_at = COALESCE(revoked_at, CURRENT_TIMESTAMP)
+		WHERE key_id = $1 AND project_id = $2
+		RETURNING ` + apiKeyColumns
+	var k models.APIKey
+	if err := db.QueryRow(query, keyID, projectID).Scan(apiKeyDest(&k)...); err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+// TouchAPIKey records that a key was used. The write is skipped when the key
+// was already used within touchInterval.
+func TouchAPIKey(db *sql.DB, keyID string) error {
+	query := `
+		UPDATE api_keys SET last_used_at = CURRENT_TIMESTAMP
+		WHERE key_id = $1 AND (last_used_at IS NULL OR last_used_at < CURRENT_TIMESTAMP - $2 * INTERVAL '1 second')
+	`
+	_, err := db.Exec(query, keyID, int(touchInterval.Seconds()))
+	return err
+}
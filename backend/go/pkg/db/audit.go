@@ -0,0 +1,132 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/xzero/ai-workflow/pkg/models"
+)
+
+// auditColumns are the columns scanned by scanAuditEvent
+const auditColumns = `
+	event_id, action, actor_did, project_id, COALESCE(workflow_id::text, ''),
+	changes, details, COALESCE(source_ip, ''), COALESCE(user_agent, ''),
+	COALESCE(request_id, ''), created_at
+`
+
+// InsertAuditEvent appends an event and fills in its ID and time
+func InsertAuditEvent(db *sql.DB, event *models.AuditEvent) error {
+	changes, err := json.Marshal(event.Changes)
+	if err != nil {
+		return err
+	}
+	details, err := json.Marshal(event.Details)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO audit_events (
+			action, actor_did, project_id, workflow_id, changes, details,
+			source_ip, user_agent, request_id
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING event_id, created_at
+	`
+	return db.QueryRow(
+		query,
+		event.Action,
+		event.ActorDID,
+		event.ProjectID,
+		nullString(event.WorkflowID),
+		changes,
+		details,
+		nullString(event.SourceIP),
+		nullString(event.UserAgent),
+		nullString(event.RequestID),
+	).Scan(&event.EventID, &event.CreatedAt)
+}
+
+// ListAuditEvents returns the audit events matching filter
+func ListAuditEvents(db *sql.DB, filter models.ListAuditEventsFilter) ([]models.AuditEvent, error) {
+	var conditions []string
+	var args []interface{}
+	addCondition := func(clause string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(clause, len(args)))
+	}
+
+	if filter.ProjectID != "" {
+		addCondition("project_id = $%d", filter.ProjectID)
+	}
+	if filter.ActorDID != "" {
+		addCondition("actor_did = $%d", filter.ActorDID)
+	}
+	if filter.Action != "" {
+		addCondition("action = $%d", filter.Action)
+	}
+	if filter.WorkflowID != "" {
+		addCondition("workflow_id = $%d", filter.WorkflowID)
+	}
+	if filter.Since != nil {
+		addCondition("created_at >= $%d", *filter.Since)
+	}
+	if filter.Until != nil {
+		addCondition("created_at < $%d", *filter.Until)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+	direction := "DESC"
+	if filter.Ascending {
+		direction = "ASC"
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM audit_events
+		%s
+		ORDER BY created_at %s, event_id %s
+		LIMIT $%d OFFSET $%d
+	`, auditColumns, where, direction, direction, len(args)-1, len(args))
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []models.AuditEvent{}
+	for rows.Next() {
+		var e models.AuditEvent
+		var changes, details []byte
+		err := rows.Scan(
+			&e.EventID,
+			&e.Action,
+			&e.ActorDID,
+			&e.ProjectID,
+			&e.WorkflowID,
+			&changes,
+			&details,
+			&e.SourceIP,
+			&e.UserAgent,
+			&e.RequestID,
+			&e.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(changes, &e.Changes); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(details, &e.Details); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
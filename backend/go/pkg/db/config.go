@@ -0,0 +1,314 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Default Supabase pooler, used when only SUPABASE_URL is configured
+const (
+	DefaultSupabasePoolerHost = "aws-1-ap-south-1.pooler.supabase.com"
+	DefaultSupabasePoolerPort = 6543
+)
+
+// Config describes how to reach the database and how to size the pool.
+// The connection is taken from URL, or else built from the discrete
+// Host/Port/User/Password/Name settings.
+type Config struct {
+	URL      string // full DSN, postgres:// URL or key=value form
+	Host     string
+	Port     int
+	User     string
+	Password string
+	Name     string
+	SSLMode  string            // empty keeps the driver default (require)
+	Params   map[string]string // extra connection parameters
+
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration // 0 keeps connections forever
+	ConnMaxIdleTime time.Duration // 0 keeps idle connections forever
+
+	ConnectTimeout time.Duration // bound of a single connection attempt
+	ConnectRetries int           // extra attempts after the first failed ping
+	RetryBackoff   time.Duration // delay before the first retry, doubled each time
+	MaxBackoff     time.Duration // upper bound of the retry delay
+}
+
+// DefaultConfig returns the pool and retry defaults, sized for Lambda
+func DefaultConfig() Config {
+	return Config{
+		Port:           5432,
+		User:           "postgres",
+		Name:           "postgres",
+		MaxOpenConns:   10,
+		MaxIdleConns:   5,
+		ConnectTimeout: 10 * time.Second,
+		ConnectRetries: 3,
+		RetryBackoff:   500 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+	}
+}
+
+// SupabaseConfig returns the config of the Supabase transaction pooler of
+// the project at supabaseURL (https://<ref>.supabase.co)
+func SupabaseConfig(supabaseURL, password string) (Config, error) {
+	projectRef := extractProjectRef(supabaseURL)
+	if projectRef == "" {
+		return Config{}, fmt.Errorf("invalid SUPABASE_URL format: %s", supabaseURL)
+	}
+
+	cfg := DefaultConfig()
+	cfg.Host = DefaultSupabasePoolerHost
+	cfg.Port = DefaultSupabasePoolerPort
+	cfg.User = "postgres." + projectRef
+	cfg.Password = password
+	cfg.SSLMode = "require"
+	// The pooler runs in transaction mode and cannot keep prepared statements
+	cfg.Params = map[string]string{"prefer_simple_protocol": "yes"}
+	return cfg, nil
+}
+
+// ConfigFromEnv reads the database settings. The connection is taken from the
+// first of these that is set:
+//
+//   - DATABASE_URL
+//   - DB_HOST with DB_PORT, DB_USER, DB_PASSWORD, DB_NAME and DB_SSLMODE
+//   - SUPABASE_URL with DB_PASSWORD, through SUPABASE_POOLER_HOST and
+//     SUPABASE_POOLER_PORT
+//
+// DB_MAX_OPEN_CONNS, DB_MAX_IDLE_CONNS, DB_CONN_MAX_LIFETIME,
+// DB_CONN_MAX_IDLE_TIME, DB_CONNECT_TIMEOUT, DB_CONNECT_RETRIES and
+// DB_RETRY_BACKOFF override the pool and retry defaults.
+func ConfigFromEnv() (Config, error) {
+	var cfg Config
+	switch {
+	case os.Getenv("DATABASE_URL") != "":
+		cfg = DefaultConfig()
+		cfg.URL = os.Getenv("DATABASE_URL")
+	case os.Getenv("DB_HOST") != "":
+		cfg = DefaultConfig()
+		cfg.Host = os.Getenv("DB_HOST")
+		cfg.Password = os.Getenv("DB_PASSWORD")
+		cfg.SSLMode = os.Getenv("DB_SSLMODE")
+		if v := os.Getenv("DB_USER"); v != "" {
+			cfg.User = v
+		}
+		if v := os.Getenv("DB_NAME"); v != "" {
+			cfg.Name = v
+		}
+		if err := envInt("DB_PORT", &cfg.Port); err != nil {
+			return Config{}, err
+		}
+	case os.Getenv("SUPABASE_URL") != "":
+		var err error
+		cfg, err = SupabaseConfig(os.Getenv("SUPABASE_URL"), os.Getenv("DB_PASSWORD"))
+		if err != nil {
+			return Config{}, err
+		}
+		if v := os.Getenv("SUPABASE_POOLER_HOST"); v != "" {
+			cfg.Host = v
+		}
+		if err := envInt("SUPABASE_POOLER_PORT", &cfg.Port); err != nil {
+			return Config{}, err
+		}
+	default:
+		return Config{}, errors.New("database is not configured, set DATABASE_URL, DB_HOST or SUPABASE_URL")
+	}
+
+	for _, err := range []error{
+		envInt("DB_MAX_OPEN_CONNS", &cfg.MaxOpenConns),
+		envInt("DB_MAX_IDLE_CONNS", &cfg.MaxIdleConns),
+		envDuration("DB_CONN_MAX_LIFETIME", &cfg.ConnMaxLifetime),
+		envDuration("DB_CONN_MAX_IDLE_TIME", &cfg.ConnMaxIdleTime),
+		envDuration("DB_CONNECT_TIMEOUT", &cfg.ConnectTimeout),
+		envInt("DB_CONNECT_RETRIES", &cfg.ConnectRetries),
+		envDuration("DB_RETRY_BACKOFF", &cfg.RetryBackoff),
+	} {
+		if err != nil {
+			return Config{}, err
+		}
+	}
+	return cfg, nil
+}
+
+// DSN returns the connection string passed to the driver
+func (c Config) DSN() (string, error) {
+	params := map[string]string{}
+	for k, v := range c.Params {
+		params[k] = v
+	}
+	if c.SSLMode != "" {
+		params["sslmode"] = c.SSLMode
+	}
+	if c.ConnectTimeout > 0 {
+		// The driver counts whole seconds
+		seconds := int((c.ConnectTimeout + time.Second - 1) / time.Second)
+		params["connect_timeout"] = strconv.Itoa(seconds)
+	}
+
+	if c.URL != "" {
+		return withParams(c.URL, params)
+	}
+	if c.Host == "" {
+		return "", errors.New("database host is not configured")
+	}
+
+	dsn := url.URL{
+		Scheme: "postgresql",
+		User:   url.UserPassword(c.User, c.Password),
+		Host:   fmt.Sprintf("%s:%d", c.Host, c.Port),
+		Path:   "/" + c.Name,
+	}
+	query := url.Values{}
+	for k, v := range params {
+		query.Set(k, v)
+	}
+	dsn.RawQuery = query.Encode()
+	return dsn.String(), nil
+}
+
+// withParams adds params to dsn unless it already sets them
+func withParams(dsn string, params map[string]string) (string, error) {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return "", errors.New("invalid DATABASE_URL")
+		}
+		query := u.Query()
+		for k, v := range params {
+			if query.Get(k) == "" {
+				query.Set(k, v)
+			}
+		}
+		u.RawQuery = query.Encode()
+		return u.String(), nil
+	}
+
+	// key=value form
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if !strings.Contains(dsn, k+"=") {
+			dsn += fmt.Sprintf(" %s=%s", k, params[k])
+		}
+	}
+	return dsn, nil
+}
+
+// Open connects with cfg. The first ping is retried with exponential backoff
+// so a database that is still waking up does not fail a cold start; errors
+// that cannot go away by waiting, such as a wrong password, fail at once.
+func Open(ctx context.Context, cfg Config) (*sql.DB, error) {
+	dsn, err := cfg.DSN()
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+	backoff := cfg.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		err = ping(ctx, db, cfg.ConnectTimeout)
+		if err == nil {
+			return db, nil
+		}
+		if attempt >= cfg.ConnectRetries || isPermanent(err) {
+			break
+		}
+
+		log.Printf("Database not reachable (attempt %d/%d), retrying in %s: %v", attempt+1, cfg.ConnectRetries+1, backoff, err)
+		select {
+		case <-ctx.Done():
+			db.Close()
+			return nil, fmt.Errorf("failed to ping database: %w", ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if cfg.MaxBackoff > 0 && backoff > cfg.MaxBackoff {
+			backoff = cfg.MaxBackoff
+		}
+	}
+
+	db.Close()
+	return nil, fmt.Errorf("failed to ping database: %w", err)
+}
+
+// ConnectFromEnv connects with the settings of ConfigFromEnv
+func ConnectFromEnv() (*sql.DB, error) {
+	cfg, err := ConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return Open(context.Background(), cfg)
+}
+
+func ping(ctx context.Context, db *sql.DB, timeout time.Duration) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return db.PingContext(ctx)
+}
+
+// isPermanent reports whether err is a server answer that retrying cannot
+// change: invalid credentials or an unknown database
+func isPermanent(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	switch pqErr.Code.Class() {
+	case "28", "3D": // invalid_authorization_specification, invalid_catalog_name
+		return true
+	}
+	return false
+}
+
+func envInt(name string, target *int) error {
+	v := os.Getenv(name)
+	if v == "" {
+		return nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return fmt.Errorf("invalid %s: %q", name, v)
+	}
+	*target = n
+	return nil
+}
+
+func envDuration(name string, target *time.Duration) error {
+	v := os.Getenv(name)
+	if v == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		return fmt.Errorf("invalid %s: %q", name, v)
+	}
+	*target = d
+	return nil
+}
@@ -0,0 +1,85 @@
+package db
+
+import (
+	"database/sql"
+	"strconv"
+
+	"github.com/xzero/ai-workflow/pkg/models"
+)
+
+// staleEmbedding matches workflows whose embedding is missing, was computed
+// from an older content_version or by another model than $1
+const staleEmbedding = `(
+	embedding IS NULL
+	OR embedding_version IS DISTINCT FROM content_version::text
+	OR embedding_model IS DISTINCT FROM $1
+)`
+
+// ListStaleEmbeddings returns up to limit workflows that need a new embedding of model
+func ListStaleEmbeddings(db *sql.DB, model string, limit int) ([]models.EmbeddingSource, error) {
+	query := `
+		SELECT workflow_id, workflow_name, description, parameters, input_schema, content_version
+		FROM workflows
+		WHERE deleted_at IS NULL AND ` + staleEmbedding + `
+		ORDER BY updated_at
+		LIMIT $2
+	`
+
+	rows, err := db.Query(query, model, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sources := []models.EmbeddingSource{}
+	for rows.Next() {
+		var s models.EmbeddingSource
+		if err := rows.Scan(&s.WorkflowID, &s.WorkflowName, &s.Description, &s.Parameters, &s.InputSchema, &s.ContentVersion); err != nil {
+			return nil, err
+		}
+		sources = append(sources, s)
+	}
+
+	return sources, rows.Err()
+}
+
+// GetStaleEmbedding returns the content of a workflow if its embedding of
+// model is stale, sql.ErrNoRows otherwise
+func GetStaleEmbedding(db *sql.DB, workflowID, model string) (*models.EmbeddingSource, error) {
+	query := `
+		SELECT workflow_id, workflow_name, description, parameters, input_schema, content_version
+		FROM workflows
+		WHERE workflow_id = $2 AND ` + staleEmbedding
+
+	var s models.EmbeddingSource
+	err := db.QueryRow(query, model, workflowID).Scan(&s.WorkflowID, &s.WorkflowName, &s.Description, &s.Parameters, &s.InputSchema, &s.ContentVersion)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// SetEmbedding stores the embedding of a workflow computed from
+// contentVersion. It reports false when the content changed in the
+// meantime, leaving the workflow stale for the next refresh.
+func SetEmbedding(db *sql.DB, workflowID, vector, model string, contentVersion int) (bool, error) {
+	query := `
+		UPDATE workflows SET embedding = $1::vector, embedding_model = $2, embedding_version = $3
+		WHERE workflow_id = $4 AND content_version = $5
+	`
+	result, err := db.Exec(query, vector, model, strconv.Itoa(contentVersion), workflowID, contentVersion)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// ResetEmbeddings marks every embedding stale so the next refresh recomputes all of them
+func ResetEmbeddings(db *sql.DB) (int64, error) {
+	result, err := db.Exec(`UPDATE workflows SET embedding_version = NULL WHERE embedding_version IS NOT NULL`)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
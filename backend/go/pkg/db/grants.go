@@ -0,0 +1,69 @@
+package db
+
+import (
+	"database/sql"
+
+	"github.com/xzero/ai-workflow/pkg/models"
+)
+
+// GetWorkflowRole returns the role granted to a user on a workflow, empty
+// when there is no grant
+func GetWorkflowRole(db *sql.DB, userDID, workflowID string) (string, error) {
+	var role string
+	query := `SELECT role FROM workflow_grants WHERE workflow_id = $1 AND user_did = $2`
+	err := db.QueryRow(query, workflowID, userDID).Scan(&role)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return role, err
+}
+
+// ListWorkflowGrants returns the grants of a workflow, oldest first
+func ListWorkflowGrants(db *sql.DB, workflowID string) ([]models.WorkflowGrant, error) {
+	query := `
+		SELECT workflow_id, user_did, role, granted_by, created_at
+		FROM workflow_grants
+		WHERE workflow_id = $1
+		ORDER BY created_at, user_did
+	`
+	rows, err := db.Query(query, workflowID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	grants := []models.WorkflowGrant{}
+	for rows.Next() {
+		var g models.WorkflowGrant
+		if err := rows.Scan(&g.WorkflowID, &g.UserDID, &g.Role, &g.GrantedBy, &g.CreatedAt); err != nil {
+			return nil, err
+		}
+		grants = append(grants, g)
+	}
+	return grants, rows.Err()
+}
+
+// SetWorkflowGrant creates or replaces the grant of a user on a workflow and
+// fills in its creation time
+func SetWorkflowGrant(db *sql.DB, grant *models.WorkflowGrant) error {
+	query := `
+		INSERT INTO workflow_grants (workflow_id, user_did, role, granted_by)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (workflow_id, user_did)
+		DO UPDATE SET role = $3, granted_by = $4, created_at = CURRENT_TIMESTAMP
+		RETURNING created_at
+	`
+	return db.QueryRow(query, grant.WorkflowID, grant.UserDID, grant.Role, grant.GrantedBy).Scan(&grant.CreatedAt)
+}
+
+// DeleteWorkflowGrant removes the grant of a user on a workflow and reports
+// whether there was one
+func DeleteWorkflowGrant(db *sql.DB, workflowID, userDID string) (bool, error) {
+	query := `DELETE FROM workflow_grants WHERE workflow_id = $1 AND user_did = $2`
+	result, err := db.Exec(query, workflowID, userDID)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
@@ -0,0 +1,272 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/xzero/ai-workflow/pkg/models"
+)
+
+// CreateRun records a workflow execution and returns its run_id
+func CreateRun(db *sql.DB, run *models.WorkflowRun) (string, error) {
+	query := `
+		INSERT INTO workflow_runs (
+			workflow_id, project_id, caller_did, mode, status,
+			parameters, status_code, latency_ms, response_body,
+			response_truncated, error, created_at, finished_at,
+			request_headers, workflow_version
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		RETURNING run_id
+	`
+
+	parameters := run.Parameters
+	if parameters == nil {
+		parameters = []byte("{}")
+	}
+
+	var runID string
+	err := db.QueryRow(
+		query,
+		run.WorkflowID,
+		run.ProjectID,
+		run.CallerDID,
+		run.Mode,
+		run.Status,
+		parameters,
+		nullInt(run.StatusCode),
+		run.LatencyMS,
+		nullString(run.ResponseBody),
+		run.ResponseTruncated,
+		nullString(run.Error),
+		run.CreatedAt,
+		run.FinishedAt,
+		nullJSON(run.RequestHeaders),
+		nullInt(run.WorkflowVersion),
+	).Scan(&runID)
+
+	return runID, err
+}
+
+// GetRun returns a single workflow run including its response body
+func GetRun(db *sql.DB, runID string) (*models.WorkflowRun, error) {
+	query := `
+		SELECT ` + runColumns + `, COALESCE(response_body, '')
+		FROM workflow_runs
+		WHERE run_id = $1
+	`
+
+	var run models.WorkflowRun
+	dest := append(runDest(&run), &run.ResponseBody)
+	if err := db.QueryRow(query, runID).Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	return &run, nil
+}
+
+// ListRuns returns workflow runs matching filter, newest first.
+// Response bodies are omitted; use GetRun for the full detail.
+func ListRuns(db *sql.DB, filter models.ListRunsFilter) ([]models.WorkflowRun, error) {
+	var conditions []string
+	var args []interface{}
+	addCondition := func(clause string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(clause, len(args)))
+	}
+
+	if filter.WorkflowID != "" {
+		addCondition("workflow_id = $%d", filter.WorkflowID)
+	}
+	if filter.ProjectID != "" {
+		addCondition("project_id = $%d", filter.ProjectID)
+	}
+	if filter.CallerDID != "" {
+		addCondition("caller_did = $%d", filter.CallerDID)
+	}
+	if filter.Status != "" {
+		addCondition("status = $%d", filter.Status)
+	}
+	if filter.Since != nil {
+		addCondition("created_at >= $%d", *filter.Since)
+	}
+	if filter.Until != nil {
+		addCondition("created_at < $%d", *filter.Until)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM workflow_runs
+		%s
+		ORDER BY created_at DESC, run_id DESC
+		LIMIT $%d OFFSET $%d
+	`, runColumns, where, len(args)-1, len(args))
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := []models.WorkflowRun{}
+	for rows.Next() {
+		var run models.WorkflowRun
+		if err := rows.Scan(runDest(&run)...); err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+
+	return runs, rows.Err()
+}
+
+// ClaimRun marks the oldest queued run as running and returns it.
+// It returns sql.ErrNoRows when the queue is empty. SKIP LOCKED lets
+// several workers claim runs concurrently without blocking each other.
+func ClaimRun(db *sql.DB) (*models.WorkflowRun, error) {
+	query := `
+		UPDATE workflow_runs SET status = 'running', started_at = NOW()
+		WHERE run_id = (
+			SELECT run_id FROM workflow_runs
+			WHERE status = 'queued'
+			ORDER BY created_at
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING ` + runColumns + `, request_headers
+	`
+
+	// request_headers is NULL when the caller sent no header overrides;
+	// database/sql only maps NULL to nil for a plain []byte destination
+	var run models.WorkflowRun
+	var requestHeaders []byte
+	dest := append(runDest(&run), &requestHeaders)
+	if err := db.QueryRow(query).Scan(dest...); err != nil {
+		return nil, err
+	}
+	run.RequestHeaders = requestHeaders
+
+	return &run, nil
+}
+
+// FinishRun stores the result of a running run. A run that was cancelled
+// in the meantime keeps its cancelled status.
+func FinishRun(db *sql.DB, run *models.WorkflowRun) error {
+	query := `
+		UPDATE workflow_runs SET
+			status = $1, status_code = $2, latency_ms = $3, response_body = $4,
+			response_truncated = $5, error = $6, finished_at = $7,
+			workflow_version = COALESCE($8, workflow_version)
+		WHERE run_id = $9 AND status = 'running'
+	`
+	_, err := db.Exec(
+		query,
+		run.Status,
+		nullInt(run.StatusCode),
+		run.LatencyMS,
+		nullString(run.ResponseBody),
+		run.ResponseTruncated,
+		nullString(run.Error),
+		run.FinishedAt,
+		nullInt(run.WorkflowVersion),
+		run.RunID,
+	)
+	return err
+}
+
+// SetRunExternalID stores the upstream execution id of an async run
+func SetRunExternalID(db *sql.DB, runID, externalID string) error {
+	query := `UPDATE workflow_runs SET external_execute_id = $1 WHERE run_id = $2`
+	_, err := db.Exec(query, externalID, runID)
+	return err
+}
+
+// GetRunStatus returns the current status of a run
+func GetRunStatus(db *sql.DB, runID string) (string, error) {
+	var status string
+	err := db.QueryRow(`SELECT status FROM workflow_runs WHERE run_id = $1`, runID).Scan(&status)
+	return status, err
+}
+
+// CancelRun cancels a queued or running run and reports whether it was cancelled
+func CancelRun(db *sql.DB, runID string) (bool, error) {
+	query := `
+		UPDATE workflow_runs SET status = 'cancelled', error = 'cancelled by user', finished_at = NOW()
+		WHERE run_id = $1 AND status IN ('queued', 'running')
+	`
+	result, err := db.Exec(query, runID)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// FailStaleRuns fails runs that have been running longer than timeout,
+// e.g. because their worker was killed
+func FailStaleRuns(db *sql.DB, timeout time.Duration) (int64, error) {
+	query := `
+		UPDATE workflow_runs SET status = 'failed', error = 'run timed out', finished_at = NOW()
+		WHERE status = 'running' AND started_at < $1
+	`
+	result, err := db.Exec(query, time.Now().Add(-timeout))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// runColumns lists the workflow_runs columns scanned by runDest
+const runColumns = `
+	run_id, workflow_id, project_id, caller_did, mode, status,
+	parameters, COALESCE(external_execute_id, ''), COALESCE(status_code, 0),
+	COALESCE(latency_ms, 0), response_truncated, COALESCE(error, ''),
+	COALESCE(workflow_version, 0), created_at, started_at, finished_at
+`
+
+// runDest returns the scan destinations matching runColumns
+func runDest(run *models.WorkflowRun) []interface{} {
+	return []interface{}{
+		&run.RunID,
+		&run.WorkflowID,
+		&run.ProjectID,
+		&run.CallerDID,
+		&run.Mode,
+		&run.Status,
+		&run.Parameters,
+		&run.ExternalExecuteID,
+		&run.StatusCode,
+		&run.LatencyMS,
+		&run.ResponseTruncated,
+		&run.Error,
+		&run.WorkflowVersion,
+		&run.CreatedAt,
+		&run.StartedAt,
+		&run.FinishedAt,
+	}
+}
+
+// nullString maps an empty string to NULL
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// nullJSON maps an empty JSON value to NULL
+func nullJSON(b []byte) interface{} {
+	if len(b) == 0 {
+		return nil
+	}
+	return b
+}
+
+// nullInt maps zero to NULL
+func nullInt(i int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(i), Valid: i != 0}
+}
@@ -0,0 +1,98 @@
+package db
+
+import (
+	"database/sql"
+
+	"github.com/lib/pq"
+	"github.com/xzero/ai-workflow/pkg/models"
+)
+
+// SearchWorkflowsVector calls search_workflows_vector with a query vector in
+// pgvector text form, computed by model
+func SearchWorkflowsVector(db *sql.DB, vector, model, projectID string, topK int, threshold float64, filters models.SearchFilters) ([]models.SearchWorkflowResult, error) {
+	query := `
+		SELECT workflow_id, workflow_name, description, source, template_name, tags, similarity
+		FROM search_workflows_vector($1::vector, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+
+	args := append([]interface{}{vector, projectID, topK, threshold}, filterArgs(filters)...)
+	args = append(args, model)
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []models.SearchWorkflowResult{}
+	for rows.Next() {
+		var r models.SearchWorkflowResult
+		if err := rows.Scan(&r.WorkflowID, &r.WorkflowName, &r.Description, &r.Source, &r.TemplateName, pq.Array(&r.Tags), &r.Similarity); err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+	}
+
+	return results, rows.Err()
+}
+
+// SearchWorkflowsFulltext calls search_workflows_fulltext
+func SearchWorkflowsFulltext(db *sql.DB, text, projectID string, topK int, filters models.SearchFilters) ([]models.SearchWorkflowResult, error) {
+	query := `
+		SELECT workflow_id, workflow_name, description, source, template_name, tags, relevance
+		FROM search_workflows_fulltext($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	args := append([]interface{}{text, projectID, topK}, filterArgs(filters)...)
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []models.SearchWorkflowResult{}
+	for rows.Next() {
+		var r models.SearchWorkflowResult
+		if err := rows.Scan(&r.WorkflowID, &r.WorkflowName, &r.Description, &r.Source, &r.TemplateName, pq.Array(&r.Tags), &r.Relevance); err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+	}
+
+	return results, rows.Err()
+}
+
+// filterArgs returns the filter parameters of the search functions, NULL for
+// every filter that is not set
+func filterArgs(filters models.SearchFilters) []interface{} {
+	return []interface{}{
+		textArray(filters.Sources),
+		textArray(filters.TemplateNames),
+		textArray(filters.Tags),
+		nullString(filters.CreatorDID),
+	}
+}
+
+// textArray maps an empty list to NULL
+func textArray(values []string) interface{} {
+	if len(values) == 0 {
+		return nil
+	}
+	return pq.Array(values)
+}
+
+// HasEmbeddings reports whether any workflow searchable from projectID has
+// an embedding of model
+func HasEmbeddings(db *sql.DB, projectID, model string) (bool, error) {
+	var exists bool
+	query := `
+		SELECT EXISTS(
+			SELECT 1 FROM workflows
+			WHERE (project_id = $1 OR is_shared = true OR workflow_share_access(workflow_id, $1) IS NOT NULL)
+			AND deleted_at IS NULL
+			AND embedding IS NOT NULL
+			AND embedding_model = $2
+		)
+	`
+	err := db.QueryRow(query, projectID, model).Scan(&exists)
+	return exists, err
+}
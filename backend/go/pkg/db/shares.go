@@ -0,0 +1,85 @@
+package db
+
+import (
+	"database/sql"
+
+	"github.com/xzero/ai-workflow/pkg/models"
+)
+
+// GetProjectShareAccess returns the access a project has to a workflow
+// through workflow_shares, directly or through its organization, empty when
+// the workflow is not shared with it
+func GetProjectShareAccess(db *sql.DB, projectID, workflowID string) (string, error) {
+	var access sql.NullString
+	query := `SELECT workflow_share_access($1, $2)`
+	err := db.QueryRow(query, workflowID, projectID).Scan(&access)
+	return access.String, err
+}
+
+// GetShareAccess returns the best access a user has to a workflow through
+// the projects they belong to. Viewers of a project only get read access.
+func GetShareAccess(db *sql.DB, userDID, workflowID string) (string, error) {
+	var access string
+	query := `
+		SELECT CASE WHEN up.role = 'viewer' THEN 'read' ELSE s.access END AS access
+		FROM user_projects up
+		CROSS JOIN LATERAL (SELECT workflow_share_access($1, up.project_id::uuid) AS access) s
+		WHERE up.user_did = $2 AND s.access IS NOT NULL
+		ORDER BY access = 'execute' DESC
+		LIMIT 1
+	`
+	err := db.QueryRow(query, workflowID, userDID).Scan(&access)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return access, err
+}
+
+// ListWorkflowShares returns the shares of a workflow, oldest first
+func ListWorkflowShares(db *sql.DB, workflowID string) ([]models.WorkflowShare, error) {
+	query := `
+		SELECT workflow_id, target_type, target_id, access, shared_by, created_at
+		FROM workflow_shares
+		WHERE workflow_id = $1
+		ORDER BY created_at, target_type, target_id
+	`
+	rows, err := db.Query(query, workflowID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	shares := []models.WorkflowShare{}
+	for rows.Next() {
+		var s models.WorkflowShare
+		if err := rows.Scan(&s.WorkflowID, &s.TargetType, &s.TargetID, &s.Access, &s.SharedBy, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		shares = append(shares, s)
+	}
+	return shares, rows.Err()
+}
+
+// SetWorkflowShare creates or replaces a share and fills in its creation time
+func SetWorkflowShare(db *sql.DB, share *models.WorkflowShare) error {
+	query := `
+		INSERT INTO workflow_shares (workflow_id, target_type, target_id, access, shared_by)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (workflow_id, target_type, target_id)
+		DO UPDATE SET access = $4, shared_by = $5, created_at = CURRENT_TIMESTAMP
+		RETURNING created_at
+	`
+	return db.QueryRow(query, share.WorkflowID, share.TargetType, share.TargetID, share.Access, share.SharedBy).Scan(&share.CreatedAt)
+}
+
+// DeleteWorkflowShare revokes the share of a workflow with one target and
+// reports whether there was one
+func DeleteWorkflowShare(db *sql.DB, workflowID, targetType, targetID string) (bool, error) {
+	query := `DELETE FROM workflow_shares WHERE workflow_id = $1 AND target_type = $2 AND target_id = $3`
+	result, err := db.Exec(query, workflowID, targetType, targetID)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
@@ -0,0 +1,177 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/xzero/ai-workflow/pkg/models"
+)
+
+// ListDeletedWorkflows returns the workflows of a project in the trash, most
+// recently deleted first. Bearer tokens are omitted.
+func ListDeletedWorkflows(db *sql.DB, projectID string, limit, offset int) ([]models.Workflow, error) {
+	query := `
+		SELECT
+			workflow_id, workflow_name, description, source, template_name,
+			http_method, base_url, external_workflow_id, parameters, headers, input_schema,
+			project_id, creator_did, is_shared, tags, created_at, updated_at,
+			deleted_at, COALESCE(deleted_by, '')
+		FROM workflows
+		WHERE project_id = $1 AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC, workflow_id
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := db.Query(query, projectID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	workflows := []models.Workflow{}
+	for rows.Next() {
+		var w models.Workflow
+		err := rows.Scan(
+			&w.WorkflowID,
+			&w.WorkflowName,
+			&w.Description,
+			&w.Source,
+			&w.TemplateName,
+			&w.HTTPMethod,
+			&w.BaseURL,
+			&w.ExternalWorkflowID,
+			&w.Parameters,
+			&w.Headers,
+			&w.InputSchema,
+			&w.ProjectID,
+			&w.CreatorDID,
+			&w.IsShared,
+			pq.Array(&w.Tags),
+			&w.CreatedAt,
+			&w.UpdatedAt,
+			&w.DeletedAt,
+			&w.DeletedBy,
+		)
+		if err != nil {
+			return nil, err
+		}
+		workflows = append(workflows, w)
+	}
+	return workflows, rows.Err()
+}
+
+// RestoreWorkflow takes a workflow out of the trash. It returns
+// sql.ErrNoRows when the workflow is not in the trash.
+func RestoreWorkflow(db *sql.DB, workflowID string) error {
+	query := `
+		UPDATE workflows SET deleted_at = NULL, deleted_by = NULL
+		WHERE workflow_id = $1 AND deleted_at IS NOT NULL
+	`
+	result, err := db.Exec(query, workflowID)
+	if err != nil {
+		return err
+	}
+	return expectRow(result)
+}
+
+// PurgeDeletedWorkflows deletes for good up to limit workflows that were
+// moved to the trash before cutoff, with their versions, grants, shares and
+// project settings, and returns them. Runs and audit events are kept.
+func PurgeDeletedWorkflows(db *sql.DB, cutoff time.Time, limit int) ([]models.Workflow, error) {
+	query := `
+		DELETE FROM workflows
+		WHERE workflow_id IN (
+			SELECT workflow_id FROM workflows
+			WHERE deleted_at < $1
+			ORDER BY deleted_at
+			LIMIT $2
+		)
+		RETURNING workflow_id, workflow_name, project_id, deleted_at, COALESCE(deleted_by, '')
+	`
+	rows, err := db.Query(query, cutoff, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	purged := []models.Workflow{}
+	for rows.Next() {
+		var w models.Workflow
+		if err := rows.Scan(&w.WorkflowID, &w.WorkflowName, &w.ProjectID, &w.DeletedAt, &w.DeletedBy); err != nil {
+			return nil, err
+		}
+		purged = append(purged, w)
+	}
+	return purged, rows.Err()
+}
+
+// GetWorkflowUsage returns what uses a workflow outside its own project: its
+// shares, the other projects with their own settings for it, and the other
+// projects that recorded runs of it since since
+func GetWorkflowUsage(db *sql.DB, workflowID string, since time.Time) (*models.WorkflowUsage, error) {
+	usage := &models.WorkflowUsage{
+		SharedProjectIDs:      []string{},
+		SharedOrganizationIDs: []string{},
+		SettingsProjectIDs:    []string{},
+		RunProjectIDs:         []string{},
+	}
+
+	err := db.QueryRow(`SELECT is_shared FROM workflows WHERE workflow_id = $1`, workflowID).Scan(&usage.IsShared)
+	if err != nil {
+		return nil, err
+	}
+
+	shares, err := ListWorkflowShares(db, workflowID)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range shares {
+		if s.TargetType == models.ShareTargetOrganization {
+			usage.SharedOrganizationIDs = append(usage.SharedOrganizationIDs, s.TargetID)
+		} else {
+			usage.SharedProjectIDs = append(usage.SharedProjectIDs, s.TargetID)
+		}
+	}
+
+	settingsQuery := `
+		SELECT s.project_id
+		FROM project_workflow_settings s
+		JOIN workflows w ON w.workflow_id = s.workflow_id
+		WHERE s.workflow_id = $1 AND s.project_id <> w.project_id
+		ORDER BY s.project_id
+	`
+	if usage.SettingsProjectIDs, err = queryProjectIDs(db, settingsQuery, workflowID); err != nil {
+		return nil, err
+	}
+
+	runsQuery := `
+		SELECT DISTINCT r.project_id
+		FROM workflow_runs r
+		JOIN workflows w ON w.workflow_id = r.workflow_id
+		WHERE r.workflow_id = $1 AND r.project_id <> w.project_id AND r.created_at >= $2
+		ORDER BY r.project_id
+	`
+	if usage.RunProjectIDs, err = queryProjectIDs(db, runsQuery, workflowID, since); err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
+
+// queryProjectIDs returns the project IDs selected by query
+func queryProjectIDs(db *sql.DB, query string, args ...interface{}) ([]string, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	projectIDs := []string{}
+	for rows.Next() {
+		var projectID string
+		if err := rows.Scan(&projectID); err != nil {
+			return nil, err
+		}
+		projectIDs = append(projectIDs, projectID)
+	}
+	return projectIDs, rows.Err()
+}
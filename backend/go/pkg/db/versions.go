@@ -0,0 +1,252 @@
+package db
+
+import (
+	"database/sql"
+
+	"github.com/lib/pq"
+	"github.com/xzero/ai-workflow/pkg/models"
+)
+
+// versionColumns lists the workflow_versions columns scanned by versionDest
+const versionColumns = `
+	workflow_id, version, workflow_name, description, source, template_name,
+	http_method, base_url, bearer_token, external_workflow_id,
+	parameters, headers, input_schema, change_type, changed_fields,
+	changed_by, COALESCE(rolled_back_from, 0), created_at
+`
+
+// versionDest returns the scan destinations matching versionColumns
+func versionDest(v *models.WorkflowVersion) []interface{} {
+	return []interface{}{
+		&v.WorkflowID,
+		&v.Version,
+		&v.WorkflowName,
+		&v.Description,
+		&v.Source,
+		&v.TemplateName,
+		&v.HTTPMethod,
+		&v.BaseURL,
+		&v.BearerToken,
+		&v.ExternalWorkflowID,
+		&v.Parameters,
+		&v.Headers,
+		&v.InputSchema,
+		&v.ChangeType,
+		pq.Array(&v.ChangedFields),
+		&v.ChangedBy,
+		&v.RolledBackFrom,
+		&v.CreatedAt,
+	}
+}
+
+// LockWorkflowDefinition locks the workflow row for the rest of tx and returns
+// its current definition. Version is the latest recorded version, 0 if the
+// workflow has no history yet.
+func LockWorkflowDefinition(tx *sql.Tx, workflowID string) (*models.WorkflowVersion, error) {
+	query := `
+		SELECT
+			workflow_id, workflow_name, description, source, template_name,
+			http_method, base_url, bearer_token, external_workflow_id,
+			parameters, headers, input_schema,
+			COALESCE((SELECT MAX(version) FROM workflow_versions v WHERE v.workflow_id = w.workflow_id), 0)
+		FROM workflows w
+		WHERE workflow_id = $1 AND deleted_at IS NULL
+		FOR UPDATE
+	`
+
+	var v models.WorkflowVersion
+	err := tx.QueryRow(query, workflowID).Scan(
+		&v.WorkflowID,
+		&v.WorkflowName,
+		&v.Description,
+		&v.Source,
+		&v.TemplateName,
+		&v.HTTPMethod,
+		&v.BaseURL,
+		&v.BearerToken,
+		&v.ExternalWorkflowID,
+		&v.Parameters,
+		&v.Headers,
+		&v.InputSchema,
+		&v.Version,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// SaveVersion records the current definition of a workflow as its next
+// version. previous is the definition returned by LockWorkflowDefinition
+// before the change, or nil for a new workflow. It returns nil when the
+// definition did not change.
+func SaveVersion(tx *sql.Tx, workflowID string, previous *models.WorkflowVersion, changedBy, changeType string, rolledBackFrom int) (*models.WorkflowVersion, error) {
+	current, err := LockWorkflowDefinition(tx, workflowID)
+	if err != nil {
+		return nil, err
+	}
+
+	current.Version = 1
+	current.ChangeType = changeType
+	current.ChangedBy = changedBy
+	current.RolledBackFrom = rolledBackFrom
+	current.ChangedFields = []string{}
+	if previous != nil {
+		for _, change := range previous.Diff(current) {
+			current.ChangedFields = append(current.ChangedFields, change.Field)
+		}
+		if len(current.ChangedFields) == 0 {
+			return nil, nil
+		}
+
+		if previous.Version == 0 {
+			// Workflow without history, keep the state before the change as version 1
+			previous.ChangeType = models.VersionChangeCreate
+			previous.ChangedBy = changedBy
+			previous.Version = 1
+			if err := insertVersion(tx, previous); err != nil {
+				return nil, err
+			}
+		}
+		current.Version = previous.Version + 1
+	}
+
+	if err := insertVersion(tx, current); err != nil {
+		return nil, err
+	}
+	return current, nil
+}
+
+func insertVersion(tx *sql.Tx, v *models.WorkflowVersion) error {
+	query := `
+		INSERT INTO workflow_versions (
+			workflow_id, version, workflow_name, description, source, template_name,
+			http_method, base_url, bearer_token, external_workflow_id,
+			parameters, headers, input_schema, change_type, changed_fields,
+			changed_by, rolled_back_from
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+		RETURNING created_at
+	`
+
+	changedFields := v.ChangedFields
+	if changedFields == nil {
+		changedFields = []string{}
+	}
+
+	return tx.QueryRow(
+		query,
+		v.WorkflowID,
+		v.Version,
+		v.WorkflowName,
+		v.Description,
+		v.Source,
+		v.TemplateName,
+		v.HTTPMethod,
+		v.BaseURL,
+		v.BearerToken,
+		v.ExternalWorkflowID,
+		jsonObject(v.Parameters),
+		jsonObject(v.Headers),
+		jsonObject(v.InputSchema),
+		v.ChangeType,
+		pq.Array(changedFields),
+		v.ChangedBy,
+		nullInt(v.RolledBackFrom),
+	).Scan(&v.CreatedAt)
+}
+
+// RestoreVersion overwrites the definition of a workflow with v
+func RestoreVersion(tx *sql.Tx, v *models.WorkflowVersion) error {
+	query := `
+		UPDATE workflows SET
+			workflow_name = $1, description = $2, source = $3, template_name = $4,
+			http_method = $5, base_url = $6, bearer_token = $7, external_workflow_id = $8,
+			parameters = $9, headers = $10, input_schema = $11, updated_at = NOW()
+		WHERE workflow_id = $12
+	`
+	_, err := tx.Exec(
+		query,
+		v.WorkflowName,
+		v.Description,
+		v.Source,
+		v.TemplateName,
+		v.HTTPMethod,
+		v.BaseURL,
+		v.BearerToken,
+		v.ExternalWorkflowID,
+		jsonObject(v.Parameters),
+		jsonObject(v.Headers),
+		jsonObject(v.InputSchema),
+		v.WorkflowID,
+	)
+	return err
+}
+
+// GetVersion returns one version of a workflow
+func GetVersion(db *sql.DB, workflowID string, version int) (*models.WorkflowVersion, error) {
+	query := `
+		SELECT ` + versionColumns + `
+		FROM workflow_versions
+		WHERE workflow_id = $1 AND version = $2
+	`
+
+	var v models.WorkflowVersion
+	if err := db.QueryRow(query, workflowID, version).Scan(versionDest(&v)...); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// GetLatestVersion returns the newest version of a workflow
+func GetLatestVersion(db *sql.DB, workflowID string) (*models.WorkflowVersion, error) {
+	query := `
+		SELECT ` + versionColumns + `
+		FROM workflow_versions
+		WHERE workflow_id = $1
+		ORDER BY version DESC
+		LIMIT 1
+	`
+
+	var v models.WorkflowVersion
+	if err := db.QueryRow(query, workflowID).Scan(versionDest(&v)...); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// ListVersions returns the versions of a workflow, newest first
+func ListVersions(db *sql.DB, workflowID string, limit, offset int) ([]models.WorkflowVersion, error) {
+	query := `
+		SELECT ` + versionColumns + `
+		FROM workflow_versions
+		WHERE workflow_id = $1
+		ORDER BY version DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := db.Query(query, workflowID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := []models.WorkflowVersion{}
+	for rows.Next() {
+		var v models.WorkflowVersion
+		if err := rows.Scan(versionDest(&v)...); err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+
+	return versions, rows.Err()
+}
+
+// jsonObject maps an empty JSON value to an empty object, so the column can
+// be scanned back into a json.RawMessage
+func jsonObject(b []byte) []byte {
+	if len(b) == 0 {
+		return []byte("{}")
+	}
+	return b
+}
@@ -0,0 +1,506 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/xzero/ai-workflow/pkg/models"
+)
+
+// cursorTimeFormat keeps the microseconds of a TIMESTAMP column
+const cursorTimeFormat = "2006-01-02 15:04:05.999999"
+
+// ErrRowVersion is returned by conditional writes when the workflow no
+// longer has the expected row_version
+var ErrRowVersion = errors.New("workflow was modified")
+
+// workflowSortColumns maps sort keys to the column and the type of its cursor value
+var workflowSortColumns = map[string][2]string{
+	models.WorkflowSortCreatedAt: {"w.created_at", "timestamp"},
+	models.WorkflowSortUpdatedAt: {"w.updated_at", "timestamp"},
+	models.WorkflowSortName:      {"w.workflow_name", "text"},
+}
+
+// GetWorkflow returns the full definition of a workflow, including its
+// encrypted bearer token and latest version. It returns sql.ErrNoRows when
+// the workflow does not exist or is in the trash.
+func GetWorkflow(db *sql.DB, workflowID string) (*models.Workflow, error) {
+	return getWorkflow(db, workflowID, "deleted_at IS NULL")
+}
+
+// GetDeletedWorkflow is GetWorkflow for a workflow in the trash
+func GetDeletedWorkflow(db *sql.DB, workflowID string) (*models.Workflow, error) {
+	return getWorkflow(db, workflowID, "deleted_at IS NOT NULL")
+}
+
+// getWorkflow returns the workflow with workflowID if it matches condition
+func getWorkflow(db *sql.DB, workflowID, condition string) (*models.Workflow, error) {
+	query := `
+		SELECT
+			workflow_id, workflow_name, description, source, template_name,
+			http_method, base_url, bearer_token, external_workflow_id,
+			parameters, headers, input_schema, project_id, creator_did, is_shared, tags,
+			created_at, updated_at, deleted_at, COALESCE(deleted_by, ''), row_version,
+			COALESCE((SELECT MAX(version) FROM workflow_versions v WHERE v.workflow_id = w.workflow_id), 0)
+		FROM workflows w
+		WHERE workflow_id = $1 AND ` + condition
+
+	var w models.Workflow
+	err := db.QueryRow(query, workflowID).Scan(
+		&w.WorkflowID,
+		&w.WorkflowName,
+		&w.Description,
+		&w.Source,
+		&w.TemplateName,
+		&w.HTTPMethod,
+		&w.BaseURL,
+		&w.BearerToken,
+		&w.ExternalWorkflowID,
+		&w.Parameters,
+		&w.Headers,
+		&w.InputSchema,
+		&w.ProjectID,
+		&w.CreatorDID,
+		&w.IsShared,
+		pq.Array(&w.Tags),
+		&w.CreatedAt,
+		&w.UpdatedAt,
+		&w.DeletedAt,
+		&w.DeletedBy,
+		&w.RowVersion,
+		&w.Version,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &w, nil
+}
+
+// CreateWorkflow inserts a workflow and records it as version 1
+func CreateWorkflow(database *sql.DB, req *models.CreateWorkflowRequest, creatorDID string) (string, error) {
+	tx, err := database.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO workflows (
+			workflow_name, description, source, template_name,
+			http_method, base_url, bearer_token, external_workflow_id,
+			parameters, headers, input_schema, project_id, creator_did, tags
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		RETURNING workflow_id
+	`
+
+	var workflowID string
+	err = tx.QueryRow(
+		query,
+		req.WorkflowName,
+		req.Description,
+		req.Source,
+		req.TemplateName,
+		req.HTTPMethod,
+		req.BaseURL,
+		req.BearerToken,
+		req.ExternalWorkflowID,
+		req.Parameters,
+		req.Headers,
+		req.InputSchema,
+		req.ProjectID,
+		creatorDID,
+		pq.Array(req.Tags),
+	).Scan(&workflowID)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := SaveVersion(tx, workflowID, nil, creatorDID, models.VersionChangeCreate, 0); err != nil {
+		return "", err
+	}
+
+	return workflowID, tx.Commit()
+}
+
+// UpdateWorkflow applies req and records the new version. The version is nil
+// when req did not change anything. A rowVersion other than 0 must match the
+// workflow's row_version, else ErrRowVersion is returned.
+func UpdateWorkflow(database *sql.DB, workflowID string, req *models.UpdateWorkflowRequest, changedBy string, rowVersion int) (*models.WorkflowVersion, error) {
+	// Build dynamic UPDATE query
+	var setClauses []string
+	var args []interface{}
+	argIndex := 1
+
+	if req.WorkflowName != nil {
+		setClauses = append(setClauses, fmt.Sprintf("workflow_name = $%d", argIndex))
+		args = append(args, *req.WorkflowName)
+		argIndex++
+	}
+	if req.Description != nil {
+		setClauses = append(setClauses, fmt.Sprintf("description = $%d", argIndex))
+		args = append(args, *req.Description)
+		argIndex++
+	}
+	if req.Source != nil {
+		setClauses = append(setClauses, fmt.Sprintf("source = $%d", argIndex))
+		args = append(args, *req.Source)
+		argIndex++
+	}
+	if req.TemplateName != nil {
+		setClauses = append(setClauses, fmt.Sprintf("template_name = $%d", argIndex))
+		args = append(args, *req.TemplateName)
+		argIndex++
+	}
+	if req.HTTPMethod != nil {
+		setClauses = append(setClauses, fmt.Sprintf("http_method = $%d", argIndex))
+		args = append(args, *req.HTTPMethod)
+		argIndex++
+	}
+	if req.BaseURL != nil {
+		setClauses = append(setClauses, fmt.Sprintf("base_url = $%d", argIndex))
+		args = append(args, *req.BaseURL)
+		argIndex++
+	}
+	if req.BearerToken != nil {
+		setClauses = append(setClauses, fmt.Sprintf("bearer_token = $%d", argIndex))
+		args = append(args, *req.BearerToken)
+		argIndex++
+	}
+	if req.ExternalWorkflowID != nil {
+		setClauses = append(setClauses, fmt.Sprintf("external_workflow_id = $%d", argIndex))
+		args = append(args, *req.ExternalWorkflowID)
+		argIndex++
+	}
+	if req.Parameters != nil {
+		setClauses = append(setClauses, fmt.Sprintf("parameters = $%d", argIndex))
+		args = append(args, *req.Parameters)
+		argIndex++
+	}
+	if req.Headers != nil {
+		setClauses = append(setClauses, fmt.Sprintf("headers = $%d", argIndex))
+		args = append(args, *req.Headers)
+		argIndex++
+	}
+	if req.InputSchema != nil {
+		setClauses = append(setClauses, fmt.Sprintf("input_schema = $%d", argIndex))
+		args = append(args, *req.InputSchema)
+		argIndex++
+	}
+	if req.Tags != nil {
+		setClauses = append(setClauses, fmt.Sprintf("tags = $%d", argIndex))
+		args = append(args, pq.Array(*req.Tags))
+		argIndex++
+	}
+
+	if len(setClauses) == 0 {
+		return nil, nil // Nothing to update
+	}
+
+	// Add updated_at timestamp
+	setClauses = append(setClauses, fmt.Sprintf("updated_at = NOW()"))
+
+	// Add workflow_id to args
+	args = append(args, workflowID)
+
+	query := fmt.Sprintf("UPDATE workflows SET %s WHERE workflow_id = $%d",
+		strings.Join(setClauses, ", "), argIndex)
+
+	tx, err := database.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Lock the row so concurrent updates get consecutive versions
+	if err := lockRowVersion(tx, workflowID, rowVersion); err != nil {
+		return nil, err
+	}
+	previous, err := LockWorkflowDefinition(tx, workflowID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return nil, err
+	}
+	version, err := SaveVersion(tx, workflowID, previous, changedBy, models.VersionChangeUpdate, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return version, tx.Commit()
+}
+
+// RollbackWorkflow restores target and records it as a new version. The
+// version is nil when the workflow already matched target. It returns
+// ErrRowVersion when rowVersion is not 0 and differs from its row_version.
+func RollbackWorkflow(database *sql.DB, target *models.WorkflowVersion, changedBy string, rowVersion int) (*models.WorkflowVersion, error) {
+	tx, err := database.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := lockRowVersion(tx, target.WorkflowID, rowVersion); err != nil {
+		return nil, err
+	}
+	previous, err := LockWorkflowDefinition(tx, target.WorkflowID)
+	if err != nil {
+		return nil, err
+	}
+	if err := RestoreVersion(tx, target); err != nil {
+		return nil, err
+	}
+	saved, err := SaveVersion(tx, target.WorkflowID, previous, changedBy, models.VersionChangeRollback, target.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	return saved, tx.Commit()
+}
+
+// DeleteWorkflow moves a workflow to the trash, keeping its versions, grants,
+// shares and project settings until it is purged. It returns sql.ErrNoRows
+// when the workflow does not exist or is already in the trash, and
+// ErrRowVersion when rowVersion is not 0 and differs from its row_version.
+func DeleteWorkflow(database *sql.DB, workflowID, deletedBy string, rowVersion int) error {
+	query := `
+		UPDATE workflows SET deleted_at = CURRENT_TIMESTAMP, deleted_by = $2
+		WHERE workflow_id = $1 AND deleted_at IS NULL AND ($3 = 0 OR row_version = $3)
+	`
+	result, err := database.Exec(query, workflowID, deletedBy, rowVersion)
+	if err != nil {
+		return err
+	}
+	return conditionalWrite(database, result, workflowID)
+}
+
+// SetWorkflowShared makes a workflow visible to every project, or only to its
+// own and those it was shared with in workflow_shares. rowVersion is checked
+// like in DeleteWorkflow.
+func SetWorkflowShared(database *sql.DB, workflowID string, isShared bool, rowVersion int) error {
+	query := `
+		UPDATE workflows SET is_shared = $1
+		WHERE workflow_id = $2 AND deleted_at IS NULL AND ($3 = 0 OR row_version = $3)
+	`
+	result, err := database.Exec(query, isShared, workflowID, rowVersion)
+	if err != nil {
+		return err
+	}
+	return conditionalWrite(database, result, workflowID)
+}
+
+// lockRowVersion locks the workflow row for the rest of tx and checks that it
+// still has rowVersion; 0 skips the check
+func lockRowVersion(tx *sql.Tx, workflowID string, rowVersion int) error {
+	if rowVersion == 0 {
+		return nil
+	}
+	var current int
+	query := `SELECT row_version FROM workflows WHERE workflow_id = $1 AND deleted_at IS NULL FOR UPDATE`
+	if err := tx.QueryRow(query, workflowID).Scan(&current); err != nil {
+		return err
+	}
+	if current != rowVersion {
+		return ErrRowVersion
+	}
+	return nil
+}
+
+// conditionalWrite explains a write to a workflow that matched no row:
+// ErrRowVersion when the workflow exists, so its row_version differed, and
+// sql.ErrNoRows when it does not
+func conditionalWrite(database *sql.DB, result sql.Result, workflowID string) error {
+	if err := expectRow(result); err != sql.ErrNoRows {
+		return err
+	}
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM workflows WHERE workflow_id = $1 AND deleted_at IS NULL)`
+	if err := database.QueryRow(query, workflowID).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return ErrRowVersion
+	}
+	return sql.ErrNoRows
+}
+
+// SetWorkflowHidden hides or shows a workflow in the list of one project
+func SetWorkflowHidden(database *sql.DB, projectID, workflowID string, isHidden bool) error {
+	// Use UPSERT to insert or update the hide status
+	query := `
+		INSERT INTO project_workflow_settings (project_id, workflow_id, is_hidden)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (project_id, workflow_id)
+		DO UPDATE SET is_hidden = $3, updated_at = CURRENT_TIMESTAMP
+	`
+	_, err := database.Exec(query, projectID, workflowID, isHidden)
+	return err
+}
+
+// sharedWith matches workflows shared with every project or with project $1
+const sharedWith = "(w.is_shared = true OR workflow_share_access(w.workflow_id, $1) IS NOT NULL)"
+
+// expectRow returns sql.ErrNoRows when result affected no row
+func expectRow(result sql.Result) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// IsWorkflowSort reports whether sort is a supported sort key
+func IsWorkflowSort(sort string) bool {
+	_, ok := workflowSortColumns[sort]
+	return ok
+}
+
+// ListWorkflows returns the workflows visible from filter.ProjectID in the
+// order of filter.Sort, starting after filter.After. Bearer tokens are
+// omitted.
+func ListWorkflows(db *sql.DB, filter models.ListWorkflowsFilter) ([]models.Workflow, error) {
+	sort, ok := workflowSortColumns[filter.Sort]
+	if !ok {
+		return nil, fmt.Errorf("unsupported sort: %q", filter.Sort)
+	}
+
+	args := []interface{}{filter.ProjectID}
+	conditions := []string{"w.deleted_at IS NULL"}
+	addCondition := func(clause string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(clause, len(args)))
+	}
+
+	switch filter.Scope {
+	case models.WorkflowScopeOwn:
+		conditions = append(conditions, "w.project_id = $1")
+	case models.WorkflowScopeShared:
+		conditions = append(conditions, "w.project_id <> $1 AND "+sharedWith)
+	default:
+		conditions = append(conditions, "(w.project_id = $1 OR "+sharedWith+")")
+	}
+	if !filter.IncludeHidden {
+		conditions = append(conditions, "(pws.is_hidden IS NULL OR pws.is_hidden = false)")
+	}
+	if filter.Source != "" {
+		addCondition("w.source = $%d", filter.Source)
+	}
+	if filter.TemplateName != "" {
+		addCondition("w.template_name = $%d", filter.TemplateName)
+	}
+	if filter.CreatorDID != "" {
+		addCondition("w.creator_did = $%d", filter.CreatorDID)
+	}
+	if filter.Tag != "" {
+		addCondition("$%d = ANY(w.tags)", filter.Tag)
+	}
+	if filter.Query != "" {
+		addCondition("(w.workflow_name ILIKE $%[1]d OR w.description ILIKE $%[1]d)", "%"+escapeLike(filter.Query)+"%")
+	}
+
+	direction, comparison := "ASC", ">"
+	if filter.Descending {
+		direction, comparison = "DESC", "<"
+	}
+	if filter.After != nil {
+		args = append(args, filter.After.Value, filter.After.WorkflowID)
+		conditions = append(conditions, fmt.Sprintf("(%s, w.workflow_id) %s ($%d::%s, $%d::uuid)",
+			sort[0], comparison, len(args)-1, sort[1], len(args)))
+	}
+
+	args = append(args, filter.Limit)
+	query := fmt.Sprintf(`
+		SELECT
+			w.workflow_id,
+			w.workflow_name,
+			w.description,
+			w.source,
+			w.template_name,
+			w.http_method,
+			w.base_url,
+			w.external_workflow_id,
+			w.parameters,
+			w.headers,
+			w.input_schema,
+			w.project_id,
+			w.creator_did,
+			w.is_shared,
+			w.tags,
+			COALESCE(pws.is_hidden, false),
+			w.row_version,
+			w.created_at,
+			w.updated_at
+		FROM workflows w
+		LEFT JOIN project_workflow_settings pws
+			ON w.workflow_id = pws.workflow_id
+			AND pws.project_id = $1
+		WHERE %s
+		ORDER BY %s %s, w.workflow_id %s
+		LIMIT $%d
+	`, strings.Join(conditions, " AND "), sort[0], direction, direction, len(args))
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	workflows := []models.Workflow{}
+	for rows.Next() {
+		var w models.Workflow
+		err := rows.Scan(
+			&w.WorkflowID,
+			&w.WorkflowName,
+			&w.Description,
+			&w.Source,
+			&w.TemplateName,
+			&w.HTTPMethod,
+			&w.BaseURL,
+			&w.ExternalWorkflowID,
+			&w.Parameters,
+			&w.Headers,
+			&w.InputSchema,
+			&w.ProjectID,
+			&w.CreatorDID,
+			&w.IsShared,
+			pq.Array(&w.Tags),
+			&w.IsHidden,
+			&w.RowVersion,
+			&w.CreatedAt,
+			&w.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		workflows = append(workflows, w)
+	}
+
+	return workflows, rows.Err()
+}
+
+// WorkflowCursorOf returns the position of w in a list sorted by sort
+func WorkflowCursorOf(w *models.Workflow, sort string, descending bool) models.WorkflowCursor {
+	cursor := models.WorkflowCursor{Sort: sort, Descending: descending, WorkflowID: w.WorkflowID}
+	switch sort {
+	case models.WorkflowSortCreatedAt:
+		cursor.Value = w.CreatedAt.Format(cursorTimeFormat)
+	case models.WorkflowSortUpdatedAt:
+		cursor.Value = w.UpdatedAt.Format(cursorTimeFormat)
+	case models.WorkflowSortName:
+		cursor.Value = w.WorkflowName
+	}
+	return cursor
+}
+
+// escapeLike escapes the LIKE wildcards of s
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
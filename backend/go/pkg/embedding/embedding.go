@@ -0,0 +1,93 @@
+// Package embedding turns text into vectors for semantic workflow search.
+package embedding
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Dimensions of the workflows.embedding column
+const Dimensions = 1536
+
+// Provider embeds texts. Implementations must return one vector of
+// Dimensions() values per text, in input order.
+type Provider interface {
+	// Model identifies the vectors; vectors of different models are not comparable
+	Model() string
+	Dimensions() int
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// ProviderFromEnv returns the provider selected by EMBEDDING_PROVIDER:
+//
+//   - openai: the OpenAI compatible API at OPENAI_BASE_URL with OPENAI_API_KEY
+//   - local: the deterministic LocalProvider, for development and tests
+//   - none: no provider
+//
+// When EMBEDDING_PROVIDER is unset, openai is used if OPENAI_API_KEY is set.
+// EMBEDDING_MODEL and EMBEDDING_DIMENSIONS override the model defaults.
+// It returns nil when no provider is configured.
+func ProviderFromEnv() (Provider, error) {
+	name := strings.ToLower(os.Getenv("EMBEDDING_PROVIDER"))
+	if name == "" && os.Getenv("OPENAI_API_KEY") != "" {
+		name = "openai"
+	}
+
+	dimensions := Dimensions
+	if v := os.Getenv("EMBEDDING_DIMENSIONS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid EMBEDDING_DIMENSIONS: %q", v)
+		}
+		dimensions = n
+	}
+
+	switch name {
+	case "", "none":
+		return nil, nil
+	case "openai":
+		apiKey := os.Getenv("OPENAI_API_KEY")
+		if apiKey == "" {
+			return nil, fmt.Errorf("OPENAI_API_KEY must be set for the openai embedding provider")
+		}
+		return NewOpenAIProvider(OpenAIConfig{
+			APIKey:     apiKey,
+			BaseURL:    os.Getenv("OPENAI_BASE_URL"),
+			Model:      os.Getenv("EMBEDDING_MODEL"),
+			Dimensions: dimensions,
+		}), nil
+	case "local":
+		return NewLocalProvider(dimensions), nil
+	default:
+		return nil, fmt.Errorf("unknown EMBEDDING_PROVIDER: %q", name)
+	}
+}
+
+// EmbedOne embeds a single text
+func EmbedOne(ctx context.Context, provider Provider, text string) ([]float32, error) {
+	vectors, err := provider.Embed(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	if len(vectors) != 1 {
+		return nil, fmt.Errorf("embedding provider returned %d vectors for 1 text", len(vectors))
+	}
+	return vectors[0], nil
+}
+
+// FormatVector returns v in the text form of the pgvector type, e.g. [0.1,0.2]
+func FormatVector(v []float32) string {
+	var b strings.Builder
+	b.WriteByte('[')
+	for i, f := range v {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.FormatFloat(float64(f), 'f', -1, 32))
+	}
+	b.WriteByte(']')
+	return b.String()
+}
@@ -0,0 +1,103 @@
+package embedding
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// LocalProvider derives vectors from hashed words, without any network call.
+// The same text always yields the same vector and texts sharing words are
+// similar, which is enough for development and tests but not for semantic
+// search in production.
+type LocalProvider struct {
+	dimensions int
+}
+
+// NewLocalProvider returns a LocalProvider with the given number of dimensions
+func NewLocalProvider(dimensions int) *LocalProvider {
+	if dimensions < 1 {
+		dimensions = Dimensions
+	}
+	return &LocalProvider{dimensions: dimensions}
+}
+
+// Model identifies the local hashing scheme
+func (p *LocalProvider) Model() string {
+	return "local-hash-v1"
+}
+
+// Dimensions returns the vector length
+func (p *LocalProvider) Dimensions() int {
+	return p.dimensions
+}
+
+// Embed returns one normalized vector per text
+func (p *LocalProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i] = p.embed(text)
+	}
+	return vectors, nil
+}
+
+func (p *LocalProvider) embed(text string) []float32 {
+	vector := make([]float32, p.dimensions)
+	for _, token := range tokenize(text) {
+		h := fnv.New64a()
+		h.Write([]byte(token))
+		sum := h.Sum64()
+		// The top bit picks the sign so unrelated tokens cancel out on average
+		sign := float32(1)
+		if sum>>63 == 1 {
+			sign = -1
+		}
+		vector[sum%uint64(p.dimensions)] += sign
+	}
+
+	var norm float64
+	for _, f := range vector {
+		norm += float64(f) * float64(f)
+	}
+	if norm == 0 {
+		return vector
+	}
+	scale := float32(1 / math.Sqrt(norm))
+	for i := range vector {
+		vector[i] *= scale
+	}
+	return vector
+}
+
+// tokenize splits text into lower-case words. Han, Hiragana, Katakana and
+// Hangul characters are tokens of their own since these scripts do not
+// separate words with spaces.
+func tokenize(text string) []string {
+	var tokens []string
+	var word strings.Builder
+	flush := func() {
+		if word.Len() > 0 {
+			tokens = append(tokens, word.String())
+			word.Reset()
+		}
+	}
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case isCJK(r):
+			flush()
+			tokens = append(tokens, string(r))
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word.WriteRune(r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return tokens
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
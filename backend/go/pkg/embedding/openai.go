@@ -0,0 +1,129 @@
+package embedding
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// OpenAI defaults
+const (
+	DefaultOpenAIBaseURL = "https://api.openai.com/v1"
+	DefaultOpenAIModel   = "text-embedding-3-small"
+)
+
+// OpenAIConfig configures an OpenAIProvider
+type OpenAIConfig struct {
+	APIKey     string
+	BaseURL    string // any OpenAI compatible API, defaults to DefaultOpenAIBaseURL
+	Model      string // defaults to DefaultOpenAIModel
+	Dimensions int    // defaults to Dimensions
+}
+
+// OpenAIProvider embeds texts through the OpenAI embeddings API
+type OpenAIProvider struct {
+	config OpenAIConfig
+	client *http.Client
+}
+
+// NewOpenAIProvider returns an OpenAIProvider, filling in the config defaults
+func NewOpenAIProvider(config OpenAIConfig) *OpenAIProvider {
+	if config.BaseURL == "" {
+		config.BaseURL = DefaultOpenAIBaseURL
+	}
+	config.BaseURL = strings.TrimRight(config.BaseURL, "/")
+	if config.Model == "" {
+		config.Model = DefaultOpenAIModel
+	}
+	if config.Dimensions < 1 {
+		config.Dimensions = Dimensions
+	}
+	return &OpenAIProvider{
+		config: config,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Model returns the embedding model
+func (p *OpenAIProvider) Model() string {
+	return p.config.Model
+}
+
+// Dimensions returns the vector length requested from the API
+func (p *OpenAIProvider) Dimensions() int {
+	return p.config.Dimensions
+}
+
+type openAIEmbeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Embed calls the embeddings API with all texts in one request
+func (p *OpenAIProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"model":      p.config.Model,
+		"input":      texts,
+		"dimensions": p.config.Dimensions,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.config.BaseURL+"/embeddings", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+p.config.APIKey)
+
+	httpResp, err := p.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	respBody, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var result openAIEmbeddingResponse
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("embedding API returned %s", httpResp.Status)
+	}
+	if httpResp.StatusCode >= 400 {
+		if result.Error != nil && result.Error.Message != "" {
+			return nil, fmt.Errorf("embedding API returned %s: %s", httpResp.Status, result.Error.Message)
+		}
+		return nil, fmt.Errorf("embedding API returned %s", httpResp.Status)
+	}
+	if len(result.Data) != len(texts) {
+		return nil, fmt.Errorf("embedding API returned %d vectors for %d texts", len(result.Data), len(texts))
+	}
+
+	sort.Slice(result.Data, func(i, j int) bool { return result.Data[i].Index < result.Data[j].Index })
+	vectors := make([][]float32, len(result.Data))
+	for i, item := range result.Data {
+		if len(item.Embedding) != p.config.Dimensions {
+			return nil, fmt.Errorf("embedding API returned %d dimensions, expected %d", len(item.Embedding), p.config.Dimensions)
+		}
+		vectors[i] = item.Embedding
+	}
+	return vectors, nil
+}
@@ -0,0 +1,53 @@
+package llm
+
+import (
+	"context"
+	"sync"
+)
+
+// Fake is a Provider without network calls. It replies with the scripted
+// Replies in order, then with Reply, and records every request. An empty
+// Fake answers "{}".
+type Fake struct {
+	mu       sync.Mutex
+	replies  []string
+	requests []Request
+
+	// Reply computes the reply once the scripted replies are used up
+	Reply func(req Request) (string, error)
+}
+
+// NewFake returns a Fake that answers with replies in order
+func NewFake(replies ...string) *Fake {
+	return &Fake{replies: replies}
+}
+
+// Model identifies the fake
+func (f *Fake) Model() string {
+	return "fake"
+}
+
+// Complete records req and returns the next reply
+func (f *Fake) Complete(ctx context.Context, req Request) (string, error) {
+	f.mu.Lock()
+	f.requests = append(f.requests, req)
+	if len(f.replies) > 0 {
+		reply := f.replies[0]
+		f.replies = f.replies[1:]
+		f.mu.Unlock()
+		return reply, nil
+	}
+	f.mu.Unlock()
+
+	if f.Reply != nil {
+		return f.Reply(req)
+	}
+	return "{}", nil
+}
+
+// Requests returns the requests received so far
+func (f *Fake) Requests() []Request {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Request(nil), f.requests...)
+}
@@ -0,0 +1,71 @@
+// Package llm calls large language models through a small provider interface.
+package llm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Message roles
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// Message is one chat message
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Request is a chat completion request
+type Request struct {
+	Messages    []Message
+	JSON        bool // ask for a single JSON object as the reply
+	MaxTokens   int  // 0 keeps the provider default
+	Temperature float64
+}
+
+// Provider completes chat conversations
+type Provider interface {
+	Model() string
+	Complete(ctx context.Context, req Request) (string, error)
+}
+
+// ProviderFromEnv returns the provider selected by LLM_PROVIDER:
+//
+//   - openai: the OpenAI compatible chat API at OPENAI_BASE_URL with OPENAI_API_KEY
+//   - fake: a Fake without scripted replies, for development and tests
+//   - none: no provider
+//
+// When LLM_PROVIDER is unset, openai is used if OPENAI_API_KEY is set.
+// LLM_MODEL overrides the default model. It returns nil when no provider is
+// configured.
+func ProviderFromEnv() (Provider, error) {
+	name := strings.ToLower(os.Getenv("LLM_PROVIDER"))
+	if name == "" && os.Getenv("OPENAI_API_KEY") != "" {
+		name = "openai"
+	}
+
+	switch name {
+	case "", "none":
+		return nil, nil
+	case "openai":
+		apiKey := os.Getenv("OPENAI_API_KEY")
+		if apiKey == "" {
+			return nil, fmt.Errorf("OPENAI_API_KEY must be set for the openai LLM provider")
+		}
+		return NewOpenAIProvider(OpenAIConfig{
+			APIKey:  apiKey,
+			BaseURL: os.Getenv("OPENAI_BASE_URL"),
+			Model:   os.Getenv("LLM_MODEL"),
+		}), nil
+	case "fake":
+		return NewFake(), nil
+	default:
+		return nil, fmt.Errorf("unknown LLM_PROVIDER: %q", name)
+	}
+}
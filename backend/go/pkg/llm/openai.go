@@ -0,0 +1,115 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// OpenAI defaults
+const (
+	DefaultOpenAIBaseURL = "https://api.openai.com/v1"
+	DefaultOpenAIModel   = "gpt-4o-mini"
+)
+
+// OpenAIConfig configures an OpenAIProvider
+type OpenAIConfig struct {
+	APIKey  string
+	BaseURL string // any OpenAI compatible API, defaults to DefaultOpenAIBaseURL
+	Model   string // defaults to DefaultOpenAIModel
+}
+
+// OpenAIProvider completes chats through the OpenAI chat completions API
+type OpenAIProvider struct {
+	config OpenAIConfig
+	client *http.Client
+}
+
+// NewOpenAIProvider returns an OpenAIProvider, filling in the config defaults
+func NewOpenAIProvider(config OpenAIConfig) *OpenAIProvider {
+	if config.BaseURL == "" {
+		config.BaseURL = DefaultOpenAIBaseURL
+	}
+	config.BaseURL = strings.TrimRight(config.BaseURL, "/")
+	if config.Model == "" {
+		config.Model = DefaultOpenAIModel
+	}
+	return &OpenAIProvider{
+		config: config,
+		client: &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// Model returns the chat model
+func (p *OpenAIProvider) Model() string {
+	return p.config.Model
+}
+
+type openAIChatResponse struct {
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Complete returns the content of the first choice
+func (p *OpenAIProvider) Complete(ctx context.Context, req Request) (string, error) {
+	payload := map[string]interface{}{
+		"model":       p.config.Model,
+		"messages":    req.Messages,
+		"temperature": req.Temperature,
+	}
+	if req.MaxTokens > 0 {
+		payload["max_tokens"] = req.MaxTokens
+	}
+	if req.JSON {
+		payload["response_format"] = map[string]string{"type": "json_object"}
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.config.BaseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+p.config.APIKey)
+
+	httpResp, err := p.client.Do(httpReq)
+	if err != nil {
+		return "", err
+	}
+	defer httpResp.Body.Close()
+
+	respBody, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	var result openAIChatResponse
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return "", fmt.Errorf("chat API returned %s", httpResp.Status)
+	}
+	if httpResp.StatusCode >= 400 {
+		if result.Error != nil && result.Error.Message != "" {
+			return "", fmt.Errorf("chat API returned %s: %s", httpResp.Status, result.Error.Message)
+		}
+		return "", fmt.Errorf("chat API returned %s", httpResp.Status)
+	}
+	if len(result.Choices) == 0 {
+		return "", fmt.Errorf("chat API returned no choices")
+	}
+	return result.Choices[0].Message.Content, nil
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/xzero/ai-workflow/pkg/models"
+	"github.com/xzero/ai-workflow/pkg/response"
+)
+
+const (
+	// workflowRoute starts the routes acting on one workflow
+	workflowRoute = "/api/workflows/{id}"
+	// runRoute is the route of one run
+	runRoute = "/api/runs/{runId}"
+)
+
+// RequiredScopes returns the API key scopes allowed to call request, any one
+// of them being enough, and none when API keys may not call it at all. Reads
+// need the read scope; executing a workflow and following or cancelling its
+// runs need the execute scope.
+func RequiredScopes(request events.APIGatewayProxyRequest) []string {
+	route := request.Resource
+	switch {
+	case request.HTTPMethod == http.MethodGet && route == runRoute:
+		return []string{models.APIKeyScopeRead, models.APIKeyScopeExecute}
+	case request.HTTPMethod == http.MethodGet:
+		return []string{models.APIKeyScopeRead}
+	case request.HTTPMethod == http.MethodPost && route == "/api/workflows/search":
+		return []string{models.APIKeyScopeRead}
+	case request.HTTPMethod == http.MethodPost && (route == workflowRoute+"/execute" || route == workflowRoute+"/stream"):
+		return []string{models.APIKeyScopeExecute}
+	case request.HTTPMethod == http.MethodDelete && route == runRoute:
+		return []string{models.APIKeyScopeExecute}
+	}
+	return nil
+}
+
+// AuthorizeKey checks that key was granted the scope of request and, when
+// it is limited to some workflows, that request acts on one of them. Run
+// routes pass, their handlers check the workflow of the run.
+func AuthorizeKey(key *models.APIKey, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, bool) {
+	allowed := false
+	for _, scope := range RequiredScopes(request) {
+		allowed = allowed || key.Allows(scope)
+	}
+	if !allowed {
+		return response.Forbidden("API key does not allow this operation"), false
+	}
+	if len(key.WorkflowIDs) == 0 {
+		return events.APIGatewayProxyResponse{}, true
+	}
+
+	switch {
+	case strings.HasPrefix(request.Resource, workflowRoute):
+		if !key.AllowsWorkflow(request.PathParameters["id"]) {
+			return response.Forbidden("API key does not allow this workflow"), false
+		}
+	case strings.HasPrefix(request.Resource, "/api/runs/"):
+	default:
+		// Lists and searches would reach other workflows of the project
+		return response.Forbidden("API key does not allow this operation"), false
+	}
+	return events.APIGatewayProxyResponse{}, true
+}
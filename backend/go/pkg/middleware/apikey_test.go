@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/xzero/ai-workflow/pkg/models"
+)
+
+func TestAuthorizeKey(t *testing.T) {
+	execute := &models.APIKey{Scopes: []string{models.APIKeyScopeExecute}}
+	read := &models.APIKey{Scopes: []string{models.APIKeyScopeRead}}
+	limited := &models.APIKey{Scopes: []string{models.APIKeyScopeRead, models.APIKeyScopeExecute}, WorkflowIDs: []string{"wf-1"}}
+
+	tests := []struct {
+		name     string
+		key      *models.APIKey
+		method   string
+		resource string
+		id       string
+		want     bool
+	}{
+		{"execute key executes", execute, http.MethodPost, "/api/workflows/{id}/execute", "wf-1", true},
+		{"execute key streams", execute, http.MethodPost, "/api/workflows/{id}/stream", "wf-1", true},
+		{"execute key polls a run", execute, http.MethodGet, "/api/runs/{runId}", "", true},
+		{"execute key cancels a run", execute, http.MethodDelete, "/api/runs/{runId}", "", true},
+		{"execute key reads a workflow", execute, http.MethodGet, "/api/workflows/{id}", "wf-1", false},
+		{"execute key lists runs", execute, http.MethodGet, "/api/workflows/{id}/runs", "wf-1", false},
+		{"execute key searches", execute, http.MethodPost, "/api/workflows/search", "", false},
+		{"read key reads a workflow", read, http.MethodGet, "/api/workflows/{id}", "wf-1", true},
+		{"read key polls a run", read, http.MethodGet, "/api/runs/{runId}", "", true},
+		{"read key searches", read, http.MethodPost, "/api/workflows/search", "", true},
+		{"read key executes", read, http.MethodPost, "/api/workflows/{id}/execute", "wf-1", false},
+		{"read key cancels a run", read, http.MethodDelete, "/api/runs/{runId}", "", false},
+		{"no key updates", limited, http.MethodPut, "/api/workflows/{id}", "wf-1", false},
+		{"no key creates keys", limited, http.MethodPost, "/api/projects/{projectId}/api-keys", "", false},
+		{"limited key on its workflow", limited, http.MethodGet, "/api/workflows/{id}", "wf-1", true},
+		{"limited key on another workflow", limited, http.MethodPost, "/api/workflows/{id}/execute", "wf-2", false},
+		{"limited key polls a run", limited, http.MethodGet, "/api/runs/{runId}", "", true},
+		{"limited key lists the project", limited, http.MethodGet, "/api/projects/{projectId}/workflows", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := events.APIGatewayProxyRequest{
+				HTTPMethod:     tt.method,
+				Resource:       tt.resource,
+				PathParameters: map[string]string{"id": tt.id},
+			}
+			resp, ok := AuthorizeKey(tt.key, request)
+			if ok != tt.want {
+				t.Errorf("AuthorizeKey = %v, want %v", ok, tt.want)
+			}
+			if !ok && resp.StatusCode != http.StatusForbidden {
+				t.Errorf("status = %d, want 403", resp.StatusCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/xzero/ai-workflow/pkg/auth"
+	"github.com/xzero/ai-workflow/pkg/response"
+)
+
+type claimsKey struct{}
+
+// Authenticate rejects requests without a valid bearer token and passes the
+// claims of the others to the handler, see ClaimsFrom. Requests made with an
+// API key must also pass AuthorizeKey.
+func Authenticate(verifier auth.Verifier) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+			claims, resp, ok := Verify(ctx, verifier, Header(request.Headers, "Authorization"))
+			if !ok {
+				return resp, nil
+			}
+			if claims.APIKey != nil {
+				if resp, ok := AuthorizeKey(claims.APIKey, request); !ok {
+					addToAccessLog(ctx, "user_did", claims.DID, "auth_error", "key_scope")
+					return resp, nil
+				}
+			}
+
+			ctx = WithClaims(ctx, claims)
+			ctx = WithLog(ctx, Log(ctx).With("user_did", claims.DID))
+			addToAccessLog(ctx, "user_did", claims.DID)
+			return next(ctx, request)
+		}
+	}
+}
+
+// Verify checks the bearer token of an Authorization header. When it is
+// rejected, ok is false and resp is the 401 to return, and the reason code is
+// added to the access log. Handlers outside the chain, such as the streaming
+// handler, call it directly.
+func Verify(ctx context.Context, verifier auth.Verifier, authHeader string) (claims *auth.Claims, resp events.APIGatewayProxyResponse, ok bool) {
+	token, err := auth.ExtractToken(authHeader)
+	if err != nil {
+		return nil, response.Unauthorized("Invalid authorization header"), false
+	}
+
+	claims, err = verifier.Verify(token)
+	if err != nil {
+		reason := auth.Reason(err)
+		addToAccessLog(ctx, "auth_error", reason)
+		message := "Invalid token"
+		if reason == auth.ReasonExpired {
+			message = "Token expired"
+		}
+		return nil, response.InvalidToken(message, reason), false
+	}
+
+	return claims, resp, true
+}
+
+// WithClaims returns ctx carrying the claims of the caller
+func WithClaims(ctx context.Context, claims *auth.Claims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+// ClaimsFrom returns the claims set by Authenticate
+func ClaimsFrom(ctx context.Context) (*auth.Claims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(*auth.Claims)
+	return claims, ok && claims != nil
+}
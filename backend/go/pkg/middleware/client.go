@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// ClientInfo tells where a request came from, as seen by API Gateway
+type ClientInfo struct {
+	SourceIP  string
+	UserAgent string
+}
+
+type clientKey struct{}
+
+// Client stores the source IP and user agent of the API Gateway request
+// context for ClientFrom
+func Client() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+			client := ClientInfo{
+				SourceIP:  request.RequestContext.Identity.SourceIP,
+				UserAgent: request.RequestContext.Identity.UserAgent,
+			}
+			return next(WithClient(ctx, client), request)
+		}
+	}
+}
+
+// WithClient returns ctx carrying client, for handlers outside the chain
+func WithClient(ctx context.Context, client ClientInfo) context.Context {
+	return context.WithValue(ctx, clientKey{}, client)
+}
+
+// ClientFrom returns the client stored by Client or WithClient
+func ClientFrom(ctx context.Context) ClientInfo {
+	client, _ := ctx.Value(clientKey{}).(ClientInfo)
+	return client
+}
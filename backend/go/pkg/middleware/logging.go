@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+type loggerKey struct{}
+
+type accessLogKey struct{}
+
+// accessLog collects attributes that inner middlewares add to the access log
+type accessLog struct {
+	attrs []any
+}
+
+// NewJSONLogger returns a logger writing one JSON object per line to stdout,
+// which CloudWatch Logs Insights parses into fields
+func NewJSONLogger() *slog.Logger {
+	return slog.New(slog.NewJSONHandler(os.Stdout, nil))
+}
+
+// Logger logs one line per request with its method, route, status and
+// latency. Handlers log through Log(ctx), which adds the request ID and,
+// once authenticated, the caller's DID.
+func Logger(logger *slog.Logger) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+			start := time.Now()
+			entry := &accessLog{}
+			ctx = context.WithValue(ctx, accessLogKey{}, entry)
+			ctx = WithLog(ctx, logger.With("request_id", RequestIDFrom(ctx)))
+
+			resp, err := next(ctx, request)
+
+			attrs := []any{
+				"method", request.HTTPMethod,
+				"path", request.Path,
+				"route", request.Resource,
+				"status", resp.StatusCode,
+				"latency_ms", time.Since(start).Milliseconds(),
+			}
+			attrs = append(attrs, entry.attrs...)
+			level := slog.LevelInfo
+			if err != nil {
+				attrs = append(attrs, "error", err.Error())
+				level = slog.LevelError
+			} else if resp.StatusCode >= 500 {
+				level = slog.LevelError
+			}
+			Log(ctx).Log(ctx, level, "request", attrs...)
+
+			return resp, err
+		}
+	}
+}
+
+// addToAccessLog adds attributes to the access log line of the request
+func addToAccessLog(ctx context.Context, attrs ...any) {
+	if entry, ok := ctx.Value(accessLogKey{}).(*accessLog); ok {
+		entry.attrs = append(entry.attrs, attrs...)
+	}
+}
+
+// WithLog returns ctx carrying logger
+func WithLog(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// Log returns the logger of the request, or the default logger outside of
+// Logger
+func Log(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
@@ -0,0 +1,48 @@
+// Package middleware wraps API Gateway proxy handlers with cross-cutting
+// behaviour: request IDs, structured access logs, panic recovery and
+// authentication.
+package middleware
+
+import (
+	"context"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// Handler handles an API Gateway proxy request
+type Handler func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error)
+
+// Middleware wraps a handler
+type Middleware func(next Handler) Handler
+
+// Chain wraps h with middlewares. The first middleware is the outermost, so
+// it sees the request first and the response last.
+func Chain(h Handler, middlewares ...Middleware) Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+	return h
+}
+
+// Header returns the value of a request header regardless of its case;
+// API Gateway keeps the case sent by the client
+func Header(headers map[string]string, name string) string {
+	if v, ok := headers[name]; ok {
+		return v
+	}
+	for k, v := range headers {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return ""
+}
+
+// setHeader sets a response header, allocating the map when needed
+func setHeader(resp *events.APIGatewayProxyResponse, name, value string) {
+	if resp.Headers == nil {
+		resp.Headers = map[string]string{}
+	}
+	resp.Headers[name] = value
+}
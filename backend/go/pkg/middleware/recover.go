@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/xzero/ai-workflow/pkg/response"
+)
+
+// Recover turns a panic in the handler into a 500 response instead of
+// crashing the Lambda runtime, and logs it with the stack trace
+func Recover() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (resp events.APIGatewayProxyResponse, err error) {
+			defer func() {
+				if p := recover(); p != nil {
+					Log(ctx).Error("Panic handling request", "panic", fmt.Sprint(p), "stack", string(debug.Stack()))
+					resp, err = response.InternalError("Internal server error"), nil
+				}
+			}()
+			return next(ctx, request)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// RequestIDHeader carries the request ID in requests and responses
+const RequestIDHeader = "X-Request-Id"
+
+// maxRequestIDLength bounds request IDs supplied by clients
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// RequestID assigns every request an ID and echoes it in the response. A
+// valid X-Request-Id sent by the client is kept, otherwise the API Gateway
+// request ID is used, or a random one when there is none.
+func RequestID() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+			id := Header(request.Headers, RequestIDHeader)
+			if !validRequestID(id) {
+				id = request.RequestContext.RequestID
+			}
+			if id == "" {
+				id = newRequestID()
+			}
+
+			resp, err := next(WithRequestID(ctx, id), request)
+			setHeader(&resp, RequestIDHeader, id)
+			return resp, err
+		}
+	}
+}
+
+// WithRequestID returns ctx carrying id, for handlers outside the chain
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFrom returns the ID assigned by RequestID, or "" outside of it
+func RequestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// validRequestID accepts printable ASCII without spaces, so client IDs
+// cannot break log lines or response headers
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,101 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// API key scopes
+const (
+	APIKeyScopeExecute = "execute" // execute workflows and follow or cancel their runs
+	APIKeyScopeRead    = "read"    // read workflows, versions and runs
+)
+
+// APIKeyPrincipalPrefix starts the caller DID of requests authenticated with
+// an API key, followed by the key ID
+const APIKeyPrincipalPrefix = "apikey:"
+
+// APIKey is a project-scoped credential for machine-to-machine calls. Only
+// the SHA-256 hash of the key is stored; Prefix identifies it in listings.
+type APIKey struct {
+	KeyID       string     `json:"key_id"`
+	ProjectID   string     `json:"project_id"`
+	Name        string     `json:"name"`
+	Prefix      string     `json:"prefix"`
+	KeyHash     string     `json:"-"`
+	Scopes      []string   `json:"scopes"`
+	WorkflowIDs []string   `json:"workflow_ids"` // empty allows every workflow of the project
+	CreatedBy   string     `json:"created_by"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Principal returns the caller DID of requests made with the key
+func (k *APIKey) Principal() string {
+	return APIKeyPrincipalPrefix + k.KeyID
+}
+
+// IsExpired reports whether the key expired at now
+func (k *APIKey) IsExpired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
+
+// Allows reports whether the key was granted scope
+func (k *APIKey) Allows(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsWorkflow reports whether the key may be used on a workflow
+func (k *APIKey) AllowsWorkflow(workflowID string) bool {
+	if len(k.WorkflowIDs) == 0 {
+		return true
+	}
+	for _, id := range k.WorkflowIDs {
+		if id == workflowID {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKeyRole returns the project role of a key with scopes: runner with the
+// execute scope, viewer otherwise. The middleware keeps execute-only keys
+// from reading.
+func APIKeyRole(scopes []string) string {
+	for _, s := range scopes {
+		if s == APIKeyScopeExecute {
+			return RoleRunner
+		}
+	}
+	return RoleViewer
+}
+
+// APIKeyIDOf returns the key ID of an API key principal, false for a DID
+func APIKeyIDOf(principal string) (string, bool) {
+	if !strings.HasPrefix(principal, APIKeyPrincipalPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(principal, APIKeyPrincipalPrefix), true
+}
+
+// CreateAPIKeyRequest represents the request body for creating an API key
+type CreateAPIKeyRequest struct {
+	Name        string     `json:"name"`
+	Scopes      []string   `json:"scopes"`
+	WorkflowIDs []string   `json:"workflow_ids,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+// CreateAPIKeyResponse is a created key with its secret, which is only
+// returned once
+type CreateAPIKeyResponse struct {
+	*APIKey
+	Key string `json:"key"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import "github.com/xzero/ai-workflow/pkg/schema"
+
+// AssistantRequest represents a free-text instruction to run a workflow
+type AssistantRequest struct {
+	Instruction string        `json:"instruction"`
+	ProjectID   string        `json:"project_id"`
+	WorkflowID  string        `json:"workflow_id,omitempty"` // skips the search and fills this workflow
+	Execute     bool          `json:"execute"`               // run the proposal right away when its parameters are valid
+	TopK        int           `json:"top_k"`                 // number of candidate workflows, default 3
+	Filters     SearchFilters `json:"filters"`
+}
+
+// AssistantProposal is the execution proposed for an instruction. Its
+// parameters and version can be sent as is to the execute endpoint.
+type AssistantProposal struct {
+	WorkflowID   string                 `json:"workflow_id"`
+	WorkflowName string                 `json:"workflow_name"`
+	Version      int                    `json:"version,omitempty"`
+	Parameters   map[string]interface{} `json:"parameters"`
+	Explanation  string                 `json:"explanation,omitempty"`
+	Errors       []schema.FieldError    `json:"errors,omitempty"` // input schema violations of the parameters
+	Ready        bool                   `json:"ready"`            // the parameters pass the input schema
+}
+
+// AssistantResponse represents the outcome of an instruction
+type AssistantResponse struct {
+	Candidates   []SearchWorkflowResult   `json:"candidates"`
+	SearchMethod string                   `json:"search_method,omitempty"`
+	Proposal     *AssistantProposal       `json:"proposal"`
+	Executed     bool                     `json:"executed"`
+	Result       *ExecuteWorkflowResponse `json:"result,omitempty"`
+	Message      string                   `json:"message,omitempty"`
+}
@@ -0,0 +1,77 @@
+package models
+
+import "time"
+
+// Audit event actions
+const (
+	AuditWorkflowCreate   = "workflow.create"
+	AuditWorkflowUpdate   = "workflow.update"
+	AuditWorkflowRollback = "workflow.rollback"
+	AuditWorkflowDelete   = "workflow.delete"  // moved to the trash
+	AuditWorkflowRestore  = "workflow.restore" // restored from the trash
+	AuditWorkflowPurge    = "workflow.purge"   // deleted for good after the retention period
+	AuditWorkflowShare    = "workflow.share"   // is_shared changed or shares added
+	AuditWorkflowUnshare  = "workflow.unshare" // a share revoked
+	AuditWorkflowHide     = "workflow.hide"    // hidden or shown in a project
+	AuditWorkflowGrant    = "workflow.grant"   // grant set or removed
+	AuditWorkflowExecute  = "workflow.execute" // run recorded, in any mode
+	AuditWorkflowExport   = "workflow.export"  // definitions exported as a bundle
+	AuditAPIKeyCreate     = "api_key.create"
+	AuditAPIKeyRevoke     = "api_key.revoke"
+)
+
+// AuditSystemActor is the actor of events recorded by scheduled jobs
+const AuditSystemActor = "system"
+
+// auditActions are the valid values of AuditEvent.Action
+var auditActions = map[string]bool{
+	AuditWorkflowCreate:   true,
+	AuditWorkflowUpdate:   true,
+	AuditWorkflowRollback: true,
+	AuditWorkflowDelete:   true,
+	AuditWorkflowRestore:  true,
+	AuditWorkflowPurge:    true,
+	AuditWorkflowShare:    true,
+	AuditWorkflowUnshare:  true,
+	AuditWorkflowHide:     true,
+	AuditWorkflowGrant:    true,
+	AuditWorkflowExecute:  true,
+	AuditWorkflowExport:   true,
+	AuditAPIKeyCreate:     true,
+	AuditAPIKeyRevoke:     true,
+}
+
+// IsAuditAction reports whether action is a known audit action
+func IsAuditAction(action string) bool {
+	return auditActions[action]
+}
+
+// AuditEvent records one change to, or execution of, a workflow. Events are
+// never updated or deleted. Changes have secrets redacted.
+type AuditEvent struct {
+	EventID    string                 `json:"event_id"`
+	Action     string                 `json:"action"`
+	ActorDID   string                 `json:"actor_did"`
+	ProjectID  string                 `json:"project_id"`
+	WorkflowID string                 `json:"workflow_id,omitempty"`
+	Changes    []VersionChange        `json:"changes,omitempty"` // before and after of each changed field
+	Details    map[string]interface{} `json:"details,omitempty"` // action specific, such as the run of an execution
+	SourceIP   string                 `json:"source_ip,omitempty"`
+	UserAgent  string                 `json:"user_agent,omitempty"`
+	RequestID  string                 `json:"request_id,omitempty"`
+	CreatedAt  time.Time              `json:"created_at"`
+}
+
+// ListAuditEventsFilter represents the filters for listing audit events.
+// Events are listed newest first, or oldest first with Ascending.
+type ListAuditEventsFilter struct {
+	ProjectID  string
+	ActorDID   string
+	Action     string
+	WorkflowID string
+	Since      *time.Time
+	Until      *time.Time
+	Ascending  bool
+	Limit      int
+	Offset     int
+}
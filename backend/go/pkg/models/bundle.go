@@ -0,0 +1,86 @@
+package models
+
+import "time"
+
+// BundleFormat identifies workflow bundles. BundleVersion is bumped when
+// the format changes in a way older importers cannot read.
+const (
+	BundleFormat  = "ai-workflow-bundle"
+	BundleVersion = 1
+)
+
+// WorkflowBundle is a portable set of workflow definitions, written as JSON
+// or YAML. Secrets are replaced by placeholders such as ${NAME}, listed in
+// Secrets, and supplied again on import.
+type WorkflowBundle struct {
+	Format     string           `json:"format" yaml:"format"`
+	Version    int              `json:"version" yaml:"version"`
+	ExportedAt time.Time        `json:"exported_at" yaml:"exported_at"`
+	ProjectID  string           `json:"project_id,omitempty" yaml:"project_id,omitempty"` // exporting project, informative only
+	Secrets    []string         `json:"secrets,omitempty" yaml:"secrets,omitempty"`       // placeholder names used by the workflows
+	Workflows  []BundleWorkflow `json:"workflows" yaml:"workflows"`
+}
+
+// BundleWorkflow is the definition of one workflow in a bundle
+type BundleWorkflow struct {
+	WorkflowName       string            `json:"workflow_name" yaml:"workflow_name"`
+	Description        string            `json:"description" yaml:"description"`
+	Source             string            `json:"source" yaml:"source"`
+	TemplateName       string            `json:"template_name" yaml:"template_name"`
+	HTTPMethod         string            `json:"http_method" yaml:"http_method"`
+	BaseURL            string            `json:"base_url" yaml:"base_url"`
+	BearerToken        string            `json:"bearer_token" yaml:"bearer_token"` // a placeholder on export
+	ExternalWorkflowID string            `json:"external_workflow_id" yaml:"external_workflow_id"`
+	Parameters         interface{}       `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	Headers            map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"` // credentials are placeholders on export
+	InputSchema        interface{}       `json:"input_schema,omitempty" yaml:"input_schema,omitempty"`
+	Tags               []string          `json:"tags,omitempty" yaml:"tags,omitempty"`
+}
+
+// Import conflict strategies, applied when a workflow of the bundle has the
+// name of an existing workflow of the project
+const (
+	ImportSkip      = "skip"      // keep the existing workflow
+	ImportOverwrite = "overwrite" // update the existing workflow, as a new version
+	ImportRename    = "rename"    // create the workflow under a free name
+)
+
+// Import item statuses
+const (
+	ImportStatusCreated     = "created"
+	ImportStatusOverwritten = "overwritten"
+	ImportStatusRenamed     = "renamed"
+	ImportStatusSkipped     = "skipped"
+	ImportStatusFailed      = "failed"
+)
+
+// ImportWorkflowsRequest represents the request to import a bundle into a
+// project. It may be sent as JSON or YAML.
+type ImportWorkflowsRequest struct {
+	Bundle   *WorkflowBundle   `json:"bundle" yaml:"bundle"`
+	Strategy string            `json:"strategy" yaml:"strategy"` // skip (default), overwrite or rename
+	DryRun   bool              `json:"dry_run" yaml:"dry_run"`   // report what would happen without writing
+	Secrets  map[string]string `json:"secrets" yaml:"secrets"`   // placeholder name -> value
+}
+
+// ImportItemResult reports what happened to one workflow of the bundle
+type ImportItemResult struct {
+	Index          int      `json:"index"` // position in the bundle
+	WorkflowName   string   `json:"workflow_name"`
+	Status         string   `json:"status"`
+	WorkflowID     string   `json:"workflow_id,omitempty"`     // created or overwritten, or the existing one when skipped
+	ImportedAs     string   `json:"imported_as,omitempty"`     // the free name given by rename
+	MissingSecrets []string `json:"missing_secrets,omitempty"` // placeholders without a value; overwrites keep the stored ones
+	Error          string   `json:"error,omitempty"`
+}
+
+// ImportWorkflowsResponse is the report of an import, one item per workflow
+// of the bundle. With DryRun nothing was written and statuses tell what
+// would have happened.
+type ImportWorkflowsResponse struct {
+	ProjectID string             `json:"project_id"`
+	Strategy  string             `json:"strategy"`
+	DryRun    bool               `json:"dry_run"`
+	Summary   map[string]int     `json:"summary"` // status -> count
+	Items     []ImportItemResult `json:"items"`
+}
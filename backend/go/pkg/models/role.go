@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// Roles of a principal, in a project (user_projects.role) or on one workflow
+// (workflow_grants.role), from least to most privileged
+const (
+	RoleViewer = "viewer" // reads workflows and runs
+	RoleRunner = "runner" // also executes workflows
+	RoleMember = "member" // legacy project role: also creates workflows
+	RoleEditor = "editor" // also updates and rolls back every workflow
+	RoleAdmin  = "admin"  // also deletes, shares and hides workflows and manages API keys and grants
+)
+
+// IsGrantRole reports whether role can be granted on a single workflow
+func IsGrantRole(role string) bool {
+	return role == RoleViewer || role == RoleRunner || role == RoleEditor
+}
+
+// WorkflowGrant gives a user a role on one workflow on top of their project role
+type WorkflowGrant struct {
+	WorkflowID string    `json:"workflow_id"`
+	UserDID    string    `json:"user_did"`
+	Role       string    `json:"role"`
+	GrantedBy  string    `json:"granted_by"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// SetWorkflowGrantRequest represents the request body for granting a role on
+// a workflow. An empty role removes the grant.
+type SetWorkflowGrantRequest struct {
+	UserDID string `json:"user_did"`
+	Role    string `json:"role"`
+}
@@ -0,0 +1,69 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Run statuses
+const (
+	RunStatusQueued    = "queued"
+	RunStatusRunning   = "running"
+	RunStatusSucceeded = "succeeded"
+	RunStatusFailed    = "failed"
+	RunStatusCancelled = "cancelled"
+)
+
+// Run modes
+const (
+	RunModeSync   = "sync"
+	RunModeStream = "stream"
+	RunModeAsync  = "async"
+)
+
+// WorkflowRun represents one recorded workflow execution
+type WorkflowRun struct {
+	RunID             string          `json:"run_id"`
+	WorkflowID        string          `json:"workflow_id"`
+	ProjectID         string          `json:"project_id"`
+	CallerDID         string          `json:"caller_did"`
+	Mode              string          `json:"mode"`   // sync, stream, async
+	Status            string          `json:"status"` // queued, running, succeeded, failed, cancelled
+	Parameters        json.RawMessage `json:"parameters"`
+	RequestHeaders    json.RawMessage `json:"-"`                             // header overrides of an async run, used by the worker
+	ExternalExecuteID string          `json:"external_execute_id,omitempty"` // upstream async execution id (Coze)
+	WorkflowVersion   int             `json:"workflow_version,omitempty"`    // version that was executed, 0 while an unpinned async run is queued
+	StatusCode        int             `json:"status_code"`
+	LatencyMS         int64           `json:"latency_ms"`
+	ResponseBody      string          `json:"response_body,omitempty"`
+	ResponseTruncated bool            `json:"response_truncated"`
+	Error             string          `json:"error,omitempty"`
+	CreatedAt         time.Time       `json:"created_at"`
+	StartedAt         *time.Time      `json:"started_at,omitempty"`
+	FinishedAt        *time.Time      `json:"finished_at,omitempty"`
+}
+
+// IsFinished reports whether the run reached a final status
+func (r *WorkflowRun) IsFinished() bool {
+	return r.Status == RunStatusSucceeded || r.Status == RunStatusFailed || r.Status == RunStatusCancelled
+}
+
+// ListRunsFilter represents the filters for listing workflow runs
+type ListRunsFilter struct {
+	WorkflowID string
+	ProjectID  string
+	CallerDID  string
+	Status     string
+	Since      *time.Time
+	Until      *time.Time
+	Limit      int
+	Offset     int
+}
+
+// ListRunsResponse represents a page of workflow runs
+type ListRunsResponse struct {
+	Runs    []WorkflowRun `json:"runs"`
+	Limit   int           `json:"limit"`
+	Offset  int           `json:"offset"`
+	HasMore bool          `json:"has_more"`
+}
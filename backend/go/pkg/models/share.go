@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// Targets a workflow can be shared with
+const (
+	ShareTargetProject      = "project"
+	ShareTargetOrganization = "organization" // every project of the organization
+)
+
+// Access a share gives to the projects it targets
+const (
+	ShareAccessRead    = "read"    // list, search and read the workflow
+	ShareAccessExecute = "execute" // also execute it
+)
+
+// WorkflowShare shares a workflow with a project or an organization
+type WorkflowShare struct {
+	WorkflowID string    `json:"workflow_id"`
+	TargetType string    `json:"target_type"`
+	TargetID   string    `json:"target_id"`
+	Access     string    `json:"access"`
+	SharedBy   string    `json:"shared_by"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// IsShareAccess reports whether access is a valid share access
+func IsShareAccess(access string) bool {
+	return access == ShareAccessRead || access == ShareAccessExecute
+}
+
+// ShareAllows reports whether access includes want
+func ShareAllows(access, want string) bool {
+	switch access {
+	case ShareAccessExecute:
+		return want == ShareAccessRead || want == ShareAccessExecute
+	case ShareAccessRead:
+		return want == ShareAccessRead
+	}
+	return false
+}
+
+// ShareAccessOf limits the access a project has through a share to what a
+// principal with role in that project may do: viewers only read
+func ShareAccessOf(access, role string) string {
+	if access == "" || role == "" {
+		return ""
+	}
+	if role == RoleViewer {
+		return ShareAccessRead
+	}
+	return access
+}
+
+// AddWorkflowSharesRequest represents the request body for sharing a workflow
+// with projects and organizations. Access defaults to execute.
+type AddWorkflowSharesRequest struct {
+	ProjectIDs      []string `json:"project_ids"`
+	OrganizationIDs []string `json:"organization_ids"`
+	Access          string   `json:"access"`
+}
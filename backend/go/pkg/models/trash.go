@@ -0,0 +1,16 @@
+package models
+
+// WorkflowUsage lists what still uses a workflow outside its own project
+type WorkflowUsage struct {
+	IsShared              bool     `json:"is_shared"`               // shared with every project
+	SharedProjectIDs      []string `json:"shared_project_ids"`      // targets of project shares
+	SharedOrganizationIDs []string `json:"shared_organization_ids"` // targets of organization shares
+	SettingsProjectIDs    []string `json:"settings_project_ids"`    // other projects that hid or showed it
+	RunProjectIDs         []string `json:"run_project_ids"`         // other projects that ran it recently
+}
+
+// InUse reports whether another project still uses the workflow
+func (u *WorkflowUsage) InUse() bool {
+	return u.IsShared || len(u.SharedProjectIDs) > 0 || len(u.SharedOrganizationIDs) > 0 ||
+		len(u.SettingsProjectIDs) > 0 || len(u.RunProjectIDs) > 0
+}
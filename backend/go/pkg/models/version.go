@@ -0,0 +1,131 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+)
+
+// Version change types
+const (
+	VersionChangeCreate   = "create"
+	VersionChangeUpdate   = "update"
+	VersionChangeRollback = "rollback"
+)
+
+// WorkflowVersion is the definition of a workflow after one change
+type WorkflowVersion struct {
+	WorkflowID         string          `json:"workflow_id"`
+	Version            int             `json:"version"`
+	WorkflowName       string          `json:"workflow_name"`
+	Description        string          `json:"description"`
+	Source             string          `json:"source"`
+	TemplateName       string          `json:"template_name"`
+	HTTPMethod         string          `json:"http_method,omitempty"`
+	BaseURL            string          `json:"base_url,omitempty"`
+	BearerToken        string          `json:"bearer_token,omitempty"`
+	ExternalWorkflowID string          `json:"external_workflow_id,omitempty"`
+	Parameters         json.RawMessage `json:"parameters"`
+	Headers            json.RawMessage `json:"headers,omitempty"`
+	InputSchema        json.RawMessage `json:"input_schema"`
+	ChangeType         string          `json:"change_type"` // create, update, rollback
+	ChangedFields      []string        `json:"changed_fields"`
+	ChangedBy          string          `json:"changed_by"`
+	RolledBackFrom     int             `json:"rolled_back_from,omitempty"` // version restored by a rollback
+	CreatedAt          time.Time       `json:"created_at"`
+}
+
+// VersionChange is one field that differs between two versions
+type VersionChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// DiffVersionsResponse represents the difference between two versions
+type DiffVersionsResponse struct {
+	WorkflowID string          `json:"workflow_id"`
+	From       int             `json:"from"`
+	To         int             `json:"to"`
+	Changes    []VersionChange `json:"changes"`
+}
+
+// ListVersionsResponse represents a page of workflow versions
+type ListVersionsResponse struct {
+	Versions []WorkflowVersion `json:"versions"`
+	Limit    int               `json:"limit"`
+	Offset   int               `json:"offset"`
+	HasMore  bool              `json:"has_more"`
+}
+
+// Diff returns the fields whose value differs in other, in definition order
+func (v *WorkflowVersion) Diff(other *WorkflowVersion) []VersionChange {
+	changes := []VersionChange{}
+	text := func(field, from, to string) {
+		if from != to {
+			changes = append(changes, VersionChange{Field: field, From: from, To: to})
+		}
+	}
+	object := func(field string, from, to json.RawMessage) {
+		if !bytes.Equal(compactJSON(from), compactJSON(to)) {
+			changes = append(changes, VersionChange{Field: field, From: from, To: to})
+		}
+	}
+
+	text("workflow_name", v.WorkflowName, other.WorkflowName)
+	text("description", v.Description, other.Description)
+	text("source", v.Source, other.Source)
+	text("template_name", v.TemplateName, other.TemplateName)
+	text("http_method", v.HTTPMethod, other.HTTPMethod)
+	text("base_url", v.BaseURL, other.BaseURL)
+	text("bearer_token", v.BearerToken, other.BearerToken)
+	text("external_workflow_id", v.ExternalWorkflowID, other.ExternalWorkflowID)
+	object("parameters", v.Parameters, other.Parameters)
+	object("headers", v.Headers, other.Headers)
+	object("input_schema", v.InputSchema, other.InputSchema)
+	return changes
+}
+
+// Apply replaces the definition of workflow with this version
+func (v *WorkflowVersion) Apply(workflow *Workflow) {
+	workflow.Version = v.Version
+	workflow.WorkflowName = v.WorkflowName
+	workflow.Description = v.Description
+	workflow.Source = v.Source
+	workflow.TemplateName = v.TemplateName
+	workflow.HTTPMethod = v.HTTPMethod
+	workflow.BaseURL = v.BaseURL
+	workflow.BearerToken = v.BearerToken
+	workflow.ExternalWorkflowID = v.ExternalWorkflowID
+	workflow.Parameters = v.Parameters
+	workflow.Headers = v.Headers
+	workflow.InputSchema = v.InputSchema
+}
+
+// DefinitionOf returns the versioned fields of workflow, the inverse of Apply
+func DefinitionOf(workflow *Workflow) *WorkflowVersion {
+	return &WorkflowVersion{
+		WorkflowID:         workflow.WorkflowID,
+		Version:            workflow.Version,
+		WorkflowName:       workflow.WorkflowName,
+		Description:        workflow.Description,
+		Source:             workflow.Source,
+		TemplateName:       workflow.TemplateName,
+		HTTPMethod:         workflow.HTTPMethod,
+		BaseURL:            workflow.BaseURL,
+		BearerToken:        workflow.BearerToken,
+		ExternalWorkflowID: workflow.ExternalWorkflowID,
+		Parameters:         workflow.Parameters,
+		Headers:            workflow.Headers,
+		InputSchema:        workflow.InputSchema,
+	}
+}
+
+// compactJSON drops insignificant whitespace; null and empty values compare equal to {}
+func compactJSON(raw json.RawMessage) []byte {
+	var buf bytes.Buffer
+	if len(raw) == 0 || string(raw) == "null" || json.Compact(&buf, raw) != nil {
+		return []byte("{}")
+	}
+	return buf.Bytes()
+}
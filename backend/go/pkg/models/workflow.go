@@ -10,17 +10,63 @@ type Workflow struct {
 	WorkflowID         string          `json:"workflow_id"`
 	WorkflowName       string          `json:"workflow_name"`
 	Description        string          `json:"description"`
-	Source             string          `json:"source"`              // coze, n8n
-	TemplateName       string          `json:"template_name"`       // workflow, streamflow
-	HTTPMethod         string          `json:"http_method"`         // GET, POST, PUT
-	BaseURL            string          `json:"base_url"`
-	BearerToken        string          `json:"bearer_token"`
-	ExternalWorkflowID string          `json:"external_workflow_id"`
-	Parameters         json.RawMessage `json:"parameters"`          // JSON object
-	Headers            json.RawMessage `json:"headers"`             // JSON object
+	Source             string          `json:"source"`                // coze, n8n, dify, langflow, flowise, openai
+	TemplateName       string          `json:"template_name"`         // workflow, streamflow
+	HTTPMethod         string          `json:"http_method,omitempty"` // GET, POST, PUT
+	BaseURL            string          `json:"base_url,omitempty"`
+	BearerToken        string          `json:"bearer_token,omitempty"`
+	ExternalWorkflowID string          `json:"external_workflow_id,omitempty"`
+	Parameters         json.RawMessage `json:"parameters"`        // JSON object
+	Headers            json.RawMessage `json:"headers,omitempty"` // JSON object
+	InputSchema        json.RawMessage `json:"input_schema"`      // JSON Schema of the parameters, {} when unset
+	ProjectID          string          `json:"project_id"`
+	CreatorDID         string          `json:"creator_did"`
+	IsShared           bool            `json:"is_shared"`
+	Tags               []string        `json:"tags"`
+	IsHidden           bool            `json:"is_hidden,omitempty"` // hidden in the listing project, only set when hidden workflows are included
+	Version            int             `json:"version,omitempty"`   // latest entry of workflow_versions
+	RowVersion         int             `json:"row_version"`         // bumped by every change, sent as the ETag
+	CreatedAt          time.Time       `json:"created_at"`
+	UpdatedAt          time.Time       `json:"updated_at"`
+	DeletedAt          *time.Time      `json:"deleted_at,omitempty"` // set while the workflow is in the trash
+	DeletedBy          string          `json:"deleted_by,omitempty"`
+}
+
+// How the caller of GET /api/workflows/{id} reaches the workflow: their
+// role in its project or on the workflow, or one of creator and shared
+const (
+	WorkflowAccessAdmin   = RoleAdmin  // admin of the workflow's project
+	WorkflowAccessEditor  = RoleEditor // editor of the project or the workflow
+	WorkflowAccessMember  = RoleMember // member of the workflow's project
+	WorkflowAccessRunner  = RoleRunner
+	WorkflowAccessViewer  = RoleViewer
+	WorkflowAccessCreator = "creator" // created the workflow
+	WorkflowAccessShared  = "shared"  // reaches the workflow because it is shared
+)
+
+// WorkflowDetail is a workflow projected for its caller. Endpoint details and
+// headers are only set for admins, editors and creators, and the bearer token
+// is always masked; it is revealed by GET /api/workflows/{id}/token.
+type WorkflowDetail struct {
+	WorkflowID         string          `json:"workflow_id"`
+	WorkflowName       string          `json:"workflow_name"`
+	Description        string          `json:"description"`
+	Source             string          `json:"source"`
+	TemplateName       string          `json:"template_name"`
+	HTTPMethod         string          `json:"http_method,omitempty"`
+	BaseURL            string          `json:"base_url,omitempty"`
+	BearerToken        string          `json:"bearer_token,omitempty"`
+	ExternalWorkflowID string          `json:"external_workflow_id,omitempty"`
+	Parameters         json.RawMessage `json:"parameters"`
+	Headers            json.RawMessage `json:"headers,omitempty"`
+	InputSchema        json.RawMessage `json:"input_schema"`
 	ProjectID          string          `json:"project_id"`
 	CreatorDID         string          `json:"creator_did"`
 	IsShared           bool            `json:"is_shared"`
+	Tags               []string        `json:"tags"`
+	Version            int             `json:"version,omitempty"`
+	RowVersion         int             `json:"row_version"`
+	Access             string          `json:"access"` // a role, creator or shared, see WorkflowAccessAdmin
 	CreatedAt          time.Time       `json:"created_at"`
 	UpdatedAt          time.Time       `json:"updated_at"`
 }
@@ -37,7 +83,9 @@ type CreateWorkflowRequest struct {
 	ExternalWorkflowID string          `json:"external_workflow_id"`
 	Parameters         json.RawMessage `json:"parameters"`
 	Headers            json.RawMessage `json:"headers"`
+	InputSchema        json.RawMessage `json:"input_schema"`
 	ProjectID          string          `json:"project_id"`
+	Tags               []string        `json:"tags"`
 }
 
 // UpdateWorkflowRequest represents the request to update a workflow
@@ -52,17 +100,60 @@ type UpdateWorkflowRequest struct {
 	ExternalWorkflowID *string          `json:"external_workflow_id,omitempty"`
 	Parameters         *json.RawMessage `json:"parameters,omitempty"`
 	Headers            *json.RawMessage `json:"headers,omitempty"`
+	InputSchema        *json.RawMessage `json:"input_schema,omitempty"`
+	Tags               *[]string        `json:"tags,omitempty"`
+}
+
+// Workflow list scopes
+const (
+	WorkflowScopeAll    = "all"    // the project's own and shared workflows
+	WorkflowScopeOwn    = "own"    // the project's own workflows
+	WorkflowScopeShared = "shared" // workflows shared by other projects
+)
+
+// Workflow list sort keys
+const (
+	WorkflowSortCreatedAt = "created_at"
+	WorkflowSortUpdatedAt = "updated_at"
+	WorkflowSortName      = "workflow_name"
+)
+
+// ListWorkflowsFilter represents the filters for listing the workflows visible from a project
+type ListWorkflowsFilter struct {
+	ProjectID     string
+	Scope         string
+	Source        string
+	TemplateName  string
+	CreatorDID    string
+	Tag           string
+	Query         string // substring of the name or description
+	IncludeHidden bool
+	Sort          string
+	Descending    bool
+	After         *WorkflowCursor // continue after this position
+	Limit         int
+}
+
+// WorkflowCursor is the position of a workflow in a sorted list
+type WorkflowCursor struct {
+	Sort       string `json:"s"`
+	Descending bool   `json:"d,omitempty"`
+	Value      string `json:"v"` // sort key of the workflow
+	WorkflowID string `json:"id"`
 }
 
 // ExecuteWorkflowRequest represents the request to execute a workflow
 type ExecuteWorkflowRequest struct {
 	Parameters json.RawMessage `json:"parameters"`
 	Headers    json.RawMessage `json:"headers"`
+	ProjectID  string          `json:"project_id,omitempty"` // project the caller runs from, defaults to the workflow's project
+	Version    int             `json:"version,omitempty"`    // workflow version to run, defaults to the latest
 }
 
 // ExecuteWorkflowResponse represents the response from executing a workflow
 type ExecuteWorkflowResponse struct {
-	Request  ExecuteWorkflowRequestInfo `json:"request"`
+	RunID    string                      `json:"run_id,omitempty"`
+	Request  ExecuteWorkflowRequestInfo  `json:"request"`
 	Response ExecuteWorkflowResponseInfo `json:"response"`
 }
 
@@ -76,10 +167,33 @@ type ExecuteWorkflowRequestInfo struct {
 
 // ExecuteWorkflowResponseInfo represents the HTTP response information
 type ExecuteWorkflowResponseInfo struct {
-	Status     int                    `json:"status"`
-	StatusText string                 `json:"status_text"`
-	Headers    map[string]string      `json:"headers"`
-	Body       interface{}            `json:"body"`
+	Status     int               `json:"status"`
+	StatusText string            `json:"status_text"`
+	Headers    map[string]string `json:"headers"`
+	Body       interface{}       `json:"body"`
+	Output     string            `json:"output,omitempty"` // final output extracted by the source adapter
+	Error      *UpstreamError    `json:"error,omitempty"`  // set when the upstream call failed
+}
+
+// UpstreamError classifies a failed upstream workflow call
+type UpstreamError struct {
+	Kind      string `json:"kind"`           // auth, rate_limited, invalid_input, not_found, timeout, upstream
+	Code      string `json:"code,omitempty"` // platform specific error code
+	Message   string `json:"message"`
+	Retryable bool   `json:"retryable"`
+}
+
+func (e *UpstreamError) Error() string {
+	return e.Kind + ": " + e.Message
+}
+
+// StreamEvent represents a single typed event relayed from a streamflow workflow
+type StreamEvent struct {
+	Type      string          `json:"type"`                 // message_delta, node_finished, error, done
+	Content   string          `json:"content,omitempty"`    // text delta for message_delta
+	NodeTitle string          `json:"node_title,omitempty"` // upstream node that produced the event
+	Error     string          `json:"error,omitempty"`      // error message for error events
+	Data      json.RawMessage `json:"data,omitempty"`       // raw upstream payload
 }
 
 // ShareWorkflowRequest represents the request to share/unshare a workflow
@@ -94,25 +208,49 @@ type HideWorkflowRequest struct {
 
 // SearchWorkflowRequest represents the request to search workflows
 type SearchWorkflowRequest struct {
-	Query     string `json:"query"`
-	ProjectID string `json:"project_id"`
-	TopK      int    `json:"top_k"`
-	Threshold float64 `json:"threshold"`
+	Query        string        `json:"query"`
+	ProjectID    string        `json:"project_id"`
+	TopK         int           `json:"top_k"`
+	Threshold    float64       `json:"threshold"`
+	Mode         string        `json:"mode,omitempty"`          // auto (default), vector, fulltext, hybrid
+	Fusion       string        `json:"fusion,omitempty"`        // rrf (default) or weighted, for hybrid
+	VectorWeight *float64      `json:"vector_weight,omitempty"` // share of the vector score in weighted fusion, default 0.5
+	Filters      SearchFilters `json:"filters"`
+}
+
+// SearchFilters restricts search results; empty filters match every workflow
+type SearchFilters struct {
+	Sources       []string `json:"sources,omitempty"`
+	TemplateNames []string `json:"template_names,omitempty"`
+	Tags          []string `json:"tags,omitempty"` // workflows must have all of them
+	CreatorDID    string   `json:"creator_did,omitempty"`
 }
 
 // SearchWorkflowResult represents a search result
 type SearchWorkflowResult struct {
-	WorkflowID   string  `json:"workflow_id"`
-	WorkflowName string  `json:"workflow_name"`
-	Description  string  `json:"description"`
-	Source       string  `json:"source"`
-	TemplateName string  `json:"template_name"`
-	Similarity   float64 `json:"similarity,omitempty"`
-	Relevance    float64 `json:"relevance,omitempty"`
+	WorkflowID   string   `json:"workflow_id"`
+	WorkflowName string   `json:"workflow_name"`
+	Description  string   `json:"description"`
+	Source       string   `json:"source"`
+	TemplateName string   `json:"template_name"`
+	Tags         []string `json:"tags"`
+	Similarity   float64  `json:"similarity,omitempty"`
+	Relevance    float64  `json:"relevance,omitempty"`
+	Score        float64  `json:"score,omitempty"` // fused score of hybrid search
 }
 
 // SearchWorkflowResponse represents the search response
 type SearchWorkflowResponse struct {
 	Results      []SearchWorkflowResult `json:"results"`
-	SearchMethod string                 `json:"search_method"` // "vector" or "fulltext"
+	SearchMethod string                 `json:"search_method"` // "vector", "fulltext" or "hybrid"
+}
+
+// EmbeddingSource holds the workflow content that is embedded for search
+type EmbeddingSource struct {
+	WorkflowID     string
+	WorkflowName   string
+	Description    string
+	Parameters     json.RawMessage
+	InputSchema    json.RawMessage
+	ContentVersion int
 }
@@ -0,0 +1,252 @@
+// Package policy decides what a principal may do. Handlers ask Authorize
+// instead of checking memberships themselves, so rules are added here.
+package policy
+
+import (
+	"github.com/xzero/ai-workflow/pkg/auth"
+	"github.com/xzero/ai-workflow/pkg/models"
+)
+
+// Action is an operation checked by Authorize
+type Action string
+
+// Actions on a project, see Project
+const (
+	ActionProjectRead    Action = "project:read"     // list and search workflows, list runs
+	ActionWorkflowCreate Action = "workflow:create"  // create workflows in the project
+	ActionWorkflowHide   Action = "workflow:hide"    // hide workflows from the project's list
+	ActionRunCreate      Action = "run:create"       // record runs against the project, see WorkflowIn
+	ActionManageAPIKeys  Action = "project:api_keys" // create, list and revoke API keys
+	ActionReadAudit      Action = "project:audit"    // query and export the audit log
+)
+
+// Actions on a workflow, see Workflow
+const (
+	ActionWorkflowRead         Action = "workflow:read"          // read the definition
+	ActionWorkflowReadVersions Action = "workflow:read_versions" // read the version history
+	ActionWorkflowReadConfig   Action = "workflow:read_config"   // read the endpoint and headers
+	ActionWorkflowExecute      Action = "workflow:execute"       // execute and stream
+	ActionWorkflowUpdate       Action = "workflow:update"        // update and roll back
+	ActionWorkflowDelete       Action = "workflow:delete"        // delete
+	ActionWorkflowShare        Action = "workflow:share"         // share with every project
+	ActionWorkflowRevealToken  Action = "workflow:reveal_token"  // read the bearer token
+	ActionWorkflowGrant        Action = "workflow:grant"         // list and change grants
+)
+
+// Actions on runs, see Run. ActionRunRead on a Workflow covers all its runs.
+const (
+	ActionRunRead   Action = "run:read"
+	ActionRunCancel Action = "run:cancel"
+)
+
+// rank orders roles; legacy member sits between runner and editor
+var rank = map[string]int{
+	models.RoleViewer: 1,
+	models.RoleRunner: 2,
+	models.RoleMember: 3,
+	models.RoleEditor: 4,
+	models.RoleAdmin:  5,
+}
+
+// rule allows an action to a minimum role and to the listed relations
+type rule struct {
+	role    string
+	creator bool   // the creator of the workflow
+	share   string // principals the workflow is shared with at this access
+	caller  bool   // the caller of the run
+	shareTo string // a workflow seen from another project must be shared with it at this access
+}
+
+var rules = map[Action]rule{
+	ActionProjectRead:    {role: models.RoleViewer},
+	ActionWorkflowCreate: {role: models.RoleMember},
+	ActionWorkflowHide:   {role: models.RoleAdmin},
+	ActionRunCreate:      {role: models.RoleRunner, shareTo: models.ShareAccessExecute},
+	ActionManageAPIKeys:  {role: models.RoleAdmin},
+	ActionReadAudit:      {role: models.RoleAdmin},
+
+	ActionWorkflowRead:         {role: models.RoleViewer, share: models.ShareAccessRead, shareTo: models.ShareAccessRead},
+	ActionWorkflowReadVersions: {role: models.RoleViewer},
+	ActionWorkflowReadConfig:   {role: models.RoleEditor, creator: true},
+	ActionWorkflowExecute:      {role: models.RoleRunner, share: models.ShareAccessExecute},
+	ActionWorkflowUpdate:       {role: models.RoleEditor, creator: true},
+	ActionWorkflowDelete:       {role: models.RoleAdmin, creator: true},
+	ActionWorkflowShare:        {role: models.RoleAdmin},
+	ActionWorkflowRevealToken:  {role: models.RoleAdmin, creator: true},
+	ActionWorkflowGrant:        {role: models.RoleAdmin, creator: true},
+
+	ActionRunRead:   {role: models.RoleViewer, caller: true},
+	ActionRunCancel: {role: models.RoleAdmin, caller: true},
+}
+
+// Resource is what an action is performed on: a project, a workflow or a run
+type Resource struct {
+	ProjectID string
+	Workflow  *models.Workflow
+	Run       *models.WorkflowRun
+}
+
+// Project returns a project resource
+func Project(projectID string) Resource {
+	return Resource{ProjectID: projectID}
+}
+
+// Workflow returns a workflow resource, which belongs to its project
+func Workflow(wf *models.Workflow) Resource {
+	return Resource{ProjectID: wf.ProjectID, Workflow: wf}
+}
+
+// WorkflowIn returns wf as used from a project, such as the project a run
+// is recorded against. When it is not the workflow's project, wf must be
+// shared with it and the role is the one in that project.
+func WorkflowIn(projectID string, wf *models.Workflow) Resource {
+	return Resource{ProjectID: projectID, Workflow: wf}
+}
+
+// Run returns a run resource, which belongs to the project it was recorded against
+func Run(run *models.WorkflowRun) Resource {
+	return Resource{ProjectID: run.ProjectID, Run: run}
+}
+
+// workflowID returns the workflow a resource is about, if any
+func (r Resource) workflowID() string {
+	switch {
+	case r.Workflow != nil:
+		return r.Workflow.WorkflowID
+	case r.Run != nil:
+		return r.Run.WorkflowID
+	}
+	return ""
+}
+
+// granted reports whether grants on the workflow of a resource apply, which
+// they do not in another project
+func (r Resource) granted() bool {
+	return r.Workflow == nil || r.Workflow.ProjectID == r.ProjectID
+}
+
+// Roles looks up the roles and shares of principals; store.WorkflowStore
+// implements it
+type Roles interface {
+	GetProjectRole(principal, projectID string) (string, error)
+	GetWorkflowRole(principal, workflowID string) (string, error)
+	GetProjectShareAccess(projectID, workflowID string) (string, error)
+	GetShareAccess(userDID, workflowID string) (string, error)
+}
+
+// Policy authorizes actions with the roles of principals in projects, their
+// grants on workflows and the shares of workflows
+type Policy struct {
+	roles Roles
+}
+
+// New returns the policy looking up roles in roles
+func New(roles Roles) *Policy {
+	return &Policy{roles: roles}
+}
+
+// Authorize reports whether principal may perform action on resource. err is
+// only set when roles could not be looked up.
+func (p *Policy) Authorize(principal *auth.Claims, action Action, resource Resource) (bool, error) {
+	r, ok := rules[action]
+	if !ok || principal == nil {
+		return false, nil
+	}
+
+	// API keys limited to some workflows reach nothing else
+	if key := principal.APIKey; key != nil && len(key.WorkflowIDs) > 0 {
+		if id := resource.workflowID(); id == "" || !key.AllowsWorkflow(id) {
+			return false, nil
+		}
+	}
+
+	if r.caller && resource.Run != nil && resource.Run.CallerDID == principal.DID {
+		return true, nil
+	}
+	wf := resource.Workflow
+	if r.shareTo != "" && wf != nil && wf.ProjectID != resource.ProjectID && !wf.IsShared {
+		access, err := p.roles.GetProjectShareAccess(resource.ProjectID, wf.WorkflowID)
+		if err != nil || !models.ShareAllows(access, r.shareTo) {
+			return false, err
+		}
+	}
+	if r.creator && wf != nil && wf.CreatorDID == principal.DID {
+		return true, nil
+	}
+
+	role, err := p.Role(principal, resource)
+	if err != nil {
+		return false, err
+	}
+	if AtLeast(role, r.role) {
+		return true, nil
+	}
+
+	// Principals outside the project may reach the workflow through a share
+	if r.share == "" || wf == nil {
+		return false, nil
+	}
+	access, err := p.shareAccess(principal, wf)
+	if err != nil {
+		return false, err
+	}
+	return models.ShareAllows(access, r.share), nil
+}
+
+// Role returns the effective role of principal on resource: its project role,
+// raised by a grant on the workflow of the resource. It is empty when the
+// principal has neither. An API key has the role of its scopes in its own
+// project and no other role, whatever its DID.
+func (p *Policy) Role(principal *auth.Claims, resource Resource) (string, error) {
+	if key := principal.APIKey; key != nil {
+		if resource.ProjectID == "" || key.ProjectID != resource.ProjectID {
+			return "", nil
+		}
+		return models.APIKeyRole(key.Scopes), nil
+	}
+
+	role := ""
+	if resource.ProjectID != "" {
+		var err error
+		if role, err = p.roles.GetProjectRole(principal.DID, resource.ProjectID); err != nil {
+			return "", err
+		}
+		if role != "" && rank[role] == 0 {
+			// Rows of user_projects with other values predate roles
+			role = models.RoleMember
+		}
+	}
+
+	if id := resource.workflowID(); id != "" && resource.granted() {
+		granted, err := p.roles.GetWorkflowRole(principal.DID, id)
+		if err != nil {
+			return "", err
+		}
+		if rank[granted] > rank[role] {
+			role = granted
+		}
+	}
+	return role, nil
+}
+
+// shareAccess returns the access principal has to wf through sharing: execute
+// when it is shared with every project, else the best access of the shares
+// reaching the principal's projects
+func (p *Policy) shareAccess(principal *auth.Claims, wf *models.Workflow) (string, error) {
+	if wf.IsShared {
+		return models.ShareAccessExecute, nil
+	}
+	if key := principal.APIKey; key != nil {
+		access, err := p.roles.GetProjectShareAccess(key.ProjectID, wf.WorkflowID)
+		if err != nil {
+			return "", err
+		}
+		return models.ShareAccessOf(access, models.APIKeyRole(key.Scopes)), nil
+	}
+	return p.roles.GetShareAccess(principal.DID, wf.WorkflowID)
+}
+
+// AtLeast reports whether role ranks at or above min
+func AtLeast(role, min string) bool {
+	return rank[role] > 0 && rank[role] >= rank[min]
+}
@@ -0,0 +1,169 @@
+package policy
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/xzero/ai-workflow/pkg/auth"
+	"github.com/xzero/ai-workflow/pkg/models"
+)
+
+// roles keeps the lookups of Roles in maps keyed "<principal or project>/<project or workflow>"
+type roles struct {
+	project       map[string]string
+	workflow      map[string]string
+	projectShares map[string]string
+	userShares    map[string]string
+	err           error
+}
+
+func (r roles) GetProjectRole(principal, projectID string) (string, error) {
+	return r.project[principal+"/"+projectID], r.err
+}
+
+func (r roles) GetWorkflowRole(principal, workflowID string) (string, error) {
+	return r.workflow[principal+"/"+workflowID], r.err
+}
+
+func (r roles) GetProjectShareAccess(projectID, workflowID string) (string, error) {
+	return r.projectShares[projectID+"/"+workflowID], r.err
+}
+
+func (r roles) GetShareAccess(userDID, workflowID string) (string, error) {
+	return r.userShares[userDID+"/"+workflowID], r.err
+}
+
+func TestAuthorize(t *testing.T) {
+	p := New(roles{
+		project: map[string]string{
+			"did:admin/p1":  models.RoleAdmin,
+			"did:editor/p1": models.RoleEditor,
+			"did:member/p1": models.RoleMember,
+			"did:runner/p1": models.RoleRunner,
+			"did:viewer/p1": models.RoleViewer,
+			"did:legacy/p1": "owner",
+			"did:runner/p2": models.RoleRunner,
+			"did:viewer/p2": models.RoleViewer,
+			"did:runner/p3": models.RoleRunner,
+		},
+		workflow: map[string]string{
+			"did:granted/wf1": models.RoleEditor,
+			"did:viewer/wf1":  models.RoleAdmin,
+		},
+		projectShares: map[string]string{
+			"p2/wf1": models.ShareAccessRead,
+			"p3/wf1": models.ShareAccessExecute,
+		},
+		userShares: map[string]string{
+			"did:reader/wf1": models.ShareAccessRead,
+		},
+	})
+
+	wf := &models.Workflow{WorkflowID: "wf1", ProjectID: "p1", CreatorDID: "did:creator"}
+	shared := &models.Workflow{WorkflowID: "wf2", ProjectID: "p1", IsShared: true}
+	run := &models.WorkflowRun{RunID: "r1", WorkflowID: "wf1", ProjectID: "p1", CallerDID: "did:caller"}
+	readKey := &auth.Claims{DID: "key:k1", APIKey: &models.APIKey{ProjectID: "p1", Scopes: []string{models.APIKeyScopeRead}}}
+	executeKey := &auth.Claims{DID: "key:k2", APIKey: &models.APIKey{ProjectID: "p1", Scopes: []string{models.APIKeyScopeExecute}}}
+	scopedKey := &auth.Claims{DID: "key:k3", APIKey: &models.APIKey{ProjectID: "p1", Scopes: []string{models.APIKeyScopeExecute}, WorkflowIDs: []string{"wf1"}}}
+
+	tests := []struct {
+		name      string
+		principal *auth.Claims
+		action    Action
+		resource  Resource
+		want      bool
+	}{
+		{"no principal", nil, ActionProjectRead, Project("p1"), false},
+		{"unknown action", &auth.Claims{DID: "did:admin"}, Action("project:drop"), Project("p1"), false},
+
+		{"viewer reads the project", &auth.Claims{DID: "did:viewer"}, ActionProjectRead, Project("p1"), true},
+		{"outsider reads the project", &auth.Claims{DID: "did:outsider"}, ActionProjectRead, Project("p1"), false},
+		{"runner creates workflows", &auth.Claims{DID: "did:runner"}, ActionWorkflowCreate, Project("p1"), false},
+		{"member creates workflows", &auth.Claims{DID: "did:member"}, ActionWorkflowCreate, Project("p1"), true},
+		{"legacy role counts as member", &auth.Claims{DID: "did:legacy"}, ActionWorkflowCreate, Project("p1"), true},
+		{"legacy role stays below editor", &auth.Claims{DID: "did:legacy"}, ActionWorkflowUpdate, Workflow(wf), false},
+		{"editor hides workflows", &auth.Claims{DID: "did:editor"}, ActionWorkflowHide, Project("p1"), false},
+		{"admin hides workflows", &auth.Claims{DID: "did:admin"}, ActionWorkflowHide, Project("p1"), true},
+		{"editor manages API keys", &auth.Claims{DID: "did:editor"}, ActionManageAPIKeys, Project("p1"), false},
+		{"admin reads the audit log", &auth.Claims{DID: "did:admin"}, ActionReadAudit, Project("p1"), true},
+
+		{"member reads config", &auth.Claims{DID: "did:member"}, ActionWorkflowReadConfig, Workflow(wf), false},
+		{"editor reads config", &auth.Claims{DID: "did:editor"}, ActionWorkflowReadConfig, Workflow(wf), true},
+		{"creator reads config", &auth.Claims{DID: "did:creator"}, ActionWorkflowReadConfig, Workflow(wf), true},
+		{"creator reveals the token", &auth.Claims{DID: "did:creator"}, ActionWorkflowRevealToken, Workflow(wf), true},
+		{"editor reveals the token", &auth.Claims{DID: "did:editor"}, ActionWorkflowRevealToken, Workflow(wf), false},
+		{"creator shares", &auth.Claims{DID: "did:creator"}, ActionWorkflowShare, Workflow(wf), false},
+		{"viewer executes a workflow shared with every project", &auth.Claims{DID: "did:viewer"}, ActionWorkflowExecute, Workflow(shared), true},
+		{"member executes", &auth.Claims{DID: "did:member"}, ActionWorkflowExecute, Workflow(wf), true},
+		{"grant raises the project role", &auth.Claims{DID: "did:granted"}, ActionWorkflowUpdate, Workflow(wf), true},
+		{"grant raises a viewer", &auth.Claims{DID: "did:viewer"}, ActionWorkflowDelete, Workflow(wf), true},
+		{"grant stays on its workflow", &auth.Claims{DID: "did:granted"}, ActionWorkflowUpdate, Workflow(shared), false},
+
+		{"shared with every project", &auth.Claims{DID: "did:outsider"}, ActionWorkflowExecute, Workflow(shared), true},
+		{"read share reads", &auth.Claims{DID: "did:reader"}, ActionWorkflowRead, Workflow(wf), true},
+		{"read share does not execute", &auth.Claims{DID: "did:reader"}, ActionWorkflowExecute, Workflow(wf), false},
+		{"share does not update", &auth.Claims{DID: "did:outsider"}, ActionWorkflowUpdate, Workflow(shared), false},
+
+		{"run in a project with an execute share", &auth.Claims{DID: "did:runner"}, ActionRunCreate, WorkflowIn("p3", wf), true},
+		{"run in a project with a read share", &auth.Claims{DID: "did:runner"}, ActionRunCreate, WorkflowIn("p2", wf), false},
+		{"run in a project without share", &auth.Claims{DID: "did:runner"}, ActionRunCreate, WorkflowIn("p4", wf), false},
+		{"run in another project of a shared workflow", &auth.Claims{DID: "did:runner"}, ActionRunCreate, WorkflowIn("p2", shared), true},
+		{"read from a project with a read share", &auth.Claims{DID: "did:viewer"}, ActionWorkflowRead, WorkflowIn("p2", wf), true},
+		{"grant not applied in another project", &auth.Claims{DID: "did:viewer"}, ActionWorkflowUpdate, WorkflowIn("p2", wf), false},
+
+		{"caller reads the run", &auth.Claims{DID: "did:caller"}, ActionRunRead, Run(run), true},
+		{"caller cancels the run", &auth.Claims{DID: "did:caller"}, ActionRunCancel, Run(run), true},
+		{"viewer reads runs", &auth.Claims{DID: "did:viewer"}, ActionRunRead, Run(run), true},
+		{"editor cancels runs", &auth.Claims{DID: "did:editor"}, ActionRunCancel, Run(run), false},
+
+		{"read key reads", readKey, ActionWorkflowRead, Workflow(wf), true},
+		{"read key executes", readKey, ActionWorkflowExecute, Workflow(wf), false},
+		{"execute key executes", executeKey, ActionWorkflowExecute, Workflow(wf), true},
+		{"key has no role in other projects", executeKey, ActionProjectRead, Project("p2"), false},
+		{"key does not take grants of its DID", &auth.Claims{DID: "did:granted", APIKey: readKey.APIKey}, ActionWorkflowUpdate, Workflow(wf), false},
+		{"scoped key on its workflow", scopedKey, ActionWorkflowExecute, Workflow(wf), true},
+		{"scoped key on another workflow", scopedKey, ActionWorkflowExecute, Workflow(shared), false},
+		{"scoped key on runs of its workflow", scopedKey, ActionRunRead, Run(run), true},
+		{"scoped key on the project", scopedKey, ActionProjectRead, Project("p1"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := p.Authorize(tt.principal, tt.action, tt.resource)
+			if err != nil {
+				t.Fatalf("Authorize: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Authorize(%s) = %v, want %v", tt.action, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthorizeLookupError(t *testing.T) {
+	lookup := errors.New("connection refused")
+	p := New(roles{err: lookup})
+	allowed, err := p.Authorize(&auth.Claims{DID: "did:admin"}, ActionProjectRead, Project("p1"))
+	if allowed || err != lookup {
+		t.Errorf("Authorize = %v, %v, want false, %v", allowed, err, lookup)
+	}
+}
+
+func TestAtLeast(t *testing.T) {
+	tests := []struct {
+		role, min string
+		want      bool
+	}{
+		{models.RoleAdmin, models.RoleEditor, true},
+		{models.RoleEditor, models.RoleEditor, true},
+		{models.RoleMember, models.RoleRunner, true},
+		{models.RoleMember, models.RoleEditor, false},
+		{models.RoleViewer, models.RoleRunner, false},
+		{"", models.RoleViewer, false},
+		{"owner", "", false},
+	}
+	for _, tt := range tests {
+		if got := AtLeast(tt.role, tt.min); got != tt.want {
+			t.Errorf("AtLeast(%q, %q) = %v, want %v", tt.role, tt.min, got, tt.want)
+		}
+	}
+}
@@ -7,13 +7,23 @@ import (
 
 // Success creates a success response
 func Success(data interface{}) events.APIGatewayProxyResponse {
+	return SuccessWithStatus(200, data)
+}
+
+// Accepted creates a 202 success response for work that continues in the background
+func Accepted(data interface{}) events.APIGatewayProxyResponse {
+	return SuccessWithStatus(202, data)
+}
+
+// SuccessWithStatus creates a success response with a custom status code
+func SuccessWithStatus(statusCode int, data interface{}) events.APIGatewayProxyResponse {
 	body, _ := json.Marshal(map[string]interface{}{
 		"success": true,
 		"data":    data,
 	})
 
 	return events.APIGatewayProxyResponse{
-		StatusCode: 200,
+		StatusCode: statusCode,
 		Headers: map[string]string{
 			"Content-Type":                "application/json",
 			"Access-Control-Allow-Origin": "*",
@@ -49,6 +59,26 @@ func Unauthorized(message string) events.APIGatewayProxyResponse {
 	return Error(401, message)
 }
 
+// InvalidToken creates a 401 error response for a rejected bearer token.
+// reason is a machine-readable code such as expired or wrong_audience.
+func InvalidToken(message, reason string) events.APIGatewayProxyResponse {
+	body, _ := json.Marshal(map[string]interface{}{
+		"success": false,
+		"error":   message,
+		"reason":  reason,
+	})
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: 401,
+		Headers: map[string]string{
+			"Content-Type":                "application/json",
+			"Access-Control-Allow-Origin": "*",
+			"WWW-Authenticate":            `Bearer error="invalid_token", error_description="` + reason + `"`,
+		},
+		Body: string(body),
+	}
+}
+
 // Forbidden creates a 403 error response
 func Forbidden(message string) events.APIGatewayProxyResponse {
 	return Error(403, message)
@@ -63,3 +93,70 @@ func NotFound(message string) events.APIGatewayProxyResponse {
 func InternalError(message string) events.APIGatewayProxyResponse {
 	return Error(500, message)
 }
+
+// ValidationError creates a 422 error response with field-level details
+func ValidationError(message string, details interface{}) events.APIGatewayProxyResponse {
+	return ErrorWithDetails(422, message, details)
+}
+
+// Conflict creates a 409 error response with details of the conflict
+func Conflict(message string, details interface{}) events.APIGatewayProxyResponse {
+	return ErrorWithDetails(409, message, details)
+}
+
+// PreconditionFailed creates a 412 error response for a conditional request
+// whose If-Match no longer matches
+func PreconditionFailed(message string, details interface{}) events.APIGatewayProxyResponse {
+	return ErrorWithDetails(412, message, details)
+}
+
+// PreconditionRequired creates a 428 error response for a write sent without If-Match
+func PreconditionRequired(message string) events.APIGatewayProxyResponse {
+	return Error(428, message)
+}
+
+// ErrorWithDetails creates an error response carrying details next to the message
+func ErrorWithDetails(statusCode int, message string, details interface{}) events.APIGatewayProxyResponse {
+	body, _ := json.Marshal(map[string]interface{}{
+		"success": false,
+		"error":   message,
+		"details": details,
+	})
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: statusCode,
+		Headers: map[string]string{
+			"Content-Type":                "application/json",
+			"Access-Control-Allow-Origin": "*",
+		},
+		Body: string(body),
+	}
+}
+
+// Page describes the position of a paginated response. Lists paged by
+// cursor set NextCursor, lists paged by offset set Offset.
+type Page struct {
+	Limit      int    `json:"limit"`
+	Offset     int    `json:"offset,omitempty"` // position of the first item
+	HasMore    bool   `json:"has_more"`
+	NextCursor string `json:"next_cursor,omitempty"` // pass as cursor to get the next page
+}
+
+// Paginated creates a success response for one page of items. data stays
+// the array of items; the page is reported next to it in pagination.
+func Paginated(items interface{}, page Page) events.APIGatewayProxyResponse {
+	body, _ := json.Marshal(map[string]interface{}{
+		"success":    true,
+		"data":       items,
+		"pagination": page,
+	})
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: 200,
+		Headers: map[string]string{
+			"Content-Type":                "application/json",
+			"Access-Control-Allow-Origin": "*",
+		},
+		Body: string(body),
+	}
+}
@@ -0,0 +1,318 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Schema is the subset of JSON Schema supported for workflow inputs
+type Schema struct {
+	Type                 string             `json:"type,omitempty"` // object, string, number, integer, boolean, array
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Default              interface{}        `json:"default,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+}
+
+// FieldError describes why one field failed validation
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// SchemaError reports an invalid schema definition
+type SchemaError struct {
+	Path    string
+	Message string
+}
+
+func (e *SchemaError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// IsEmpty reports whether raw holds no schema
+func IsEmpty(raw json.RawMessage) bool {
+	s := strings.TrimSpace(string(raw))
+	return s == "" || s == "null" || s == "{}"
+}
+
+// Parse decodes and checks a workflow input schema. The root must be an
+// object schema. It returns nil without error when raw holds no schema.
+func Parse(raw json.RawMessage) (*Schema, error) {
+	if IsEmpty(raw) {
+		return nil, nil
+	}
+
+	var s Schema
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, &SchemaError{Message: "schema must be a JSON object: " + err.Error()}
+	}
+	if s.Type != "object" {
+		return nil, &SchemaError{Message: `root type must be "object"`}
+	}
+	if err := s.check(""); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// check verifies the schema definition at path
+func (s *Schema) check(path string) error {
+	fail := func(format string, args ...interface{}) error {
+		return &SchemaError{Path: path, Message: fmt.Sprintf(format, args...)}
+	}
+
+	switch s.Type {
+	case "object", "string", "number", "integer", "boolean", "array":
+	case "":
+		return fail("type is required")
+	default:
+		return fail("unsupported type %q", s.Type)
+	}
+
+	if len(s.Properties) > 0 && s.Type != "object" {
+		return fail("properties are only allowed on object schemas")
+	}
+	for _, name := range s.Required {
+		if _, ok := s.Properties[name]; !ok {
+			return fail("required property %q is not defined in properties", name)
+		}
+	}
+	for _, name := range sortedProperties(s.Properties) {
+		prop := s.Properties[name]
+		if prop == nil {
+			return fail("property %q must be a schema object", name)
+		}
+		if err := prop.check(join(path, name)); err != nil {
+			return err
+		}
+	}
+
+	if s.Items != nil {
+		if s.Type != "array" {
+			return fail("items is only allowed on array schemas")
+		}
+		if err := s.Items.check(path + "[]"); err != nil {
+			return err
+		}
+	}
+
+	if (s.Minimum != nil || s.Maximum != nil) && s.Type != "number" && s.Type != "integer" {
+		return fail("minimum/maximum are only allowed on number and integer schemas")
+	}
+	if s.Minimum != nil && s.Maximum != nil && *s.Minimum > *s.Maximum {
+		return fail("minimum must not be greater than maximum")
+	}
+
+	if (s.MinLength != nil || s.MaxLength != nil || s.Pattern != "") && s.Type != "string" {
+		return fail("minLength/maxLength/pattern are only allowed on string schemas")
+	}
+	if err := checkBounds(s.MinLength, s.MaxLength, "minLength", "maxLength"); err != nil {
+		return fail("%s", err)
+	}
+	if s.Pattern != "" {
+		if _, err := regexp.Compile(s.Pattern); err != nil {
+			return fail("invalid pattern: %v", err)
+		}
+	}
+
+	if (s.MinItems != nil || s.MaxItems != nil) && s.Type != "array" {
+		return fail("minItems/maxItems are only allowed on array schemas")
+	}
+	if err := checkBounds(s.MinItems, s.MaxItems, "minItems", "maxItems"); err != nil {
+		return fail("%s", err)
+	}
+
+	for _, value := range s.Enum {
+		if errs := s.validateType(path, value); len(errs) > 0 {
+			return fail("enum value %v does not match type %s", value, s.Type)
+		}
+	}
+	if s.Default != nil {
+		if errs := s.validate(path, s.Default); len(errs) > 0 {
+			return fail("invalid default: %s", errs[0].Message)
+		}
+	}
+
+	return nil
+}
+
+// ApplyDefaults fills missing top-level parameters with schema defaults
+func (s *Schema) ApplyDefaults(params map[string]interface{}) {
+	for name, prop := range s.Properties {
+		if _, ok := params[name]; !ok && prop.Default != nil {
+			params[name] = prop.Default
+		}
+	}
+}
+
+// Validate checks params against the schema and returns every field error,
+// sorted by field name
+func (s *Schema) Validate(params map[string]interface{}) []FieldError {
+	errs := s.validate("", params)
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Field < errs[j].Field })
+	return errs
+}
+
+// validate checks value against the schema at path
+func (s *Schema) validate(path string, value interface{}) []FieldError {
+	if errs := s.validateType(path, value); len(errs) > 0 {
+		return errs
+	}
+
+	var errs []FieldError
+	add := func(format string, args ...interface{}) {
+		errs = append(errs, FieldError{Field: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if len(s.Enum) > 0 && !containsValue(s.Enum, value) {
+		add("must be one of %s", formatEnum(s.Enum))
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				errs = append(errs, FieldError{Field: join(path, name), Message: "is required"})
+			}
+		}
+		for _, name := range sortedKeys(v) {
+			prop, ok := s.Properties[name]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					errs = append(errs, FieldError{Field: join(path, name), Message: "is not allowed"})
+				}
+				continue
+			}
+			errs = append(errs, prop.validate(join(path, name), v[name])...)
+		}
+	case string:
+		length := len([]rune(v))
+		if s.MinLength != nil && length < *s.MinLength {
+			add("must be at least %d characters", *s.MinLength)
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			add("must be at most %d characters", *s.MaxLength)
+		}
+		if s.Pattern != "" {
+			if re, err := regexp.Compile(s.Pattern); err == nil && !re.MatchString(v) {
+				add("must match pattern %s", s.Pattern)
+			}
+		}
+	case float64:
+		if s.Minimum != nil && v < *s.Minimum {
+			add("must be >= %v", *s.Minimum)
+		}
+		if s.Maximum != nil && v > *s.Maximum {
+			add("must be <= %v", *s.Maximum)
+		}
+	case []interface{}:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			add("must contain at least %d items", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			add("must contain at most %d items", *s.MaxItems)
+		}
+		if s.Items != nil {
+			for i, item := range v {
+				errs = append(errs, s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item)...)
+			}
+		}
+	}
+
+	return errs
+}
+
+// validateType checks that value has the schema's JSON type
+func (s *Schema) validateType(path string, value interface{}) []FieldError {
+	ok := false
+	switch s.Type {
+	case "object":
+		_, ok = value.(map[string]interface{})
+	case "string":
+		_, ok = value.(string)
+	case "number":
+		_, ok = value.(float64)
+	case "integer":
+		f, isNumber := value.(float64)
+		ok = isNumber && f == math.Trunc(f)
+	case "boolean":
+		_, ok = value.(bool)
+	case "array":
+		_, ok = value.([]interface{})
+	default:
+		ok = true
+	}
+	if !ok {
+		return []FieldError{{Field: path, Message: "must be of type " + s.Type}}
+	}
+	return nil
+}
+
+func checkBounds(min, max *int, minName, maxName string) error {
+	if (min != nil && *min < 0) || (max != nil && *max < 0) {
+		return fmt.Errorf("%s/%s must not be negative", minName, maxName)
+	}
+	if min != nil && max != nil && *min > *max {
+		return fmt.Errorf("%s must not be greater than %s", minName, maxName)
+	}
+	return nil
+}
+
+func containsValue(values []interface{}, value interface{}) bool {
+	want, _ := json.Marshal(value)
+	for _, v := range values {
+		if got, _ := json.Marshal(v); string(got) == string(want) {
+			return true
+		}
+	}
+	return false
+}
+
+func formatEnum(values []interface{}) string {
+	b, _ := json.Marshal(values)
+	return string(b)
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedProperties(m map[string]*Schema) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
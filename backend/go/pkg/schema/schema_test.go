@@ -0,0 +1,153 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const greetingSchema = `{
+	"type": "object",
+	"properties": {
+		"name": {"type": "string", "minLength": 1, "maxLength": 5, "pattern": "^[a-z]+$"},
+		"count": {"type": "integer", "minimum": 1, "maximum": 3, "default": 1},
+		"ratio": {"type": "number"},
+		"mood": {"type": "string", "enum": ["happy", "sad"]},
+		"loud": {"type": "boolean"},
+		"tags": {"type": "array", "items": {"type": "string"}, "minItems": 1, "maxItems": 2},
+		"address": {
+			"type": "object",
+			"properties": {"city": {"type": "string"}},
+			"required": ["city"],
+			"additionalProperties": false
+		}
+	},
+	"required": ["name"]
+}`
+
+func mustParse(t *testing.T, raw string) *Schema {
+	t.Helper()
+	s, err := Parse(json.RawMessage(raw))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	return s
+}
+
+func decode(t *testing.T, raw string) map[string]interface{} {
+	t.Helper()
+	var params map[string]interface{}
+	if err := json.Unmarshal([]byte(raw), &params); err != nil {
+		t.Fatalf("decoding params: %v", err)
+	}
+	return params
+}
+
+func TestParseEmpty(t *testing.T) {
+	for _, raw := range []string{"", " ", "null", "{}"} {
+		s, err := Parse(json.RawMessage(raw))
+		if s != nil || err != nil {
+			t.Errorf("Parse(%q) = %v, %v, want nil, nil", raw, s, err)
+		}
+	}
+}
+
+func TestParseRejectsInvalidSchemas(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		want   string
+	}{
+		{"not an object", `[1]`, "schema must be a JSON object"},
+		{"root not object", `{"type": "string"}`, `root type must be "object"`},
+		{"missing type", `{"type": "object", "properties": {"a": {}}}`, "a: type is required"},
+		{"unsupported type", `{"type": "object", "properties": {"a": {"type": "date"}}}`, `a: unsupported type "date"`},
+		{"undefined required", `{"type": "object", "required": ["a"]}`, `required property "a" is not defined`},
+		{"properties on string", `{"type": "object", "properties": {"a": {"type": "string", "properties": {"b": {"type": "string"}}}}}`, "a: properties are only allowed on object schemas"},
+		{"items on string", `{"type": "object", "properties": {"a": {"type": "string", "items": {"type": "string"}}}}`, "a: items is only allowed on array schemas"},
+		{"bad items", `{"type": "object", "properties": {"a": {"type": "array", "items": {"type": "x"}}}}`, `a[]: unsupported type "x"`},
+		{"minimum on string", `{"type": "object", "properties": {"a": {"type": "string", "minimum": 1}}}`, "minimum/maximum are only allowed"},
+		{"minimum above maximum", `{"type": "object", "properties": {"a": {"type": "number", "minimum": 2, "maximum": 1}}}`, "minimum must not be greater than maximum"},
+		{"pattern on number", `{"type": "object", "properties": {"a": {"type": "number", "pattern": "x"}}}`, "minLength/maxLength/pattern are only allowed"},
+		{"negative length", `{"type": "object", "properties": {"a": {"type": "string", "minLength": -1}}}`, "minLength/maxLength must not be negative"},
+		{"minLength above maxLength", `{"type": "object", "properties": {"a": {"type": "string", "minLength": 3, "maxLength": 2}}}`, "minLength must not be greater than maxLength"},
+		{"invalid pattern", `{"type": "object", "properties": {"a": {"type": "string", "pattern": "("}}}`, "a: invalid pattern"},
+		{"minItems on string", `{"type": "object", "properties": {"a": {"type": "string", "minItems": 1}}}`, "minItems/maxItems are only allowed"},
+		{"enum of wrong type", `{"type": "object", "properties": {"a": {"type": "integer", "enum": [1, "two"]}}}`, "enum value two does not match type integer"},
+		{"invalid default", `{"type": "object", "properties": {"a": {"type": "integer", "maximum": 3, "default": 5}}}`, "a: invalid default: must be <= 3"},
+		{"nested path", `{"type": "object", "properties": {"a": {"type": "object", "properties": {"b": {"type": "nope"}}}}}`, `a.b: unsupported type "nope"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(json.RawMessage(tt.schema))
+			if err == nil {
+				t.Fatal("Parse succeeded")
+			}
+			if _, ok := err.(*SchemaError); !ok {
+				t.Errorf("error is %T, want *SchemaError", err)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %q, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	s := mustParse(t, greetingSchema)
+
+	tests := []struct {
+		name   string
+		params string
+		want   []FieldError
+	}{
+		{"valid", `{"name": "bob", "count": 2, "ratio": 0.5, "mood": "happy", "loud": true, "tags": ["a"], "address": {"city": "x"}}`, nil},
+		{"extra top-level property", `{"name": "bob", "other": 1}`, nil},
+		{"missing required", `{}`, []FieldError{{"name", "is required"}}},
+		{"wrong type", `{"name": 1}`, []FieldError{{"name", "must be of type string"}}},
+		{"too short", `{"name": ""}`, []FieldError{{"name", "must be at least 1 characters"}, {"name", "must match pattern ^[a-z]+$"}}},
+		{"too long", `{"name": "abcdef"}`, []FieldError{{"name", "must be at most 5 characters"}}},
+		{"pattern", `{"name": "Bob"}`, []FieldError{{"name", "must match pattern ^[a-z]+$"}}},
+		{"not an integer", `{"name": "bob", "count": 1.5}`, []FieldError{{"count", "must be of type integer"}}},
+		{"below minimum", `{"name": "bob", "count": 0}`, []FieldError{{"count", "must be >= 1"}}},
+		{"above maximum", `{"name": "bob", "count": 4}`, []FieldError{{"count", "must be <= 3"}}},
+		{"enum", `{"name": "bob", "mood": "angry"}`, []FieldError{{"mood", `must be one of ["happy","sad"]`}}},
+		{"boolean", `{"name": "bob", "loud": "yes"}`, []FieldError{{"loud", "must be of type boolean"}}},
+		{"too few items", `{"name": "bob", "tags": []}`, []FieldError{{"tags", "must contain at least 1 items"}}},
+		{"too many items", `{"name": "bob", "tags": ["a", "b", "c"]}`, []FieldError{{"tags", "must contain at most 2 items"}}},
+		{"item type", `{"name": "bob", "tags": ["a", 2]}`, []FieldError{{"tags[1]", "must be of type string"}}},
+		{"nested required and additional", `{"name": "bob", "address": {"zip": "1"}}`, []FieldError{{"address.city", "is required"}, {"address.zip", "is not allowed"}}},
+		{"every error sorted by field", `{"count": 9, "loud": 1}`, []FieldError{{"count", "must be <= 3"}, {"loud", "must be of type boolean"}, {"name", "is required"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := s.Validate(decode(t, tt.params))
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyDefaults(t *testing.T) {
+	s := mustParse(t, greetingSchema)
+
+	params := decode(t, `{"name": "bob"}`)
+	s.ApplyDefaults(params)
+	if params["count"] != float64(1) {
+		t.Errorf("count = %v, want the default 1", params["count"])
+	}
+
+	params = decode(t, `{"name": "bob", "count": 3}`)
+	s.ApplyDefaults(params)
+	if params["count"] != float64(3) {
+		t.Errorf("count = %v, want the given 3", params["count"])
+	}
+	if _, ok := params["ratio"]; ok {
+		t.Error("ratio was set without a default")
+	}
+}
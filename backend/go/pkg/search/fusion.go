@@ -0,0 +1,98 @@
+package search
+
+import (
+	"sort"
+
+	"github.com/xzero/ai-workflow/pkg/models"
+)
+
+// Fusion methods of hybrid search
+const (
+	FusionRRF      = "rrf"
+	FusionWeighted = "weighted"
+)
+
+// RRFConstant damps the weight of the top ranks in reciprocal rank fusion.
+// 60 is the value from the original paper and works without tuning.
+const RRFConstant = 60
+
+// DefaultVectorWeight is the share of the vector score in weighted fusion
+const DefaultVectorWeight = 0.5
+
+// FuseRRF merges ranked result lists with reciprocal rank fusion: a workflow
+// scores the sum of 1/(RRFConstant + rank) over the lists it appears in. It
+// only looks at ranks, so similarity and full-text relevance need no common
+// scale.
+func FuseRRF(lists ...[]models.SearchWorkflowResult) []models.SearchWorkflowResult {
+	merged := newMerger()
+	for _, list := range lists {
+		for rank, r := range list {
+			merged.add(r, 1/float64(RRFConstant+rank+1))
+		}
+	}
+	return merged.sorted()
+}
+
+// FuseWeighted merges vector and full-text results by a weighted sum of their
+// scores. Similarity is used as is; relevance is divided by the best
+// relevance of the list to bring it to the same 0..1 scale.
+func FuseWeighted(vector, fulltext []models.SearchWorkflowResult, vectorWeight float64) []models.SearchWorkflowResult {
+	merged := newMerger()
+	for _, r := range vector {
+		merged.add(r, vectorWeight*r.Similarity)
+	}
+
+	best := 0.0
+	for _, r := range fulltext {
+		if r.Relevance > best {
+			best = r.Relevance
+		}
+	}
+	for _, r := range fulltext {
+		score := 0.0
+		if best > 0 {
+			score = r.Relevance / best
+		}
+		merged.add(r, (1-vectorWeight)*score)
+	}
+	return merged.sorted()
+}
+
+// merger accumulates the scores of results by workflow
+type merger struct {
+	results []models.SearchWorkflowResult
+	index   map[string]int
+}
+
+func newMerger() *merger {
+	return &merger{results: []models.SearchWorkflowResult{}, index: map[string]int{}}
+}
+
+// add adds score to the workflow of r, keeping the similarity and relevance
+// reported by each list
+func (m *merger) add(r models.SearchWorkflowResult, score float64) {
+	i, ok := m.index[r.WorkflowID]
+	if !ok {
+		r.Score = score
+		m.index[r.WorkflowID] = len(m.results)
+		m.results = append(m.results, r)
+		return
+	}
+
+	existing := &m.results[i]
+	existing.Score += score
+	if r.Similarity > existing.Similarity {
+		existing.Similarity = r.Similarity
+	}
+	if r.Relevance > existing.Relevance {
+		existing.Relevance = r.Relevance
+	}
+}
+
+// sorted returns the results by descending score; ties keep insertion order
+func (m *merger) sorted() []models.SearchWorkflowResult {
+	sort.SliceStable(m.results, func(i, j int) bool {
+		return m.results[i].Score > m.results[j].Score
+	})
+	return m.results
+}
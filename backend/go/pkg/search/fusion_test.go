@@ -0,0 +1,107 @@
+package search
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/xzero/ai-workflow/pkg/models"
+)
+
+// ids returns the workflow IDs of results in order
+func ids(results []models.SearchWorkflowResult) []string {
+	out := []string{}
+	for _, r := range results {
+		out = append(out, r.WorkflowID)
+	}
+	return out
+}
+
+func TestFuseRRF(t *testing.T) {
+	tests := []struct {
+		name  string
+		lists [][]models.SearchWorkflowResult
+		want  []string
+	}{
+		{"no lists", nil, []string{}},
+		{"one list keeps its order", [][]models.SearchWorkflowResult{
+			{{WorkflowID: "a"}, {WorkflowID: "b"}, {WorkflowID: "c"}},
+		}, []string{"a", "b", "c"}},
+		{"found by both lists first", [][]models.SearchWorkflowResult{
+			{{WorkflowID: "a"}, {WorkflowID: "b"}},
+			{{WorkflowID: "c"}, {WorkflowID: "b"}},
+		}, []string{"b", "a", "c"}},
+		{"ties keep the first list first", [][]models.SearchWorkflowResult{
+			{{WorkflowID: "a"}},
+			{{WorkflowID: "b"}},
+		}, []string{"a", "b"}},
+		{"ranks only, not scores", [][]models.SearchWorkflowResult{
+			{{WorkflowID: "a", Similarity: 0.1}, {WorkflowID: "b", Similarity: 0.99}},
+		}, []string{"a", "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ids(FuseRRF(tt.lists...)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FuseRRF = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFuseRRFScores(t *testing.T) {
+	got := FuseRRF(
+		[]models.SearchWorkflowResult{{WorkflowID: "a", Similarity: 0.9}, {WorkflowID: "b", Similarity: 0.8}},
+		[]models.SearchWorkflowResult{{WorkflowID: "b", Relevance: 0.4}},
+	)
+	if len(got) != 2 {
+		t.Fatalf("got %d results, want 2", len(got))
+	}
+	b := got[0]
+	if want := 1.0/(RRFConstant+2) + 1.0/(RRFConstant+1); math.Abs(b.Score-want) > 1e-12 {
+		t.Errorf("score of b = %v, want %v", b.Score, want)
+	}
+	if b.Similarity != 0.8 || b.Relevance != 0.4 {
+		t.Errorf("b similarity, relevance = %v, %v, want 0.8, 0.4", b.Similarity, b.Relevance)
+	}
+}
+
+func TestFuseWeighted(t *testing.T) {
+	vector := []models.SearchWorkflowResult{
+		{WorkflowID: "a", Similarity: 0.9},
+		{WorkflowID: "b", Similarity: 0.6},
+	}
+	fulltext := []models.SearchWorkflowResult{
+		{WorkflowID: "c", Relevance: 2},
+		{WorkflowID: "b", Relevance: 1},
+	}
+	tests := []struct {
+		name   string
+		weight float64
+		want   []string
+		scores map[string]float64
+	}{
+		{"even", 0.5, []string{"b", "c", "a"}, map[string]float64{"a": 0.45, "b": 0.55, "c": 0.5}},
+		{"vector only", 1, []string{"a", "b", "c"}, map[string]float64{"a": 0.9, "b": 0.6, "c": 0}},
+		{"full text only", 0, []string{"c", "b", "a"}, map[string]float64{"a": 0, "b": 0.5, "c": 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FuseWeighted(vector, fulltext, tt.weight)
+			if order := ids(got); !reflect.DeepEqual(order, tt.want) {
+				t.Errorf("FuseWeighted = %v, want %v", order, tt.want)
+			}
+			for _, r := range got {
+				if math.Abs(r.Score-tt.scores[r.WorkflowID]) > 1e-9 {
+					t.Errorf("score of %s = %v, want %v", r.WorkflowID, r.Score, tt.scores[r.WorkflowID])
+				}
+			}
+		})
+	}
+}
+
+func TestFuseWeightedZeroRelevance(t *testing.T) {
+	got := FuseWeighted(nil, []models.SearchWorkflowResult{{WorkflowID: "a"}}, 0.5)
+	if len(got) != 1 || got[0].Score != 0 {
+		t.Errorf("FuseWeighted = %v, want a scoring 0", got)
+	}
+}
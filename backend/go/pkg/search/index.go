@@ -0,0 +1,145 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/xzero/ai-workflow/pkg/embedding"
+	"github.com/xzero/ai-workflow/pkg/models"
+)
+
+// DefaultBatchSize is the number of workflows embedded per provider call
+const DefaultBatchSize = 50
+
+// RefreshEmbeddings embeds every workflow whose embedding is missing, older
+// than its content_version or computed by another model, batchSize at a time.
+// Switching the provider's model therefore re-embeds all workflows. It
+// returns the number of embeddings stored.
+func RefreshEmbeddings(ctx context.Context, index Index, provider embedding.Provider, batchSize int) (int, error) {
+	if err := checkDimensions(provider); err != nil {
+		return 0, err
+	}
+	if batchSize < 1 {
+		batchSize = DefaultBatchSize
+	}
+
+	total := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+
+		sources, err := index.ListStaleEmbeddings(provider.Model(), batchSize)
+		if err != nil {
+			return total, err
+		}
+		if len(sources) == 0 {
+			return total, nil
+		}
+
+		stored, err := embedBatch(ctx, index, provider, sources)
+		total += stored
+		if err != nil {
+			return total, err
+		}
+		// Every row of the batch changed while it was embedded; stop instead
+		// of picking up the same rows again
+		if stored == 0 || len(sources) < batchSize {
+			return total, nil
+		}
+	}
+}
+
+// EmbedWorkflow refreshes the embedding of one workflow if it is stale
+func EmbedWorkflow(ctx context.Context, index Index, provider embedding.Provider, workflowID string) error {
+	if err := checkDimensions(provider); err != nil {
+		return err
+	}
+
+	source, err := index.GetStaleEmbedding(workflowID, provider.Model())
+	if err != nil || source == nil {
+		return err
+	}
+
+	_, err = embedBatch(ctx, index, provider, []models.EmbeddingSource{*source})
+	return err
+}
+
+// embedBatch embeds sources with one provider call and returns the number of embeddings stored
+func embedBatch(ctx context.Context, index Index, provider embedding.Provider, sources []models.EmbeddingSource) (int, error) {
+	texts := make([]string, len(sources))
+	for i := range sources {
+		texts[i] = WorkflowText(&sources[i])
+	}
+
+	vectors, err := provider.Embed(ctx, texts)
+	if err != nil {
+		return 0, err
+	}
+	if len(vectors) != len(sources) {
+		return 0, fmt.Errorf("embedding provider returned %d vectors for %d texts", len(vectors), len(sources))
+	}
+
+	stored := 0
+	for i, source := range sources {
+		ok, err := index.SetEmbedding(source.WorkflowID, vectors[i], provider.Model(), source.ContentVersion)
+		if err != nil {
+			log.Printf("Error storing embedding of workflow %s: %v", source.WorkflowID, err)
+			continue
+		}
+		if ok {
+			stored++
+		}
+	}
+	return stored, nil
+}
+
+// WorkflowText returns the text that represents a workflow in search: its
+// name, description, parameter names and the titles and descriptions of its
+// input schema. Parameter values are left out since they may hold secrets.
+func WorkflowText(source *models.EmbeddingSource) string {
+	lines := []string{source.WorkflowName, source.Description}
+
+	var parameters map[string]interface{}
+	if json.Unmarshal(source.Parameters, &parameters) == nil && len(parameters) > 0 {
+		names := make([]string, 0, len(parameters))
+		for name := range parameters {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		lines = append(lines, "Parameters: "+strings.Join(names, ", "))
+	}
+
+	var inputSchema struct {
+		Properties map[string]struct {
+			Title       string `json:"title"`
+			Description string `json:"description"`
+		} `json:"properties"`
+	}
+	if json.Unmarshal(source.InputSchema, &inputSchema) == nil {
+		names := make([]string, 0, len(inputSchema.Properties))
+		for name := range inputSchema.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			property := inputSchema.Properties[name]
+			line := strings.TrimSpace(name + " " + property.Title + ": " + property.Description)
+			lines = append(lines, strings.TrimSuffix(line, ":"))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// checkDimensions rejects providers whose vectors do not fit workflows.embedding
+func checkDimensions(provider embedding.Provider) error {
+	if provider.Dimensions() != embedding.Dimensions {
+		return fmt.Errorf("embedding provider %s returns %d dimensions, workflows.embedding has %d", provider.Model(), provider.Dimensions(), embedding.Dimensions)
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+// Package search finds workflows by meaning (vector similarity), by words
+// (PostgreSQL full-text search) or by both at once (hybrid search).
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/xzero/ai-workflow/pkg/embedding"
+	"github.com/xzero/ai-workflow/pkg/models"
+	"github.com/xzero/ai-workflow/pkg/workflow"
+)
+
+// Search modes. MethodVector, MethodFulltext and MethodHybrid are also
+// reported in search_method.
+const (
+	ModeAuto       = "auto"
+	MethodVector   = "vector"
+	MethodFulltext = "fulltext"
+	MethodHybrid   = "hybrid"
+)
+
+// Request limits
+const (
+	DefaultTopK      = 5
+	MaxTopK          = 50
+	DefaultThreshold = 0.7
+	MaxQueryLength   = 1000
+	MaxFilterValues  = 20
+)
+
+// hybridCandidates is the minimum number of results fetched from each
+// search before fusion, so workflows ranked low by one method can still
+// rise with the other
+const hybridCandidates = 20
+
+// ErrVectorUnavailable is returned for vector search without an embedding provider
+var ErrVectorUnavailable = errors.New("vector search is not configured")
+
+// Normalize validates req and fills in the default top_k, threshold, mode,
+// fusion and vector_weight
+func Normalize(req *models.SearchWorkflowRequest) error {
+	req.Query = strings.TrimSpace(req.Query)
+	if req.Query == "" {
+		return errors.New("query is required")
+	}
+	if len([]rune(req.Query)) > MaxQueryLength {
+		return errors.New("query is too long")
+	}
+	if req.ProjectID == "" {
+		return errors.New("project_id is required")
+	}
+
+	if req.TopK == 0 {
+		req.TopK = DefaultTopK
+	}
+	if req.TopK < 1 || req.TopK > MaxTopK {
+		return errors.New("top_k must be between 1 and 50")
+	}
+	if req.Threshold == 0 {
+		req.Threshold = DefaultThreshold
+	}
+	if req.Threshold < 0 || req.Threshold > 1 {
+		return errors.New("threshold must be between 0 and 1")
+	}
+
+	switch req.Mode {
+	case "":
+		req.Mode = ModeAuto
+	case ModeAuto, MethodVector, MethodFulltext, MethodHybrid:
+	default:
+		return errors.New("mode must be one of: auto, vector, fulltext, hybrid")
+	}
+	switch req.Fusion {
+	case "":
+		req.Fusion = FusionRRF
+	case FusionRRF, FusionWeighted:
+	default:
+		return errors.New("fusion must be 'rrf' or 'weighted'")
+	}
+	if req.VectorWeight == nil {
+		weight := DefaultVectorWeight
+		req.VectorWeight = &weight
+	}
+	if *req.VectorWeight < 0 || *req.VectorWeight > 1 {
+		return errors.New("vector_weight must be between 0 and 1")
+	}
+
+	return normalizeFilters(&req.Filters)
+}
+
+func normalizeFilters(filters *models.SearchFilters) error {
+	if len(filters.Sources) > MaxFilterValues || len(filters.TemplateNames) > MaxFilterValues {
+		return fmt.Errorf("filters accept at most %d values each", MaxFilterValues)
+	}
+	for _, source := range filters.Sources {
+		if !workflow.IsSupportedSource(source) {
+			return errors.New("invalid source filter, must be one of: " + strings.Join(workflow.Sources(), ", "))
+		}
+	}
+	for _, templateName := range filters.TemplateNames {
+		if templateName != "workflow" && templateName != "streamflow" {
+			return errors.New("invalid template_name filter, must be 'workflow' or 'streamflow'")
+		}
+	}
+
+	tags, err := NormalizeTags(filters.Tags)
+	if err != nil {
+		return err
+	}
+	filters.Tags = tags
+	filters.CreatorDID = strings.TrimSpace(filters.CreatorDID)
+	return nil
+}
+
+// Index is where workflows are searched and their embeddings kept;
+// store.SearchStore implements it
+type Index interface {
+	SearchVector(vector []float32, model, projectID string, topK int, threshold float64, filters models.SearchFilters) ([]models.SearchWorkflowResult, error)
+	SearchFulltext(text, projectID string, topK int, filters models.SearchFilters) ([]models.SearchWorkflowResult, error)
+	HasEmbeddings(projectID, model string) (bool, error)
+	ListStaleEmbeddings(model string, limit int) ([]models.EmbeddingSource, error)
+	GetStaleEmbedding(workflowID, model string) (*models.EmbeddingSource, error)
+	SetEmbedding(workflowID string, vector []float32, model string, contentVersion int) (bool, error)
+}
+
+// Search returns the workflows visible from req.ProjectID that match
+// req.Query and req.Filters. req must be normalized.
+//
+//   - vector embeds the query with provider and searches by similarity
+//   - fulltext matches English words and CJK bigrams
+//   - hybrid runs both and fuses the rankings with req.Fusion; without a
+//     provider, or when embedding the query fails, it returns the full-text
+//     results alone
+//   - auto searches by vector and falls back to full-text search when
+//     provider is nil, the query cannot be embedded or no searchable
+//     workflow has an embedding of the provider's model
+func Search(ctx context.Context, index Index, provider embedding.Provider, req models.SearchWorkflowRequest) (*models.SearchWorkflowResponse, error) {
+	switch req.Mode {
+	case MethodVector:
+		if provider == nil {
+			return nil, ErrVectorUnavailable
+		}
+		results, err := searchVector(ctx, index, provider, req, req.TopK)
+		if err != nil {
+			return nil, err
+		}
+		if results == nil {
+			results = []models.SearchWorkflowResult{}
+		}
+		return &models.SearchWorkflowResponse{Results: results, SearchMethod: MethodVector}, nil
+
+	case MethodHybrid:
+		return searchHybrid(ctx, index, provider, req)
+
+	case ModeAuto:
+		if provider != nil {
+			results, err := searchVector(ctx, index, provider, req, req.TopK)
+			if err == nil && results != nil {
+				return &models.SearchWorkflowResponse{Results: results, SearchMethod: MethodVector}, nil
+			}
+			if err != nil {
+				log.Printf("Vector search failed, falling back to full-text search: %v", err)
+			}
+		}
+	}
+
+	results, err := index.SearchFulltext(req.Query, req.ProjectID, req.TopK, req.Filters)
+	if err != nil {
+		return nil, err
+	}
+	return &models.SearchWorkflowResponse{Results: results, SearchMethod: MethodFulltext}, nil
+}
+
+// searchHybrid fuses the vector and full-text rankings and keeps the top_k best
+func searchHybrid(ctx context.Context, index Index, provider embedding.Provider, req models.SearchWorkflowRequest) (*models.SearchWorkflowResponse, error) {
+	candidates := req.TopK * 4
+	if candidates < hybridCandidates {
+		candidates = hybridCandidates
+	}
+
+	fulltext, err := index.SearchFulltext(req.Query, req.ProjectID, candidates, req.Filters)
+	if err != nil {
+		return nil, err
+	}
+
+	var vector []models.SearchWorkflowResult
+	if provider != nil {
+		vector, err = searchVector(ctx, index, provider, req, candidates)
+		if err != nil {
+			log.Printf("Vector search failed, using full-text results only: %v", err)
+			vector = nil
+		}
+	}
+	if vector == nil {
+		if len(fulltext) > req.TopK {
+			fulltext = fulltext[:req.TopK]
+		}
+		return &models.SearchWorkflowResponse{Results: fulltext, SearchMethod: MethodFulltext}, nil
+	}
+
+	var results []models.SearchWorkflowResult
+	if req.Fusion == FusionWeighted {
+		results = FuseWeighted(vector, fulltext, *req.VectorWeight)
+	} else {
+		results = FuseRRF(vector, fulltext)
+	}
+	if len(results) > req.TopK {
+		results = results[:req.TopK]
+	}
+	return &models.SearchWorkflowResponse{Results: results, SearchMethod: MethodHybrid}, nil
+}
+
+// searchVector returns nil results without an error when no workflow can be
+// compared with the query
+func searchVector(ctx context.Context, index Index, provider embedding.Provider, req models.SearchWorkflowRequest, limit int) ([]models.SearchWorkflowResult, error) {
+	available, err := index.HasEmbeddings(req.ProjectID, provider.Model())
+	if err != nil || !available {
+		return nil, err
+	}
+
+	vector, err := embedding.EmbedOne(ctx, provider, req.Query)
+	if err != nil {
+		return nil, err
+	}
+	return index.SearchVector(vector, provider.Model(), req.ProjectID, limit, req.Threshold, req.Filters)
+}
@@ -0,0 +1,34 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Tag limits
+const (
+	MaxTags      = 20
+	MaxTagLength = 50
+)
+
+// NormalizeTags trims and lower-cases tags and drops empty and duplicate
+// ones, so filtering by tag does not depend on how a tag was typed
+func NormalizeTags(tags []string) ([]string, error) {
+	normalized := []string{}
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if len([]rune(tag)) > MaxTagLength {
+			return nil, fmt.Errorf("tag %q is longer than %d characters", tag, MaxTagLength)
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	if len(normalized) > MaxTags {
+		return nil, fmt.Errorf("at most %d tags are allowed", MaxTags)
+	}
+	return normalized, nil
+}
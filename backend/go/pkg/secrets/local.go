@@ -0,0 +1,105 @@
+package secrets
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// LocalKeyProvider wraps data keys with a master key held in memory.
+// It is meant for development and self-hosting; production deployments
+// should plug in a KMS backed KeyProvider.
+type LocalKeyProvider struct {
+	keyID string
+	key   []byte
+}
+
+// NewLocalKeyProvider creates a provider from a 32-byte master key
+func NewLocalKeyProvider(key []byte) (*LocalKeyProvider, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("master key must be 32 bytes, got %d", len(key))
+	}
+	sum := sha256.Sum256(key)
+	return &LocalKeyProvider{
+		keyID: "local:" + hex.EncodeToString(sum[:4]),
+		key:   key,
+	}, nil
+}
+
+// LoadLocalKeyFile reads a master key file containing 32 raw bytes or
+// the base64 / hex encoding of 32 bytes
+func LoadLocalKeyFile(path string) (*LocalKeyProvider, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+	if len(b) == 32 {
+		return NewLocalKeyProvider(b)
+	}
+	key, err := decodeKey(strings.TrimSpace(string(b)))
+	if err != nil {
+		return nil, err
+	}
+	return NewLocalKeyProvider(key)
+}
+
+// KeyID identifies the master key
+func (p *LocalKeyProvider) KeyID() string {
+	return p.keyID
+}
+
+// GenerateDataKey returns a random data key and its wrapped form
+func (p *LocalKeyProvider) GenerateDataKey(ctx context.Context) ([]byte, []byte, error) {
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, nil, err
+	}
+	nonce, ciphertext, err := seal(p.key, dataKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return dataKey, append(nonce, ciphertext...), nil
+}
+
+// DecryptDataKey unwraps a data key
+func (p *LocalKeyProvider) DecryptDataKey(ctx context.Context, wrapped []byte) ([]byte, error) {
+	const nonceSize = 12
+	if len(wrapped) < nonceSize {
+		return nil, errors.New("invalid wrapped data key")
+	}
+	return open(p.key, wrapped[:nonceSize], wrapped[nonceSize:])
+}
+
+// ProviderFromEnv returns the key provider configured by SECRETS_KEY_FILE
+// (path to a key file) or SECRETS_KEY (base64 or hex encoded key).
+// It returns nil when neither is set.
+func ProviderFromEnv() (KeyProvider, error) {
+	if path := os.Getenv("SECRETS_KEY_FILE"); path != "" {
+		return LoadLocalKeyFile(path)
+	}
+	if encoded := os.Getenv("SECRETS_KEY"); encoded != "" {
+		key, err := decodeKey(encoded)
+		if err != nil {
+			return nil, err
+		}
+		return NewLocalKeyProvider(key)
+	}
+	return nil, nil
+}
+
+// decodeKey decodes a base64 or hex encoded 32-byte key
+func decodeKey(encoded string) ([]byte, error) {
+	if key, err := hex.DecodeString(encoded); err == nil && len(key) == 32 {
+		return key, nil
+	}
+	if key, err := base64.StdEncoding.DecodeString(encoded); err == nil && len(key) == 32 {
+		return key, nil
+	}
+	return nil, errors.New("master key must be 32 bytes encoded as base64 or hex")
+}
@@ -0,0 +1,92 @@
+package secrets
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Redacted replaces secret values in API responses
+const Redacted = "********"
+
+// sensitiveHeaders are masked in every response
+var sensitiveHeaders = map[string]bool{
+	"authorization":       true,
+	"proxy-authorization": true,
+	"cookie":              true,
+	"set-cookie":          true,
+	"x-api-key":           true,
+	"api-key":             true,
+	"x-auth-token":        true,
+}
+
+// IsSensitiveHeader reports whether a header carries credentials
+func IsSensitiveHeader(name string) bool {
+	name = strings.ToLower(name)
+	return sensitiveHeaders[name] || strings.Contains(name, "token") || strings.Contains(name, "secret")
+}
+
+// IsRedacted reports whether value is, or contains, the redaction placeholder
+func IsRedacted(value string) bool {
+	return strings.Contains(value, Redacted)
+}
+
+// MaskHeaders returns a copy of headers with credential values masked.
+// The auth scheme of Authorization-like headers is kept, e.g. "Bearer ********".
+func MaskHeaders(headers map[string]string) map[string]string {
+	masked := make(map[string]string, len(headers))
+	for k, v := range headers {
+		if !IsSensitiveHeader(k) || v == "" {
+			masked[k] = v
+			continue
+		}
+		if scheme, _, ok := strings.Cut(v, " "); ok {
+			masked[k] = scheme + " " + Redacted
+		} else {
+			masked[k] = Redacted
+		}
+	}
+	return masked
+}
+
+// MaskHeadersJSON masks a JSON object of headers. Invalid JSON is returned unchanged.
+func MaskHeadersJSON(raw json.RawMessage) json.RawMessage {
+	if len(raw) == 0 {
+		return raw
+	}
+	var headers map[string]string
+	if err := json.Unmarshal(raw, &headers); err != nil {
+		return raw
+	}
+	b, err := json.Marshal(MaskHeaders(headers))
+	if err != nil {
+		return raw
+	}
+	return b
+}
+
+// RestoreMaskedHeaders replaces masked values in updated with the values
+// stored in current, so clients can send back headers they received masked
+func RestoreMaskedHeaders(updated, current json.RawMessage) (json.RawMessage, error) {
+	var next map[string]string
+	if err := json.Unmarshal(updated, &next); err != nil {
+		return nil, err
+	}
+	var prev map[string]string
+	if len(current) > 0 {
+		if err := json.Unmarshal(current, &prev); err != nil {
+			return nil, err
+		}
+	}
+
+	for k, v := range next {
+		if IsRedacted(v) {
+			if old, ok := prev[k]; ok {
+				next[k] = old
+			} else {
+				delete(next, k)
+			}
+		}
+	}
+
+	return json.Marshal(next)
+}
@@ -0,0 +1,92 @@
+package secrets
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMaskHeaders(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"Authorization", "Bearer abc", "Bearer " + Redacted},
+		{"Proxy-Authorization", "Basic dXNlcg==", "Basic " + Redacted},
+		{"Cookie", "session=abc", Redacted},
+		{"X-API-Key", "abc", Redacted},
+		{"X-Access-Token", "abc", Redacted},
+		{"Client-Secret", "abc", Redacted},
+		{"X-Trace", "on", "on"},
+		{"Content-Type", "application/json", "application/json"},
+		{"Authorization", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name+"="+tt.value, func(t *testing.T) {
+			got := MaskHeaders(map[string]string{tt.name: tt.value})
+			if got[tt.name] != tt.want {
+				t.Errorf("MaskHeaders = %q, want %q", got[tt.name], tt.want)
+			}
+		})
+	}
+}
+
+func TestMaskHeadersJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{"object", `{"X-API-Key": "abc", "X-Trace": "on"}`, `{"X-API-Key":"` + Redacted + `","X-Trace":"on"}`},
+		{"empty", ``, ``},
+		{"invalid", `[1, 2]`, `[1, 2]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MaskHeadersJSON(json.RawMessage(tt.raw)); string(got) != tt.want {
+				t.Errorf("MaskHeadersJSON = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRestoreMaskedHeaders(t *testing.T) {
+	current := json.RawMessage(`{"Authorization": "Bearer abc", "X-API-Key": "key", "X-Trace": "on"}`)
+
+	tests := []struct {
+		name    string
+		updated string
+		want    map[string]string
+	}{
+		{"masked values are kept", `{"Authorization": "Bearer ********", "X-API-Key": "********", "X-Trace": "off"}`,
+			map[string]string{"Authorization": "Bearer abc", "X-API-Key": "key", "X-Trace": "off"}},
+		{"new values replace them", `{"Authorization": "Bearer xyz"}`,
+			map[string]string{"Authorization": "Bearer xyz"}},
+		{"masked values without a stored value are dropped", `{"Cookie": "********"}`,
+			map[string]string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, err := RestoreMaskedHeaders(json.RawMessage(tt.updated), current)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got map[string]string
+			if err := json.Unmarshal(raw, &got); err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("headers = %v, want %v", got, tt.want)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("%s = %q, want %q", k, got[k], v)
+				}
+			}
+		})
+	}
+
+	if _, err := RestoreMaskedHeaders(json.RawMessage(`{"X": 1}`), current); err == nil {
+		t.Error("RestoreMaskedHeaders accepted a non-string value")
+	}
+}
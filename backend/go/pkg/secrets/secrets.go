@@ -0,0 +1,166 @@
+package secrets
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// envelopePrefix marks values encrypted by Encrypt
+const envelopePrefix = "enc:v1:"
+
+// ErrNoKeyProvider is returned when an encrypted value is read without a key provider
+var ErrNoKeyProvider = errors.New("no secrets key provider configured")
+
+// KeyProvider wraps and unwraps data keys with a master key.
+// The method set mirrors KMS GenerateDataKey / Decrypt so a KMS backed
+// provider can be plugged in without touching callers.
+type KeyProvider interface {
+	// KeyID identifies the master key used to wrap data keys
+	KeyID() string
+	// GenerateDataKey returns a new 256-bit data key in plaintext and wrapped form
+	GenerateDataKey(ctx context.Context) (plaintext, wrapped []byte, err error)
+	// DecryptDataKey unwraps a data key returned by GenerateDataKey
+	DecryptDataKey(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+// envelope is the serialized form of an encrypted value
+type envelope struct {
+	KeyID      string `json:"kid"`
+	DataKey    []byte `json:"dk"`
+	Nonce      []byte `json:"n"`
+	Ciphertext []byte `json:"ct"`
+}
+
+// IsEncrypted reports whether value was produced by Encrypt
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, envelopePrefix)
+}
+
+// Encrypt encrypts plaintext with a fresh data key (envelope encryption).
+// Without a provider the value is returned unchanged so deployments that
+// have not configured a key keep working.
+func Encrypt(ctx context.Context, provider KeyProvider, plaintext string) (string, error) {
+	if provider == nil || plaintext == "" || IsEncrypted(plaintext) {
+		return plaintext, nil
+	}
+
+	dataKey, wrapped, err := provider.GenerateDataKey(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate data key: %w", err)
+	}
+
+	nonce, ciphertext, err := seal(dataKey, []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+
+	b, err := json.Marshal(envelope{
+		KeyID:      provider.KeyID(),
+		DataKey:    wrapped,
+		Nonce:      nonce,
+		Ciphertext: ciphertext,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return envelopePrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Decrypt reverses Encrypt. Values stored before encryption was enabled are
+// returned unchanged.
+func Decrypt(ctx context.Context, provider KeyProvider, value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	if provider == nil {
+		return "", ErrNoKeyProvider
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(value, envelopePrefix))
+	if err != nil {
+		return "", fmt.Errorf("invalid encrypted value: %w", err)
+	}
+	var env envelope
+	if err := json.Unmarshal(b, &env); err != nil {
+		return "", fmt.Errorf("invalid encrypted value: %w", err)
+	}
+
+	dataKey, err := provider.DecryptDataKey(ctx, env.DataKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt data key %s: %w", env.KeyID, err)
+	}
+
+	plaintext, err := open(dataKey, env.Nonce, env.Ciphertext)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
+
+// seal encrypts plaintext with AES-256-GCM
+func seal(key, plaintext []byte) (nonce, ciphertext []byte, err error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, err
+	}
+	return nonce, gcm.Seal(nil, nonce, plaintext, nil), nil
+}
+
+// open decrypts ciphertext produced by seal
+func open(key, nonce, ciphertext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != gcm.NonceSize() {
+		return nil, errors.New("invalid nonce size")
+	}
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// EncryptJSON encrypts a JSON value and stores the envelope as a JSON string,
+// so the result still fits JSONB columns
+func EncryptJSON(ctx context.Context, provider KeyProvider, raw json.RawMessage) (json.RawMessage, error) {
+	if provider == nil || len(raw) == 0 {
+		return raw, nil
+	}
+	encrypted, err := Encrypt(ctx, provider, string(raw))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(encrypted)
+}
+
+// DecryptJSON reverses EncryptJSON. Plain JSON values are returned unchanged.
+func DecryptJSON(ctx context.Context, provider KeyProvider, raw json.RawMessage) (json.RawMessage, error) {
+	var value string
+	if json.Unmarshal(raw, &value) != nil || !IsEncrypted(value) {
+		return raw, nil
+	}
+	plaintext, err := Decrypt(ctx, provider, value)
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(plaintext), nil
+}
@@ -0,0 +1,139 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	ctx := context.Background()
+	keys, err := NewLocalKeyProvider(bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		plaintext     string
+		wantEncrypted bool
+	}{
+		{"token", "app-xxxxxxxx", true},
+		{"unicode", "令牌 🔑", true},
+		{"empty", "", false},
+		{"already encrypted", "enc:v1:abc", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encrypted, err := Encrypt(ctx, keys, tt.plaintext)
+			if err != nil {
+				t.Fatalf("Encrypt: %v", err)
+			}
+			if !tt.wantEncrypted {
+				if encrypted != tt.plaintext {
+					t.Errorf("Encrypt = %q, want it unchanged", encrypted)
+				}
+				return
+			}
+			if !IsEncrypted(encrypted) || strings.Contains(encrypted, tt.plaintext) {
+				t.Errorf("Encrypt = %q, carries the plaintext", encrypted)
+			}
+			decrypted, err := Decrypt(ctx, keys, encrypted)
+			if err != nil {
+				t.Fatalf("Decrypt: %v", err)
+			}
+			if decrypted != tt.plaintext {
+				t.Errorf("Decrypt = %q, want %q", decrypted, tt.plaintext)
+			}
+		})
+	}
+}
+
+func TestEncryptUsesFreshDataKeys(t *testing.T) {
+	ctx := context.Background()
+	keys, err := NewLocalKeyProvider(bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := Encrypt(ctx, keys, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Encrypt(ctx, keys, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first == second {
+		t.Error("encrypting twice gave the same value")
+	}
+}
+
+func TestDecryptFailures(t *testing.T) {
+	ctx := context.Background()
+	keys, err := NewLocalKeyProvider(bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKeys, err := NewLocalKeyProvider(bytes.Repeat([]byte{8}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted, err := Encrypt(ctx, keys, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		provider KeyProvider
+		value    string
+	}{
+		{"no provider", nil, encrypted},
+		{"other master key", otherKeys, encrypted},
+		{"not base64", keys, "enc:v1:***"},
+		{"not an envelope", keys, "enc:v1:bm90IGpzb24"},
+		{"truncated", keys, encrypted[:len(encrypted)-8]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := Decrypt(ctx, tt.provider, tt.value); err == nil {
+				t.Errorf("Decrypt = %q, want an error", got)
+			}
+		})
+	}
+
+	// Values stored before encryption was enabled are read as they are
+	if got, err := Decrypt(ctx, nil, "plain-token"); err != nil || got != "plain-token" {
+		t.Errorf("Decrypt(plaintext) = %q, %v", got, err)
+	}
+}
+
+func TestEncryptJSON(t *testing.T) {
+	ctx := context.Background()
+	keys, err := NewLocalKeyProvider(bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw := json.RawMessage(`{"Authorization": "Bearer abc"}`)
+
+	encrypted, err := EncryptJSON(ctx, keys, raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var value string
+	if err := json.Unmarshal(encrypted, &value); err != nil || !IsEncrypted(value) {
+		t.Fatalf("EncryptJSON = %s, want a JSON string holding an envelope", encrypted)
+	}
+	decrypted, err := DecryptJSON(ctx, keys, encrypted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(decrypted) != string(raw) {
+		t.Errorf("DecryptJSON = %s, want %s", decrypted, raw)
+	}
+	if plain, err := DecryptJSON(ctx, keys, raw); err != nil || string(plain) != string(raw) {
+		t.Errorf("DecryptJSON(plain) = %s, %v", plain, err)
+	}
+}
@@ -0,0 +1,1141 @@
+package store
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xzero/ai-workflow/pkg/db"
+	"github.com/xzero/ai-workflow/pkg/models"
+)
+
+// Memory is a WorkflowStore, RunStore, SearchStore, APIKeyStore and
+// AuditStore kept in process memory, for tests and local development. It is
+// safe for concurrent use.
+type Memory struct {
+	mu        sync.Mutex
+	workflows map[string]*models.Workflow
+	trash     map[string]*models.Workflow         // deleted workflows, out of workflows until restored
+	versions  map[string][]models.WorkflowVersion // oldest first
+	hidden    map[string]map[string]bool          // project_id -> workflow_id -> hidden
+	members   map[string]map[string]string        // project_id -> user_did -> role
+	grants    map[string][]models.WorkflowGrant   // workflow_id -> grants, oldest first
+	shares    map[string][]models.WorkflowShare   // workflow_id -> shares, oldest first
+	orgs      map[string]map[string]bool          // organization_id -> project_id -> member
+	apiKeys   map[string]*models.APIKey           // key_id -> key
+	audit     []models.AuditEvent                 // oldest first
+	runs      []models.WorkflowRun                // oldest first
+	vectors   map[string]memoryEmbedding          // workflow_id -> embedding
+}
+
+// memoryEmbedding is the embedding of a workflow with the model and content
+// version it was computed from
+type memoryEmbedding struct {
+	vector         []float32
+	model          string
+	contentVersion int
+}
+
+// NewMemory returns an empty store without any project members
+func NewMemory() *Memory {
+	return &Memory{
+		workflows: map[string]*models.Workflow{},
+		trash:     map[string]*models.Workflow{},
+		versions:  map[string][]models.WorkflowVersion{},
+		hidden:    map[string]map[string]bool{},
+		members:   map[string]map[string]string{},
+		grants:    map[string][]models.WorkflowGrant{},
+		shares:    map[string][]models.WorkflowShare{},
+		orgs:      map[string]map[string]bool{},
+		apiKeys:   map[string]*models.APIKey{},
+		vectors:   map[string]memoryEmbedding{},
+	}
+}
+
+// AddMember adds userDID to a project with role, such as models.RoleAdmin
+func (m *Memory) AddMember(projectID, userDID, role string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.members[projectID] == nil {
+		m.members[projectID] = map[string]string{}
+	}
+	m.members[projectID][userDID] = role
+}
+
+// AddOrganizationProject adds a project to an organization, so workflows
+// shared with the organization reach it
+func (m *Memory) AddOrganizationProject(organizationID, projectID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.orgs[organizationID] == nil {
+		m.orgs[organizationID] = map[string]bool{}
+	}
+	m.orgs[organizationID][projectID] = true
+}
+
+// Get returns a copy of the workflow
+func (m *Memory) Get(workflowID string) (*models.Workflow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	w, ok := m.workflows[workflowID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	c := copyWorkflow(w)
+	c.Version = len(m.versions[workflowID])
+	return &c, nil
+}
+
+// List filters, sorts and pages the workflows like db.ListWorkflows. Names
+// are compared byte-wise rather than with the database collation.
+func (m *Memory) List(filter models.ListWorkflowsFilter) ([]models.Workflow, error) {
+	if !db.IsWorkflowSort(filter.Sort) {
+		return nil, fmt.Errorf("unsupported sort: %q", filter.Sort)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	query := strings.ToLower(filter.Query)
+	workflows := []models.Workflow{}
+	for _, w := range m.workflows {
+		own := w.ProjectID == filter.ProjectID
+		switch filter.Scope {
+		case models.WorkflowScopeOwn:
+			if !own {
+				continue
+			}
+		case models.WorkflowScopeShared:
+			if own || !m.sharedWith(w, filter.ProjectID) {
+				continue
+			}
+		default:
+			if !own && !m.sharedWith(w, filter.ProjectID) {
+				continue
+			}
+		}
+
+		hidden := m.hidden[filter.ProjectID][w.WorkflowID]
+		if hidden && !filter.IncludeHidden {
+			continue
+		}
+		if (filter.Source != "" && w.Source != filter.Source) ||
+			(filter.TemplateName != "" && w.TemplateName != filter.TemplateName) ||
+			(filter.CreatorDID != "" && w.CreatorDID != filter.CreatorDID) ||
+			(filter.Tag != "" && !contains(w.Tags, filter.Tag)) {
+			continue
+		}
+		if query != "" && !strings.Contains(strings.ToLower(w.WorkflowName), query) &&
+			!strings.Contains(strings.ToLower(w.Description), query) {
+			continue
+		}
+
+		c := copyWorkflow(w)
+		c.BearerToken = ""
+		c.IsHidden = hidden
+		if filter.After != nil && !after(db.WorkflowCursorOf(&c, filter.Sort, filter.Descending), *filter.After) {
+			continue
+		}
+		workflows = append(workflows, c)
+	}
+
+	sort.Slice(workflows, func(i, j int) bool {
+		return after(
+			db.WorkflowCursorOf(&workflows[j], filter.Sort, filter.Descending),
+			db.WorkflowCursorOf(&workflows[i], filter.Sort, filter.Descending),
+		)
+	})
+	if filter.Limit > 0 && len(workflows) > filter.Limit {
+		workflows = workflows[:filter.Limit]
+	}
+	return workflows, nil
+}
+
+// Create stores the workflow with a new ID and records version 1
+func (m *Memory) Create(req *models.CreateWorkflowRequest, creatorDID string) (string, error) {
+	workflowID, err := newID()
+	if err != nil {
+		return "", err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	w := &models.Workflow{
+		WorkflowID:         workflowID,
+		WorkflowName:       req.WorkflowName,
+		Description:        req.Description,
+		Source:             req.Source,
+		TemplateName:       req.TemplateName,
+		HTTPMethod:         req.HTTPMethod,
+		BaseURL:            req.BaseURL,
+		BearerToken:        req.BearerToken,
+		ExternalWorkflowID: req.ExternalWorkflowID,
+		Parameters:         req.Parameters,
+		Headers:            req.Headers,
+		InputSchema:        req.InputSchema,
+		ProjectID:          req.ProjectID,
+		CreatorDID:         creatorDID,
+		Tags:               append([]string{}, req.Tags...),
+		CreatedAt:          now,
+		UpdatedAt:          now,
+		RowVersion:         1,
+	}
+	m.workflows[workflowID] = w
+
+	v := models.DefinitionOf(w)
+	v.Version = 1
+	v.ChangeType = models.VersionChangeCreate
+	v.ChangedFields = []string{}
+	v.ChangedBy = creatorDID
+	v.CreatedAt = now
+	m.versions[workflowID] = []models.WorkflowVersion{*v}
+
+	return workflowID, nil
+}
+
+// Update applies the fields set in req
+func (m *Memory) Update(workflowID string, req *models.UpdateWorkflowRequest, changedBy string, rowVersion int) (*models.WorkflowVersion, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	w, ok := m.workflows[workflowID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if !matchRowVersion(w, rowVersion) {
+		return nil, ErrPreconditionFailed
+	}
+	previous := m.latest(workflowID)
+
+	changed := false
+	setText := func(dst *string, src *string) {
+		if src != nil {
+			*dst = *src
+			changed = true
+		}
+	}
+	setText(&w.WorkflowName, req.WorkflowName)
+	setText(&w.Description, req.Description)
+	setText(&w.Source, req.Source)
+	setText(&w.TemplateName, req.TemplateName)
+	setText(&w.HTTPMethod, req.HTTPMethod)
+	setText(&w.BaseURL, req.BaseURL)
+	setText(&w.BearerToken, req.BearerToken)
+	setText(&w.ExternalWorkflowID, req.ExternalWorkflowID)
+	if req.Parameters != nil {
+		w.Parameters = *req.Parameters
+		changed = true
+	}
+	if req.Headers != nil {
+		w.Headers = *req.Headers
+		changed = true
+	}
+	if req.InputSchema != nil {
+		w.InputSchema = *req.InputSchema
+		changed = true
+	}
+	if req.Tags != nil {
+		w.Tags = append([]string{}, (*req.Tags)...)
+		changed = true
+	}
+
+	if !changed {
+		return nil, nil
+	}
+	w.UpdatedAt = time.Now()
+	w.RowVersion++
+
+	return m.saveVersion(w, previous, changedBy, models.VersionChangeUpdate, 0), nil
+}
+
+// Rollback restores the definition of target
+func (m *Memory) Rollback(target *models.WorkflowVersion, changedBy string, rowVersion int) (*models.WorkflowVersion, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	w, ok := m.workflows[target.WorkflowID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if !matchRowVersion(w, rowVersion) {
+		return nil, ErrPreconditionFailed
+	}
+	previous := m.latest(target.WorkflowID)
+
+	target.Apply(w)
+	w.UpdatedAt = time.Now()
+
+	v := m.saveVersion(w, previous, changedBy, models.VersionChangeRollback, target.Version)
+	if v != nil {
+		w.RowVersion++
+	}
+	return v, nil
+}
+
+// Delete moves the workflow to the trash, keeping its versions, grants,
+// shares and hidden flags
+func (m *Memory) Delete(workflowID, deletedBy string, rowVersion int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	w, ok := m.workflows[workflowID]
+	if !ok {
+		return ErrNotFound
+	}
+	if !matchRowVersion(w, rowVersion) {
+		return ErrPreconditionFailed
+	}
+	now := time.Now()
+	w.DeletedAt = &now
+	w.DeletedBy = deletedBy
+	w.UpdatedAt = now
+	w.RowVersion++
+	m.trash[workflowID] = w
+	delete(m.workflows, workflowID)
+	return nil
+}
+
+// GetDeleted returns a copy of a workflow in the trash
+func (m *Memory) GetDeleted(workflowID string) (*models.Workflow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	w, ok := m.trash[workflowID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	c := copyWorkflow(w)
+	c.Version = len(m.versions[workflowID])
+	return &c, nil
+}
+
+// ListDeleted returns the workflows of projectID in the trash, most recently
+// deleted first
+func (m *Memory) ListDeleted(projectID string, limit, offset int) ([]models.Workflow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	workflows := []models.Workflow{}
+	for _, w := range m.trash {
+		if w.ProjectID == projectID {
+			c := copyWorkflow(w)
+			c.BearerToken = ""
+			workflows = append(workflows, c)
+		}
+	}
+	sort.Slice(workflows, func(i, j int) bool {
+		a, b := workflows[i], workflows[j]
+		if !a.DeletedAt.Equal(*b.DeletedAt) {
+			return a.DeletedAt.After(*b.DeletedAt)
+		}
+		return a.WorkflowID < b.WorkflowID
+	})
+
+	if offset >= len(workflows) {
+		return []models.Workflow{}, nil
+	}
+	workflows = workflows[offset:]
+	if len(workflows) > limit {
+		workflows = workflows[:limit]
+	}
+	return workflows, nil
+}
+
+// Restore moves a workflow out of the trash
+func (m *Memory) Restore(workflowID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	w, ok := m.trash[workflowID]
+	if !ok {
+		return ErrNotFound
+	}
+	w.DeletedAt = nil
+	w.DeletedBy = ""
+	w.UpdatedAt = time.Now()
+	w.RowVersion++
+	m.workflows[workflowID] = w
+	delete(m.trash, workflowID)
+	return nil
+}
+
+// GetUsage returns the shares of the workflow, the other projects that hid
+// or showed it, and the other projects its runs were recorded against since
+// since
+func (m *Memory) GetUsage(workflowID string, since time.Time) (*models.WorkflowUsage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	usage := &models.WorkflowUsage{
+		SharedProjectIDs:      []string{},
+		SharedOrganizationIDs: []string{},
+		SettingsProjectIDs:    []string{},
+		RunProjectIDs:         []string{},
+	}
+	for _, s := range m.shares[workflowID] {
+		if s.TargetType == models.ShareTargetOrganization {
+			usage.SharedOrganizationIDs = append(usage.SharedOrganizationIDs, s.TargetID)
+		} else {
+			usage.SharedProjectIDs = append(usage.SharedProjectIDs, s.TargetID)
+		}
+	}
+
+	owner := ""
+	if w, ok := m.workflows[workflowID]; ok {
+		owner = w.ProjectID
+		usage.IsShared = w.IsShared
+	} else if w, ok := m.trash[workflowID]; ok {
+		owner = w.ProjectID
+		usage.IsShared = w.IsShared
+	}
+	for projectID, hidden := range m.hidden {
+		if _, ok := hidden[workflowID]; ok && projectID != owner {
+			usage.SettingsProjectIDs = append(usage.SettingsProjectIDs, projectID)
+		}
+	}
+	sort.Strings(usage.SettingsProjectIDs)
+
+	seen := map[string]bool{}
+	for _, r := range m.runs {
+		if r.WorkflowID == workflowID && r.ProjectID != owner && !r.CreatedAt.Before(since) && !seen[r.ProjectID] {
+			seen[r.ProjectID] = true
+			usage.RunProjectIDs = append(usage.RunProjectIDs, r.ProjectID)
+		}
+	}
+	sort.Strings(usage.RunProjectIDs)
+	return usage, nil
+}
+
+// SetShared updates the is_shared flag of the workflow
+func (m *Memory) SetShared(workflowID string, isShared bool, rowVersion int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	w, ok := m.workflows[workflowID]
+	if !ok {
+		return ErrNotFound
+	}
+	if !matchRowVersion(w, rowVersion) {
+		return ErrPreconditionFailed
+	}
+	if w.IsShared != isShared {
+		w.IsShared = isShared
+		w.RowVersion++
+	}
+	return nil
+}
+
+// matchRowVersion reports whether a write expecting rowVersion may apply to w
+func matchRowVersion(w *models.Workflow, rowVersion int) bool {
+	return rowVersion == 0 || w.RowVersion == rowVersion
+}
+
+// SetHidden hides or shows the workflow in the list of projectID
+func (m *Memory) SetHidden(projectID, workflowID string, isHidden bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.workflows[workflowID]; !ok {
+		return ErrNotFound
+	}
+	if m.hidden[projectID] == nil {
+		m.hidden[projectID] = map[string]bool{}
+	}
+	m.hidden[projectID][workflowID] = isHidden
+	return nil
+}
+
+// GetVersion returns one version of the workflow
+func (m *Memory) GetVersion(workflowID string, version int) (*models.WorkflowVersion, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, v := range m.versions[workflowID] {
+		if v.Version == version {
+			return copyVersion(&v), nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// GetLatestVersion returns the newest version of the workflow
+func (m *Memory) GetLatestVersion(workflowID string) (*models.WorkflowVersion, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	versions := m.versions[workflowID]
+	if len(versions) == 0 {
+		return nil, ErrNotFound
+	}
+	return copyVersion(&versions[len(versions)-1]), nil
+}
+
+// ListVersions returns a page of versions, newest first
+func (m *Memory) ListVersions(workflowID string, limit, offset int) ([]models.WorkflowVersion, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	versions := m.versions[workflowID]
+	page := []models.WorkflowVersion{}
+	for i := len(versions) - 1 - offset; i >= 0 && len(page) < limit; i-- {
+		page = append(page, *copyVersion(&versions[i]))
+	}
+	return page, nil
+}
+
+// GetProjectRole returns the role principal was added with
+func (m *Memory) GetProjectRole(principal, projectID string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.members[projectID][principal], nil
+}
+
+// GetWorkflowRole returns the role granted to principal on the workflow
+func (m *Memory) GetWorkflowRole(principal, workflowID string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, g := range m.grants[workflowID] {
+		if g.UserDID == principal {
+			return g.Role, nil
+		}
+	}
+	return "", nil
+}
+
+// ListGrants returns the grants of a workflow, oldest first
+func (m *Memory) ListGrants(workflowID string) ([]models.WorkflowGrant, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]models.WorkflowGrant{}, m.grants[workflowID]...), nil
+}
+
+// SetGrant replaces the grant of the user, which then counts as the newest
+func (m *Memory) SetGrant(grant *models.WorkflowGrant) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.workflows[grant.WorkflowID]; !ok {
+		return ErrNotFound
+	}
+	grant.CreatedAt = time.Now()
+	m.deleteGrant(grant.WorkflowID, grant.UserDID)
+	m.grants[grant.WorkflowID] = append(m.grants[grant.WorkflowID], *grant)
+	return nil
+}
+
+// DeleteGrant removes the grant of the user
+func (m *Memory) DeleteGrant(workflowID, userDID string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.deleteGrant(workflowID, userDID), nil
+}
+
+// deleteGrant removes a grant; m.mu must be held
+func (m *Memory) deleteGrant(workflowID, userDID string) bool {
+	grants := m.grants[workflowID]
+	for i, g := range grants {
+		if g.UserDID == userDID {
+			m.grants[workflowID] = append(grants[:i:i], grants[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// GetProjectShareAccess returns the best access of the shares targeting the
+// project or one of its organizations
+func (m *Memory) GetProjectShareAccess(projectID, workflowID string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.projectShareAccess(projectID, workflowID), nil
+}
+
+// GetShareAccess returns the best access of the projects userDID was added to
+func (m *Memory) GetShareAccess(userDID, workflowID string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	best := ""
+	for projectID, members := range m.members {
+		access := models.ShareAccessOf(m.projectShareAccess(projectID, workflowID), members[userDID])
+		if access == models.ShareAccessExecute || best == "" {
+			best = access
+		}
+	}
+	return best, nil
+}
+
+// ListShares returns the shares of a workflow, oldest first
+func (m *Memory) ListShares(workflowID string) ([]models.WorkflowShare, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]models.WorkflowShare{}, m.shares[workflowID]...), nil
+}
+
+// SetShare replaces the share with the target, which then counts as the newest
+func (m *Memory) SetShare(share *models.WorkflowShare) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.workflows[share.WorkflowID]; !ok {
+		return ErrNotFound
+	}
+	share.CreatedAt = time.Now()
+	m.deleteShare(share.WorkflowID, share.TargetType, share.TargetID)
+	m.shares[share.WorkflowID] = append(m.shares[share.WorkflowID], *share)
+	return nil
+}
+
+// DeleteShare revokes the share with the target
+func (m *Memory) DeleteShare(workflowID, targetType, targetID string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.deleteShare(workflowID, targetType, targetID), nil
+}
+
+// deleteShare removes a share; m.mu must be held
+func (m *Memory) deleteShare(workflowID, targetType, targetID string) bool {
+	shares := m.shares[workflowID]
+	for i, s := range shares {
+		if s.TargetType == targetType && s.TargetID == targetID {
+			m.shares[workflowID] = append(shares[:i:i], shares[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// projectShareAccess is GetProjectShareAccess; m.mu must be held
+func (m *Memory) projectShareAccess(projectID, workflowID string) string {
+	best := ""
+	for _, s := range m.shares[workflowID] {
+		if (s.TargetType == models.ShareTargetProject && s.TargetID == projectID) ||
+			(s.TargetType == models.ShareTargetOrganization && m.orgs[s.TargetID][projectID]) {
+			if s.Access == models.ShareAccessExecute || best == "" {
+				best = s.Access
+			}
+		}
+	}
+	return best
+}
+
+// sharedWith reports whether w is shared with every project or with
+// projectID; m.mu must be held
+func (m *Memory) sharedWith(w *models.Workflow, projectID string) bool {
+	return w.IsShared || m.projectShareAccess(projectID, w.WorkflowID) != ""
+}
+
+// CreateAPIKey stores a copy of key
+func (m *Memory) CreateAPIKey(key *models.APIKey) (string, error) {
+	id, err := newID()
+	if err != nil {
+		return "", err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, k := range m.apiKeys {
+		if k.Prefix == key.Prefix {
+			return "", fmt.Errorf("duplicate API key prefix %q", key.Prefix)
+		}
+	}
+	key.KeyID = id
+	key.CreatedAt = time.Now()
+	c := copyAPIKey(key)
+	m.apiKeys[id] = &c
+	return id, nil
+}
+
+// GetAPIKeyByPrefix returns a copy of the key with prefix
+func (m *Memory) GetAPIKeyByPrefix(prefix string) (*models.APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, k := range m.apiKeys {
+		if k.Prefix == prefix {
+			c := copyAPIKey(k)
+			return &c, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// ListAPIKeys returns copies of the keys of a project, newest first
+func (m *Memory) ListAPIKeys(projectID string) ([]models.APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keys := []models.APIKey{}
+	for _, k := range m.apiKeys {
+		if k.ProjectID == projectID {
+			keys = append(keys, copyAPIKey(k))
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.After(keys[j].CreatedAt)
+	})
+	return keys, nil
+}
+
+// RevokeAPIKey revokes a key of the project, keeping an earlier revocation time
+func (m *Memory) RevokeAPIKey(projectID, keyID string) (*models.APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	k, ok := m.apiKeys[keyID]
+	if !ok || k.ProjectID != projectID {
+		return nil, ErrNotFound
+	}
+	if k.RevokedAt == nil {
+		now := time.Now()
+		k.RevokedAt = &now
+	}
+	c := copyAPIKey(k)
+	return &c, nil
+}
+
+// TouchAPIKey sets the last use of a key to now
+func (m *Memory) TouchAPIKey(keyID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if k, ok := m.apiKeys[keyID]; ok {
+		now := time.Now()
+		k.LastUsedAt = &now
+	}
+	return nil
+}
+
+// AppendAuditEvent appends a copy of event
+func (m *Memory) AppendAuditEvent(event *models.AuditEvent) error {
+	id, err := newID()
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	event.EventID = id
+	event.CreatedAt = time.Now()
+	m.audit = append(m.audit, *event)
+	return nil
+}
+
+// ListAuditEvents filters and pages the audit log like db.ListAuditEvents
+func (m *Memory) ListAuditEvents(filter models.ListAuditEventsFilter) ([]models.AuditEvent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	matches := []models.AuditEvent{}
+	for i := range m.audit {
+		e := m.audit[i]
+		if !filter.Ascending {
+			e = m.audit[len(m.audit)-1-i]
+		}
+		if (filter.ProjectID != "" && e.ProjectID != filter.ProjectID) ||
+			(filter.ActorDID != "" && e.ActorDID != filter.ActorDID) ||
+			(filter.Action != "" && e.Action != filter.Action) ||
+			(filter.WorkflowID != "" && e.WorkflowID != filter.WorkflowID) ||
+			(filter.Since != nil && e.CreatedAt.Before(*filter.Since)) ||
+			(filter.Until != nil && !e.CreatedAt.Before(*filter.Until)) {
+			continue
+		}
+		matches = append(matches, e)
+	}
+
+	if filter.Offset >= len(matches) {
+		return []models.AuditEvent{}, nil
+	}
+	matches = matches[filter.Offset:]
+	if len(matches) > filter.Limit {
+		matches = matches[:filter.Limit]
+	}
+	return matches, nil
+}
+
+// CreateRun appends a copy of run with a new ID
+func (m *Memory) CreateRun(run *models.WorkflowRun) (string, error) {
+	runID, err := newID()
+	if err != nil {
+		return "", err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c := *run
+	c.RunID = runID
+	if c.Parameters == nil {
+		c.Parameters = []byte("{}")
+	}
+	m.runs = append(m.runs, c)
+	return runID, nil
+}
+
+// GetRun returns a copy of the run
+func (m *Memory) GetRun(runID string) (*models.WorkflowRun, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.runs {
+		if m.runs[i].RunID == runID {
+			c := m.runs[i]
+			return &c, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// ListRuns filters and pages the runs like db.ListRuns
+func (m *Memory) ListRuns(filter models.ListRunsFilter) ([]models.WorkflowRun, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	matches := []models.WorkflowRun{}
+	for i := len(m.runs) - 1; i >= 0; i-- {
+		r := m.runs[i]
+		if (filter.WorkflowID != "" && r.WorkflowID != filter.WorkflowID) ||
+			(filter.ProjectID != "" && r.ProjectID != filter.ProjectID) ||
+			(filter.CallerDID != "" && r.CallerDID != filter.CallerDID) ||
+			(filter.Status != "" && r.Status != filter.Status) ||
+			(filter.Since != nil && r.CreatedAt.Before(*filter.Since)) ||
+			(filter.Until != nil && !r.CreatedAt.Before(*filter.Until)) {
+			continue
+		}
+		r.ResponseBody = ""
+		matches = append(matches, r)
+	}
+	// Runs recorded in the same instant keep their reverse insertion order
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].CreatedAt.After(matches[j].CreatedAt)
+	})
+
+	if filter.Offset >= len(matches) {
+		return []models.WorkflowRun{}, nil
+	}
+	matches = matches[filter.Offset:]
+	if len(matches) > filter.Limit {
+		matches = matches[:filter.Limit]
+	}
+	return matches, nil
+}
+
+// CancelRun cancels the run if it is queued or running
+func (m *Memory) CancelRun(runID string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.runs {
+		r := &m.runs[i]
+		if r.RunID != runID {
+			continue
+		}
+		if r.Status != models.RunStatusQueued && r.Status != models.RunStatusRunning {
+			return false, nil
+		}
+		now := time.Now()
+		r.Status = models.RunStatusCancelled
+		r.Error = "cancelled by user"
+		r.FinishedAt = &now
+		return true, nil
+	}
+	return false, nil
+}
+
+// SearchVector ranks the searchable workflows by the cosine similarity of
+// their embedding of model with vector
+func (m *Memory) SearchVector(vector []float32, model, projectID string, topK int, threshold float64, filters models.SearchFilters) ([]models.SearchWorkflowResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	results := []models.SearchWorkflowResult{}
+	for _, w := range m.searchable(projectID, filters) {
+		e, ok := m.vectors[w.WorkflowID]
+		if !ok || e.model != model || len(e.vector) != len(vector) {
+			continue
+		}
+		similarity := cosine(e.vector, vector)
+		if similarity < threshold {
+			continue
+		}
+		r := searchResult(w)
+		r.Similarity = similarity
+		results = append(results, r)
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Similarity > results[j].Similarity })
+	if len(results) > topK {
+		results = results[:topK]
+	}
+	return results, nil
+}
+
+// SearchFulltext matches the lower-cased words of text in the name,
+// description and tags of the searchable workflows. Relevance is the share
+// of the words found; there is no stemming and no CJK bigrams.
+func (m *Memory) SearchFulltext(text, projectID string, topK int, filters models.SearchFilters) ([]models.SearchWorkflowResult, error) {
+	words := strings.Fields(strings.ToLower(text))
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	results := []models.SearchWorkflowResult{}
+	if len(words) == 0 {
+		return results, nil
+	}
+	for _, w := range m.searchable(projectID, filters) {
+		document := strings.ToLower(w.WorkflowName + " " + w.Description + " " + strings.Join(w.Tags, " "))
+		found := 0
+		for _, word := range words {
+			if strings.Contains(document, word) {
+				found++
+			}
+		}
+		if found == 0 {
+			continue
+		}
+		r := searchResult(w)
+		r.Relevance = float64(found) / float64(len(words))
+		results = append(results, r)
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Relevance > results[j].Relevance })
+	if len(results) > topK {
+		results = results[:topK]
+	}
+	return results, nil
+}
+
+// HasEmbeddings reports whether a searchable workflow has an embedding of model
+func (m *Memory) HasEmbeddings(projectID, model string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, w := range m.searchable(projectID, models.SearchFilters{}) {
+		if e, ok := m.vectors[w.WorkflowID]; ok && e.model == model {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ListStaleEmbeddings returns workflows without a current embedding of
+// model, by workflow ID. The content version is the number of versions.
+func (m *Memory) ListStaleEmbeddings(model string, limit int) ([]models.EmbeddingSource, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ids := make([]string, 0, len(m.workflows))
+	for id := range m.workflows {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	sources := []models.EmbeddingSource{}
+	for _, id := range ids {
+		if len(sources) >= limit {
+			break
+		}
+		if source := m.staleEmbedding(id, model); source != nil {
+			sources = append(sources, *source)
+		}
+	}
+	return sources, nil
+}
+
+// GetStaleEmbedding returns the content of the workflow if its embedding of
+// model is stale
+func (m *Memory) GetStaleEmbedding(workflowID, model string) (*models.EmbeddingSource, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.staleEmbedding(workflowID, model), nil
+}
+
+// SetEmbedding stores a copy of vector while the workflow has contentVersion
+func (m *Memory) SetEmbedding(workflowID string, vector []float32, model string, contentVersion int) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.workflows[workflowID]; !ok || len(m.versions[workflowID]) != contentVersion {
+		return false, nil
+	}
+	m.vectors[workflowID] = memoryEmbedding{
+		vector:         append([]float32{}, vector...),
+		model:          model,
+		contentVersion: contentVersion,
+	}
+	return true, nil
+}
+
+// searchable returns the workflows visible from projectID, neither hidden
+// there nor deleted, that match filters; m.mu must be held
+func (m *Memory) searchable(projectID string, filters models.SearchFilters) []*models.Workflow {
+	workflows := []*models.Workflow{}
+	for _, w := range m.workflows {
+		if w.ProjectID != projectID && !m.sharedWith(w, projectID) {
+			continue
+		}
+		if m.hidden[projectID][w.WorkflowID] {
+			continue
+		}
+		if (len(filters.Sources) > 0 && !contains(filters.Sources, w.Source)) ||
+			(len(filters.TemplateNames) > 0 && !contains(filters.TemplateNames, w.TemplateName)) ||
+			(filters.CreatorDID != "" && w.CreatorDID != filters.CreatorDID) {
+			continue
+		}
+		missing := false
+		for _, tag := range filters.Tags {
+			if !contains(w.Tags, tag) {
+				missing = true
+				break
+			}
+		}
+		if !missing {
+			workflows = append(workflows, w)
+		}
+	}
+	// Map order is random; keep ties in a stable order
+	sort.Slice(workflows, func(i, j int) bool { return workflows[i].WorkflowID < workflows[j].WorkflowID })
+	return workflows
+}
+
+// staleEmbedding is GetStaleEmbedding; m.mu must be held
+func (m *Memory) staleEmbedding(workflowID, model string) *models.EmbeddingSource {
+	w, ok := m.workflows[workflowID]
+	if !ok {
+		return nil
+	}
+	contentVersion := len(m.versions[workflowID])
+	if e, ok := m.vectors[workflowID]; ok && e.model == model && e.contentVersion == contentVersion {
+		return nil
+	}
+	return &models.EmbeddingSource{
+		WorkflowID:     w.WorkflowID,
+		WorkflowName:   w.WorkflowName,
+		Description:    w.Description,
+		Parameters:     w.Parameters,
+		InputSchema:    w.InputSchema,
+		ContentVersion: contentVersion,
+	}
+}
+
+func searchResult(w *models.Workflow) models.SearchWorkflowResult {
+	return models.SearchWorkflowResult{
+		WorkflowID:   w.WorkflowID,
+		WorkflowName: w.WorkflowName,
+		Description:  w.Description,
+		Source:       w.Source,
+		TemplateName: w.TemplateName,
+		Tags:         append([]string{}, w.Tags...),
+	}
+}
+
+// cosine returns the cosine similarity of two vectors of the same length
+func cosine(a, b []float32) float64 {
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// latest returns the newest version of a workflow; m.mu must be held
+func (m *Memory) latest(workflowID string) *models.WorkflowVersion {
+	versions := m.versions[workflowID]
+	return copyVersion(&versions[len(versions)-1])
+}
+
+// saveVersion records the definition of w when it differs from previous,
+// mirroring db.SaveVersion; m.mu must be held
+func (m *Memory) saveVersion(w *models.Workflow, previous *models.WorkflowVersion, changedBy, changeType string, rolledBackFrom int) *models.WorkflowVersion {
+	current := models.DefinitionOf(w)
+	current.ChangedFields = []string{}
+	for _, change := range previous.Diff(current) {
+		current.ChangedFields = append(current.ChangedFields, change.Field)
+	}
+	if len(current.ChangedFields) == 0 {
+		return nil
+	}
+
+	current.Version = previous.Version + 1
+	current.ChangeType = changeType
+	current.ChangedBy = changedBy
+	current.RolledBackFrom = rolledBackFrom
+	current.CreatedAt = w.UpdatedAt
+	m.versions[w.WorkflowID] = append(m.versions[w.WorkflowID], *current)
+	return copyVersion(current)
+}
+
+func copyWorkflow(w *models.Workflow) models.Workflow {
+	c := *w
+	c.Tags = append([]string{}, w.Tags...)
+	return c
+}
+
+func copyAPIKey(k *models.APIKey) models.APIKey {
+	c := *k
+	c.Scopes = append([]string{}, k.Scopes...)
+	c.WorkflowIDs = append([]string{}, k.WorkflowIDs...)
+	return c
+}
+
+func copyVersion(v *models.WorkflowVersion) *models.WorkflowVersion {
+	c := *v
+	c.ChangedFields = append([]string{}, v.ChangedFields...)
+	return &c
+}
+
+// contains reports whether values has value
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// after reports whether position a comes after b in the order of b
+func after(a, b models.WorkflowCursor) bool {
+	cmp := strings.Compare(a.Value, b.Value)
+	if cmp == 0 {
+		cmp = strings.Compare(a.WorkflowID, b.WorkflowID)
+	}
+	if b.Descending {
+		return cmp < 0
+	}
+	return cmp > 0
+}
+
+// newID returns a random UUID like the workflow_id column default
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
@@ -0,0 +1,262 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/xzero/ai-workflow/pkg/db"
+	"github.com/xzero/ai-workflow/pkg/embedding"
+	"github.com/xzero/ai-workflow/pkg/models"
+)
+
+// Postgres is the WorkflowStore, RunStore, SearchStore, APIKeyStore and
+// AuditStore backed by the tables in database/schema.sql
+type Postgres struct {
+	db *sql.DB
+}
+
+// NewPostgres returns the store backed by database
+func NewPostgres(database *sql.DB) *Postgres {
+	return &Postgres{db: database}
+}
+
+// notFound maps sql.ErrNoRows to ErrNotFound
+func notFound(err error) error {
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	return err
+}
+
+// conditional also maps db.ErrRowVersion to ErrPreconditionFailed
+func conditional(err error) error {
+	if err == db.ErrRowVersion {
+		return ErrPreconditionFailed
+	}
+	return notFound(err)
+}
+
+// Get returns the workflow from the workflows table
+func (p *Postgres) Get(workflowID string) (*models.Workflow, error) {
+	wf, err := db.GetWorkflow(p.db, workflowID)
+	return wf, notFound(err)
+}
+
+// List runs the keyset query of db.ListWorkflows
+func (p *Postgres) List(filter models.ListWorkflowsFilter) ([]models.Workflow, error) {
+	return db.ListWorkflows(p.db, filter)
+}
+
+// Create inserts the workflow and its first version in one transaction
+func (p *Postgres) Create(req *models.CreateWorkflowRequest, creatorDID string) (string, error) {
+	return db.CreateWorkflow(p.db, req, creatorDID)
+}
+
+// Update locks the workflow row so concurrent updates get consecutive versions
+func (p *Postgres) Update(workflowID string, req *models.UpdateWorkflowRequest, changedBy string, rowVersion int) (*models.WorkflowVersion, error) {
+	v, err := db.UpdateWorkflow(p.db, workflowID, req, changedBy, rowVersion)
+	return v, conditional(err)
+}
+
+// Rollback restores target in one transaction
+func (p *Postgres) Rollback(target *models.WorkflowVersion, changedBy string, rowVersion int) (*models.WorkflowVersion, error) {
+	v, err := db.RollbackWorkflow(p.db, target, changedBy, rowVersion)
+	return v, conditional(err)
+}
+
+// Delete sets deleted_at; the row is removed by the purge job
+func (p *Postgres) Delete(workflowID, deletedBy string, rowVersion int) error {
+	return conditional(db.DeleteWorkflow(p.db, workflowID, deletedBy, rowVersion))
+}
+
+// SetShared updates is_shared
+func (p *Postgres) SetShared(workflowID string, isShared bool, rowVersion int) error {
+	return conditional(db.SetWorkflowShared(p.db, workflowID, isShared, rowVersion))
+}
+
+// SetHidden upserts the project_workflow_settings row
+func (p *Postgres) SetHidden(projectID, workflowID string, isHidden bool) error {
+	return db.SetWorkflowHidden(p.db, projectID, workflowID, isHidden)
+}
+
+// GetDeleted returns the workflow if deleted_at is set
+func (p *Postgres) GetDeleted(workflowID string) (*models.Workflow, error) {
+	wf, err := db.GetDeletedWorkflow(p.db, workflowID)
+	return wf, notFound(err)
+}
+
+// ListDeleted pages the workflows of the project with deleted_at set
+func (p *Postgres) ListDeleted(projectID string, limit, offset int) ([]models.Workflow, error) {
+	return db.ListDeletedWorkflows(p.db, projectID, limit, offset)
+}
+
+// Restore clears deleted_at
+func (p *Postgres) Restore(workflowID string) error {
+	return notFound(db.RestoreWorkflow(p.db, workflowID))
+}
+
+// GetUsage reads is_shared, workflow_shares, the project_workflow_settings
+// of other projects and the runs recorded against other projects
+func (p *Postgres) GetUsage(workflowID string, since time.Time) (*models.WorkflowUsage, error) {
+	usage, err := db.GetWorkflowUsage(p.db, workflowID, since)
+	return usage, notFound(err)
+}
+
+// GetVersion returns one row of workflow_versions
+func (p *Postgres) GetVersion(workflowID string, version int) (*models.WorkflowVersion, error) {
+	v, err := db.GetVersion(p.db, workflowID, version)
+	return v, notFound(err)
+}
+
+// GetLatestVersion returns the newest row of workflow_versions
+func (p *Postgres) GetLatestVersion(workflowID string) (*models.WorkflowVersion, error) {
+	v, err := db.GetLatestVersion(p.db, workflowID)
+	return v, notFound(err)
+}
+
+// ListVersions returns a page of workflow_versions
+func (p *Postgres) ListVersions(workflowID string, limit, offset int) ([]models.WorkflowVersion, error) {
+	return db.ListVersions(p.db, workflowID, limit, offset)
+}
+
+// GetProjectRole looks up user_projects
+func (p *Postgres) GetProjectRole(principal, projectID string) (string, error) {
+	return db.GetProjectRole(p.db, principal, projectID)
+}
+
+// GetWorkflowRole looks up workflow_grants
+func (p *Postgres) GetWorkflowRole(principal, workflowID string) (string, error) {
+	return db.GetWorkflowRole(p.db, principal, workflowID)
+}
+
+// ListGrants returns the workflow_grants of a workflow
+func (p *Postgres) ListGrants(workflowID string) ([]models.WorkflowGrant, error) {
+	return db.ListWorkflowGrants(p.db, workflowID)
+}
+
+// SetGrant upserts into workflow_grants
+func (p *Postgres) SetGrant(grant *models.WorkflowGrant) error {
+	return db.SetWorkflowGrant(p.db, grant)
+}
+
+// DeleteGrant deletes from workflow_grants
+func (p *Postgres) DeleteGrant(workflowID, userDID string) (bool, error) {
+	return db.DeleteWorkflowGrant(p.db, workflowID, userDID)
+}
+
+// GetProjectShareAccess calls workflow_share_access
+func (p *Postgres) GetProjectShareAccess(projectID, workflowID string) (string, error) {
+	return db.GetProjectShareAccess(p.db, projectID, workflowID)
+}
+
+// GetShareAccess calls workflow_share_access for the projects of the user
+func (p *Postgres) GetShareAccess(userDID, workflowID string) (string, error) {
+	return db.GetShareAccess(p.db, userDID, workflowID)
+}
+
+// ListShares returns the workflow_shares of a workflow
+func (p *Postgres) ListShares(workflowID string) ([]models.WorkflowShare, error) {
+	return db.ListWorkflowShares(p.db, workflowID)
+}
+
+// SetShare upserts into workflow_shares
+func (p *Postgres) SetShare(share *models.WorkflowShare) error {
+	return db.SetWorkflowShare(p.db, share)
+}
+
+// DeleteShare deletes from workflow_shares
+func (p *Postgres) DeleteShare(workflowID, targetType, targetID string) (bool, error) {
+	return db.DeleteWorkflowShare(p.db, workflowID, targetType, targetID)
+}
+
+// CreateRun inserts into workflow_runs
+func (p *Postgres) CreateRun(run *models.WorkflowRun) (string, error) {
+	return db.CreateRun(p.db, run)
+}
+
+// GetRun returns one row of workflow_runs
+func (p *Postgres) GetRun(runID string) (*models.WorkflowRun, error) {
+	run, err := db.GetRun(p.db, runID)
+	return run, notFound(err)
+}
+
+// ListRuns pages workflow_runs
+func (p *Postgres) ListRuns(filter models.ListRunsFilter) ([]models.WorkflowRun, error) {
+	return db.ListRuns(p.db, filter)
+}
+
+// CancelRun sets the status of a queued or running run
+func (p *Postgres) CancelRun(runID string) (bool, error) {
+	return db.CancelRun(p.db, runID)
+}
+
+// SearchVector calls search_workflows_vector
+func (p *Postgres) SearchVector(vector []float32, model, projectID string, topK int, threshold float64, filters models.SearchFilters) ([]models.SearchWorkflowResult, error) {
+	return db.SearchWorkflowsVector(p.db, embedding.FormatVector(vector), model, projectID, topK, threshold, filters)
+}
+
+// SearchFulltext calls search_workflows_fulltext
+func (p *Postgres) SearchFulltext(text, projectID string, topK int, filters models.SearchFilters) ([]models.SearchWorkflowResult, error) {
+	return db.SearchWorkflowsFulltext(p.db, text, projectID, topK, filters)
+}
+
+// HasEmbeddings looks for an embedding of model in workflows
+func (p *Postgres) HasEmbeddings(projectID, model string) (bool, error) {
+	return db.HasEmbeddings(p.db, projectID, model)
+}
+
+// ListStaleEmbeddings compares embedding_version with content_version
+func (p *Postgres) ListStaleEmbeddings(model string, limit int) ([]models.EmbeddingSource, error) {
+	return db.ListStaleEmbeddings(p.db, model, limit)
+}
+
+// GetStaleEmbedding compares embedding_version with content_version
+func (p *Postgres) GetStaleEmbedding(workflowID, model string) (*models.EmbeddingSource, error) {
+	source, err := db.GetStaleEmbedding(p.db, workflowID, model)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return source, err
+}
+
+// SetEmbedding updates the embedding columns while content_version matches
+func (p *Postgres) SetEmbedding(workflowID string, vector []float32, model string, contentVersion int) (bool, error) {
+	return db.SetEmbedding(p.db, workflowID, embedding.FormatVector(vector), model, contentVersion)
+}
+
+// CreateAPIKey inserts into api_keys
+func (p *Postgres) CreateAPIKey(key *models.APIKey) (string, error) {
+	return db.CreateAPIKey(p.db, key)
+}
+
+// GetAPIKeyByPrefix looks up api_keys by prefix
+func (p *Postgres) GetAPIKeyByPrefix(prefix string) (*models.APIKey, error) {
+	key, err := db.GetAPIKeyByPrefix(p.db, prefix)
+	return key, notFound(err)
+}
+
+// ListAPIKeys returns the api_keys of a project
+func (p *Postgres) ListAPIKeys(projectID string) ([]models.APIKey, error) {
+	return db.ListAPIKeys(p.db, projectID)
+}
+
+// RevokeAPIKey sets revoked_at
+func (p *Postgres) RevokeAPIKey(projectID, keyID string) (*models.APIKey, error) {
+	key, err := db.RevokeAPIKey(p.db, projectID, keyID)
+	return key, notFound(err)
+}
+
+// TouchAPIKey sets last_used_at
+func (p *Postgres) TouchAPIKey(keyID string) error {
+	return db.TouchAPIKey(p.db, keyID)
+}
+
+// AppendAuditEvent inserts into audit_events
+func (p *Postgres) AppendAuditEvent(event *models.AuditEvent) error {
+	return db.InsertAuditEvent(p.db, event)
+}
+
+// ListAuditEvents queries audit_events
+func (p *Postgres) ListAuditEvents(filter models.ListAuditEventsFilter) ([]models.AuditEvent, error) {
+	return db.ListAuditEvents(p.db, filter)
+}
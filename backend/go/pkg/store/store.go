@@ -0,0 +1,135 @@
+// Package store abstracts where workflows are kept, so handlers can run
+// against Postgres in production and an in-memory store in tests.
+package store
+
+import (
+	"errors"
+	"time"
+
+	"github.com/xzero/ai-workflow/pkg/models"
+)
+
+// ErrNotFound is returned when a workflow or version does not exist
+var ErrNotFound = errors.New("not found")
+
+// ErrPreconditionFailed is returned by conditional writes when the workflow
+// no longer has the expected RowVersion
+var ErrPreconditionFailed = errors.New("precondition failed")
+
+// WorkflowStore keeps workflows, their version history, and the project
+// roles, workflow grants and shares that guard them. Workflows are returned
+// with their encrypted bearer token; callers mask it before responding.
+// Writes taking a rowVersion only apply while the workflow still has that
+// RowVersion, 0 skipping the check, and fail with ErrPreconditionFailed.
+type WorkflowStore interface {
+	// Get returns the full definition of a workflow with its latest version
+	Get(workflowID string) (*models.Workflow, error)
+	// List returns the workflows visible from filter.ProjectID, without bearer tokens
+	List(filter models.ListWorkflowsFilter) ([]models.Workflow, error)
+	// Create stores a workflow as version 1 and returns its ID
+	Create(req *models.CreateWorkflowRequest, creatorDID string) (string, error)
+	// Update applies req and returns the new version, nil when the definition did not change
+	Update(workflowID string, req *models.UpdateWorkflowRequest, changedBy string, rowVersion int) (*models.WorkflowVersion, error)
+	// Rollback restores target as a new version, nil when the workflow already matched it
+	Rollback(target *models.WorkflowVersion, changedBy string, rowVersion int) (*models.WorkflowVersion, error)
+	// Delete moves the workflow to the trash; Get and List no longer return it
+	Delete(workflowID, deletedBy string, rowVersion int) error
+	SetShared(workflowID string, isShared bool, rowVersion int) error
+	SetHidden(projectID, workflowID string, isHidden bool) error
+
+	// GetDeleted returns a workflow in the trash
+	GetDeleted(workflowID string) (*models.Workflow, error)
+	// ListDeleted returns the workflows of a project in the trash, most
+	// recently deleted first, without bearer tokens
+	ListDeleted(projectID string, limit, offset int) ([]models.Workflow, error)
+	// Restore takes a workflow out of the trash
+	Restore(workflowID string) error
+	// GetUsage returns what uses a workflow outside its own project, counting
+	// runs recorded since since
+	GetUsage(workflowID string, since time.Time) (*models.WorkflowUsage, error)
+
+	GetVersion(workflowID string, version int) (*models.WorkflowVersion, error)
+	GetLatestVersion(workflowID string) (*models.WorkflowVersion, error)
+	// ListVersions returns versions newest first
+	ListVersions(workflowID string, limit, offset int) ([]models.WorkflowVersion, error)
+
+	// GetProjectRole returns the role of principal in the project, empty when it has none
+	GetProjectRole(principal, projectID string) (string, error)
+	// GetWorkflowRole returns the role granted to principal on the workflow, empty when none is
+	GetWorkflowRole(principal, workflowID string) (string, error)
+	// ListGrants returns the grants of a workflow, oldest first
+	ListGrants(workflowID string) ([]models.WorkflowGrant, error)
+	// SetGrant creates or replaces the grant of grant.UserDID on grant.WorkflowID
+	SetGrant(grant *models.WorkflowGrant) error
+	// DeleteGrant removes a grant and reports whether there was one
+	DeleteGrant(workflowID, userDID string) (bool, error)
+
+	// GetProjectShareAccess returns the access a project has to a workflow
+	// through its shares, directly or through its organization
+	GetProjectShareAccess(projectID, workflowID string) (string, error)
+	// GetShareAccess returns the best access a user has to a workflow through
+	// the shares of the projects they belong to, see models.ShareAccessOf
+	GetShareAccess(userDID, workflowID string) (string, error)
+	// ListShares returns the shares of a workflow, oldest first
+	ListShares(workflowID string) ([]models.WorkflowShare, error)
+	// SetShare creates or replaces the share of a workflow with share's target
+	SetShare(share *models.WorkflowShare) error
+	// DeleteShare revokes a share and reports whether there was one
+	DeleteShare(workflowID, targetType, targetID string) (bool, error)
+}
+
+// APIKeyStore keeps the API keys of projects, see models.APIKey. A key has
+// a role in its own project while it is active, see models.APIKeyRole.
+type APIKeyStore interface {
+	// CreateAPIKey stores key, whose KeyHash is set, and fills in its ID and creation time
+	CreateAPIKey(key *models.APIKey) (string, error)
+	// GetAPIKeyByPrefix returns the key with prefix, including revoked and expired keys
+	GetAPIKeyByPrefix(prefix string) (*models.APIKey, error)
+	// ListAPIKeys returns the keys of a project, newest first
+	ListAPIKeys(projectID string) ([]models.APIKey, error)
+	// RevokeAPIKey revokes a key of the project and returns it
+	RevokeAPIKey(projectID, keyID string) (*models.APIKey, error)
+	// TouchAPIKey records that a key was used
+	TouchAPIKey(keyID string) error
+}
+
+// AuditStore keeps the append-only audit log, see audit.Emitter
+type AuditStore interface {
+	// AppendAuditEvent stores event and fills in its ID and time
+	AppendAuditEvent(event *models.AuditEvent) error
+	// ListAuditEvents returns the events matching filter
+	ListAuditEvents(filter models.ListAuditEventsFilter) ([]models.AuditEvent, error)
+}
+
+// RunStore keeps the records of workflow executions
+type RunStore interface {
+	// CreateRun records a run and returns its ID
+	CreateRun(run *models.WorkflowRun) (string, error)
+	// GetRun returns a run with its response body
+	GetRun(runID string) (*models.WorkflowRun, error)
+	// ListRuns returns the runs matching filter, newest first, without response bodies
+	ListRuns(filter models.ListRunsFilter) ([]models.WorkflowRun, error)
+	// CancelRun cancels a queued or running run and reports whether it was cancelled
+	CancelRun(runID string) (bool, error)
+}
+
+// SearchStore finds the workflows visible from a project and keeps their
+// embeddings; search.Index is the part used by the search package. Results
+// leave out deleted workflows and those hidden in the project.
+type SearchStore interface {
+	// SearchVector returns up to topK workflows whose embedding of model is
+	// at least threshold similar to vector, most similar first
+	SearchVector(vector []float32, model, projectID string, topK int, threshold float64, filters models.SearchFilters) ([]models.SearchWorkflowResult, error)
+	// SearchFulltext returns up to topK workflows matching text, most relevant first
+	SearchFulltext(text, projectID string, topK int, filters models.SearchFilters) ([]models.SearchWorkflowResult, error)
+	// HasEmbeddings reports whether any workflow visible from projectID has an embedding of model
+	HasEmbeddings(projectID, model string) (bool, error)
+	// ListStaleEmbeddings returns up to limit workflows that need a new embedding of model
+	ListStaleEmbeddings(model string, limit int) ([]models.EmbeddingSource, error)
+	// GetStaleEmbedding returns the content of a workflow whose embedding of
+	// model is stale, nil when it is current
+	GetStaleEmbedding(workflowID, model string) (*models.EmbeddingSource, error)
+	// SetEmbedding stores the embedding of a workflow computed from
+	// contentVersion and reports false when the content changed in the meantime
+	SetEmbedding(workflowID string, vector []float32, model string, contentVersion int) (bool, error)
+}
@@ -0,0 +1,186 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/xzero/ai-workflow/pkg/db"
+	"github.com/xzero/ai-workflow/pkg/models"
+	"github.com/xzero/ai-workflow/pkg/secrets"
+	"github.com/xzero/ai-workflow/pkg/workflow"
+)
+
+// Options configures the run worker
+type Options struct {
+	Concurrency    int           // runs executed at the same time
+	RunTimeout     time.Duration // upper bound of a single run
+	PollInterval   time.Duration // delay between queue polls when idle
+	CancelInterval time.Duration // delay between cancellation checks of a running run
+	IdleExit       time.Duration // stop after the queue stayed empty this long, 0 runs forever
+	Keys           secrets.KeyProvider
+}
+
+// DefaultOptions returns the worker defaults
+func DefaultOptions() Options {
+	return Options{
+		Concurrency:    4,
+		RunTimeout:     10 * time.Minute,
+		PollInterval:   2 * time.Second,
+		CancelInterval: 5 * time.Second,
+	}
+}
+
+// OptionsFromEnv returns the defaults overridden by WORKER_CONCURRENCY,
+// WORKER_RUN_TIMEOUT and WORKER_IDLE_EXIT
+func OptionsFromEnv(keys secrets.KeyProvider) Options {
+	opts := DefaultOptions()
+	opts.Keys = keys
+	if v, err := strconv.Atoi(os.Getenv("WORKER_CONCURRENCY")); err == nil && v > 0 {
+		opts.Concurrency = v
+	}
+	if v, err := time.ParseDuration(os.Getenv("WORKER_RUN_TIMEOUT")); err == nil && v > 0 {
+		opts.RunTimeout = v
+	}
+	if v, err := time.ParseDuration(os.Getenv("WORKER_IDLE_EXIT")); err == nil && v > 0 {
+		opts.IdleExit = v
+	}
+	return opts
+}
+
+// Run claims queued runs and executes them until ctx is done or the queue
+// stayed empty for opts.IdleExit. When ctx has a deadline, no new run is
+// claimed unless it can finish within opts.RunTimeout before the deadline.
+func Run(ctx context.Context, database *sql.DB, opts Options) error {
+	if n, err := db.FailStaleRuns(database, opts.RunTimeout+time.Minute); err != nil {
+		log.Printf("Error failing stale runs: %v", err)
+	} else if n > 0 {
+		log.Printf("Failed %d stale runs", n)
+	}
+
+	slots := make(chan struct{}, opts.Concurrency)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	idleSince := time.Now()
+	for {
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < opts.RunTimeout {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case slots <- struct{}{}:
+		}
+
+		run, err := db.ClaimRun(database)
+		if err != nil {
+			<-slots
+			if !errors.Is(err, sql.ErrNoRows) {
+				log.Printf("Error claiming run: %v", err)
+			}
+			if opts.IdleExit > 0 && time.Since(idleSince) > opts.IdleExit {
+				return nil
+			}
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(opts.PollInterval):
+			}
+			continue
+		}
+
+		idleSince = time.Now()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			process(ctx, database, run, opts)
+		}()
+	}
+}
+
+// errWorkflowDeleted fails runs whose workflow was deleted after they were queued
+var errWorkflowDeleted = errors.New("workflow was deleted")
+
+// process executes one claimed run and stores its result
+func process(ctx context.Context, database *sql.DB, run *models.WorkflowRun, opts Options) {
+	ctx, cancel := context.WithTimeout(ctx, opts.RunTimeout)
+	defer cancel()
+
+	// Abort the upstream call when the run gets cancelled
+	go watchCancellation(ctx, cancel, database, run.RunID, opts.CancelInterval)
+
+	wf, err := db.GetWorkflow(database, run.WorkflowID)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = errWorkflowDeleted
+	}
+	if err == nil && run.WorkflowVersion > 0 && run.WorkflowVersion != wf.Version {
+		// Pinned run
+		var pinned *models.WorkflowVersion
+		pinned, err = db.GetVersion(database, run.WorkflowID, run.WorkflowVersion)
+		if err == nil {
+			pinned.Apply(wf)
+		}
+	}
+	if err == nil {
+		run.WorkflowVersion = wf.Version
+		wf.BearerToken, err = secrets.Decrypt(ctx, opts.Keys, wf.BearerToken)
+	}
+	var headers json.RawMessage
+	if err == nil {
+		headers, err = secrets.DecryptJSON(ctx, opts.Keys, run.RequestHeaders)
+	}
+	if err != nil {
+		workflow.Finish(run, 0, nil, err)
+		finish(database, run)
+		return
+	}
+
+	req := models.ExecuteWorkflowRequest{
+		Parameters: run.Parameters,
+		Headers:    headers,
+	}
+	result, err := workflow.ExecuteAsync(ctx, wf, &req, func(executeID string) {
+		if err := db.SetRunExternalID(database, run.RunID, executeID); err != nil {
+			log.Printf("Error storing execute_id of run %s: %v", run.RunID, err)
+		}
+	})
+	workflow.FinishExecution(run, result, err)
+	finish(database, run)
+}
+
+// watchCancellation cancels ctx once the run's status is no longer running
+func watchCancellation(ctx context.Context, cancel context.CancelFunc, database *sql.DB, runID string, interval time.Duration) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+
+		status, err := db.GetRunStatus(database, runID)
+		if err != nil {
+			log.Printf("Error checking status of run %s: %v", runID, err)
+			continue
+		}
+		if status != models.RunStatusRunning {
+			cancel()
+			return
+		}
+	}
+}
+
+// finish stores the result of a run
+func finish(database *sql.DB, run *models.WorkflowRun) {
+	if err := db.FinishRun(database, run); err != nil {
+		log.Printf("Error finishing run %s: %v", run.RunID, err)
+	}
+}
@@ -0,0 +1,38 @@
+package worker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOptionsFromEnv(t *testing.T) {
+	tests := []struct {
+		name            string
+		concurrency     string
+		runTimeout      string
+		idleExit        string
+		wantConcurrency int
+		wantRunTimeout  time.Duration
+		wantIdleExit    time.Duration
+	}{
+		{"defaults", "", "", "", 4, 10 * time.Minute, 0},
+		{"overrides", "8", "90s", "5m", 8, 90 * time.Second, 5 * time.Minute},
+		{"invalid values keep the defaults", "many", "10", "-1m", 4, 10 * time.Minute, 0},
+		{"zero keeps the defaults", "0", "0s", "0s", 4, 10 * time.Minute, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("WORKER_CONCURRENCY", tt.concurrency)
+			t.Setenv("WORKER_RUN_TIMEOUT", tt.runTimeout)
+			t.Setenv("WORKER_IDLE_EXIT", tt.idleExit)
+			opts := OptionsFromEnv(nil)
+			if opts.Concurrency != tt.wantConcurrency || opts.RunTimeout != tt.wantRunTimeout || opts.IdleExit != tt.wantIdleExit {
+				t.Errorf("concurrency, run timeout, idle exit = %d, %s, %s, want %d, %s, %s",
+					opts.Concurrency, opts.RunTimeout, opts.IdleExit, tt.wantConcurrency, tt.wantRunTimeout, tt.wantIdleExit)
+			}
+			if opts.PollInterval != 2*time.Second || opts.CancelInterval != 5*time.Second {
+				t.Errorf("poll, cancel interval = %s, %s, want the defaults", opts.PollInterval, opts.CancelInterval)
+			}
+		})
+	}
+}
@@ -0,0 +1,263 @@
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/xzero/ai-workflow/pkg/models"
+)
+
+// Workflow sources with a built-in adapter
+const (
+	SourceCoze     = "coze"
+	SourceN8n      = "n8n"
+	SourceDify     = "dify"
+	SourceLangflow = "langflow"
+	SourceFlowise  = "flowise"
+	SourceOpenAI   = "openai"
+)
+
+// Upstream error kinds reported in models.UpstreamError
+const (
+	ErrorKindAuth         = "auth"
+	ErrorKindRateLimited  = "rate_limited"
+	ErrorKindInvalidInput = "invalid_input"
+	ErrorKindNotFound     = "not_found"
+	ErrorKindTimeout      = "timeout"
+	ErrorKindUpstream     = "upstream"
+)
+
+// SourceAdapter translates between the workflow center and one upstream
+// workflow platform. Adapters only shape requests and interpret responses;
+// the HTTP round trip itself is done by Execute, Stream and ExecuteAsync.
+type SourceAdapter interface {
+	// Source is the value stored in workflows.source
+	Source() string
+
+	// BuildRequest returns the upstream request for the effective parameters.
+	// stream is set for streamflow workflows. Custom headers of the workflow
+	// and the caller are merged over the returned headers afterwards.
+	BuildRequest(workflow *models.Workflow, parameters map[string]interface{}, stream bool) (*models.ExecuteWorkflowRequestInfo, error)
+
+	// ParseResponse decodes a complete upstream response body
+	ParseResponse(body []byte) interface{}
+
+	// ParseStreamEvent converts one upstream SSE event into typed events.
+	// name is empty for unnamed SSE events and newline delimited JSON lines.
+	ParseStreamEvent(name string, data []byte) []models.StreamEvent
+
+	// ExtractOutput returns the workflow's final output from a parsed response
+	ExtractOutput(body interface{}) string
+
+	// ClassifyError reports why an upstream call failed, or nil if it
+	// succeeded. Some platforms report failures with a 200 status.
+	ClassifyError(statusCode int, body interface{}) *models.UpstreamError
+}
+
+var (
+	adaptersMu sync.RWMutex
+	adapters   = map[string]SourceAdapter{}
+)
+
+func init() {
+	RegisterAdapter(cozeAdapter{})
+	RegisterAdapter(n8nAdapter{})
+	RegisterAdapter(difyAdapter{})
+	RegisterAdapter(langflowAdapter{})
+	RegisterAdapter(flowiseAdapter{})
+	RegisterAdapter(openAIAdapter{})
+}
+
+// RegisterAdapter makes adapter available for its source, replacing any
+// adapter registered before for the same source
+func RegisterAdapter(adapter SourceAdapter) {
+	adaptersMu.Lock()
+	defer adaptersMu.Unlock()
+	adapters[adapter.Source()] = adapter
+}
+
+// AdapterFor returns the adapter registered for source
+func AdapterFor(source string) (SourceAdapter, error) {
+	adaptersMu.RLock()
+	defer adaptersMu.RUnlock()
+	adapter, ok := adapters[source]
+	if !ok {
+		return nil, fmt.Errorf("unsupported workflow source %q", source)
+	}
+	return adapter, nil
+}
+
+// IsSupportedSource reports whether an adapter is registered for source
+func IsSupportedSource(source string) bool {
+	_, err := AdapterFor(source)
+	return err == nil
+}
+
+// Sources returns the registered sources in alphabetical order
+func Sources() []string {
+	adaptersMu.RLock()
+	defer adaptersMu.RUnlock()
+	sources := make([]string, 0, len(adapters))
+	for source := range adapters {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+	return sources
+}
+
+// baseAdapter provides the behaviour shared by most adapters. Adapters embed
+// it and override what their platform does differently.
+type baseAdapter struct{}
+
+// ParseResponse decodes body as JSON and falls back to the raw string
+func (baseAdapter) ParseResponse(body []byte) interface{} {
+	var bodyJSON interface{}
+	if err := json.Unmarshal(body, &bodyJSON); err != nil {
+		return string(body)
+	}
+	return bodyJSON
+}
+
+// ParseStreamEvent understands Coze style SSE events and n8n style JSON chunks
+func (baseAdapter) ParseStreamEvent(name string, data []byte) []models.StreamEvent {
+	return parseSSEEvent(name, string(data))
+}
+
+// ExtractOutput looks for the usual output fields and returns plain text
+// bodies unchanged
+func (baseAdapter) ExtractOutput(body interface{}) string {
+	return outputOf(body, "output", "text", "answer", "result", "message", "data")
+}
+
+// ClassifyError classifies failures by HTTP status only
+func (baseAdapter) ClassifyError(statusCode int, body interface{}) *models.UpstreamError {
+	if statusCode < 400 {
+		return nil
+	}
+	return statusError(statusCode, errorMessageOf(body))
+}
+
+// newRequestInfo returns a request to the workflow's base_url with the
+// default bearer authentication
+func newRequestInfo(workflow *models.Workflow, body map[string]interface{}, stream bool) *models.ExecuteWorkflowRequestInfo {
+	headers := map[string]string{
+		"Authorization": "Bearer " + workflow.BearerToken,
+		"Content-Type":  "application/json",
+	}
+	if stream {
+		headers["Accept"] = "text/event-stream"
+	}
+	return &models.ExecuteWorkflowRequestInfo{
+		Method:  workflow.HTTPMethod,
+		URL:     workflow.BaseURL,
+		Headers: headers,
+		Body:    body,
+	}
+}
+
+// statusError classifies an upstream failure by its HTTP status
+func statusError(statusCode int, message string) *models.UpstreamError {
+	if message == "" {
+		message = http.StatusText(statusCode)
+	}
+	upstreamErr := &models.UpstreamError{Kind: ErrorKindUpstream, Message: message}
+	switch {
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		upstreamErr.Kind = ErrorKindAuth
+	case statusCode == http.StatusTooManyRequests:
+		upstreamErr.Kind = ErrorKindRateLimited
+		upstreamErr.Retryable = true
+	case statusCode == http.StatusNotFound:
+		upstreamErr.Kind = ErrorKindNotFound
+	case statusCode == http.StatusRequestTimeout || statusCode == http.StatusGatewayTimeout:
+		upstreamErr.Kind = ErrorKindTimeout
+		upstreamErr.Retryable = true
+	case statusCode >= 500:
+		upstreamErr.Retryable = true
+	case statusCode >= 400:
+		upstreamErr.Kind = ErrorKindInvalidInput
+	}
+	return upstreamErr
+}
+
+// errorMessageOf finds an error message in a decoded error body
+func errorMessageOf(body interface{}) string {
+	switch value := body.(type) {
+	case string:
+		return strings.TrimSpace(value)
+	case map[string]interface{}:
+		for _, key := range []string{"error_message", "message", "msg", "detail", "error"} {
+			switch field := value[key].(type) {
+			case string:
+				if field != "" {
+					return field
+				}
+			case map[string]interface{}:
+				if message := errorMessageOf(field); message != "" {
+					return message
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// outputOf returns the first non-empty field of body named in keys as text.
+// Arrays are unwrapped to their first element and string bodies are returned
+// unchanged.
+func outputOf(body interface{}, keys ...string) string {
+	switch value := body.(type) {
+	case string:
+		return value
+	case []interface{}:
+		if len(value) > 0 {
+			return outputOf(value[0], keys...)
+		}
+	case map[string]interface{}:
+		for _, key := range keys {
+			if field, ok := value[key]; ok && field != nil {
+				if text := textOf(field); text != "" {
+					return text
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// pathOf walks body along keys, using integer-like indexes for arrays
+func pathOf(body interface{}, keys ...interface{}) interface{} {
+	for _, key := range keys {
+		switch k := key.(type) {
+		case string:
+			object, ok := body.(map[string]interface{})
+			if !ok {
+				return nil
+			}
+			body = object[k]
+		case int:
+			array, ok := body.([]interface{})
+			if !ok || k >= len(array) {
+				return nil
+			}
+			body = array[k]
+		}
+	}
+	return body
+}
+
+// takeString removes the first of keys present in parameters and returns its
+// value as text
+func takeString(parameters map[string]interface{}, keys ...string) string {
+	for _, key := range keys {
+		if value, ok := parameters[key]; ok {
+			delete(parameters, key)
+			return textOf(value)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,311 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/xzero/ai-workflow/pkg/models"
+	"github.com/xzero/ai-workflow/pkg/secrets"
+)
+
+// upstream is an httptest stand-in for a workflow platform. It records the
+// request it receives and answers with status and body.
+type upstream struct {
+	*httptest.Server
+	method  string
+	query   string
+	headers http.Header
+	body    map[string]interface{}
+}
+
+func newUpstream(t *testing.T, status int, contentType, body string) *upstream {
+	t.Helper()
+	u := &upstream{}
+	u.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		u.method = r.Method
+		u.query = r.URL.RawQuery
+		u.headers = r.Header.Clone()
+		data, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(data, &u.body); err != nil {
+			t.Errorf("upstream received invalid JSON %q: %v", data, err)
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(u.Close)
+	return u
+}
+
+func testWorkflow(source, url string) *models.Workflow {
+	return &models.Workflow{
+		WorkflowID:         "wf-1",
+		Source:             source,
+		TemplateName:       "workflow",
+		HTTPMethod:         "POST",
+		BaseURL:            url,
+		BearerToken:        "secret-token",
+		ExternalWorkflowID: "external-1",
+		Parameters:         json.RawMessage(`{}`),
+		Headers:            json.RawMessage(`{}`),
+	}
+}
+
+func executeRequest(parameters string) *models.ExecuteWorkflowRequest {
+	return &models.ExecuteWorkflowRequest{Parameters: json.RawMessage(parameters)}
+}
+
+func TestAdapterRoundTrips(t *testing.T) {
+	tests := []struct {
+		source     string
+		parameters string
+		response   string
+		wantBody   map[string]interface{}
+		wantOutput string
+	}{
+		{
+			source:     SourceCoze,
+			parameters: `{"city": "Paris"}`,
+			response:   `{"code": 0, "msg": "", "data": "{\"output\": \"sunny\"}"}`,
+			wantBody: map[string]interface{}{
+				"workflow_id": "external-1",
+				"parameters":  map[string]interface{}{"city": "Paris"},
+			},
+			wantOutput: "sunny",
+		},
+		{
+			source:     SourceN8n,
+			parameters: `{"city": "Paris"}`,
+			response:   `[{"output": "sunny"}]`,
+			wantBody:   map[string]interface{}{"workflow_id": "external-1", "city": "Paris"},
+			wantOutput: "sunny",
+		},
+		{
+			source:     SourceDify,
+			parameters: `{"city": "Paris", "user": "alice"}`,
+			response:   `{"data": {"status": "succeeded", "outputs": {"forecast": "sunny"}}}`,
+			wantBody: map[string]interface{}{
+				"inputs":        map[string]interface{}{"city": "Paris"},
+				"response_mode": "blocking",
+				"user":          "alice",
+			},
+			wantOutput: "sunny",
+		},
+		{
+			source:     SourceLangflow,
+			parameters: `{"message": "weather in Paris?", "session_id": "s1"}`,
+			response:   `{"outputs": [{"outputs": [{"results": {"message": {"text": "sunny"}}}]}]}`,
+			wantBody: map[string]interface{}{
+				"input_value": "weather in Paris?",
+				"input_type":  "chat",
+				"output_type": "chat",
+				"session_id":  "s1",
+			},
+			wantOutput: "sunny",
+		},
+		{
+			source:     SourceFlowise,
+			parameters: `{"question": "weather?", "city": "Paris"}`,
+			response:   `{"text": "sunny"}`,
+			wantBody: map[string]interface{}{
+				"question":       "weather?",
+				"overrideConfig": map[string]interface{}{"vars": map[string]interface{}{"city": "Paris"}},
+			},
+			wantOutput: "sunny",
+		},
+		{
+			source:     SourceOpenAI,
+			parameters: `{"system": "be brief", "prompt": "weather?", "temperature": 0}`,
+			response:   `{"choices": [{"message": {"role": "assistant", "content": "sunny"}}]}`,
+			wantBody: map[string]interface{}{
+				"model": "external-1",
+				"messages": []interface{}{
+					map[string]interface{}{"role": "system", "content": "be brief"},
+					map[string]interface{}{"role": "user", "content": "weather?"},
+				},
+				"temperature": float64(0),
+				"stream":      false,
+			},
+			wantOutput: "sunny",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			u := newUpstream(t, http.StatusOK, "application/json", tt.response)
+
+			result, err := Execute(context.Background(), testWorkflow(tt.source, u.URL), executeRequest(tt.parameters))
+			if err != nil {
+				t.Fatalf("Execute: %v", err)
+			}
+
+			if u.method != http.MethodPost {
+				t.Errorf("method = %s, want POST", u.method)
+			}
+			if tt.source == SourceLangflow {
+				if got := u.headers.Get("x-api-key"); got != "secret-token" {
+					t.Errorf("x-api-key = %q, want the bearer token", got)
+				}
+				if got := u.headers.Get("Authorization"); got != "" {
+					t.Errorf("Authorization = %q, want none", got)
+				}
+			} else if got := u.headers.Get("Authorization"); got != "Bearer secret-token" {
+				t.Errorf("Authorization = %q, want the bearer token", got)
+			}
+			if !reflect.DeepEqual(u.body, tt.wantBody) {
+				t.Errorf("upstream body = %v, want %v", u.body, tt.wantBody)
+			}
+
+			if result.Response.Status != http.StatusOK {
+				t.Errorf("status = %d, want 200", result.Response.Status)
+			}
+			if result.Response.Error != nil {
+				t.Errorf("error = %+v, want none", result.Response.Error)
+			}
+			if result.Response.Output != tt.wantOutput {
+				t.Errorf("output = %q, want %q", result.Response.Output, tt.wantOutput)
+			}
+		})
+	}
+}
+
+func TestCustomHeadersOverrideDefaults(t *testing.T) {
+	u := newUpstream(t, http.StatusOK, "application/json", `{"output": "ok"}`)
+	wf := testWorkflow(SourceN8n, u.URL)
+	wf.Headers = json.RawMessage(`{"X-Team": "core", "Authorization": "Bearer stored"}`)
+
+	// Masked values sent back by a client keep the stored header
+	req := executeRequest(`{}`)
+	req.Headers = json.RawMessage(`{"X-Team": "ops", "Authorization": "` + secrets.Redacted + `"}`)
+	if _, err := Execute(context.Background(), wf, req); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if got := u.headers.Get("X-Team"); got != "ops" {
+		t.Errorf("X-Team = %q, want the caller's value", got)
+	}
+	if got := u.headers.Get("Authorization"); got != "Bearer secret-token" {
+		t.Errorf("Authorization = %q, want the default bearer token", got)
+	}
+}
+
+func TestClassifyErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		source    string
+		status    int
+		response  string
+		wantKind  string
+		wantCode  string
+		retryable bool
+	}{
+		{"coze code with status 200", SourceCoze, http.StatusOK, `{"code": 4013, "msg": "too many requests"}`, ErrorKindRateLimited, "4013", true},
+		{"coze auth", SourceCoze, http.StatusOK, `{"code": 4100, "msg": "bad token"}`, ErrorKindAuth, "4100", false},
+		{"dify failed run", SourceDify, http.StatusOK, `{"data": {"status": "failed", "error": "node crashed"}}`, ErrorKindUpstream, "", false},
+		{"openai rate limit", SourceOpenAI, http.StatusTooManyRequests, `{"error": {"message": "slow down", "code": "rate_limit_exceeded"}}`, ErrorKindRateLimited, "rate_limit_exceeded", true},
+		{"n8n not found", SourceN8n, http.StatusNotFound, `{"message": "webhook not registered"}`, ErrorKindNotFound, "", false},
+		{"flowise server error", SourceFlowise, http.StatusInternalServerError, `oops`, ErrorKindUpstream, "", true},
+		{"langflow bad input", SourceLangflow, http.StatusUnprocessableEntity, `{"detail": "invalid tweaks"}`, ErrorKindInvalidInput, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := newUpstream(t, tt.status, "application/json", tt.response)
+			result, err := Execute(context.Background(), testWorkflow(tt.source, u.URL), executeRequest(`{}`))
+			if err != nil {
+				t.Fatalf("Execute: %v", err)
+			}
+			got := result.Response.Error
+			if got == nil {
+				t.Fatal("no upstream error reported")
+			}
+			if got.Kind != tt.wantKind || got.Code != tt.wantCode || got.Retryable != tt.retryable {
+				t.Errorf("error = %+v, want kind %s, code %q, retryable %v", got, tt.wantKind, tt.wantCode, tt.retryable)
+			}
+			if got.Message == "" {
+				t.Error("error has no message")
+			}
+			if result.Response.Output != "" {
+				t.Errorf("output = %q, want none for a failed call", result.Response.Output)
+			}
+		})
+	}
+}
+
+func TestStreamRoundTrips(t *testing.T) {
+	tests := []struct {
+		source string
+		stream string
+		want   string
+	}{
+		{SourceCoze, "event: Message\ndata: {\"content\": \"sun\"}\n\nevent: Message\ndata: {\"content\": \"ny\"}\n\nevent: Done\ndata: {}\n\n", "sunny"},
+		{SourceN8n, "{\"type\": \"begin\"}\n{\"type\": \"item\", \"content\": \"sun\"}\n{\"type\": \"item\", \"content\": \"ny\"}\n", "sunny"},
+		{SourceDify, "data: {\"event\": \"text_chunk\", \"data\": {\"text\": \"sun\"}}\n\ndata: {\"event\": \"text_chunk\", \"data\": {\"text\": \"ny\"}}\n\ndata: {\"event\": \"workflow_finished\", \"data\": {\"status\": \"succeeded\"}}\n\n", "sunny"},
+		{SourceLangflow, "data: {\"event\": \"token\", \"data\": {\"chunk\": \"sun\"}}\n\ndata: {\"event\": \"token\", \"data\": {\"chunk\": \"ny\"}}\n\ndata: {\"event\": \"end\", \"data\": {}}\n\n", "sunny"},
+		{SourceFlowise, "data: {\"event\": \"token\", \"data\": \"sun\"}\n\ndata: {\"event\": \"token\", \"data\": \"ny\"}\n\ndata: {\"event\": \"end\", \"data\": \"[DONE]\"}\n\n", "sunny"},
+		{SourceOpenAI, "data: {\"choices\": [{\"delta\": {\"role\": \"assistant\"}}]}\n\ndata: {\"choices\": [{\"delta\": {\"content\": \"sun\"}}]}\n\ndata: {\"choices\": [{\"delta\": {\"content\": \"ny\"}}]}\n\ndata: [DONE]\n\n", "sunny"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			u := newUpstream(t, http.StatusOK, "text/event-stream", tt.stream)
+			wf := testWorkflow(tt.source, u.URL)
+			wf.TemplateName = TemplateStreamflow
+
+			var output strings.Builder
+			var last models.StreamEvent
+			status, err := Stream(context.Background(), wf, executeRequest(`{"prompt": "weather?"}`), func(event models.StreamEvent) error {
+				if event.Type == EventError {
+					t.Errorf("error event: %s", event.Error)
+				}
+				if event.Type == EventMessageDelta {
+					output.WriteString(event.Content)
+				}
+				last = event
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("Stream: %v", err)
+			}
+			if status != http.StatusOK {
+				t.Errorf("status = %d, want 200", status)
+			}
+			if tt.source == SourceLangflow && u.query != "stream=true" {
+				t.Errorf("query = %q, want stream=true", u.query)
+			}
+			if got := u.headers.Get("Accept"); got != "text/event-stream" {
+				t.Errorf("Accept = %q, want text/event-stream", got)
+			}
+			if output.String() != tt.want {
+				t.Errorf("streamed output = %q, want %q", output.String(), tt.want)
+			}
+			if last.Type != EventDone {
+				t.Errorf("last event = %q, want done", last.Type)
+			}
+		})
+	}
+}
+
+func TestStreamReportsUpstreamErrors(t *testing.T) {
+	u := newUpstream(t, http.StatusUnauthorized, "application/json", `{"error": {"message": "invalid api key", "type": "auth"}}`)
+	wf := testWorkflow(SourceOpenAI, u.URL)
+	wf.TemplateName = TemplateStreamflow
+
+	var events []models.StreamEvent
+	status, err := Stream(context.Background(), wf, executeRequest(`{}`), func(event models.StreamEvent) error {
+		events = append(events, event)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	if status != http.StatusUnauthorized {
+		t.Errorf("status = %d, want 401", status)
+	}
+	if len(events) != 2 || events[0].Type != EventError || events[0].Error != "invalid api key" || events[1].Type != EventDone {
+		t.Errorf("events = %+v, want the upstream error then done", events)
+	}
+}
@@ -0,0 +1,178 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/xzero/ai-workflow/pkg/models"
+)
+
+// asyncClient is used by the run worker, where upstream calls may take far
+// longer than the API Gateway limit
+var asyncClient = &http.Client{
+	Timeout: 14 * time.Minute,
+}
+
+// CozePollInterval is the delay between two Coze run history polls
+var CozePollInterval = 3 * time.Second
+
+// Coze async execution statuses
+const (
+	cozeStatusRunning = "Running"
+	cozeStatusFail    = "Fail"
+)
+
+// cozeAsyncResponse is returned by the Coze run API when is_async is set
+type cozeAsyncResponse struct {
+	Code      int    `json:"code"`
+	Msg       string `json:"msg"`
+	ExecuteID string `json:"execute_id"`
+}
+
+// cozeRunHistory is returned by the Coze run history API
+type cozeRunHistory struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+	Data []struct {
+		ExecuteStatus string `json:"execute_status"`
+		ErrorMessage  string `json:"error_message"`
+		Output        string `json:"output"`
+	} `json:"data"`
+}
+
+// ExecuteAsync runs a workflow on behalf of the run worker.
+// Coze workflows are submitted with is_async and the returned execute_id is
+// polled until the execution finishes; onSubmitted receives the execute_id.
+// Other sources are called directly, bounded only by ctx and asyncClient.
+func ExecuteAsync(ctx context.Context, workflow *models.Workflow, req *models.ExecuteWorkflowRequest, onSubmitted func(executeID string)) (*models.ExecuteWorkflowResponse, error) {
+	if workflow.Source != SourceCoze || workflow.TemplateName == TemplateStreamflow {
+		return execute(ctx, asyncClient, workflow, req)
+	}
+
+	adapter, err := AdapterFor(SourceCoze)
+	if err != nil {
+		return nil, err
+	}
+
+	requestInfo, err := buildRequest(adapter, workflow, req)
+	if err != nil {
+		return nil, err
+	}
+	requestInfo.Body["is_async"] = true
+
+	result, body, err := call(ctx, requestInfo, requestInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	var submitted cozeAsyncResponse
+	if result.Response.Status >= 400 || json.Unmarshal(body, &submitted) != nil ||
+		submitted.Code != 0 || submitted.ExecuteID == "" {
+		// Submission failed, report the upstream answer as is
+		result.Response.Error = adapter.ClassifyError(result.Response.Status, result.Response.Body)
+		return result, nil
+	}
+	if onSubmitted != nil {
+		onSubmitted(submitted.ExecuteID)
+	}
+
+	historyURL, err := cozeRunHistoryURL(workflow, submitted.ExecuteID)
+	if err != nil {
+		return nil, err
+	}
+	pollInfo := &models.ExecuteWorkflowRequestInfo{
+		Method:  http.MethodGet,
+		URL:     historyURL,
+		Headers: map[string]string{"Authorization": requestInfo.Headers["Authorization"]},
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(CozePollInterval):
+		}
+
+		pollResult, body, err := call(ctx, requestInfo, pollInfo)
+		if err != nil {
+			return nil, err
+		}
+
+		var history cozeRunHistory
+		if pollResult.Response.Status >= 400 || json.Unmarshal(body, &history) != nil || history.Code != 0 {
+			return pollResult, fmt.Errorf("failed to poll coze execution %s", submitted.ExecuteID)
+		}
+		if len(history.Data) == 0 || history.Data[0].ExecuteStatus == cozeStatusRunning {
+			continue
+		}
+
+		pollResult.Response.Body = history.Data[0]
+		if history.Data[0].ExecuteStatus == cozeStatusFail {
+			return pollResult, fmt.Errorf("coze execution failed: %s", history.Data[0].ErrorMessage)
+		}
+		pollResult.Response.Output = adapter.ExtractOutput(map[string]interface{}{"data": history.Data[0].Output})
+		return pollResult, nil
+	}
+}
+
+// call sends info and returns the response reported for requestInfo together
+// with the raw response body
+func call(ctx context.Context, requestInfo, info *models.ExecuteWorkflowRequestInfo) (*models.ExecuteWorkflowResponse, []byte, error) {
+	var httpResp *http.Response
+	var err error
+	if info.Method == http.MethodGet {
+		httpReq, reqErr := http.NewRequestWithContext(ctx, http.MethodGet, info.URL, nil)
+		if reqErr != nil {
+			return nil, nil, reqErr
+		}
+		for k, v := range info.Headers {
+			httpReq.Header.Set(k, v)
+		}
+		httpResp, err = asyncClient.Do(httpReq)
+	} else {
+		httpResp, err = send(ctx, asyncClient, info)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	defer httpResp.Body.Close()
+
+	body, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var bodyJSON interface{}
+	if err := json.Unmarshal(body, &bodyJSON); err != nil {
+		bodyJSON = string(body)
+	}
+
+	return &models.ExecuteWorkflowResponse{
+		Request: *requestInfo,
+		Response: models.ExecuteWorkflowResponseInfo{
+			Status:     httpResp.StatusCode,
+			StatusText: http.StatusText(httpResp.StatusCode),
+			Headers:    responseHeaders(httpResp),
+			Body:       bodyJSON,
+		},
+	}, body, nil
+}
+
+// cozeRunHistoryURL builds the run history URL on the same host as the workflow's base_url
+func cozeRunHistoryURL(workflow *models.Workflow, executeID string) (string, error) {
+	base, err := url.Parse(workflow.BaseURL)
+	if err != nil {
+		return "", err
+	}
+	history := url.URL{
+		Scheme: base.Scheme,
+		Host:   base.Host,
+		Path:   "/v1/workflows/" + workflow.ExternalWorkflowID + "/run_histories/" + executeID,
+	}
+	return history.String(), nil
+}
@@ -0,0 +1,88 @@
+package workflow
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/xzero/ai-workflow/pkg/models"
+)
+
+// Common Coze API error codes
+const (
+	cozeCodeInvalidParam = 4000
+	cozeCodeRateLimited  = 4013
+	cozeCodeAuthInvalid  = 4100
+	cozeCodeNoPermission = 4101
+	cozeCodeNotFound     = 4200
+)
+
+// cozeAdapter calls the Coze workflow run API (/v1/workflow/run and
+// /v1/workflow/stream_run)
+type cozeAdapter struct {
+	baseAdapter
+}
+
+func (cozeAdapter) Source() string {
+	return SourceCoze
+}
+
+// BuildRequest wraps the parameters as { "workflow_id": "xxx", "parameters": { ... } }
+func (cozeAdapter) BuildRequest(workflow *models.Workflow, parameters map[string]interface{}, stream bool) (*models.ExecuteWorkflowRequestInfo, error) {
+	body := map[string]interface{}{
+		"workflow_id": workflow.ExternalWorkflowID,
+		"parameters":  parameters,
+	}
+	return newRequestInfo(workflow, body, stream), nil
+}
+
+// ExtractOutput decodes the JSON document Coze returns as a string in data
+func (a cozeAdapter) ExtractOutput(body interface{}) string {
+	data, ok := pathOf(body, "data").(string)
+	if !ok {
+		return a.baseAdapter.ExtractOutput(body)
+	}
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(data), &decoded); err != nil {
+		return data
+	}
+	if output := outputOf(decoded, "output", "data"); output != "" {
+		return output
+	}
+	return data
+}
+
+// ClassifyError also reports the failures Coze returns with status 200 and a
+// non-zero code
+func (cozeAdapter) ClassifyError(statusCode int, body interface{}) *models.UpstreamError {
+	code, _ := pathOf(body, "code").(float64)
+	if statusCode < 400 && code == 0 {
+		return nil
+	}
+
+	var upstreamErr *models.UpstreamError
+	if statusCode >= 400 {
+		upstreamErr = statusError(statusCode, errorMessageOf(body))
+	} else {
+		upstreamErr = &models.UpstreamError{Kind: ErrorKindUpstream, Message: errorMessageOf(body)}
+		if upstreamErr.Message == "" {
+			upstreamErr.Message = "coze returned code " + strconv.Itoa(int(code))
+		}
+	}
+	if code == 0 {
+		return upstreamErr
+	}
+
+	upstreamErr.Code = strconv.Itoa(int(code))
+	switch int(code) {
+	case cozeCodeInvalidParam:
+		upstreamErr.Kind = ErrorKindInvalidInput
+	case cozeCodeRateLimited:
+		upstreamErr.Kind = ErrorKindRateLimited
+		upstreamErr.Retryable = true
+	case cozeCodeAuthInvalid, cozeCodeNoPermission:
+		upstreamErr.Kind = ErrorKindAuth
+	case cozeCodeNotFound:
+		upstreamErr.Kind = ErrorKindNotFound
+	}
+	return upstreamErr
+}
@@ -0,0 +1,131 @@
+package workflow
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/xzero/ai-workflow/pkg/models"
+)
+
+// difyDefaultUser identifies workflow center calls to Dify when the
+// parameters carry no user
+const difyDefaultUser = "ai-workflow"
+
+// difyAdapter calls the Dify workflow API (POST /v1/workflows/run)
+type difyAdapter struct {
+	baseAdapter
+}
+
+func (difyAdapter) Source() string {
+	return SourceDify
+}
+
+// BuildRequest sends the parameters as inputs. Dify requires a user, which
+// is taken from the "user" parameter.
+func (difyAdapter) BuildRequest(workflow *models.Workflow, parameters map[string]interface{}, stream bool) (*models.ExecuteWorkflowRequestInfo, error) {
+	user := takeString(parameters, "user")
+	if user == "" {
+		user = difyDefaultUser
+	}
+	responseMode := "blocking"
+	if stream {
+		responseMode = "streaming"
+	}
+	body := map[string]interface{}{
+		"inputs":        parameters,
+		"response_mode": responseMode,
+		"user":          user,
+	}
+	return newRequestInfo(workflow, body, stream), nil
+}
+
+// difyStreamEvent holds the fields we look at in a Dify stream chunk
+type difyStreamEvent struct {
+	Event   string `json:"event"`
+	Answer  string `json:"answer"`
+	Message string `json:"message"`
+	Data    struct {
+		Title  string `json:"title"`
+		Text   string `json:"text"`
+		Status string `json:"status"`
+		Error  string `json:"error"`
+	} `json:"data"`
+}
+
+// ParseStreamEvent converts the unnamed JSON events of the Dify streaming mode
+func (a difyAdapter) ParseStreamEvent(name string, data []byte) []models.StreamEvent {
+	if strings.TrimSpace(string(data)) == "" {
+		return nil
+	}
+
+	var event difyStreamEvent
+	if err := json.Unmarshal(data, &event); err != nil || event.Event == "" {
+		return a.baseAdapter.ParseStreamEvent(name, data)
+	}
+
+	raw := rawJSON(data)
+	switch event.Event {
+	case "text_chunk":
+		return []models.StreamEvent{{Type: EventMessageDelta, Content: event.Data.Text}}
+	case "message", "agent_message":
+		return []models.StreamEvent{{Type: EventMessageDelta, Content: event.Answer}}
+	case "node_finished":
+		return []models.StreamEvent{{Type: EventNodeFinished, NodeTitle: event.Data.Title, Data: raw}}
+	case "workflow_finished":
+		if event.Data.Status != "" && event.Data.Status != "succeeded" {
+			return []models.StreamEvent{
+				{Type: EventError, Error: difyRunError(event.Data.Status, event.Data.Error), Data: raw},
+				{Type: EventDone},
+			}
+		}
+		return []models.StreamEvent{{Type: EventDone, Data: raw}}
+	case "message_end":
+		return []models.StreamEvent{{Type: EventDone, Data: raw}}
+	case "error":
+		return []models.StreamEvent{{Type: EventError, Error: event.Message, Data: raw}}
+	default:
+		// ping, workflow_started, node_started, tts_message, ...
+		return nil
+	}
+}
+
+// ExtractOutput returns the workflow outputs; a single output is unwrapped
+func (difyAdapter) ExtractOutput(body interface{}) string {
+	if answer, ok := pathOf(body, "answer").(string); ok {
+		return answer
+	}
+	outputs, ok := pathOf(body, "data", "outputs").(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	if len(outputs) == 1 {
+		for _, output := range outputs {
+			return textOf(output)
+		}
+	}
+	return textOf(outputs)
+}
+
+// ClassifyError also reports workflow runs that finished with a failed status
+func (difyAdapter) ClassifyError(statusCode int, body interface{}) *models.UpstreamError {
+	if statusCode >= 400 {
+		upstreamErr := statusError(statusCode, errorMessageOf(body))
+		upstreamErr.Code, _ = pathOf(body, "code").(string)
+		return upstreamErr
+	}
+
+	status, _ := pathOf(body, "data", "status").(string)
+	if status == "" || status == "succeeded" {
+		return nil
+	}
+	message, _ := pathOf(body, "data", "error").(string)
+	return &models.UpstreamError{Kind: ErrorKindUpstream, Message: difyRunError(status, message)}
+}
+
+// difyRunError describes a workflow run that did not succeed
+func difyRunError(status, message string) string {
+	if message == "" {
+		return "dify workflow " + status
+	}
+	return message
+}
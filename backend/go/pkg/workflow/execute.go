@@ -0,0 +1,255 @@
+package workflow
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/xzero/ai-workflow/pkg/models"
+	"github.com/xzero/ai-workflow/pkg/schema"
+	"github.com/xzero/ai-workflow/pkg/secrets"
+)
+
+// TemplateStreamflow is the template name of workflows that stream their output
+const TemplateStreamflow = "streamflow"
+
+// client is used for blocking workflow calls
+var client = &http.Client{
+	Timeout: 30 * time.Second,
+}
+
+// streamClient is used for streaming workflow calls, which are bounded by the
+// caller's context instead of a fixed timeout
+var streamClient = &http.Client{}
+
+// Execute calls the upstream workflow and waits for the complete response.
+// Streamflow workflows are still read to the end, but their body is returned
+// as the list of parsed stream events instead of a raw string.
+func Execute(ctx context.Context, workflow *models.Workflow, req *models.ExecuteWorkflowRequest) (*models.ExecuteWorkflowResponse, error) {
+	return execute(ctx, client, workflow, req)
+}
+
+// execute performs a blocking workflow call with httpClient
+func execute(ctx context.Context, httpClient *http.Client, workflow *models.Workflow, req *models.ExecuteWorkflowRequest) (*models.ExecuteWorkflowResponse, error) {
+	adapter, err := AdapterFor(workflow.Source)
+	if err != nil {
+		return nil, err
+	}
+
+	requestInfo, err := buildRequest(adapter, workflow, req)
+	if err != nil {
+		return nil, err
+	}
+
+	httpResp, err := send(ctx, httpClient, requestInfo)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	// Build response
+	result := &models.ExecuteWorkflowResponse{
+		Request: *requestInfo,
+		Response: models.ExecuteWorkflowResponseInfo{
+			Status:     httpResp.StatusCode,
+			StatusText: http.StatusText(httpResp.StatusCode),
+			Headers:    responseHeaders(httpResp),
+		},
+	}
+
+	if workflow.TemplateName == TemplateStreamflow && httpResp.StatusCode < 400 {
+		streamEvents := []models.StreamEvent{}
+		var output strings.Builder
+		err := ParseStream(httpResp.Body, adapter, func(event models.StreamEvent) error {
+			streamEvents = append(streamEvents, event)
+			switch event.Type {
+			case EventMessageDelta:
+				output.WriteString(event.Content)
+			case EventError:
+				if result.Response.Error == nil {
+					result.Response.Error = &models.UpstreamError{Kind: ErrorKindUpstream, Message: event.Error}
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		result.Response.Body = streamEvents
+		result.Response.Output = output.String()
+		return result, nil
+	}
+
+	// Read response body
+	respBody, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Response.Body = adapter.ParseResponse(respBody)
+	result.Response.Error = adapter.ClassifyError(httpResp.StatusCode, result.Response.Body)
+	if result.Response.Error == nil {
+		result.Response.Output = adapter.ExtractOutput(result.Response.Body)
+	}
+
+	return result, nil
+}
+
+// Stream calls the upstream workflow and passes every parsed event to emit as
+// soon as it arrives. A final done event is always emitted unless emit fails.
+// Upstream HTTP errors are reported as an error event followed by done.
+// The upstream status code is returned once the stream has finished.
+func Stream(ctx context.Context, workflow *models.Workflow, req *models.ExecuteWorkflowRequest, emit func(models.StreamEvent) error) (int, error) {
+	adapter, err := AdapterFor(workflow.Source)
+	if err != nil {
+		return 0, err
+	}
+
+	requestInfo, err := buildRequest(adapter, workflow, req)
+	if err != nil {
+		return 0, err
+	}
+
+	httpResp, err := send(ctx, streamClient, requestInfo)
+	if err != nil {
+		return 0, err
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode >= 400 {
+		respBody, _ := io.ReadAll(io.LimitReader(httpResp.Body, 64*1024))
+		errEvent := models.StreamEvent{
+			Type:  EventError,
+			Error: http.StatusText(httpResp.StatusCode),
+			Data:  rawJSON(respBody),
+		}
+		if upstreamErr := adapter.ClassifyError(httpResp.StatusCode, adapter.ParseResponse(respBody)); upstreamErr != nil {
+			errEvent.Error = upstreamErr.Message
+		}
+		if err := emit(errEvent); err != nil {
+			return httpResp.StatusCode, err
+		}
+		return httpResp.StatusCode, emit(models.StreamEvent{Type: EventDone})
+	}
+
+	return httpResp.StatusCode, ParseStream(httpResp.Body, adapter, emit)
+}
+
+// EffectiveParameters returns the parameters an execution sends upstream.
+// Caller parameters are merged over the workflow's default parameters,
+// which are merged over the defaults declared in its input schema.
+func EffectiveParameters(workflow *models.Workflow, req *models.ExecuteWorkflowRequest) (map[string]interface{}, error) {
+	parameters := map[string]interface{}{}
+	if len(workflow.Parameters) > 0 {
+		if err := json.Unmarshal(workflow.Parameters, &parameters); err != nil {
+			return nil, err
+		}
+		if parameters == nil {
+			parameters = map[string]interface{}{}
+		}
+	}
+
+	if len(req.Parameters) > 0 {
+		var callerParameters map[string]interface{}
+		if err := json.Unmarshal(req.Parameters, &callerParameters); err != nil {
+			return nil, err
+		}
+		for k, v := range callerParameters {
+			parameters[k] = v
+		}
+	}
+
+	// Schema errors are reported by ValidateParameters
+	if inputSchema, err := schema.Parse(workflow.InputSchema); err == nil && inputSchema != nil {
+		inputSchema.ApplyDefaults(parameters)
+	}
+
+	return parameters, nil
+}
+
+// ValidateParameters checks the effective parameters of an execution against
+// the workflow's input schema. It returns nil when the workflow has no schema.
+func ValidateParameters(workflow *models.Workflow, req *models.ExecuteWorkflowRequest) ([]schema.FieldError, error) {
+	inputSchema, err := schema.Parse(workflow.InputSchema)
+	if err != nil || inputSchema == nil {
+		return nil, err
+	}
+
+	parameters, err := EffectiveParameters(workflow, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return inputSchema.Validate(parameters), nil
+}
+
+// buildRequest lets adapter build the upstream request and merges the custom headers
+func buildRequest(adapter SourceAdapter, workflow *models.Workflow, req *models.ExecuteWorkflowRequest) (*models.ExecuteWorkflowRequestInfo, error) {
+	parameters, err := EffectiveParameters(workflow, req)
+	if err != nil {
+		return nil, err
+	}
+
+	requestInfo, err := adapter.BuildRequest(workflow, parameters, workflow.TemplateName == TemplateStreamflow)
+	if err != nil {
+		return nil, err
+	}
+
+	// Merge headers: use request headers if provided, otherwise use workflow defaults
+	var customHeaders map[string]string
+	if req.Headers != nil && len(req.Headers) > 0 {
+		if err := json.Unmarshal(req.Headers, &customHeaders); err != nil {
+			return nil, err
+		}
+	} else if len(workflow.Headers) > 0 {
+		if err := json.Unmarshal(workflow.Headers, &customHeaders); err != nil {
+			return nil, err
+		}
+	}
+
+	// Merge custom headers (ALLOW overriding any header including Authorization)
+	// User's input takes precedence
+	for k, v := range customHeaders {
+		// Masked values echoed back by clients keep the stored credentials
+		if secrets.IsRedacted(v) {
+			continue
+		}
+		requestInfo.Headers[k] = v
+	}
+
+	return requestInfo, nil
+}
+
+// send performs the upstream HTTP request described by info
+func send(ctx context.Context, httpClient *http.Client, info *models.ExecuteWorkflowRequestInfo) (*http.Response, error) {
+	bodyBytes, err := json.Marshal(info.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, info.Method, info.URL, bytes.NewBuffer(bodyBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range info.Headers {
+		httpReq.Header.Set(k, v)
+	}
+
+	return httpClient.Do(httpReq)
+}
+
+// responseHeaders flattens the upstream response headers
+func responseHeaders(httpResp *http.Response) map[string]string {
+	respHeaders := make(map[string]string)
+	for k, v := range httpResp.Header {
+		if len(v) > 0 {
+			respHeaders[k] = v[0]
+		}
+	}
+	return respHeaders
+}
@@ -0,0 +1,88 @@
+package workflow
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/xzero/ai-workflow/pkg/models"
+)
+
+// flowiseAdapter calls the Flowise prediction API (POST /api/v1/prediction/{chatflow_id})
+type flowiseAdapter struct {
+	baseAdapter
+}
+
+func (flowiseAdapter) Source() string {
+	return SourceFlowise
+}
+
+// BuildRequest sends question (or message/query/input/prompt) as the question and
+// passes overrideConfig, history, chatId and uploads through. Any other
+// parameters become overrideConfig.vars.
+func (flowiseAdapter) BuildRequest(workflow *models.Workflow, parameters map[string]interface{}, stream bool) (*models.ExecuteWorkflowRequestInfo, error) {
+	body := map[string]interface{}{
+		"question": takeString(parameters, "question", "message", "query", "input", "prompt"),
+	}
+	for _, key := range []string{"overrideConfig", "history", "chatId", "uploads"} {
+		if value, ok := parameters[key]; ok {
+			body[key] = value
+			delete(parameters, key)
+		}
+	}
+
+	if len(parameters) > 0 {
+		overrideConfig, _ := body["overrideConfig"].(map[string]interface{})
+		if overrideConfig == nil {
+			overrideConfig = map[string]interface{}{}
+		}
+		vars, _ := overrideConfig["vars"].(map[string]interface{})
+		if vars == nil {
+			vars = map[string]interface{}{}
+		}
+		for k, v := range parameters {
+			vars[k] = v
+		}
+		overrideConfig["vars"] = vars
+		body["overrideConfig"] = overrideConfig
+	}
+
+	if stream {
+		body["streaming"] = true
+	}
+	return newRequestInfo(workflow, body, stream), nil
+}
+
+// flowiseStreamEvent holds a Flowise stream chunk
+type flowiseStreamEvent struct {
+	Event string      `json:"event"`
+	Data  interface{} `json:"data"`
+}
+
+// ParseStreamEvent converts the {"event": ..., "data": ...} chunks Flowise streams
+func (a flowiseAdapter) ParseStreamEvent(name string, data []byte) []models.StreamEvent {
+	if strings.TrimSpace(string(data)) == "" {
+		return nil
+	}
+
+	var event flowiseStreamEvent
+	if err := json.Unmarshal(data, &event); err != nil || event.Event == "" {
+		return a.baseAdapter.ParseStreamEvent(name, data)
+	}
+
+	switch event.Event {
+	case "token":
+		return []models.StreamEvent{{Type: EventMessageDelta, Content: textOf(event.Data)}}
+	case "error":
+		return []models.StreamEvent{{Type: EventError, Error: textOf(event.Data), Data: rawJSON(data)}}
+	case "end":
+		return []models.StreamEvent{{Type: EventDone}}
+	default:
+		// start, metadata, sourceDocuments, usedTools, agentReasoning, ...
+		return nil
+	}
+}
+
+// ExtractOutput returns the prediction text, or its JSON output
+func (flowiseAdapter) ExtractOutput(body interface{}) string {
+	return outputOf(body, "text", "json")
+}
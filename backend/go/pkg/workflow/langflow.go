@@ -0,0 +1,102 @@
+package workflow
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"github.com/xzero/ai-workflow/pkg/models"
+)
+
+// langflowAdapter calls the Langflow run API (POST /api/v1/run/{flow_id}).
+// Flows take a single chat input; components are configured through tweaks.
+type langflowAdapter struct {
+	baseAdapter
+}
+
+func (langflowAdapter) Source() string {
+	return SourceLangflow
+}
+
+// BuildRequest sends input_value (or message/question/query/input/prompt) as the chat
+// input and passes input_type, output_type, session_id and tweaks through.
+// Langflow authenticates with x-api-key instead of a bearer token.
+func (langflowAdapter) BuildRequest(workflow *models.Workflow, parameters map[string]interface{}, stream bool) (*models.ExecuteWorkflowRequestInfo, error) {
+	body := map[string]interface{}{
+		"input_value": takeString(parameters, "input_value", "message", "question", "query", "input", "prompt"),
+		"input_type":  "chat",
+		"output_type": "chat",
+	}
+	for _, key := range []string{"input_type", "output_type", "session_id", "tweaks"} {
+		if value, ok := parameters[key]; ok {
+			body[key] = value
+		}
+	}
+
+	info := newRequestInfo(workflow, body, stream)
+	delete(info.Headers, "Authorization")
+	info.Headers["x-api-key"] = workflow.BearerToken
+
+	if stream {
+		runURL, err := url.Parse(info.URL)
+		if err != nil {
+			return nil, err
+		}
+		query := runURL.Query()
+		query.Set("stream", "true")
+		runURL.RawQuery = query.Encode()
+		info.URL = runURL.String()
+	}
+
+	return info, nil
+}
+
+// langflowStreamEvent holds the fields we look at in a Langflow stream chunk
+type langflowStreamEvent struct {
+	Event string `json:"event"`
+	Data  struct {
+		Chunk string `json:"chunk"`
+		Error string `json:"error"`
+		Text  string `json:"text"`
+	} `json:"data"`
+}
+
+// ParseStreamEvent converts the {"event": ..., "data": {...}} chunks Langflow streams
+func (a langflowAdapter) ParseStreamEvent(name string, data []byte) []models.StreamEvent {
+	if strings.TrimSpace(string(data)) == "" {
+		return nil
+	}
+
+	var event langflowStreamEvent
+	if err := json.Unmarshal(data, &event); err != nil || event.Event == "" {
+		return a.baseAdapter.ParseStreamEvent(name, data)
+	}
+
+	switch event.Event {
+	case "token":
+		return []models.StreamEvent{{Type: EventMessageDelta, Content: event.Data.Chunk}}
+	case "end":
+		return []models.StreamEvent{{Type: EventDone, Data: rawJSON(data)}}
+	case "error":
+		message := event.Data.Error
+		if message == "" {
+			message = event.Data.Text
+		}
+		return []models.StreamEvent{{Type: EventError, Error: message, Data: rawJSON(data)}}
+	default:
+		// add_message repeats the streamed tokens, end_vertex carries build data
+		return nil
+	}
+}
+
+// ExtractOutput returns the text of the first chat output
+func (a langflowAdapter) ExtractOutput(body interface{}) string {
+	first := pathOf(body, "outputs", 0, "outputs", 0)
+	if text, ok := pathOf(first, "results", "message", "text").(string); ok {
+		return text
+	}
+	if text, ok := pathOf(first, "outputs", "message", "message").(string); ok {
+		return text
+	}
+	return a.baseAdapter.ExtractOutput(body)
+}
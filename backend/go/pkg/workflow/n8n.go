@@ -0,0 +1,20 @@
+package workflow
+
+import (
+	"github.com/xzero/ai-workflow/pkg/models"
+)
+
+// n8nAdapter calls an n8n webhook trigger
+type n8nAdapter struct {
+	baseAdapter
+}
+
+func (n8nAdapter) Source() string {
+	return SourceN8n
+}
+
+// BuildRequest sends the parameters as the webhook body with workflow_id added
+func (n8nAdapter) BuildRequest(workflow *models.Workflow, parameters map[string]interface{}, stream bool) (*models.ExecuteWorkflowRequestInfo, error) {
+	parameters["workflow_id"] = workflow.ExternalWorkflowID
+	return newRequestInfo(workflow, parameters, stream), nil
+}
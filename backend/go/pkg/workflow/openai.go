@@ -0,0 +1,105 @@
+package workflow
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/xzero/ai-workflow/pkg/models"
+)
+
+// openAIAdapter calls OpenAI compatible chat completion endpoints
+// (POST .../v1/chat/completions). external_workflow_id holds the model.
+type openAIAdapter struct {
+	baseAdapter
+}
+
+func (openAIAdapter) Source() string {
+	return SourceOpenAI
+}
+
+// BuildRequest sends messages as is, or builds them from system and
+// prompt (or input/message/query/question). Any other parameters, such as
+// temperature or max_tokens, are passed through.
+func (openAIAdapter) BuildRequest(workflow *models.Workflow, parameters map[string]interface{}, stream bool) (*models.ExecuteWorkflowRequestInfo, error) {
+	messages, ok := parameters["messages"]
+	if ok {
+		delete(parameters, "messages")
+	} else {
+		var chat []map[string]interface{}
+		if system := takeString(parameters, "system"); system != "" {
+			chat = append(chat, map[string]interface{}{"role": "system", "content": system})
+		}
+		chat = append(chat, map[string]interface{}{
+			"role":    "user",
+			"content": takeString(parameters, "prompt", "input", "message", "query", "question"),
+		})
+		messages = chat
+	}
+
+	body := parameters
+	body["model"] = workflow.ExternalWorkflowID
+	body["messages"] = messages
+	body["stream"] = stream
+	return newRequestInfo(workflow, body, stream), nil
+}
+
+// openAIStreamChunk holds the fields we look at in a chat completion chunk
+type openAIStreamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// ParseStreamEvent converts chat completion chunks; [DONE] ends the stream
+func (a openAIAdapter) ParseStreamEvent(name string, data []byte) []models.StreamEvent {
+	text := strings.TrimSpace(string(data))
+	if text == "" {
+		return nil
+	}
+	if text == "[DONE]" {
+		return []models.StreamEvent{{Type: EventDone}}
+	}
+
+	var chunk openAIStreamChunk
+	if err := json.Unmarshal(data, &chunk); err != nil {
+		return a.baseAdapter.ParseStreamEvent(name, data)
+	}
+	if chunk.Error != nil {
+		return []models.StreamEvent{{Type: EventError, Error: chunk.Error.Message, Data: rawJSON(data)}}
+	}
+
+	var content strings.Builder
+	for _, choice := range chunk.Choices {
+		content.WriteString(choice.Delta.Content)
+	}
+	if content.Len() == 0 {
+		// Role announcements and finish_reason chunks carry no text
+		return nil
+	}
+	return []models.StreamEvent{{Type: EventMessageDelta, Content: content.String()}}
+}
+
+// ExtractOutput returns the content of the first choice
+func (openAIAdapter) ExtractOutput(body interface{}) string {
+	content, _ := pathOf(body, "choices", 0, "message", "content").(string)
+	return content
+}
+
+// ClassifyError also reports the error code or type of an OpenAI error body
+func (a openAIAdapter) ClassifyError(statusCode int, body interface{}) *models.UpstreamError {
+	upstreamErr := a.baseAdapter.ClassifyError(statusCode, body)
+	if upstreamErr == nil {
+		return nil
+	}
+	if code, ok := pathOf(body, "error", "code").(string); ok && code != "" {
+		upstreamErr.Code = code
+	} else if errType, ok := pathOf(body, "error", "type").(string); ok {
+		upstreamErr.Code = errType
+	}
+	return upstreamErr
+}
@@ -0,0 +1,82 @@
+package workflow
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/xzero/ai-workflow/pkg/models"
+)
+
+// MaxRunBodySize is the number of response bytes kept in a run record
+const MaxRunBodySize = 64 * 1024
+
+// NewRun builds the run record of an execution that started at startedAt.
+// projectID is the project the caller ran the workflow from.
+func NewRun(workflow *models.Workflow, req *models.ExecuteWorkflowRequest, callerDID, projectID, mode string, startedAt time.Time) *models.WorkflowRun {
+	run := &models.WorkflowRun{
+		WorkflowID:      workflow.WorkflowID,
+		ProjectID:       projectID,
+		CallerDID:       callerDID,
+		Mode:            mode,
+		WorkflowVersion: workflow.Version,
+		CreatedAt:       startedAt,
+	}
+	if parameters, err := EffectiveParameters(workflow, req); err == nil {
+		run.Parameters, _ = json.Marshal(parameters)
+	}
+	return run
+}
+
+// FinishExecution completes run with the outcome of Execute or ExecuteAsync.
+// Upstream failures classified by the source adapter fail the run.
+func FinishExecution(run *models.WorkflowRun, result *models.ExecuteWorkflowResponse, execErr error) {
+	if result == nil {
+		Finish(run, 0, nil, execErr)
+		return
+	}
+	if execErr == nil && result.Response.Error != nil {
+		execErr = result.Response.Error
+	}
+	Finish(run, result.Response.Status, result.Response.Body, execErr)
+}
+
+// Finish completes run with the upstream status, response body and error
+func Finish(run *models.WorkflowRun, statusCode int, body interface{}, execErr error) {
+	finishedAt := time.Now()
+	run.FinishedAt = &finishedAt
+	startedAt := run.CreatedAt
+	if run.StartedAt != nil {
+		// Async runs measure latency from the moment a worker picked them up
+		startedAt = *run.StartedAt
+	}
+	run.LatencyMS = finishedAt.Sub(startedAt).Milliseconds()
+	run.StatusCode = statusCode
+
+	if body != nil {
+		var text string
+		if s, ok := body.(string); ok {
+			text = s
+		} else if b, err := json.Marshal(body); err == nil {
+			text = string(b)
+		}
+		if len(text) > MaxRunBodySize {
+			// Drop any rune cut in half so the text stays valid UTF-8
+			text = strings.ToValidUTF8(text[:MaxRunBodySize], "")
+			run.ResponseTruncated = true
+		}
+		run.ResponseBody = text
+	}
+
+	switch {
+	case execErr != nil:
+		run.Status = models.RunStatusFailed
+		run.Error = execErr.Error()
+	case statusCode >= 400:
+		run.Status = models.RunStatusFailed
+		run.Error = "upstream returned " + http.StatusText(statusCode)
+	default:
+		run.Status = models.RunStatusSucceeded
+	}
+}
@@ -0,0 +1,99 @@
+package workflow
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/xzero/ai-workflow/pkg/models"
+)
+
+func TestFinish(t *testing.T) {
+	long := strings.Repeat("a", MaxRunBodySize-1) + "é and more"
+	tests := []struct {
+		name          string
+		status        int
+		body          interface{}
+		err           error
+		wantStatus    string
+		wantError     string
+		wantBody      string
+		wantTruncated bool
+	}{
+		{"success", 200, "ok", nil, models.RunStatusSucceeded, "", "ok", false},
+		{"json body", 200, map[string]string{"output": "done"}, nil, models.RunStatusSucceeded, "", `{"output":"done"}`, false},
+		{"no body", 204, nil, nil, models.RunStatusSucceeded, "", "", false},
+		{"upstream error status", 502, "bad gateway", nil, models.RunStatusFailed, "upstream returned Bad Gateway", "bad gateway", false},
+		{"execution error", 0, nil, errors.New("connection refused"), models.RunStatusFailed, "connection refused", "", false},
+		{"long body", 200, long, nil, models.RunStatusSucceeded, "", strings.Repeat("a", MaxRunBodySize-1), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run := &models.WorkflowRun{CreatedAt: time.Now().Add(-time.Second)}
+			Finish(run, tt.status, tt.body, tt.err)
+			if run.Status != tt.wantStatus || run.Error != tt.wantError {
+				t.Errorf("status, error = %q, %q, want %q, %q", run.Status, run.Error, tt.wantStatus, tt.wantError)
+			}
+			if run.ResponseBody != tt.wantBody || run.ResponseTruncated != tt.wantTruncated {
+				t.Errorf("body = %d bytes (truncated %v), want %d bytes (truncated %v)", len(run.ResponseBody), run.ResponseTruncated, len(tt.wantBody), tt.wantTruncated)
+			}
+			if !utf8.ValidString(run.ResponseBody) {
+				t.Errorf("body is not valid UTF-8")
+			}
+			if run.StatusCode != tt.status || run.FinishedAt == nil || run.LatencyMS < 1000 {
+				t.Errorf("status_code, finished_at, latency_ms = %d, %v, %d", run.StatusCode, run.FinishedAt, run.LatencyMS)
+			}
+		})
+	}
+}
+
+func TestFinishMeasuresAsyncRunsFromStart(t *testing.T) {
+	startedAt := time.Now()
+	run := &models.WorkflowRun{CreatedAt: startedAt.Add(-time.Hour), StartedAt: &startedAt}
+	Finish(run, 200, nil, nil)
+	if run.LatencyMS >= 1000 {
+		t.Errorf("latency_ms = %d, want it measured from started_at", run.LatencyMS)
+	}
+}
+
+func TestFinishExecution(t *testing.T) {
+	tests := []struct {
+		name       string
+		result     *models.ExecuteWorkflowResponse
+		err        error
+		wantStatus string
+		wantError  string
+	}{
+		{
+			name:       "success",
+			result:     &models.ExecuteWorkflowResponse{Response: models.ExecuteWorkflowResponseInfo{Status: 200, Body: "ok"}},
+			wantStatus: models.RunStatusSucceeded,
+		},
+		{
+			name: "error classified by the adapter",
+			result: &models.ExecuteWorkflowResponse{Response: models.ExecuteWorkflowResponseInfo{
+				Status: 200,
+				Error:  &models.UpstreamError{Kind: "upstream", Message: "workflow failed"},
+			}},
+			wantStatus: models.RunStatusFailed,
+			wantError:  "upstream: workflow failed",
+		},
+		{
+			name:       "no response",
+			err:        errors.New("timeout"),
+			wantStatus: models.RunStatusFailed,
+			wantError:  "timeout",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run := &models.WorkflowRun{CreatedAt: time.Now()}
+			FinishExecution(run, tt.result, tt.err)
+			if run.Status != tt.wantStatus || run.Error != tt.wantError {
+				t.Errorf("status, error = %q, %q, want %q, %q", run.Status, run.Error, tt.wantStatus, tt.wantError)
+			}
+		})
+	}
+}
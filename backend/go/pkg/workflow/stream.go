@@ -0,0 +1,243 @@
+package workflow
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/xzero/ai-workflow/pkg/models"
+)
+
+// Stream event types relayed to clients
+const (
+	EventMessageDelta = "message_delta"
+	EventNodeFinished = "node_finished"
+	EventError        = "error"
+	EventDone         = "done"
+)
+
+// maxLineSize bounds a single upstream SSE or NDJSON line
+const maxLineSize = 1024 * 1024
+
+// ParseStream reads an upstream stream and emits typed events as they arrive.
+// It splits Server-Sent Events as well as newline delimited JSON chunks and
+// lets adapter convert each of them. A done event is emitted exactly once.
+func ParseStream(r io.Reader, adapter SourceAdapter, emit func(models.StreamEvent) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+
+	done := false
+	send := func(events []models.StreamEvent) error {
+		for _, event := range events {
+			if done {
+				return nil
+			}
+			if event.Type == EventDone {
+				done = true
+			}
+			if err := emit(event); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// Pending SSE event fields
+	var eventName string
+	var dataLines []string
+	dispatch := func() error {
+		if eventName == "" && len(dataLines) == 0 {
+			return nil
+		}
+		events := adapter.ParseStreamEvent(eventName, []byte(strings.Join(dataLines, "\n")))
+		eventName = ""
+		dataLines = nil
+		return send(events)
+	}
+
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		switch {
+		case line == "":
+			if err := dispatch(); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, ":"):
+			// SSE comment / keep-alive
+		case strings.HasPrefix(line, "event:"):
+			eventName = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			dataLines = append(dataLines, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		case strings.HasPrefix(line, "id:"), strings.HasPrefix(line, "retry:"):
+			// SSE fields we do not need
+		default:
+			// Newline delimited JSON chunk
+			if err := send(adapter.ParseStreamEvent("", []byte(line))); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		if sendErr := send([]models.StreamEvent{{Type: EventError, Error: err.Error()}}); sendErr != nil {
+			return sendErr
+		}
+	} else if err := dispatch(); err != nil {
+		return err
+	}
+
+	return send([]models.StreamEvent{{Type: EventDone}})
+}
+
+// WriteSSE writes an event to w in Server-Sent Events format
+func WriteSSE(w io.Writer, event models.StreamEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+	return err
+}
+
+// parseSSEEvent converts one SSE event into typed events. It covers the Coze
+// event names and the unnamed JSON chunks of n8n.
+func parseSSEEvent(name, data string) []models.StreamEvent {
+	if strings.TrimSpace(data) == "[DONE]" {
+		return []models.StreamEvent{{Type: EventDone}}
+	}
+
+	switch strings.ToLower(name) {
+	case "", "message", "data":
+		return parseJSONChunk([]byte(data))
+	case "ping":
+		return nil
+	case "error":
+		return []models.StreamEvent{errorEvent([]byte(data))}
+	case "interrupt":
+		return []models.StreamEvent{{Type: EventError, Error: "workflow interrupted", Data: rawJSON([]byte(data))}}
+	case "done":
+		return []models.StreamEvent{{Type: EventDone, Data: rawJSON([]byte(data))}}
+	default:
+		return parseJSONChunk([]byte(data))
+	}
+}
+
+// streamChunk holds the fields we look at in a JSON stream payload.
+// It covers the Coze Message event and n8n's begin/item/end/error chunks.
+type streamChunk struct {
+	Type         string      `json:"type"`
+	Content      interface{} `json:"content"`
+	Text         string      `json:"text"`
+	Output       interface{} `json:"output"`
+	NodeTitle    string      `json:"node_title"`
+	NodeIsFinish bool        `json:"node_is_finish"`
+	ErrorMessage string      `json:"error_message"`
+	Metadata     struct {
+		NodeName string `json:"nodeName"`
+	} `json:"metadata"`
+}
+
+// parseJSONChunk converts a JSON payload into typed events
+func parseJSONChunk(data []byte) []models.StreamEvent {
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return nil
+	}
+
+	var chunk streamChunk
+	if err := json.Unmarshal(data, &chunk); err != nil {
+		// Plain text chunk
+		return []models.StreamEvent{{Type: EventMessageDelta, Content: string(data)}}
+	}
+
+	raw := rawJSON(data)
+	nodeTitle := chunk.NodeTitle
+	if nodeTitle == "" {
+		nodeTitle = chunk.Metadata.NodeName
+	}
+
+	switch chunk.Type {
+	case "begin":
+		return nil
+	case "end":
+		return []models.StreamEvent{{Type: EventNodeFinished, NodeTitle: nodeTitle, Data: raw}}
+	case "error":
+		return []models.StreamEvent{errorEvent(data)}
+	}
+
+	if chunk.ErrorMessage != "" {
+		return []models.StreamEvent{errorEvent(data)}
+	}
+
+	var events []models.StreamEvent
+	content := textOf(chunk.Content)
+	if content == "" {
+		content = chunk.Text
+	}
+	if content == "" {
+		content = textOf(chunk.Output)
+	}
+	if content != "" {
+		events = append(events, models.StreamEvent{
+			Type:      EventMessageDelta,
+			Content:   content,
+			NodeTitle: nodeTitle,
+			Data:      raw,
+		})
+	}
+	if chunk.NodeIsFinish {
+		events = append(events, models.StreamEvent{Type: EventNodeFinished, NodeTitle: nodeTitle})
+	}
+	if len(events) == 0 {
+		events = append(events, models.StreamEvent{Type: EventMessageDelta, Data: raw})
+	}
+	return events
+}
+
+// errorEvent builds an error event from an upstream error payload
+func errorEvent(data []byte) models.StreamEvent {
+	var payload struct {
+		ErrorMessage string      `json:"error_message"`
+		Message      string      `json:"message"`
+		Content      interface{} `json:"content"`
+	}
+	event := models.StreamEvent{Type: EventError, Data: rawJSON(data)}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		event.Error = string(data)
+		return event
+	}
+	event.Error = payload.ErrorMessage
+	if event.Error == "" {
+		event.Error = payload.Message
+	}
+	if event.Error == "" {
+		event.Error = textOf(payload.Content)
+	}
+	if event.Error == "" {
+		event.Error = "upstream workflow error"
+	}
+	return event
+}
+
+// textOf returns v as text, encoding non-string values as JSON
+func textOf(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	default:
+		b, _ := json.Marshal(value)
+		return string(b)
+	}
+}
+
+// rawJSON returns data if it is valid JSON, nil otherwise
+func rawJSON(data []byte) json.RawMessage {
+	if len(data) == 0 || !json.Valid(data) {
+		return nil
+	}
+	return json.RawMessage(data)
+}
@@ -80,10 +80,16 @@ function App() {
     
     try {
       setLoading(true)
-      const response = await api.getWorkflows(selectedProject.project_id)
-      if (response.success) {
-        setWorkflows(response.data)
-      }
+      // Follow the cursor until every page is loaded
+      let all = []
+      let cursor
+      do {
+        const response = await api.getWorkflows(selectedProject.project_id, { limit: 200, cursor })
+        if (!response.success) break
+        all = all.concat(response.data)
+        cursor = response.pagination?.has_more ? response.pagination.next_cursor : undefined
+      } while (cursor)
+      setWorkflows(all)
     } catch (err) {
       setError(err.error || 'Failed to load workflows')
     } finally {
@@ -102,7 +108,7 @@ function App() {
       let response
       if (selectedWorkflow) {
         // Update existing workflow
-        response = await api.updateWorkflow(selectedWorkflow.workflow_id, workflowData)
+        response = await api.updateWorkflow(selectedWorkflow.workflow_id, workflowData, selectedWorkflow.row_version)
       } else {
         // Create new workflow
         response = await api.createWorkflow(workflowData)
@@ -114,6 +120,10 @@ function App() {
         loadWorkflows()
       }
     } catch (err) {
+      // Someone else saved first: refresh so the next edit starts from their version
+      if (err.details?.row_version) {
+        loadWorkflows()
+      }
       throw err
     }
   }
@@ -131,21 +141,79 @@ function App() {
     setShowCreateForm(true)
   }
 
+  const rowVersionOf = (workflowId) =>
+    workflows.find((w) => w.workflow_id === workflowId)?.row_version
+
   const handleDeleteWorkflow = async (workflowId) => {
     try {
-      await api.deleteWorkflow(workflowId)
+      await api.deleteWorkflow(workflowId, rowVersionOf(workflowId))
       loadWorkflows()
     } catch (err) {
       setError(err.error || 'Failed to delete workflow')
+      if (err.details?.row_version) {
+        loadWorkflows()
+      }
     }
   }
 
   const handleShareWorkflow = async (workflowId, isShared) => {
     try {
-      await api.shareWorkflow(workflowId, isShared)
+      await api.shareWorkflow(workflowId, isShared, rowVersionOf(workflowId))
       loadWorkflows()
     } catch (err) {
       setError(err.error || 'Failed to share workflow')
+      if (err.details?.row_version) {
+        loadWorkflows()
+      }
+    }
+  }
+
+  const handleExportWorkflows = async () => {
+    try {
+      const bundle = await api.exportWorkflows(selectedProject.project_id)
+      const url = URL.createObjectURL(new Blob([bundle], { type: 'application/yaml' }))
+      const link = document.createElement('a')
+      link.href = url
+      link.download = `workflows-${selectedProject.project_name || selectedProject.project_id}.yaml`
+      link.click()
+      URL.revokeObjectURL(url)
+    } catch (err) {
+      setError(err.error || 'Failed to export workflows')
+    }
+  }
+
+  const handleImportWorkflows = async (file) => {
+    try {
+      const content = await file.text()
+      // Nest the bundle file, YAML or JSON, under the import options
+      const request = (dryRun, secrets) => [
+        'strategy: rename',
+        `dry_run: ${dryRun}`,
+        `secrets: ${JSON.stringify(secrets)}`,
+        'bundle:',
+        ...content.split('\n').map((line) => `  ${line}`)
+      ].join('\n')
+
+      // A dry run tells which secrets the bundle needs
+      const preview = await api.importWorkflows(selectedProject.project_id, request(true, {}))
+      const missing = [...new Set(preview.data.items.flatMap((item) => item.missing_secrets || []))]
+      const secrets = {}
+      for (const name of missing) {
+        const value = window.prompt(`请输入密钥 ${name} 的值`)
+        if (value) secrets[name] = value
+      }
+      if (!window.confirm(`确定要导入 ${preview.data.items.length} 个工作流吗？同名工作流将重命名导入。`)) {
+        return
+      }
+
+      const result = await api.importWorkflows(selectedProject.project_id, request(false, secrets))
+      const failed = result.data.items.filter((item) => item.status === 'failed')
+      if (failed.length > 0) {
+        setError(failed.map((item) => `${item.workflow_name}: ${item.error}`).join('; '))
+      }
+      loadWorkflows()
+    } catch (err) {
+      setError(err.error || 'Failed to import workflows')
     }
   }
 
@@ -198,6 +266,21 @@ function App() {
               >
                 + 创建工作流
               </button>
+              <button className="btn-secondary" onClick={handleExportWorkflows}>
+                导出
+              </button>
+              <label className="btn-secondary">
+                导入
+                <input
+                  type="file"
+                  accept=".yaml,.yml,.json"
+                  style={{ display: 'none' }}
+                  onChange={(e) => {
+                    if (e.target.files[0]) handleImportWorkflows(e.target.files[0])
+                    e.target.value = ''
+                  }}
+                />
+              </label>
             </div>
           )}
 
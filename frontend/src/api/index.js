@@ -43,23 +43,44 @@ workflowApi.interceptors.response.use((response) => response.data, handleErrorIn
 loginApi.interceptors.request.use(addTokenInterceptor, (error) => Promise.reject(error))
 loginApi.interceptors.response.use((response) => response.data, handleErrorInterceptor)
 
+// Writes carry the row_version the user saw as If-Match, so a workflow
+// changed in the meantime fails with 412 instead of being overwritten
+const ifMatch = (rowVersion) => (rowVersion ? { headers: { 'If-Match': `"${rowVersion}"` } } : {})
+
 export default {
   // Project APIs (from DID Login)
   getProjects: () => loginApi.get('/api/projects'),
 
   // Workflow APIs (from AI Workflow backend)
-  getWorkflows: (projectId) => workflowApi.get(`/api/projects/${projectId}/workflows`),
+  getWorkflows: (projectId, params) => workflowApi.get(`/api/projects/${projectId}/workflows`, { params }),
+  
+  getWorkflow: (workflowId) => workflowApi.get(`/api/workflows/${workflowId}`),
   
   createWorkflow: (data) => workflowApi.post('/api/workflows', data),
   
-  updateWorkflow: (workflowId, data) => workflowApi.put(`/api/workflows/${workflowId}`, data),
+  updateWorkflow: (workflowId, data, rowVersion) =>
+    workflowApi.put(`/api/workflows/${workflowId}`, data, ifMatch(rowVersion)),
   
-  deleteWorkflow: (workflowId) => workflowApi.delete(`/api/workflows/${workflowId}`),
+  deleteWorkflow: (workflowId, rowVersion) => workflowApi.delete(`/api/workflows/${workflowId}`, ifMatch(rowVersion)),
   
   executeWorkflow: (workflowId, data) => workflowApi.post(`/api/workflows/${workflowId}/execute`, data),
   
-  shareWorkflow: (workflowId, isShared) => workflowApi.put(`/api/workflows/${workflowId}/share`, { is_shared: isShared }),
+  shareWorkflow: (workflowId, isShared, rowVersion) =>
+    workflowApi.put(`/api/workflows/${workflowId}/share`, { is_shared: isShared }, ifMatch(rowVersion)),
   
   hideWorkflow: (projectId, workflowId, isHidden) => 
     workflowApi.put(`/api/projects/${projectId}/workflows/${workflowId}/hide`, { is_hidden: isHidden }),
+
+  // Returns the bundle as text; secrets are ${NAME} placeholders
+  exportWorkflows: (projectId, format = 'yaml', workflowIds = []) =>
+    workflowApi.get(`/api/projects/${projectId}/workflows/export`, {
+      params: { format, workflow_ids: workflowIds.join(',') || undefined },
+      responseType: 'text'
+    }),
+
+  // request is a YAML or JSON document with bundle, strategy, dry_run and secrets
+  importWorkflows: (projectId, request) =>
+    workflowApi.post(`/api/projects/${projectId}/workflows/import`, request, {
+      headers: { 'Content-Type': 'application/yaml' }
+    }),
 }
@@ -3,9 +3,8 @@ import api from '../api'
 import './ExecuteWorkflow.css'
 
 function ExecuteWorkflow({ workflow, onClose }) {
-  // Build initial headers with Authorization
+  // Build initial headers; the backend adds the stored Authorization header
   const initialHeaders = {
-    "Authorization": `Bearer ${workflow.bearer_token}`,
     "Content-Type": "application/json",
     ...(workflow.headers || {})
   }
@@ -47,18 +47,21 @@ function WorkflowCard({ workflow, onExecute, onEdit, onDelete, onShare, onHide,
       <div className="workflow-card-body">
         <p className="workflow-description">{workflow.description}</p>
         
-        <div className="workflow-meta">
-          <div className="workflow-meta-item">
-            <span className="label">Method:</span>
-            <span className="value">{workflow.http_method}</span>
+        {/* Only callers who may read the configuration get the endpoint */}
+        {workflow.base_url && (
+          <div className="workflow-meta">
+            <div className="workflow-meta-item">
+              <span className="label">Method:</span>
+              <span className="value">{workflow.http_method}</span>
+            </div>
+            <div className="workflow-meta-item">
+              <span className="label">URL:</span>
+              <span className="value workflow-url" title={workflow.base_url}>
+                {workflow.base_url}
+              </span>
+            </div>
           </div>
-          <div className="workflow-meta-item">
-            <span className="label">URL:</span>
-            <span className="value workflow-url" title={workflow.base_url}>
-              {workflow.base_url}
-            </span>
-          </div>
-        </div>
+        )}
       </div>
 
       <div className="workflow-card-footer">
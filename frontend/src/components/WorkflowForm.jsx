@@ -129,6 +129,10 @@ function WorkflowForm({ projectId, workflow, onSubmit, onCancel }) {
               <select name="source" value={formData.source} onChange={handleChange}>
                 <option value="coze">Coze</option>
                 <option value="n8n">n8n</option>
+                <option value="dify">Dify</option>
+                <option value="langflow">Langflow</option>
+                <option value="flowise">Flowise</option>
+                <option value="openai">OpenAI 兼容</option>
               </select>
             </div>
 